@@ -0,0 +1,379 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/middleware"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+)
+
+// AdminUserStore represents a store for managing all the users.
+type AdminUserStore interface {
+	ListUsers(ctx context.Context, arg database.ListUsersParams) ([]database.User, error)
+	SetUserRole(ctx context.Context, arg database.SetUserRoleParams) (database.User, error)
+	SuspendUser(ctx context.Context, id uuid.UUID) (database.User, error)
+	UnsuspendUser(ctx context.Context, id uuid.UUID) (database.User, error)
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+}
+
+// AdminFeedStore represents a store for managing all the feeds.
+type AdminFeedStore interface {
+	GetFeed(ctx context.Context, id uuid.UUID) (database.Feed, error)
+	ListFeedsWithHealth(ctx context.Context, arg database.ListFeedsWithHealthParams) ([]database.ListFeedsWithHealthRow, error)
+	SetFeedDisabled(ctx context.Context, arg database.SetFeedDisabledParams) (database.Feed, error)
+}
+
+// AdminPostStore represents a store for purging the posts.
+type AdminPostStore interface {
+	PurgePosts(ctx context.Context, arg database.PurgePostsParams) (int64, error)
+}
+
+// FeedRefresher represents a fetcher refreshing a feed at once.
+type FeedRefresher interface {
+	Refresh(ctx context.Context, feed database.Feed) error
+}
+
+// AdminHandler is the handler of the administration of the instance, restricted to the admins.
+type AdminHandler struct {
+	userStore AdminUserStore
+	feedStore AdminFeedStore
+	postStore AdminPostStore
+	refresher FeedRefresher
+}
+
+// NewAdminHandler returns a new admin handler.
+func NewAdminHandler(userStore AdminUserStore, feedStore AdminFeedStore, postStore AdminPostStore, refresher FeedRefresher) *AdminHandler {
+	return &AdminHandler{
+		userStore: userStore,
+		feedStore: feedStore,
+		postStore: postStore,
+		refresher: refresher,
+	}
+}
+
+// setRoleReq is the request to set the role of a user.
+type setRoleReq struct {
+	Role string `json:"role"`
+}
+
+// adminUserResp is a user as seen by the admins, without its secrets like its feed token.
+type adminUserResp struct {
+	ID          uuid.UUID    `json:"id"`
+	Name        string       `json:"name"`
+	Email       string       `json:"email"`
+	Role        string       `json:"role"`
+	SuspendedAt sql.NullTime `json:"suspended_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+func newAdminUserResp(user database.User) adminUserResp {
+	return adminUserResp{
+		ID:          user.ID,
+		Name:        user.Name,
+		Email:       user.Email,
+		Role:        user.Role,
+		SuspendedAt: user.SuspendedAt,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+	}
+}
+
+// purgePostsResp is the response to purge the posts.
+type purgePostsResp struct {
+	Deleted int64 `json:"deleted"`
+}
+
+// ListUsers lists all the users, the oldest first.
+// It supports the 'offset' and 'limit' query parameters.
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	users, err := h.userStore.ListUsers(ctx, database.ListUsersParams{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "list users", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	resp := make([]adminUserResp, 0, len(users))
+	for _, user := range users {
+		resp = append(resp, newAdminUserResp(user))
+	}
+	respond.WithJSON(w, http.StatusOK, resp)
+}
+
+// SetUserRole sets the role of a user, admin or user.
+// The admins cannot demote themselves, so an instance always keeps an admin.
+func (h *AdminHandler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.otherUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req setRoleReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Role != middleware.RoleAdmin && req.Role != middleware.RoleUser {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid role: %q", req.Role))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.userStore.SetUserRole(ctx, database.SetUserRoleParams{
+		ID:   id,
+		Role: req.Role,
+	})
+	h.respondWithUser(w, r, user, err)
+}
+
+// SuspendUser suspends a user, it cannot authenticate anymore until its suspension is lifted.
+func (h *AdminHandler) SuspendUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.otherUserID(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.userStore.SuspendUser(ctx, id)
+	h.respondWithUser(w, r, user, err)
+}
+
+// UnsuspendUser lifts the suspension of a user.
+func (h *AdminHandler) UnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.otherUserID(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.userStore.UnsuspendUser(ctx, id)
+	h.respondWithUser(w, r, user, err)
+}
+
+// DeleteUser deletes a user with all its data, like the user deleting its account.
+func (h *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.otherUserID(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.userStore.DeleteUser(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respond.WithJSONError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "delete user", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// otherUserID returns the id of the user of the path, which cannot be the authenticated admin.
+// On error, the response is written.
+func (h *AdminHandler) otherUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	adminID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return uuid.UUID{}, false
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid id: %q", chi.URLParam(r, "id")))
+		return uuid.UUID{}, false
+	}
+	if id == adminID {
+		respond.WithJSONError(w, http.StatusBadRequest, "an admin cannot manage its own account")
+		return uuid.UUID{}, false
+	}
+
+	return id, true
+}
+
+// respondWithUser responds with the user updated by an admin action, or with its error.
+func (h *AdminHandler) respondWithUser(w http.ResponseWriter, r *http.Request, user database.User, err error) {
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respond.WithJSONError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "update user", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, newAdminUserResp(user))
+}
+
+// ListFeeds lists all the feeds with their health status and their number of followers, the failing ones first.
+// It supports the 'offset' and 'limit' query parameters.
+func (h *AdminHandler) ListFeeds(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	feeds, err := h.feedStore.ListFeedsWithHealth(ctx, database.ListFeedsWithHealthParams{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "list feeds with health", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, feeds)
+}
+
+// RefreshFeed fetches a feed at once, then returns it with the result of the fetch in its health.
+func (h *AdminHandler) RefreshFeed(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid id: %q", chi.URLParam(r, "id")))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	feed, err := h.feedStore.GetFeed(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respond.WithJSONError(w, http.StatusNotFound, "feed not found")
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "get feed", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	// A failed fetch is recorded in the health of the feed, it is not an error of the request.
+	if err := h.refresher.Refresh(ctx, feed); err != nil {
+		slog.Log(r.Context(), slog.LevelInfo, "refresh feed", "feed", feed.ID, "error", err)
+	}
+
+	feed, err = h.feedStore.GetFeed(ctx, id)
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "get feed", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, feed)
+}
+
+// DisableFeed disables a feed, it is not fetched anymore but its posts are kept.
+func (h *AdminHandler) DisableFeed(w http.ResponseWriter, r *http.Request) {
+	h.setFeedDisabled(w, r, true)
+}
+
+// EnableFeed enables a disabled feed, it is fetched again at its turn.
+func (h *AdminHandler) EnableFeed(w http.ResponseWriter, r *http.Request) {
+	h.setFeedDisabled(w, r, false)
+}
+
+func (h *AdminHandler) setFeedDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid id: %q", chi.URLParam(r, "id")))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	feed, err := h.feedStore.SetFeedDisabled(ctx, database.SetFeedDisabledParams{
+		ID:       id,
+		Disabled: disabled,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respond.WithJSONError(w, http.StatusNotFound, "feed not found")
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "set feed disabled", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, feed)
+}
+
+// PurgePosts deletes the posts of the feed of the 'feed_id' query parameter,
+// published before the RFC 3339 date of the 'before' query parameter. At least one of them is required.
+// The starred posts keep their copy, and the posts still in the feed documents are created again at the next fetch.
+func (h *AdminHandler) PurgePosts(w http.ResponseWriter, r *http.Request) {
+	feedID, err := getOptionalUUID(r, "feed_id")
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var before sql.NullTime
+	if value := r.URL.Query().Get("before"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid before: %q", value))
+			return
+		}
+		before = sql.NullTime{Time: t, Valid: true}
+	}
+	if !feedID.Valid && !before.Valid {
+		respond.WithJSONError(w, http.StatusBadRequest, "feed_id or before is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	count, err := h.postStore.PurgePosts(ctx, database.PurgePostsParams{
+		FeedID: feedID,
+		Before: before,
+	})
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "purge posts", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, purgePostsResp{Deleted: count})
+}
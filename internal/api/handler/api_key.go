@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/middleware"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+)
+
+// maxApiKeyGracePeriod is the longest time the previous API key still works after a rotation.
+const maxApiKeyGracePeriod = 7 * 24 * time.Hour
+
+// createApiKeyReq is the request to create an API key.
+type createApiKeyReq struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// ExpiresAt is the expiration of the key, it never expires when it is not set.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// validate checks the name, the scopes and the expiration of the key.
+func (req createApiKeyReq) validate() error {
+	if strings.TrimSpace(req.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(req.Scopes) == 0 {
+		return fmt.Errorf("scopes are required, among: %s", strings.Join(middleware.Scopes, ", "))
+	}
+	for _, scope := range req.Scopes {
+		if !middleware.HasScopes(middleware.Scopes, scope) {
+			return fmt.Errorf("invalid scope: %q", scope)
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return errors.New("expires_at must be in the future")
+	}
+
+	return nil
+}
+
+// CreateApiKey creates an API key of the authenticated user, restricted to the given scopes.
+// The key is only returned in the response, it cannot be retrieved later.
+func (h *UserHandler) CreateApiKey(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	var req createApiKeyReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := req.validate(); err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.CreateApiKeyParams{
+		UserID: userID,
+		Name:   strings.TrimSpace(req.Name),
+		Scopes: req.Scopes,
+	}
+	if req.ExpiresAt != nil {
+		params.ExpiresAt = sql.NullTime{Time: *req.ExpiresAt, Valid: true}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	key, err := h.store.CreateApiKey(ctx, params)
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "create api key", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, key)
+}
+
+// ListApiKeys lists the API keys of the authenticated user, with their prefix but not the keys themselves.
+func (h *UserHandler) ListApiKeys(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	keys, err := h.store.ListApiKeys(ctx, userID)
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "list api keys", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, keys)
+}
+
+// DeleteApiKey revokes an API key of the authenticated user.
+func (h *UserHandler) DeleteApiKey(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid id: %q", chi.URLParam(r, "id")))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.DeleteApiKey(ctx, database.DeleteApiKeyParams{
+		ID:     id,
+		UserID: userID,
+	}); err != nil {
+		slog.Log(r.Context(), slog.LevelError, "delete api key", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// rotateApiKeyReq is the request to rotate an API key.
+type rotateApiKeyReq struct {
+	// GracePeriodHours is the time the previous key still works, it is revoked at once by default.
+	GracePeriodHours int `json:"grace_period_hours"`
+}
+
+// RotateApiKey replaces the API key authenticating the request by a new one, with the same name, scopes
+// and expiration, and returns it only once. The previous key still works during the optional grace period.
+func (h *UserHandler) RotateApiKey(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+	keyID, err := GetApiKeyIDFromContext(r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	// The body is optional.
+	var req rotateApiKeyReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	gracePeriod := time.Duration(req.GracePeriodHours) * time.Hour
+	if gracePeriod < 0 || gracePeriod > maxApiKeyGracePeriod {
+		respond.WithJSONError(w, http.StatusBadRequest,
+			fmt.Sprintf("grace_period_hours must be between 0 and %d", int(maxApiKeyGracePeriod.Hours())))
+		return
+	}
+
+	params := database.RotateApiKeyParams{ID: keyID, UserID: userID}
+	if gracePeriod > 0 {
+		params.PreviousExpiresAt = sql.NullTime{Time: time.Now().Add(gracePeriod), Valid: true}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	key, err := h.store.RotateApiKey(ctx, params)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "rotate api key", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, key)
+}
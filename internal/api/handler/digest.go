@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/digest"
+)
+
+// DigestStore represents a store for managing the digest settings.
+type DigestStore interface {
+	UpsertDigestSettings(ctx context.Context, arg database.UpsertDigestSettingsParams) (database.DigestSetting, error)
+	GetDigestSettings(ctx context.Context, userID uuid.UUID) (database.DigestSetting, error)
+	DeleteDigestSettings(ctx context.Context, userID uuid.UUID) error
+}
+
+// DigestHandler is the handler for the email digest settings.
+type DigestHandler struct {
+	store DigestStore
+}
+
+// NewDigestHandler returns a new digest handler.
+func NewDigestHandler(store DigestStore) *DigestHandler {
+	return &DigestHandler{store: store}
+}
+
+// digestReq is the request to set the digest settings.
+type digestReq struct {
+	Email     string `json:"email"`
+	Frequency string `json:"frequency"`
+	Hour      int    `json:"hour"`
+	// Weekday is the day of a weekly digest, from 0 (Sunday) to 6, it defaults to Monday.
+	Weekday *int `json:"weekday"`
+	// Timezone is the IANA name of the time zone of the hour, it defaults to UTC.
+	Timezone string `json:"timezone"`
+	// Enabled defaults to true.
+	Enabled *bool `json:"enabled"`
+}
+
+// schedule validates the request and returns its schedule.
+func (req digestReq) schedule() (digest.Schedule, error) {
+	addr, err := mail.ParseAddress(req.Email)
+	if err != nil || addr.Address != req.Email {
+		return digest.Schedule{}, fmt.Errorf("invalid email: %q", req.Email)
+	}
+
+	loc, err := time.LoadLocation(req.Timezone)
+	if err != nil {
+		return digest.Schedule{}, fmt.Errorf("invalid timezone: %q", req.Timezone)
+	}
+
+	schedule := digest.Schedule{
+		Frequency: req.Frequency,
+		Hour:      req.Hour,
+		Weekday:   time.Weekday(*req.Weekday),
+		Location:  loc,
+	}
+	if err := schedule.Validate(); err != nil {
+		return digest.Schedule{}, err
+	}
+
+	return schedule, nil
+}
+
+// SetDigestSettings creates or replaces the digest settings of the authenticated user.
+// The next digest is scheduled from now.
+func (h *DigestHandler) SetDigestSettings(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	req := digestReq{Timezone: "UTC"}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.Log(r.Context(), slog.LevelInfo, "decode digest settings", "error", err)
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Weekday == nil {
+		req.Weekday = new(int)
+		*req.Weekday = int(time.Monday)
+	}
+	if req.Enabled == nil {
+		req.Enabled = new(bool)
+		*req.Enabled = true
+	}
+	schedule, err := req.schedule()
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	settings, err := h.store.UpsertDigestSettings(ctx, database.UpsertDigestSettingsParams{
+		UserID:     userID,
+		Email:      req.Email,
+		Frequency:  schedule.Frequency,
+		Hour:       int32(schedule.Hour),
+		Weekday:    int32(schedule.Weekday),
+		Timezone:   schedule.Location.String(),
+		Enabled:    *req.Enabled,
+		NextSendAt: schedule.Next(time.Now()),
+	})
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "upsert digest settings", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, settings)
+}
+
+// GetDigestSettings returns the digest settings of the authenticated user.
+func (h *DigestHandler) GetDigestSettings(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	settings, err := h.store.GetDigestSettings(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respond.WithJSONError(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "get digest settings", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, settings)
+}
+
+// DeleteDigestSettings deletes the digest settings of the authenticated user, no digest is sent anymore.
+func (h *DigestHandler) DeleteDigestSettings(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.DeleteDigestSettings(ctx, userID); err != nil {
+		slog.Log(r.Context(), slog.LevelError, "delete digest settings", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/opml"
+)
+
+// The formats of the export archive.
+const (
+	exportFormatZip   = "zip"
+	exportFormatTarGz = "tar.gz"
+)
+
+// ExportUserStore represents a store for reading the profile of a user.
+type ExportUserStore interface {
+	GetUserFromId(ctx context.Context, id uuid.UUID) (database.User, error)
+}
+
+// ExportFeedStore represents a store for reading the feeds and the follows of a user.
+type ExportFeedStore interface {
+	ListFolders(ctx context.Context, userID uuid.UUID) ([]database.Folder, error)
+	ListFeedFollowsWithFeeds(ctx context.Context, userID uuid.NullUUID) ([]database.ListFeedFollowsWithFeedsRow, error)
+	ListFeedsByCreator(ctx context.Context, userID uuid.NullUUID) ([]database.Feed, error)
+}
+
+// ExportPostStore represents a store for reading the read and starred posts of a user.
+type ExportPostStore interface {
+	ListReadPosts(ctx context.Context, userID uuid.UUID) ([]database.ListReadPostsRow, error)
+	ListAllStarredPosts(ctx context.Context, userID uuid.UUID) ([]database.StarredPost, error)
+}
+
+// ExportHandler is the handler exporting all the data of a user in an archive,
+// for the data protection requests or to move the user to another instance.
+type ExportHandler struct {
+	userStore       ExportUserStore
+	feedStore       ExportFeedStore
+	postStore       ExportPostStore
+	filterRuleStore FilterRuleStore
+}
+
+// NewExportHandler returns a new export handler.
+func NewExportHandler(userStore ExportUserStore, feedStore ExportFeedStore, postStore ExportPostStore, filterRuleStore FilterRuleStore) *ExportHandler {
+	return &ExportHandler{
+		userStore:       userStore,
+		feedStore:       feedStore,
+		postStore:       postStore,
+		filterRuleStore: filterRuleStore,
+	}
+}
+
+// exportFile is a file of the export archive.
+type exportFile struct {
+	name string
+	data []byte
+}
+
+// ExportUser exports the data of the authenticated user in an archive.
+// The 'format' query parameter selects a 'zip' (default) or a 'tar.gz' archive holding
+// the profile, the folders, the follows as JSON and OPML, the read and starred posts,
+// the filter rules and the feeds created by the user.
+func (h *ExportHandler) ExportUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exportFormatZip
+	}
+	if format != exportFormatZip && format != exportFormatTarGz {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid format: %q, 'zip' or 'tar.gz' is expected", format))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	files, err := h.exportFiles(ctx, userID)
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "export user", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	// Build the archive in a buffer to be able to report an error before the headers are sent.
+	var buf bytes.Buffer
+	now := time.Now().UTC()
+	contentType := "application/zip"
+	if format == exportFormatTarGz {
+		contentType = "application/gzip"
+		err = writeTarGz(&buf, files, now)
+	} else {
+		err = writeZip(&buf, files, now)
+	}
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "write export archive", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="export-%s.%s"`, now.Format("2006-01-02"), format))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// exportFiles reads the data of the user and renders the files of the archive.
+func (h *ExportHandler) exportFiles(ctx context.Context, userID uuid.UUID) ([]exportFile, error) {
+	user, err := h.userStore.GetUserFromId(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+	folders, err := h.feedStore.ListFolders(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list folders: %w", err)
+	}
+	follows, err := h.feedStore.ListFeedFollowsWithFeeds(ctx, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("list feed follows with feeds: %w", err)
+	}
+	feeds, err := h.feedStore.ListFeedsByCreator(ctx, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("list feeds by creator: %w", err)
+	}
+	read, err := h.postStore.ListReadPosts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list read posts: %w", err)
+	}
+	starred, err := h.postStore.ListAllStarredPosts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list starred posts: %w", err)
+	}
+	rules, err := h.filterRuleStore.ListFilterRules(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list filter rules: %w", err)
+	}
+
+	var files []exportFile
+	for _, content := range []struct {
+		name string
+		v    any
+	}{
+		{"profile.json", user},
+		{"folders.json", folders},
+		{"follows.json", follows},
+		{"read.json", read},
+		{"starred.json", starred},
+		{"filters.json", rules},
+		{"feeds.json", feeds},
+	} {
+		data, err := json.MarshalIndent(content.v, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("marshal %s: %w", content.name, err)
+		}
+		files = append(files, exportFile{name: content.name, data: data})
+	}
+
+	var buf bytes.Buffer
+	doc := opml.NewDocument("Subscriptions", folderNames(folders), opmlSubscriptions(folders, follows))
+	if err := opml.Write(&buf, doc); err != nil {
+		return nil, fmt.Errorf("write opml: %w", err)
+	}
+	files = append(files, exportFile{name: "follows.opml", data: buf.Bytes()})
+
+	return files, nil
+}
+
+// writeZip writes the files in a zip archive.
+func writeZip(w io.Writer, files []exportFile, modified time.Time) error {
+	zw := zip.NewWriter(w)
+	for _, file := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(file.data); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// writeTarGz writes the files in a gzipped tar archive.
+func writeTarGz(w io.Writer, files []exportFile, modified time.Time) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for _, file := range files {
+		hdr := &tar.Header{Name: file.name, Mode: 0o600, Size: int64(len(file.data)), ModTime: modified}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gw.Close()
+}
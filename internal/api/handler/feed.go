@@ -2,23 +2,43 @@ package handler
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
 	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
 )
 
+// The visibility of a private feed, only followed by its creator and the users it is shared with.
+const feedVisibilityPrivate = "private"
+
+// The visibilities of the feeds, a public feed is listed, an unlisted feed is followed by its id or its url.
+var feedVisibilities = map[string]bool{
+	"public":              true,
+	"unlisted":            true,
+	feedVisibilityPrivate: true,
+}
+
 // FeedStore represents a store for managing feed data.
 type FeedStore interface {
 	CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error)
 	ListFeeds(ctx context.Context, arg database.ListFeedsParams) ([]database.Feed, error)
 	CreateFeedAndFollow(ctx context.Context, arg database.CreateFeedParams) (database.Feed, database.FeedFollow, error)
+	GetManagedFeed(ctx context.Context, arg database.GetManagedFeedParams) (database.Feed, error)
+	UpdateFeed(ctx context.Context, arg database.UpdateFeedParams) (database.Feed, error)
+	DeleteFeed(ctx context.Context, id uuid.UUID) error
+	CreateFeedShare(ctx context.Context, arg database.CreateFeedShareParams) (database.FeedShare, error)
+	ListFeedShares(ctx context.Context, feedID uuid.UUID) ([]database.FeedShare, error)
+	DeleteFeedShare(ctx context.Context, arg database.DeleteFeedShareParams) error
 }
 
 // FeedHandler is the handler for feed related requests.
@@ -35,6 +55,23 @@ func NewFeedHandler(store FeedStore) *FeedHandler {
 type createFeedReq struct {
 	Name string `json:"name"`
 	URL  string `json:"url"`
+	// Visibility is public by default.
+	Visibility string `json:"visibility"`
+}
+
+// updateFeedReq is the request to update a feed, only the given fields are changed.
+type updateFeedReq struct {
+	Name *string `json:"name"`
+	URL  *string `json:"url"`
+	// Visibility made private unfollows the feed for the users it is not shared with.
+	Visibility *string `json:"visibility"`
+	// Disabled pauses or resumes the fetching of the feed.
+	Disabled *bool `json:"disabled"`
+}
+
+// shareFeedReq is the request to share a feed with a user.
+type shareFeedReq struct {
+	UserID uuid.UUID `json:"user_id"`
 }
 
 // createFeedResponse is the response to create a feed.
@@ -43,7 +80,7 @@ type createFeedResponse struct {
 	FeedFollow database.FeedFollow `json:"feed_follow"`
 }
 
-// CreateFeed creates a new feed.
+// CreateFeed creates a new feed, public unless another visibility is given.
 func (h *FeedHandler) CreateFeed(w http.ResponseWriter, r *http.Request) {
 	var req createFeedReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -52,6 +89,15 @@ func (h *FeedHandler) CreateFeed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	visibility := sql.NullString{}
+	if req.Visibility != "" {
+		if !feedVisibilities[req.Visibility] {
+			respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid visibility: %q", req.Visibility))
+			return
+		}
+		visibility = sql.NullString{String: req.Visibility, Valid: true}
+	}
+
 	userIDVal := r.Context().Value("user")
 	userID, ok := userIDVal.(uuid.UUID)
 	if userIDVal == nil || !ok {
@@ -60,15 +106,20 @@ func (h *FeedHandler) CreateFeed(w http.ResponseWriter, r *http.Request) {
 	}
 
 	feed, follow, err := h.store.CreateFeedAndFollow(r.Context(), database.CreateFeedParams{
-		Name:   req.Name,
-		Url:    req.URL,
-		UserID: uuid.NullUUID{UUID: userID, Valid: true},
+		Name:       req.Name,
+		Url:        req.URL,
+		UserID:     uuid.NullUUID{UUID: userID, Valid: true},
+		Visibility: visibility,
 	})
 
 	if err != nil {
-		// Error should be filtered here.
-		slog.Log(r.Context(), slog.LevelError, "create feed: %v", err)
-		respond.WithJSONError(w, http.StatusInternalServerError, err.Error())
+		// Only a feed the user can see reserves its url, the private feeds of the other users do not.
+		if database.IsUniqueViolation(err) {
+			respond.WithJSONError(w, http.StatusConflict, fmt.Sprintf("a feed already exists with the url: %q", req.URL))
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "create feed", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
 		return
 	}
 
@@ -78,7 +129,7 @@ func (h *FeedHandler) CreateFeed(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// ListFeeds returns a list of feeds.
+// ListFeeds returns a list of the public feeds.
 func (h *FeedHandler) ListFeeds(w http.ResponseWriter, r *http.Request) {
 	// Get the values of 'offset' and 'limit' from the URL query parameters
 	offsetStr := r.URL.Query().Get("offset")
@@ -117,3 +168,216 @@ func (h *FeedHandler) ListFeeds(w http.ResponseWriter, r *http.Request) {
 
 	respond.WithJSON(w, http.StatusOK, feeds)
 }
+
+// GetFeed returns a feed managed by the authenticated user, as its creator or as an admin.
+func (h *FeedHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
+	feed, ok := h.getManagedFeed(w, r)
+	if !ok {
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, feed)
+}
+
+// UpdateFeed renames a feed, changes its url or its visibility, or pauses and resumes its fetching.
+// Only the creator of the feed and the admins can update it,
+// and it cannot be made private while users it is not shared with follow it.
+func (h *FeedHandler) UpdateFeed(w http.ResponseWriter, r *http.Request) {
+	var req updateFeedReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.UpdateFeedParams{}
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			respond.WithJSONError(w, http.StatusBadRequest, "name cannot be empty")
+			return
+		}
+		params.Name = sql.NullString{String: strings.TrimSpace(*req.Name), Valid: true}
+	}
+	if req.URL != nil {
+		if err := validateFeedURL(*req.URL); err != nil {
+			respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		params.Url = sql.NullString{String: *req.URL, Valid: true}
+	}
+	if req.Visibility != nil {
+		if !feedVisibilities[*req.Visibility] {
+			respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid visibility: %q", *req.Visibility))
+			return
+		}
+		params.Visibility = sql.NullString{String: *req.Visibility, Valid: true}
+	}
+	if req.Disabled != nil {
+		params.Disabled = sql.NullBool{Bool: *req.Disabled, Valid: true}
+	}
+
+	feed, ok := h.getManagedFeed(w, r)
+	if !ok {
+		return
+	}
+	params.ID = feed.ID
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	feed, err := h.store.UpdateFeed(ctx, params)
+	if err != nil {
+		if errors.Is(err, database.ErrFeedFollowedUnshared) {
+			respond.WithJSONError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if database.IsUniqueViolation(err) {
+			respond.WithJSONError(w, http.StatusConflict, fmt.Sprintf("a feed already exists with the url: %q", params.Url.String))
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "update feed", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, feed)
+}
+
+// DeleteFeed deletes a feed with its posts.
+// Only the creator of the feed and the admins can delete it, and only while no other user follows it.
+func (h *FeedHandler) DeleteFeed(w http.ResponseWriter, r *http.Request) {
+	feed, ok := h.getManagedFeed(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.DeleteFeed(ctx, feed.ID); err != nil {
+		if errors.Is(err, database.ErrFeedFollowed) {
+			respond.WithJSONError(w, http.StatusConflict, err.Error())
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "delete feed", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ShareFeed shares a feed with a user, who can follow it even when it is private.
+func (h *FeedHandler) ShareFeed(w http.ResponseWriter, r *http.Request) {
+	var req shareFeedReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	feed, ok := h.getManagedFeed(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	share, err := h.store.CreateFeedShare(ctx, database.CreateFeedShareParams{FeedID: feed.ID, UserID: req.UserID})
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			respond.WithJSONError(w, http.StatusConflict, "the feed is already shared with the user")
+			return
+		}
+		if database.IsForeignKeyViolation(err) {
+			respond.WithJSONError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "create feed share", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, share)
+}
+
+// ListFeedShares lists the users a feed is shared with.
+func (h *FeedHandler) ListFeedShares(w http.ResponseWriter, r *http.Request) {
+	feed, ok := h.getManagedFeed(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	shares, err := h.store.ListFeedShares(ctx, feed.ID)
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "list feed shares", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, shares)
+}
+
+// UnshareFeed stops sharing a feed with the user of the 'user_id' url parameter.
+// The user unfollows the feed while it is private.
+func (h *FeedHandler) UnshareFeed(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid user_id: %q", chi.URLParam(r, "user_id")))
+		return
+	}
+
+	feed, ok := h.getManagedFeed(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.DeleteFeedShare(ctx, database.DeleteFeedShareParams{FeedID: feed.ID, UserID: userID}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respond.WithJSONError(w, http.StatusNotFound, "the feed is not shared with the user")
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "delete feed share", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getManagedFeed returns the feed of the 'id' url parameter when the authenticated user manages it.
+// Otherwise, the error is responded and false is returned, a feed managed by someone else is not found.
+func (h *FeedHandler) getManagedFeed(w http.ResponseWriter, r *http.Request) (database.Feed, bool) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return database.Feed{}, false
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid id: %q", chi.URLParam(r, "id")))
+		return database.Feed{}, false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	feed, err := h.store.GetManagedFeed(ctx, database.GetManagedFeedParams{ID: id, UserID: userID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respond.WithJSONError(w, http.StatusNotFound, "feed not found")
+			return database.Feed{}, false
+		}
+		slog.Log(r.Context(), slog.LevelError, "get managed feed", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return database.Feed{}, false
+	}
+
+	return feed, true
+}
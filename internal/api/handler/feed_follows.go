@@ -2,11 +2,13 @@ package handler
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -18,8 +20,20 @@ import (
 // FeedFollowsStore represents a store for managing feed follows data.
 type FeedFollowsStore interface {
 	CreateFeedFollows(ctx context.Context, arg database.CreateFeedFollowsParams) (database.FeedFollow, error)
-	ListFeedFollows(ctx context.Context, arg database.ListFeedFollowsParams) ([]database.FeedFollow, error)
+	CreateFeedFollowsIfNotExists(ctx context.Context, arg database.CreateFeedFollowsIfNotExistsParams) (database.FeedFollow, error)
+	ListAllFeedFollows(ctx context.Context, userID uuid.NullUUID) ([]database.FeedFollow, error)
+	ListFeedFollowsWithFeeds(ctx context.Context, userID uuid.NullUUID) ([]database.ListFeedFollowsWithFeedsRow, error)
 	DeleteFeedFollows(ctx context.Context, arg database.DeleteFeedFollowsParams) error
+	UpdateFeedFollows(ctx context.Context, arg database.UpdateFeedFollowsParams) (database.FeedFollow, error)
+	CreateFolder(ctx context.Context, arg database.CreateFolderParams) (database.Folder, error)
+	ListFolders(ctx context.Context, userID uuid.UUID) ([]database.Folder, error)
+	UpdateFolder(ctx context.Context, arg database.UpdateFolderParams) (database.Folder, error)
+	DeleteFolder(ctx context.Context, arg database.DeleteFolderParams) error
+	GetOrCreateFolder(ctx context.Context, arg database.GetOrCreateFolderParams) (database.Folder, error)
+	GetNextFolderPosition(ctx context.Context, userID uuid.UUID) (int32, error)
+	GetNextFeedFollowPosition(ctx context.Context, arg database.GetNextFeedFollowPositionParams) (int32, error)
+	CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error)
+	GetFeedByURL(ctx context.Context, arg database.GetFeedByURLParams) (database.Feed, error)
 }
 
 // FeedFollowsHandler is the handler for feed follows related requests.
@@ -38,6 +52,7 @@ type createFeedFollowsReq struct {
 }
 
 // CreateFeedFollows creates a new feed follows.
+// A private feed is only followed by its creator and the users it is shared with.
 func (h *FeedFollowsHandler) CreateFeedFollows(w http.ResponseWriter, r *http.Request) {
 	var req createFeedFollowsReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -65,6 +80,11 @@ func (h *FeedFollowsHandler) CreateFeedFollows(w http.ResponseWriter, r *http.Re
 			},
 		})
 	if err != nil {
+		// The private feeds are not found by the users they are not shared with.
+		if errors.Is(err, sql.ErrNoRows) {
+			respond.WithJSONError(w, http.StatusNotFound, "feed not found")
+			return
+		}
 		slog.Log(r.Context(), slog.LevelError, "create feed follow: %v", err)
 		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
 		return
@@ -73,7 +93,21 @@ func (h *FeedFollowsHandler) CreateFeedFollows(w http.ResponseWriter, r *http.Re
 	respond.WithJSON(w, http.StatusOK, feedFollows)
 }
 
-// ListFeedFollows lists the feed follows.
+// folderFeedFollows is a folder with its feed follows.
+type folderFeedFollows struct {
+	database.Folder
+	FeedFollows []database.FeedFollow `json:"feed_follows"`
+}
+
+// listFeedFollowsResponse is the response to list the feed follows.
+// The feed follows are grouped by folder, the ones without folder are listed apart.
+type listFeedFollowsResponse struct {
+	Folders     []folderFeedFollows   `json:"folders"`
+	FeedFollows []database.FeedFollow `json:"feed_follows"`
+}
+
+// ListFeedFollows lists all the feed follows, grouped by folder.
+// The whole tree is returned at once, a page of the follows would leave some folders incomplete.
 func (h *FeedFollowsHandler) ListFeedFollows(w http.ResponseWriter, r *http.Request) {
 	userIDVal := r.Context().Value("user")
 	userID, ok := userIDVal.(uuid.UUID)
@@ -82,43 +116,152 @@ func (h *FeedFollowsHandler) ListFeedFollows(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// Get the values of 'offset' and 'limit' from the URL query parameters
-	offsetStr := r.URL.Query().Get("offset")
-	if offsetStr == "" {
-		offsetStr = "0"
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	follows, err := h.store.ListAllFeedFollows(ctx, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		// Error should be filtered here.
+		slog.Log(r.Context(), slog.LevelError, "list feed follows", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid offset: %q", offsetStr))
+
+	// All the folders are listed, even the empty ones, to render the whole structure.
+	folders, err := h.store.ListFolders(ctx, userID)
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "list folders", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	limitStr := r.URL.Query().Get("limit")
-	if limitStr == "" {
-		limitStr = "10"
+	respond.WithJSON(w, http.StatusOK, groupFeedFollows(folders, follows))
+}
+
+// groupFeedFollows groups the feed follows by folder, keeping the order of both lists.
+func groupFeedFollows(folders []database.Folder, follows []database.FeedFollow) listFeedFollowsResponse {
+	resp := listFeedFollowsResponse{
+		Folders:     make([]folderFeedFollows, 0, len(folders)),
+		FeedFollows: []database.FeedFollow{},
+	}
+
+	index := make(map[uuid.UUID]int, len(folders))
+	for i, folder := range folders {
+		index[folder.ID] = i
+		resp.Folders = append(resp.Folders, folderFeedFollows{
+			Folder:      folder,
+			FeedFollows: []database.FeedFollow{},
+		})
 	}
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 || limit > 100 {
-		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit: %q", limitStr))
+
+	for _, follow := range follows {
+		i, ok := index[follow.FolderID.UUID]
+		if !follow.FolderID.Valid || !ok {
+			resp.FeedFollows = append(resp.FeedFollows, follow)
+			continue
+		}
+		resp.Folders[i].FeedFollows = append(resp.Folders[i].FeedFollows, follow)
+	}
+
+	return resp
+}
+
+// updateFeedFollowsReq is the request to update a feed follow, the omitted fields are nil.
+type updateFeedFollowsReq struct {
+	FolderID uuid.NullUUID `json:"folder_id"`
+	Position *int32        `json:"position"`
+	// Title overrides the name of the feed for the user.
+	Title *string `json:"title"`
+	// Muted hides the feed from the main timeline.
+	Muted *bool `json:"muted"`
+	// Notify sends the posts of the feed to the webhooks and the email digest of the user.
+	Notify *bool `json:"notify"`
+	// RetentionDays hides the posts older than the given number of days.
+	RetentionDays *int32 `json:"retention_days"`
+}
+
+// UpdateFeedFollows updates the folder, the position and the settings of a feed follow.
+// The omitted fields keep their value, a null folder_id removes the feed follow from its folder,
+// and a null or empty title and a null retention_days clear them.
+// The update is done in a single statement, so concurrent updates of different fields are all kept.
+func (h *FeedFollowsHandler) UpdateFeedFollows(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
 		return
 	}
 
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid id: %q", chi.URLParam(r, "id")))
+		return
+	}
+
+	// The fields given as null are told apart from the omitted ones by their presence in the request.
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var req updateFeedFollowsReq
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &req); err != nil {
+		slog.Log(r.Context(), slog.LevelInfo, "decode feed follow", "error", err)
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.RetentionDays != nil && *req.RetentionDays <= 0 {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid retention_days: %d", *req.RetentionDays))
+		return
+	}
+
+	_, setFolderID := fields["folder_id"]
+	_, setTitle := fields["title"]
+	_, setRetentionDays := fields["retention_days"]
+	params := database.UpdateFeedFollowsParams{
+		ID:               id,
+		UserID:           uuid.NullUUID{UUID: userID, Valid: true},
+		SetFolderID:      setFolderID,
+		FolderID:         req.FolderID,
+		SetTitle:         setTitle,
+		SetRetentionDays: setRetentionDays,
+	}
+	if req.Position != nil {
+		params.Position = sql.NullInt32{Int32: *req.Position, Valid: true}
+	}
+	if req.Title != nil && *req.Title != "" {
+		params.Title = sql.NullString{String: *req.Title, Valid: true}
+	}
+	if req.Muted != nil {
+		params.Muted = sql.NullBool{Bool: *req.Muted, Valid: true}
+	}
+	if req.Notify != nil {
+		params.Notify = sql.NullBool{Bool: *req.Notify, Valid: true}
+	}
+	if req.RetentionDays != nil {
+		params.RetentionDays = sql.NullInt32{Int32: *req.RetentionDays, Valid: true}
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	feeds, err := h.store.ListFeedFollows(ctx, database.ListFeedFollowsParams{
-		UserID: uuid.NullUUID{UUID: userID, Valid: true},
-		Limit:  int32(limit),
-		Offset: int32(offset),
-	})
+	follow, err := h.store.UpdateFeedFollows(ctx, params)
 	if err != nil {
-		// Error should be filtered here.
-		slog.Log(r.Context(), slog.LevelError, "list feeds: %v", err)
-		respond.WithJSONError(w, http.StatusInternalServerError, err.Error())
+		if errors.Is(err, sql.ErrNoRows) {
+			// The feed follow or the folder does not exist for this user.
+			respond.WithJSONError(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "update feed follow", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
 		return
 	}
 
-	respond.WithJSON(w, http.StatusOK, feeds)
+	respond.WithJSON(w, http.StatusOK, follow)
 }
 
 // DeleteFeedFollows deletes a feed follows.
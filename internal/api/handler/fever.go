@@ -0,0 +1,475 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/middleware"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+)
+
+const (
+	// feverAPIVersion is the version of the Fever API implemented by the handler.
+	feverAPIVersion = 3
+	// feverItemsLimit is the number of items returned at once, as defined by the Fever API.
+	feverItemsLimit = 50
+	// feverKindling is the id of the group of all the feeds.
+	feverKindling = 0
+)
+
+// feverScopes are the scopes of the API keys required by the Fever API.
+var feverScopes = []string{middleware.ScopeFeedsRead, middleware.ScopePostsRead}
+
+// FeverUserStore represents a store to authenticate a user by its Fever key.
+type FeverUserStore interface {
+	GetUserFromFeverKey(ctx context.Context, feverKey string) (database.GetUserFromFeverKeyRow, error)
+}
+
+// FeverFeedStore represents a store of the feeds and the folders followed by a user.
+type FeverFeedStore interface {
+	ListFolders(ctx context.Context, userID uuid.UUID) ([]database.Folder, error)
+	ListFeedFollowsWithFeeds(ctx context.Context, userID uuid.NullUUID) ([]database.ListFeedFollowsWithFeedsRow, error)
+}
+
+// FeverPostStore represents a store of the posts of a user, with their read and starred status.
+type FeverPostStore interface {
+	ListPostsWithStatus(ctx context.Context, arg database.ListPostsWithStatusParams) ([]database.ListPostsWithStatusRow, error)
+	CountPosts(ctx context.Context, userID uuid.UUID) (int32, error)
+	ListUnreadPostIDs(ctx context.Context, userID uuid.UUID) ([]int32, error)
+	ListStarredPostIDs(ctx context.Context, userID uuid.UUID) ([]int32, error)
+	MarkPostsRead(ctx context.Context, arg database.MarkPostsReadParams) ([]int32, error)
+	MarkPostsUnread(ctx context.Context, arg database.MarkPostsUnreadParams) error
+	MarkPostsReadBefore(ctx context.Context, arg database.MarkPostsReadBeforeParams) (int64, error)
+	StarPost(ctx context.Context, arg database.StarPostParams) (database.StarredPost, error)
+	UnstarPostByPostID(ctx context.Context, arg database.UnstarPostByPostIDParams) error
+}
+
+// FeverHandler is the handler of the Fever API, used by many mobile clients.
+// The groups are the folders, the feeds are the followed feeds and the items are their posts.
+type FeverHandler struct {
+	userStore FeverUserStore
+	feedStore FeverFeedStore
+	postStore FeverPostStore
+}
+
+// NewFeverHandler returns a new Fever handler.
+func NewFeverHandler(userStore FeverUserStore, feedStore FeverFeedStore, postStore FeverPostStore) *FeverHandler {
+	return &FeverHandler{
+		userStore: userStore,
+		feedStore: feedStore,
+		postStore: postStore,
+	}
+}
+
+type feverGroup struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+type feverFeedsGroup struct {
+	GroupID int64  `json:"group_id"`
+	FeedIDs string `json:"feed_ids"`
+}
+
+type feverFeed struct {
+	ID                int64  `json:"id"`
+	FaviconID         int64  `json:"favicon_id"`
+	Title             string `json:"title"`
+	URL               string `json:"url"`
+	SiteURL           string `json:"site_url"`
+	IsSpark           int    `json:"is_spark"`
+	LastUpdatedOnTime int64  `json:"last_updated_on_time"`
+}
+
+type feverItem struct {
+	ID            int32  `json:"id"`
+	FeedID        int64  `json:"feed_id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	HTML          string `json:"html"`
+	URL           string `json:"url"`
+	IsSaved       int    `json:"is_saved"`
+	IsRead        int    `json:"is_read"`
+	CreatedOnTime int64  `json:"created_on_time"`
+}
+
+// feverID returns the integer id of a feed or a folder, the Fever API does not support the uuids.
+// It is derived from the uuid, so it is stable without being stored.
+func feverID(id uuid.UUID) int64 {
+	return int64(binary.BigEndian.Uint32(id[:4]) & 0x7fffffff)
+}
+
+// feverBool converts a boolean to the integer expected by the Fever API.
+func feverBool(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// joinIDs returns the comma separated ids.
+func joinIDs[T int32 | int64](ids []T) string {
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, strconv.FormatInt(int64(id), 10))
+	}
+
+	return strings.Join(values, ",")
+}
+
+// HandleAPI answers the Fever API requests.
+// The client is authenticated by the 'api_key' field, the md5 of 'user id:api key',
+// the key must grant the 'feeds:read' and 'posts:read' scopes.
+// The query selects the parts of the response: groups, feeds, favicons, items, links,
+// unread_item_ids and saved_item_ids, and marks the items, the feeds or the groups.
+func (h *FeverHandler) HandleAPI(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid form: %v", err))
+		return
+	}
+	if !r.Form.Has("api") {
+		respond.WithJSONError(w, http.StatusBadRequest, "missing api parameter")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	resp := map[string]any{"api_version": feverAPIVersion, "auth": 0}
+	key, err := h.userStore.GetUserFromFeverKey(ctx, strings.ToLower(r.Form.Get("api_key")))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Log(r.Context(), slog.LevelError, "get user from fever key", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	// The clients expect an unauthenticated response rather than an error.
+	if err != nil || !middleware.HasScopes(key.Scopes, feverScopes...) {
+		respond.WithJSON(w, http.StatusOK, resp)
+		return
+	}
+	resp["auth"] = 1
+
+	if err := h.handle(ctx, r, key.User.ID, resp); err != nil {
+		var badRequest feverBadRequest
+		if errors.As(err, &badRequest) {
+			respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "fever api", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, resp)
+}
+
+// feverBadRequest is an invalid parameter of a Fever request.
+type feverBadRequest string
+
+func (e feverBadRequest) Error() string {
+	return string(e)
+}
+
+// feverInt returns an optional integer parameter of a Fever request.
+func feverInt(r *http.Request, key string) (int64, bool, error) {
+	value := r.Form.Get(key)
+	if value == "" {
+		return 0, false, nil
+	}
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false, feverBadRequest(fmt.Sprintf("invalid %s: %q", key, value))
+	}
+
+	return i, true, nil
+}
+
+// handle fills the response of an authenticated Fever request.
+func (h *FeverHandler) handle(ctx context.Context, r *http.Request, userID uuid.UUID, resp map[string]any) error {
+	// The ids of the items updated by a mark operation are always returned.
+	marked := ""
+	if r.Form.Has("mark") {
+		var err error
+		marked, err = h.mark(ctx, r, userID)
+		if err != nil {
+			return err
+		}
+	}
+	has := func(key string) bool {
+		return r.Form.Has(key) || key == marked
+	}
+
+	follows, err := h.feedStore.ListFeedFollowsWithFeeds(ctx, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		return err
+	}
+
+	// The last refresh is the last fetch of the followed feeds.
+	var lastRefreshed int64
+	for _, follow := range follows {
+		if follow.FeedLastFetchedAt.Valid {
+			lastRefreshed = max(lastRefreshed, follow.FeedLastFetchedAt.Time.Unix())
+		}
+	}
+	resp["last_refreshed_on_time"] = lastRefreshed
+
+	if has("groups") {
+		folders, err := h.feedStore.ListFolders(ctx, userID)
+		if err != nil {
+			return err
+		}
+		groups := make([]feverGroup, 0, len(folders))
+		for _, folder := range folders {
+			groups = append(groups, feverGroup{ID: feverID(folder.ID), Title: folder.Name})
+		}
+		resp["groups"] = groups
+	}
+	if has("groups") || has("feeds") {
+		resp["feeds_groups"] = feverFeedsGroups(follows)
+	}
+
+	if has("feeds") {
+		feeds := make([]feverFeed, 0, len(follows))
+		for _, follow := range follows {
+			feed := feverFeed{
+				ID:      feverID(follow.FeedID.UUID),
+				Title:   follow.FeedName,
+				URL:     follow.FeedUrl,
+				SiteURL: follow.FeedSiteUrl,
+			}
+			if follow.Title.Valid {
+				feed.Title = follow.Title.String
+			}
+			if follow.FeedLastFetchedAt.Valid {
+				feed.LastUpdatedOnTime = follow.FeedLastFetchedAt.Time.Unix()
+			}
+			feeds = append(feeds, feed)
+		}
+		resp["feeds"] = feeds
+	}
+
+	// The favicons and the hot links are not supported.
+	if has("favicons") {
+		resp["favicons"] = []any{}
+	}
+	if has("links") {
+		resp["links"] = []any{}
+	}
+
+	if has("items") {
+		if err := h.items(ctx, r, userID, resp); err != nil {
+			return err
+		}
+	}
+
+	if has("unread_item_ids") {
+		ids, err := h.postStore.ListUnreadPostIDs(ctx, userID)
+		if err != nil {
+			return err
+		}
+		resp["unread_item_ids"] = joinIDs(ids)
+	}
+	if has("saved_item_ids") {
+		ids, err := h.postStore.ListStarredPostIDs(ctx, userID)
+		if err != nil {
+			return err
+		}
+		resp["saved_item_ids"] = joinIDs(ids)
+	}
+
+	return nil
+}
+
+// feverFeedsGroups returns the feeds of each folder.
+func feverFeedsGroups(follows []database.ListFeedFollowsWithFeedsRow) []feverFeedsGroup {
+	var groupIDs []int64
+	feedIDs := make(map[int64][]int64)
+	for _, follow := range follows {
+		if !follow.FolderID.Valid {
+			continue
+		}
+		groupID := feverID(follow.FolderID.UUID)
+		if _, ok := feedIDs[groupID]; !ok {
+			groupIDs = append(groupIDs, groupID)
+		}
+		feedIDs[groupID] = append(feedIDs[groupID], feverID(follow.FeedID.UUID))
+	}
+
+	groups := make([]feverFeedsGroup, 0, len(groupIDs))
+	for _, groupID := range groupIDs {
+		groups = append(groups, feverFeedsGroup{GroupID: groupID, FeedIDs: joinIDs(feedIDs[groupID])})
+	}
+
+	return groups
+}
+
+// items adds the items selected by the 'since_id', 'max_id' or 'with_ids' parameters to the response.
+func (h *FeverHandler) items(ctx context.Context, r *http.Request, userID uuid.UUID, resp map[string]any) error {
+	params := database.ListPostsWithStatusParams{
+		UserID: userID,
+		Limit:  feverItemsLimit,
+	}
+	if sinceID, ok, err := feverInt(r, "since_id"); err != nil {
+		return err
+	} else if ok {
+		params.AfterID = sql.NullInt32{Int32: int32(sinceID), Valid: true}
+	}
+	if maxID, ok, err := feverInt(r, "max_id"); err != nil {
+		return err
+	} else if ok && maxID > 0 {
+		params.BeforeID = sql.NullInt32{Int32: int32(maxID), Valid: true}
+	}
+	if withIDs := r.Form.Get("with_ids"); withIDs != "" {
+		for _, value := range strings.Split(withIDs, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(value), 10, 32)
+			if err != nil {
+				return feverBadRequest(fmt.Sprintf("invalid with_ids: %q", withIDs))
+			}
+			params.Ids = append(params.Ids, int32(id))
+		}
+		if len(params.Ids) > feverItemsLimit {
+			return feverBadRequest(fmt.Sprintf("too many ids in with_ids, %d at most", feverItemsLimit))
+		}
+	}
+
+	posts, err := h.postStore.ListPostsWithStatus(ctx, params)
+	if err != nil {
+		return err
+	}
+	total, err := h.postStore.CountPosts(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	items := make([]feverItem, 0, len(posts))
+	for _, row := range posts {
+		items = append(items, feverItem{
+			ID:            row.Post.ID,
+			FeedID:        feverID(row.Post.FeedID.UUID),
+			Title:         row.Post.Title,
+			Author:        row.Post.Author,
+			HTML:          row.Post.Description,
+			URL:           row.Post.Url,
+			IsSaved:       feverBool(row.IsStarred),
+			IsRead:        feverBool(row.IsRead),
+			CreatedOnTime: row.Post.PublishedAt.Unix(),
+		})
+	}
+	resp["items"] = items
+	resp["total_items"] = total
+
+	return nil
+}
+
+// mark applies the 'mark' operation of the request:
+// an item is marked as read, unread, saved or unsaved, a feed or a group is marked as read.
+// It returns the list of ids to add to the response, the unread or the saved items.
+func (h *FeverHandler) mark(ctx context.Context, r *http.Request, userID uuid.UUID) (string, error) {
+	mark := r.Form.Get("mark")
+	as := r.Form.Get("as")
+	id, ok, err := feverInt(r, "id")
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", feverBadRequest("missing id")
+	}
+
+	switch mark {
+	case "item":
+		postID := int32(id)
+		switch as {
+		case "read":
+			_, err = h.postStore.MarkPostsRead(ctx, database.MarkPostsReadParams{UserID: userID, PostIds: []int32{postID}})
+		case "unread":
+			err = h.postStore.MarkPostsUnread(ctx, database.MarkPostsUnreadParams{UserID: userID, PostIds: []int32{postID}})
+		case "saved":
+			_, err = h.postStore.StarPost(ctx, database.StarPostParams{UserID: userID, PostID: postID})
+			// The posts of the feeds not followed cannot be saved.
+			if errors.Is(err, sql.ErrNoRows) {
+				err = nil
+			}
+		case "unsaved":
+			err = h.postStore.UnstarPostByPostID(ctx, database.UnstarPostByPostIDParams{UserID: userID, PostID: postID})
+		default:
+			return "", feverBadRequest(fmt.Sprintf("invalid as: %q", as))
+		}
+		if err != nil {
+			return "", err
+		}
+		if as == "saved" || as == "unsaved" {
+			return "saved_item_ids", nil
+		}
+
+		return "unread_item_ids", nil
+	case "feed", "group":
+		if as != "read" {
+			return "", feverBadRequest(fmt.Sprintf("invalid as: %q", as))
+		}
+		before, ok, err := feverInt(r, "before")
+		if err != nil {
+			return "", err
+		}
+		params := database.MarkPostsReadBeforeParams{UserID: userID, Before: time.Now()}
+		if ok {
+			params.Before = time.Unix(before, 0)
+		}
+
+		if err := h.scopeOf(ctx, userID, mark, id, &params); err != nil {
+			return "", err
+		}
+		if _, err := h.postStore.MarkPostsReadBefore(ctx, params); err != nil {
+			return "", err
+		}
+
+		return "unread_item_ids", nil
+	default:
+		return "", feverBadRequest(fmt.Sprintf("invalid mark: %q", mark))
+	}
+}
+
+// scopeOf restricts the posts marked as read to the feed or the folder of a Fever id.
+// The Kindling group is all the feeds.
+func (h *FeverHandler) scopeOf(ctx context.Context, userID uuid.UUID, mark string, id int64, params *database.MarkPostsReadBeforeParams) error {
+	if mark == "group" {
+		if id == feverKindling {
+			return nil
+		}
+		folders, err := h.feedStore.ListFolders(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, folder := range folders {
+			if feverID(folder.ID) == id {
+				params.FolderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+				return nil
+			}
+		}
+
+		return feverBadRequest(fmt.Sprintf("unknown group: %d", id))
+	}
+
+	follows, err := h.feedStore.ListFeedFollowsWithFeeds(ctx, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		return err
+	}
+	for _, follow := range follows {
+		if feverID(follow.FeedID.UUID) == id {
+			params.FeedID = follow.FeedID
+			return nil
+		}
+	}
+
+	return feverBadRequest(fmt.Sprintf("unknown feed: %d", id))
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+)
+
+// The kinds of filter rules.
+var filterRuleKinds = map[string]bool{
+	"keyword":  true,
+	"regex":    true,
+	"author":   true,
+	"category": true,
+}
+
+// The actions of filter rules.
+var filterRuleActions = map[string]bool{
+	"include": true,
+	"exclude": true,
+}
+
+// FilterRuleStore represents a store for managing filter rules data.
+type FilterRuleStore interface {
+	CreateFilterRule(ctx context.Context, arg database.CreateFilterRuleParams) (database.FilterRule, error)
+	ListFilterRules(ctx context.Context, userID uuid.UUID) ([]database.FilterRule, error)
+	UpdateFilterRule(ctx context.Context, arg database.UpdateFilterRuleParams) (database.FilterRule, error)
+	DeleteFilterRule(ctx context.Context, arg database.DeleteFilterRuleParams) error
+}
+
+// FilterRuleHandler is the handler for filter rules related requests.
+// The filter rules are applied to the timeline of the user.
+type FilterRuleHandler struct {
+	store FilterRuleStore
+}
+
+// NewFilterRuleHandler returns a new filter rule handler.
+func NewFilterRuleHandler(store FilterRuleStore) *FilterRuleHandler {
+	return &FilterRuleHandler{store: store}
+}
+
+// filterRuleReq is the request to create or update a filter rule.
+type filterRuleReq struct {
+	Kind    string `json:"kind"`
+	Action  string `json:"action"`
+	Pattern string `json:"pattern"`
+	// FeedID and FolderID restrict the rule to a feed or a folder.
+	FeedID   uuid.NullUUID `json:"feed_id"`
+	FolderID uuid.NullUUID `json:"folder_id"`
+}
+
+// validate checks the request values.
+func (req filterRuleReq) validate() error {
+	if !filterRuleKinds[req.Kind] {
+		return fmt.Errorf("invalid kind: %q", req.Kind)
+	}
+	if !filterRuleActions[req.Action] {
+		return fmt.Errorf("invalid action: %q", req.Action)
+	}
+	if req.Pattern == "" {
+		return errors.New("missing pattern")
+	}
+
+	return nil
+}
+
+// decodeFilterRuleReq decodes and validates a filter rule request, responding on error.
+func decodeFilterRuleReq(w http.ResponseWriter, r *http.Request) (filterRuleReq, bool) {
+	var req filterRuleReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.Log(r.Context(), slog.LevelInfo, "decode filter rule", "error", err)
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return filterRuleReq{}, false
+	}
+	if err := req.validate(); err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return filterRuleReq{}, false
+	}
+
+	return req, true
+}
+
+// CreateFilterRule creates a new filter rule for the authenticated user.
+func (h *FilterRuleHandler) CreateFilterRule(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	req, ok := decodeFilterRuleReq(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rule, err := h.store.CreateFilterRule(ctx, database.CreateFilterRuleParams{
+		UserID:   userID,
+		Kind:     req.Kind,
+		Action:   req.Action,
+		Pattern:  req.Pattern,
+		FeedID:   req.FeedID,
+		FolderID: req.FolderID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The feed is not followed or the folder does not exist for this user.
+			respond.WithJSONError(w, http.StatusNotFound, "feed or folder not found")
+			return
+		}
+		if database.IsInvalidRegularExpression(err) {
+			respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid regex: %q", req.Pattern))
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "create filter rule", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, rule)
+}
+
+// ListFilterRules lists the filter rules of the authenticated user.
+func (h *FilterRuleHandler) ListFilterRules(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rules, err := h.store.ListFilterRules(ctx, userID)
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "list filter rules", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, rules)
+}
+
+// UpdateFilterRule replaces a filter rule of the authenticated user.
+func (h *FilterRuleHandler) UpdateFilterRule(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid id: %q", chi.URLParam(r, "id")))
+		return
+	}
+
+	req, ok := decodeFilterRuleReq(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rule, err := h.store.UpdateFilterRule(ctx, database.UpdateFilterRuleParams{
+		ID:       id,
+		UserID:   userID,
+		Kind:     req.Kind,
+		Action:   req.Action,
+		Pattern:  req.Pattern,
+		FeedID:   req.FeedID,
+		FolderID: req.FolderID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The rule, the followed feed or the folder does not exist for this user.
+			respond.WithJSONError(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			return
+		}
+		if database.IsInvalidRegularExpression(err) {
+			respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid regex: %q", req.Pattern))
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "update filter rule", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, rule)
+}
+
+// DeleteFilterRule deletes a filter rule of the authenticated user.
+func (h *FilterRuleHandler) DeleteFilterRule(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid id: %q", chi.URLParam(r, "id")))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.DeleteFilterRule(ctx, database.DeleteFilterRuleParams{
+		ID:     id,
+		UserID: userID,
+	}); err != nil {
+		slog.Log(r.Context(), slog.LevelError, "delete filter rule", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+)
+
+// folderReq is the request to create or update a folder.
+type folderReq struct {
+	Name     string `json:"name"`
+	Position int32  `json:"position"`
+}
+
+// CreateFolder creates a new folder for the authenticated user.
+func (h *FeedFollowsHandler) CreateFolder(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	var req folderReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.Log(r.Context(), slog.LevelInfo, "decode folder", "error", err)
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Name == "" {
+		respond.WithJSONError(w, http.StatusBadRequest, "missing name")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	folder, err := h.store.CreateFolder(ctx, database.CreateFolderParams{
+		UserID:   userID,
+		Name:     req.Name,
+		Position: req.Position,
+	})
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			respond.WithJSONError(w, http.StatusConflict, fmt.Sprintf("folder %q already exists", req.Name))
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "create folder", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, folder)
+}
+
+// ListFolders lists the folders of the authenticated user.
+func (h *FeedFollowsHandler) ListFolders(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	folders, err := h.store.ListFolders(ctx, userID)
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "list folders", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, folders)
+}
+
+// UpdateFolder renames and moves a folder of the authenticated user.
+func (h *FeedFollowsHandler) UpdateFolder(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid id: %q", chi.URLParam(r, "id")))
+		return
+	}
+
+	var req folderReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.Log(r.Context(), slog.LevelInfo, "decode folder", "error", err)
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Name == "" {
+		respond.WithJSONError(w, http.StatusBadRequest, "missing name")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	folder, err := h.store.UpdateFolder(ctx, database.UpdateFolderParams{
+		ID:       id,
+		UserID:   userID,
+		Name:     req.Name,
+		Position: req.Position,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respond.WithJSONError(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			return
+		}
+		if database.IsUniqueViolation(err) {
+			respond.WithJSONError(w, http.StatusConflict, fmt.Sprintf("folder %q already exists", req.Name))
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "update folder", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, folder)
+}
+
+// DeleteFolder deletes a folder of the authenticated user.
+// The feed follows of the folder are kept, without folder.
+func (h *FeedFollowsHandler) DeleteFolder(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid id: %q", chi.URLParam(r, "id")))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.DeleteFolder(ctx, database.DeleteFolderParams{
+		ID:     id,
+		UserID: userID,
+	}); err != nil {
+		slog.Log(r.Context(), slog.LevelError, "delete folder", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
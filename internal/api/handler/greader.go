@@ -0,0 +1,801 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/middleware"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+)
+
+const (
+	// greaderDefaultCount is the number of items returned by a stream when the client does not choose it.
+	greaderDefaultCount = 20
+	// greaderMaxCount is the maximum number of items returned by a stream at once.
+	greaderMaxCount = 1000
+	// greaderItemPrefix is the prefix of the long form of the item ids.
+	greaderItemPrefix = "tag:google.com,2005:reader/item/"
+)
+
+// Streams and tags of the Google Reader API, the user is always the authenticated one.
+const (
+	greaderReadingList = "user/-/state/com.google/reading-list"
+	greaderRead        = "user/-/state/com.google/read"
+	greaderStarred     = "user/-/state/com.google/starred"
+	greaderKeptUnread  = "user/-/state/com.google/kept-unread"
+	greaderLabelPrefix = "user/-/label/"
+	greaderFeedPrefix  = "feed/"
+)
+
+// greaderScopes are the scopes of the API keys required by the Google Reader API.
+var greaderScopes = []string{middleware.ScopeFeedsRead, middleware.ScopePostsRead}
+
+// GReaderUserStore represents a store to authenticate a user by its API key.
+type GReaderUserStore interface {
+	GetUserFromApiKey(ctx context.Context, apiKey string) (database.GetUserFromApiKeyRow, error)
+}
+
+// GReaderFeedStore represents a store of the feeds and the folders followed by a user.
+type GReaderFeedStore interface {
+	ListFolders(ctx context.Context, userID uuid.UUID) ([]database.Folder, error)
+	ListFeedFollowsWithFeeds(ctx context.Context, userID uuid.NullUUID) ([]database.ListFeedFollowsWithFeedsRow, error)
+}
+
+// GReaderPostStore represents a store of the posts of a user, with their read and starred status.
+type GReaderPostStore interface {
+	ListPostsWithStatus(ctx context.Context, arg database.ListPostsWithStatusParams) ([]database.ListPostsWithStatusRow, error)
+	MarkPostsRead(ctx context.Context, arg database.MarkPostsReadParams) ([]int32, error)
+	MarkPostsUnread(ctx context.Context, arg database.MarkPostsUnreadParams) error
+	MarkPostsReadBefore(ctx context.Context, arg database.MarkPostsReadBeforeParams) (int64, error)
+	StarPost(ctx context.Context, arg database.StarPostParams) (database.StarredPost, error)
+	UnstarPostByPostID(ctx context.Context, arg database.UnstarPostByPostIDParams) error
+}
+
+// GReaderHandler is the handler of the subset of the Google Reader API used by clients like NetNewsWire or FeedMe.
+// The labels are the folders, the subscriptions are the followed feeds and the items are their posts.
+type GReaderHandler struct {
+	userStore GReaderUserStore
+	feedStore GReaderFeedStore
+	postStore GReaderPostStore
+}
+
+// NewGReaderHandler returns a new Google Reader handler.
+func NewGReaderHandler(userStore GReaderUserStore, feedStore GReaderFeedStore, postStore GReaderPostStore) *GReaderHandler {
+	return &GReaderHandler{
+		userStore: userStore,
+		feedStore: feedStore,
+		postStore: postStore,
+	}
+}
+
+// greaderBadRequest is an invalid parameter of a Google Reader request.
+type greaderBadRequest string
+
+func (e greaderBadRequest) Error() string {
+	return string(e)
+}
+
+// writeText writes a plain text response, as expected by the clients for the actions.
+func writeText(w http.ResponseWriter, code int, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(code)
+	_, _ = io.WriteString(w, text)
+}
+
+// greaderError responds to a failed request, the invalid parameters are reported to the client.
+func greaderError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	var badRequest greaderBadRequest
+	if errors.As(err, &badRequest) {
+		writeText(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	slog.Log(r.Context(), slog.LevelError, msg, "error", err)
+	writeText(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+}
+
+// ClientLogin authenticates a user by its id, the 'Email' field, and its API key, the 'Passwd' field.
+// Like for the Fever API, the id is used as it does not change when the user is renamed.
+// The key must grant the 'feeds:read' and 'posts:read' scopes.
+// The token returned to the client is the API key, given in the 'Authorization: GoogleLogin auth=<token>'
+// header of the next requests.
+func (h *GReaderHandler) ClientLogin(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeText(w, http.StatusBadRequest, fmt.Sprintf("invalid form: %v", err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	apiKey := r.Form.Get("Passwd")
+	key, err := h.userStore.GetUserFromApiKey(ctx, apiKey)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Log(r.Context(), slog.LevelError, "get user from api key", "error", err)
+		writeText(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	if err != nil || key.User.ID.String() != r.Form.Get("Email") || !middleware.HasScopes(key.Scopes, greaderScopes...) {
+		writeText(w, http.StatusUnauthorized, "Error=BadAuthentication\n")
+		return
+	}
+
+	if r.Form.Get("output") == "json" {
+		respond.WithJSON(w, http.StatusOK, map[string]string{"SID": apiKey, "LSID": apiKey, "Auth": apiKey})
+		return
+	}
+	writeText(w, http.StatusOK, fmt.Sprintf("SID=%s\nLSID=%s\nAuth=%s\n", apiKey, apiKey, apiKey))
+}
+
+// Authenticate authenticates the user by the token of the 'Authorization: GoogleLogin auth=<token>' header,
+// then adds it to the context of the request as the API key middleware does.
+func (h *GReaderHandler) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "GoogleLogin auth=")
+		if !ok || token == "" {
+			writeText(w, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+			return
+		}
+
+		key, err := h.userStore.GetUserFromApiKey(r.Context(), token)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			slog.Log(r.Context(), slog.LevelError, "get user from api key", "error", err)
+			writeText(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+		if err != nil || !middleware.HasScopes(key.Scopes, greaderScopes...) {
+			writeText(w, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "user", key.User.ID)))
+	}
+}
+
+// Token returns the token required by the actions.
+// The API is authenticated by a header and not by a cookie, so the token is not checked.
+func (h *GReaderHandler) Token(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		writeText(w, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+
+	writeText(w, http.StatusOK, userID.String()+"\n")
+}
+
+// UserInfo returns the authenticated user.
+func (h *GReaderHandler) UserInfo(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		writeText(w, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, map[string]string{
+		"userId":        userID.String(),
+		"userProfileId": userID.String(),
+	})
+}
+
+type greaderTag struct {
+	ID   string `json:"id"`
+	Type string `json:"type,omitempty"`
+}
+
+// ListTags returns the starred state and the labels, the folders of the user.
+func (h *GReaderHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		writeText(w, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	folders, err := h.feedStore.ListFolders(ctx, userID)
+	if err != nil {
+		greaderError(w, r, "list folders", err)
+		return
+	}
+
+	tags := make([]greaderTag, 0, len(folders)+1)
+	tags = append(tags, greaderTag{ID: greaderStarred})
+	for _, folder := range folders {
+		tags = append(tags, greaderTag{ID: greaderLabelPrefix + folder.Name, Type: "folder"})
+	}
+
+	respond.WithJSON(w, http.StatusOK, map[string]any{"tags": tags})
+}
+
+type greaderCategory struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+type greaderSubscription struct {
+	ID         string            `json:"id"`
+	Title      string            `json:"title"`
+	Categories []greaderCategory `json:"categories"`
+	URL        string            `json:"url"`
+	HTMLURL    string            `json:"htmlUrl"`
+	IconURL    string            `json:"iconUrl"`
+}
+
+// ListSubscriptions returns the feeds followed by the user with their label.
+func (h *GReaderHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		writeText(w, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	subs, err := h.subscriptions(ctx, userID)
+	if err != nil {
+		greaderError(w, r, "list subscriptions", err)
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, map[string]any{"subscriptions": subs})
+}
+
+// subscriptions returns the subscriptions of the user in the order of the follows.
+func (h *GReaderHandler) subscriptions(ctx context.Context, userID uuid.UUID) ([]greaderSubscription, error) {
+	folders, err := h.feedStore.ListFolders(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	follows, err := h.feedStore.ListFeedFollowsWithFeeds(ctx, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make(map[uuid.UUID]string, len(folders))
+	for _, folder := range folders {
+		labels[folder.ID] = folder.Name
+	}
+
+	subs := make([]greaderSubscription, 0, len(follows))
+	for _, follow := range follows {
+		sub := greaderSubscription{
+			ID:         greaderFeedPrefix + follow.FeedID.UUID.String(),
+			Title:      follow.FeedName,
+			Categories: []greaderCategory{},
+			URL:        follow.FeedUrl,
+			HTMLURL:    follow.FeedSiteUrl,
+		}
+		if follow.Title.Valid {
+			sub.Title = follow.Title.String
+		}
+		if label, ok := labels[follow.FolderID.UUID]; ok && follow.FolderID.Valid {
+			sub.Categories = append(sub.Categories, greaderCategory{ID: greaderLabelPrefix + label, Label: label})
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs, nil
+}
+
+// normalizeStream replaces the user id of a stream or a tag by '-', the clients can use both forms.
+func normalizeStream(stream string) string {
+	rest, ok := strings.CutPrefix(stream, "user/")
+	if !ok {
+		return stream
+	}
+	if _, tag, ok := strings.Cut(rest, "/"); ok {
+		return "user/-/" + tag
+	}
+
+	return stream
+}
+
+// streamParams restricts the posts to the stream: the reading list, the read or starred items, a feed or a label.
+// The starred stream lists all the starred posts, the other streams only the visible posts of the followed feeds.
+func (h *GReaderHandler) streamParams(ctx context.Context, userID uuid.UUID, stream string, params *database.ListPostsWithStatusParams) error {
+	stream = normalizeStream(stream)
+	params.FollowedOnly = stream != greaderStarred
+	switch {
+	case stream == greaderReadingList:
+	case stream == greaderRead:
+		params.IsRead = sql.NullBool{Bool: true, Valid: true}
+	case stream == greaderStarred:
+		params.IsStarred = sql.NullBool{Bool: true, Valid: true}
+	case strings.HasPrefix(stream, greaderFeedPrefix):
+		feedID, err := uuid.Parse(strings.TrimPrefix(stream, greaderFeedPrefix))
+		if err != nil {
+			return greaderBadRequest(fmt.Sprintf("invalid stream: %q", stream))
+		}
+		params.FeedID = uuid.NullUUID{UUID: feedID, Valid: true}
+	case strings.HasPrefix(stream, greaderLabelPrefix):
+		name := strings.TrimPrefix(stream, greaderLabelPrefix)
+		folders, err := h.feedStore.ListFolders(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, folder := range folders {
+			if folder.Name == name {
+				params.FolderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+				return nil
+			}
+		}
+		return greaderBadRequest(fmt.Sprintf("unknown label: %q", name))
+	default:
+		return greaderBadRequest(fmt.Sprintf("invalid stream: %q", stream))
+	}
+
+	return nil
+}
+
+// streamQuery returns the query of the posts of a stream, from the parameters of the request:
+// 'n' the number of items, 'r=o' for the oldest first, 'c' the continuation of the previous page,
+// 'ot' the oldest publication time in seconds, 'xt' the excluded state and 'it' the included state.
+func (h *GReaderHandler) streamQuery(ctx context.Context, r *http.Request, userID uuid.UUID, stream string) (database.ListPostsWithStatusParams, error) {
+	params := database.ListPostsWithStatusParams{
+		UserID: userID,
+		Limit:  greaderDefaultCount,
+	}
+	if err := h.streamParams(ctx, userID, stream, &params); err != nil {
+		return params, err
+	}
+
+	query := r.Form
+	if n := query.Get("n"); n != "" {
+		count, err := strconv.Atoi(n)
+		if err != nil || count <= 0 {
+			return params, greaderBadRequest(fmt.Sprintf("invalid n: %q", n))
+		}
+		params.Limit = int32(min(count, greaderMaxCount))
+	}
+
+	// The continuation is the id of the last item of the previous page.
+	continuation := int32(math.MaxInt32)
+	if c := query.Get("c"); c != "" {
+		id, err := strconv.ParseInt(c, 10, 32)
+		if err != nil {
+			return params, greaderBadRequest(fmt.Sprintf("invalid continuation: %q", c))
+		}
+		continuation = int32(id)
+	}
+	if query.Get("r") == "o" {
+		if continuation != math.MaxInt32 {
+			params.AfterID = sql.NullInt32{Int32: continuation, Valid: true}
+		}
+	} else {
+		params.BeforeID = sql.NullInt32{Int32: continuation, Valid: true}
+	}
+
+	if ot := query.Get("ot"); ot != "" {
+		seconds, err := strconv.ParseInt(ot, 10, 64)
+		if err != nil {
+			return params, greaderBadRequest(fmt.Sprintf("invalid ot: %q", ot))
+		}
+		params.PublishedAfter = sql.NullTime{Time: time.Unix(seconds, 0), Valid: true}
+	}
+
+	for _, tag := range query["xt"] {
+		switch normalizeStream(tag) {
+		case greaderRead:
+			params.IsRead = sql.NullBool{Bool: false, Valid: true}
+		case greaderStarred:
+			params.IsStarred = sql.NullBool{Bool: false, Valid: true}
+		}
+	}
+	for _, tag := range query["it"] {
+		switch normalizeStream(tag) {
+		case greaderRead:
+			params.IsRead = sql.NullBool{Bool: true, Valid: true}
+		case greaderStarred:
+			params.IsStarred = sql.NullBool{Bool: true, Valid: true}
+		}
+	}
+
+	return params, nil
+}
+
+// continuationOf returns the continuation of a full page, or an empty string for the last page.
+func continuationOf(rows []database.ListPostsWithStatusRow, limit int32) string {
+	if len(rows) == 0 || len(rows) < int(limit) {
+		return ""
+	}
+
+	return strconv.FormatInt(int64(rows[len(rows)-1].Post.ID), 10)
+}
+
+type greaderItemRef struct {
+	ID              string   `json:"id"`
+	DirectStreamIDs []string `json:"directStreamIds"`
+	TimestampUsec   string   `json:"timestampUsec"`
+}
+
+// StreamItemIDs returns the ids of the items of the stream given by the 's' parameter.
+func (h *GReaderHandler) StreamItemIDs(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		writeText(w, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeText(w, http.StatusBadRequest, fmt.Sprintf("invalid form: %v", err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	params, err := h.streamQuery(ctx, r, userID, r.Form.Get("s"))
+	if err != nil {
+		greaderError(w, r, "stream query", err)
+		return
+	}
+	rows, err := h.postStore.ListPostsWithStatus(ctx, params)
+	if err != nil {
+		greaderError(w, r, "list posts with status", err)
+		return
+	}
+
+	refs := make([]greaderItemRef, 0, len(rows))
+	for _, row := range rows {
+		refs = append(refs, greaderItemRef{
+			ID:              strconv.FormatInt(int64(row.Post.ID), 10),
+			DirectStreamIDs: []string{},
+			TimestampUsec:   strconv.FormatInt(row.Post.PublishedAt.UnixMicro(), 10),
+		})
+	}
+
+	resp := map[string]any{"itemRefs": refs}
+	if continuation := continuationOf(rows, params.Limit); continuation != "" {
+		resp["continuation"] = continuation
+	}
+	respond.WithJSON(w, http.StatusOK, resp)
+}
+
+type greaderLink struct {
+	Href string `json:"href"`
+	Type string `json:"type,omitempty"`
+}
+
+type greaderContent struct {
+	Direction string `json:"direction"`
+	Content   string `json:"content"`
+}
+
+type greaderOrigin struct {
+	StreamID string `json:"streamId"`
+	Title    string `json:"title"`
+	HTMLURL  string `json:"htmlUrl"`
+}
+
+type greaderItem struct {
+	ID            string         `json:"id"`
+	CrawlTimeMsec string         `json:"crawlTimeMsec"`
+	TimestampUsec string         `json:"timestampUsec"`
+	Published     int64          `json:"published"`
+	Updated       int64          `json:"updated"`
+	Title         string         `json:"title"`
+	Canonical     []greaderLink  `json:"canonical"`
+	Alternate     []greaderLink  `json:"alternate"`
+	Summary       greaderContent `json:"summary"`
+	Author        string         `json:"author"`
+	Categories    []string       `json:"categories"`
+	Origin        greaderOrigin  `json:"origin"`
+}
+
+// items converts the posts to the items of the API, with the state and the label of their feed.
+func (h *GReaderHandler) items(ctx context.Context, userID uuid.UUID, rows []database.ListPostsWithStatusRow) ([]greaderItem, error) {
+	subs, err := h.subscriptions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	origins := make(map[string]greaderSubscription, len(subs))
+	for _, sub := range subs {
+		origins[sub.ID] = sub
+	}
+
+	items := make([]greaderItem, 0, len(rows))
+	for _, row := range rows {
+		post := row.Post
+		streamID := greaderFeedPrefix + post.FeedID.UUID.String()
+		sub, followed := origins[streamID]
+
+		// A starred post of an unfollowed feed is not in the reading list.
+		categories := []string{}
+		if followed {
+			categories = append(categories, greaderReadingList)
+		}
+		if row.IsRead {
+			categories = append(categories, greaderRead)
+		}
+		if row.IsStarred {
+			categories = append(categories, greaderStarred)
+		}
+		for _, category := range sub.Categories {
+			categories = append(categories, category.ID)
+		}
+
+		items = append(items, greaderItem{
+			ID:            fmt.Sprintf("%s%016x", greaderItemPrefix, post.ID),
+			CrawlTimeMsec: strconv.FormatInt(post.CreatedAt.UnixMilli(), 10),
+			TimestampUsec: strconv.FormatInt(post.PublishedAt.UnixMicro(), 10),
+			Published:     post.PublishedAt.Unix(),
+			Updated:       post.UpdatedAt.Unix(),
+			Title:         post.Title,
+			Canonical:     []greaderLink{{Href: post.Url}},
+			Alternate:     []greaderLink{{Href: post.Url, Type: "text/html"}},
+			Summary:       greaderContent{Direction: "ltr", Content: post.Description},
+			Author:        post.Author,
+			Categories:    categories,
+			Origin: greaderOrigin{
+				StreamID: streamID,
+				Title:    sub.Title,
+				HTMLURL:  sub.HTMLURL,
+			},
+		})
+	}
+
+	return items, nil
+}
+
+// StreamContents returns the items of the stream given in the path.
+func (h *GReaderHandler) StreamContents(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		writeText(w, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeText(w, http.StatusBadRequest, fmt.Sprintf("invalid form: %v", err))
+		return
+	}
+
+	// The stream is matched on the escaped path when it contains escaped characters.
+	stream := chi.URLParam(r, "*")
+	if r.URL.RawPath != "" {
+		if unescaped, err := url.PathUnescape(stream); err == nil {
+			stream = unescaped
+		}
+	}
+	if stream == "" {
+		stream = greaderReadingList
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	params, err := h.streamQuery(ctx, r, userID, stream)
+	if err != nil {
+		greaderError(w, r, "stream query", err)
+		return
+	}
+	rows, err := h.postStore.ListPostsWithStatus(ctx, params)
+	if err != nil {
+		greaderError(w, r, "list posts with status", err)
+		return
+	}
+	items, err := h.items(ctx, userID, rows)
+	if err != nil {
+		greaderError(w, r, "stream items", err)
+		return
+	}
+
+	resp := map[string]any{
+		"id":      stream,
+		"updated": time.Now().Unix(),
+		"items":   items,
+	}
+	if continuation := continuationOf(rows, params.Limit); continuation != "" {
+		resp["continuation"] = continuation
+	}
+	respond.WithJSON(w, http.StatusOK, resp)
+}
+
+// parseItemIDs returns the post ids of the 'i' parameters, in the long or the short form.
+func parseItemIDs(values []string) ([]int32, error) {
+	ids := make([]int32, 0, len(values))
+	for _, value := range values {
+		var id int64
+		var err error
+		if hex, ok := strings.CutPrefix(value, greaderItemPrefix); ok {
+			id, err = strconv.ParseInt(hex, 16, 64)
+		} else {
+			id, err = strconv.ParseInt(value, 10, 64)
+		}
+		if err != nil || id <= 0 || id > math.MaxInt32 {
+			return nil, greaderBadRequest(fmt.Sprintf("invalid item id: %q", value))
+		}
+		ids = append(ids, int32(id))
+	}
+
+	return ids, nil
+}
+
+// StreamItemsContents returns the items given by the 'i' parameters.
+func (h *GReaderHandler) StreamItemsContents(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		writeText(w, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeText(w, http.StatusBadRequest, fmt.Sprintf("invalid form: %v", err))
+		return
+	}
+
+	ids, err := parseItemIDs(r.Form["i"])
+	if err != nil {
+		greaderError(w, r, "parse item ids", err)
+		return
+	}
+	if len(ids) > greaderMaxCount {
+		writeText(w, http.StatusBadRequest, fmt.Sprintf("too many items, %d at most", greaderMaxCount))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	items := []greaderItem{}
+	if len(ids) > 0 {
+		rows, err := h.postStore.ListPostsWithStatus(ctx, database.ListPostsWithStatusParams{
+			UserID: userID,
+			Ids:    ids,
+			Limit:  int32(len(ids)),
+		})
+		if err != nil {
+			greaderError(w, r, "list posts with status", err)
+			return
+		}
+		items, err = h.items(ctx, userID, rows)
+		if err != nil {
+			greaderError(w, r, "stream items", err)
+			return
+		}
+	}
+
+	respond.WithJSON(w, http.StatusOK, map[string]any{
+		"id":      greaderReadingList,
+		"updated": time.Now().Unix(),
+		"items":   items,
+	})
+}
+
+// EditTag adds the tags of the 'a' parameters to the items of the 'i' parameters
+// and removes the tags of the 'r' parameters. The supported tags are the read and the starred states.
+func (h *GReaderHandler) EditTag(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		writeText(w, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeText(w, http.StatusBadRequest, fmt.Sprintf("invalid form: %v", err))
+		return
+	}
+
+	ids, err := parseItemIDs(r.Form["i"])
+	if err != nil {
+		greaderError(w, r, "parse item ids", err)
+		return
+	}
+	if len(ids) == 0 {
+		writeText(w, http.StatusBadRequest, "missing item id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	for _, tag := range r.Form["a"] {
+		if err := h.editTag(ctx, userID, normalizeStream(tag), true, ids); err != nil {
+			greaderError(w, r, "add tag", err)
+			return
+		}
+	}
+	for _, tag := range r.Form["r"] {
+		if err := h.editTag(ctx, userID, normalizeStream(tag), false, ids); err != nil {
+			greaderError(w, r, "remove tag", err)
+			return
+		}
+	}
+
+	writeText(w, http.StatusOK, "OK")
+}
+
+// editTag adds or removes a tag of the posts.
+// Keeping a post unread is the same as removing the read state, the labels of the posts cannot be changed.
+func (h *GReaderHandler) editTag(ctx context.Context, userID uuid.UUID, tag string, add bool, ids []int32) error {
+	if tag == greaderKeptUnread {
+		tag, add = greaderRead, !add
+	}
+
+	switch tag {
+	case greaderRead:
+		if add {
+			_, err := h.postStore.MarkPostsRead(ctx, database.MarkPostsReadParams{UserID: userID, PostIds: ids})
+			return err
+		}
+		return h.postStore.MarkPostsUnread(ctx, database.MarkPostsUnreadParams{UserID: userID, PostIds: ids})
+	case greaderStarred:
+		for _, id := range ids {
+			var err error
+			if add {
+				_, err = h.postStore.StarPost(ctx, database.StarPostParams{UserID: userID, PostID: id})
+				// The posts of the feeds not followed cannot be starred.
+				if errors.Is(err, sql.ErrNoRows) {
+					err = nil
+				}
+			} else {
+				err = h.postStore.UnstarPostByPostID(ctx, database.UnstarPostByPostIDParams{UserID: userID, PostID: id})
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return greaderBadRequest(fmt.Sprintf("unsupported tag: %q", tag))
+	}
+}
+
+// MarkAllAsRead marks as read the items of the stream given by the 's' parameter,
+// published before the 'ts' parameter in microseconds, or now.
+func (h *GReaderHandler) MarkAllAsRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		writeText(w, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeText(w, http.StatusBadRequest, fmt.Sprintf("invalid form: %v", err))
+		return
+	}
+
+	params := database.MarkPostsReadBeforeParams{UserID: userID, Before: time.Now()}
+	if ts := r.Form.Get("ts"); ts != "" {
+		usec, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			writeText(w, http.StatusBadRequest, fmt.Sprintf("invalid ts: %q", ts))
+			return
+		}
+		params.Before = time.UnixMicro(usec)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	// The stream is resolved as for the listing, only the feeds and the labels restrict the posts.
+	var stream database.ListPostsWithStatusParams
+	if err := h.streamParams(ctx, userID, r.Form.Get("s"), &stream); err != nil {
+		greaderError(w, r, "stream query", err)
+		return
+	}
+	if stream.IsRead.Valid || stream.IsStarred.Valid {
+		writeText(w, http.StatusBadRequest, fmt.Sprintf("unsupported stream: %q", r.Form.Get("s")))
+		return
+	}
+	params.FeedID = stream.FeedID
+	params.FolderID = stream.FolderID
+
+	if _, err := h.postStore.MarkPostsReadBefore(ctx, params); err != nil {
+		greaderError(w, r, "mark posts read before", err)
+		return
+	}
+
+	writeText(w, http.StatusOK, "OK")
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/oidc"
+)
+
+const (
+	// oidcLoginCookie holds the state, the nonce and the PKCE verifier of a login until its callback.
+	oidcLoginCookie = "oidc_login"
+	// oidcLoginDuration is the time the user has to sign in at the provider.
+	oidcLoginDuration = 10 * time.Minute
+)
+
+// OIDCProvider represents an OpenID Connect provider.
+type OIDCProvider interface {
+	Issuer() string
+	AuthCodeURL(state, nonce, verifier string) string
+	Login(ctx context.Context, code, verifier, nonce string) (oidc.Claims, error)
+}
+
+// OIDCStore represents a store for the users signing in with an OpenID Connect provider.
+type OIDCStore interface {
+	GetOrCreateUserFromIdentity(ctx context.Context, identity database.Identity) (database.User, error)
+	CreateSession(ctx context.Context, arg database.CreateSessionParams) (database.CreateSessionRow, error)
+}
+
+// OIDCHandler is the handler of the single sign-on with an OpenID Connect provider.
+type OIDCHandler struct {
+	store    OIDCStore
+	provider OIDCProvider
+	// redirectURL is the page of the web interface the users are redirected to once logged in.
+	redirectURL string
+}
+
+// NewOIDCHandler returns a new OpenID Connect handler.
+// Once logged in, the users are redirected to the redirect URL, or the session is returned as JSON without it.
+func NewOIDCHandler(store OIDCStore, provider OIDCProvider, redirectURL string) *OIDCHandler {
+	return &OIDCHandler{
+		store:       store,
+		provider:    provider,
+		redirectURL: redirectURL,
+	}
+}
+
+// Login redirects the user to the provider to sign in.
+// The state, the nonce and the PKCE verifier are kept in a short-lived cookie until the callback.
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var values [3]string
+	for i := range values {
+		value, err := oidc.RandomString()
+		if err != nil {
+			slog.Log(r.Context(), slog.LevelError, "generate oidc login", "error", err)
+			respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+		values[i] = value
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	// The cookie is sent back with the top-level redirection of the provider to the callback.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcLoginCookie,
+		Value:    strings.Join(values[:], "."),
+		Path:     "/v1/oidc",
+		MaxAge:   int(oidcLoginDuration.Seconds()),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, h.provider.AuthCodeURL(state, nonce, verifier), http.StatusFound)
+}
+
+// Callback receives the authorization code of the provider, then opens a session of the user.
+// The user is created or linked by its email at its first login.
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("error") != "" {
+		respond.WithJSONError(w, http.StatusUnauthorized, "login refused by the provider: "+q.Get("error"))
+		return
+	}
+
+	cookie, err := r.Cookie(oidcLoginCookie)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, "missing login, it may have expired")
+		return
+	}
+	values := strings.Split(cookie.Value, ".")
+	// The state binds the callback to the login started in this browser.
+	if len(values) != 3 || subtle.ConstantTimeCompare([]byte(values[0]), []byte(q.Get("state"))) != 1 {
+		respond.WithJSONError(w, http.StatusBadRequest, "invalid state")
+		return
+	}
+	nonce, verifier := values[1], values[2]
+
+	// The login is only used once.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcLoginCookie,
+		Path:     "/v1/oidc",
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	claims, err := h.provider.Login(ctx, q.Get("code"), verifier, nonce)
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelInfo, "oidc login", "error", err)
+		respond.WithJSONError(w, http.StatusUnauthorized, "login failed")
+		return
+	}
+
+	user, err := h.store.GetOrCreateUserFromIdentity(ctx, database.Identity{
+		Issuer:        h.provider.Issuer(),
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(claims.Email),
+		EmailVerified: claims.EmailVerified,
+		Name:          identityName(claims),
+	})
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "get or create user from identity", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	if user.SuspendedAt.Valid {
+		respond.WithJSONError(w, http.StatusForbidden, "account suspended")
+		return
+	}
+
+	resp, err := openSession(ctx, w, r, h.store, user)
+	if err != nil {
+		return
+	}
+
+	if h.redirectURL != "" {
+		http.Redirect(w, r, h.redirectURL, http.StatusSeeOther)
+		return
+	}
+	respond.WithJSON(w, http.StatusOK, resp)
+}
+
+// identityName returns the name of a user created from its identity, the first claim set among its name,
+// its username, its email and its subject.
+func identityName(claims oidc.Claims) string {
+	for _, name := range []string{claims.Name, claims.PreferredUsername, claims.Email} {
+		if name = strings.TrimSpace(name); name != "" {
+			return name
+		}
+	}
+
+	return claims.Subject
+}
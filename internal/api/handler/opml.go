@@ -0,0 +1,338 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/opml"
+)
+
+// maxOPMLSize is the maximum size of an imported OPML document.
+const maxOPMLSize = 5 << 20
+
+// Status of an imported subscription.
+const (
+	// importCreated means the feed was created and followed.
+	importCreated = "created"
+	// importFollowed means the existing feed was followed.
+	importFollowed = "followed"
+	// importDuplicate means the feed was already followed, or listed twice in the document.
+	importDuplicate = "duplicate"
+	// importInvalid means the subscription could not be imported.
+	importInvalid = "invalid"
+)
+
+// importEntry is the report of an imported subscription.
+type importEntry struct {
+	URL          string        `json:"url"`
+	Title        string        `json:"title"`
+	Folder       string        `json:"folder,omitempty"`
+	Status       string        `json:"status"`
+	Error        string        `json:"error,omitempty"`
+	FeedID       uuid.NullUUID `json:"feed_id"`
+	FeedFollowID uuid.NullUUID `json:"feed_follow_id"`
+}
+
+// importReport is the response to import an OPML document.
+// When the import fails, the error is set and the entries list the subscriptions imported so far.
+type importReport struct {
+	Error     string        `json:"error,omitempty"`
+	Created   int           `json:"created"`
+	Followed  int           `json:"followed"`
+	Duplicate int           `json:"duplicate"`
+	Invalid   int           `json:"invalid"`
+	Entries   []importEntry `json:"entries"`
+}
+
+func (r *importReport) add(entry importEntry) {
+	switch entry.Status {
+	case importCreated:
+		r.Created++
+	case importFollowed:
+		r.Followed++
+	case importDuplicate:
+		r.Duplicate++
+	case importInvalid:
+		r.Invalid++
+	}
+	r.Entries = append(r.Entries, entry)
+}
+
+// ImportFeedFollows follows the subscriptions of an OPML document.
+// The document is sent in the 'file' field of a multipart form, or as the request body.
+// The missing feeds are created, and the nested outlines become folders.
+// It responds with the status of every subscription of the document.
+// The imported follows and folders are placed after the existing ones.
+func (h *FeedFollowsHandler) ImportFeedFollows(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxOPMLSize)
+
+	var body io.Reader = r.Body
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("missing opml file: %v", err))
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	doc, err := opml.Parse(body)
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelInfo, "parse opml", "error", err)
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The import is not bounded by the usual timeout, a document can list hundreds of feeds.
+	ctx := r.Context()
+
+	report := importReport{Entries: []importEntry{}}
+	if err := h.importSubscriptions(ctx, userID, doc.Subscriptions(), &report); err != nil {
+		slog.Log(ctx, slog.LevelError, "import subscriptions", "error", err)
+		// The subscriptions imported before the error are reported.
+		report.Error = http.StatusText(http.StatusInternalServerError)
+		respond.WithJSON(w, http.StatusInternalServerError, report)
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, report)
+}
+
+// importSubscriptions imports the subscriptions and adds their status to the report.
+// The follows of each folder are positioned after the existing follows of the folder.
+func (h *FeedFollowsHandler) importSubscriptions(ctx context.Context, userID uuid.UUID, subs []opml.Subscription, report *importReport) error {
+	folderPosition, err := h.store.GetNextFolderPosition(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get next folder position: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	folders := make(map[string]database.Folder)
+	positions := make(map[string]int32)
+	for _, sub := range subs {
+		entry := importEntry{
+			URL:    sub.XMLURL,
+			Title:  sub.Title,
+			Folder: sub.Folder,
+		}
+
+		if err := validateFeedURL(sub.XMLURL); err != nil {
+			entry.Status = importInvalid
+			entry.Error = err.Error()
+			report.add(entry)
+			continue
+		}
+		if seen[sub.XMLURL] {
+			entry.Status = importDuplicate
+			report.add(entry)
+			continue
+		}
+		seen[sub.XMLURL] = true
+
+		folderID := uuid.NullUUID{}
+		if sub.Folder != "" {
+			folder, ok := folders[sub.Folder]
+			if !ok {
+				// An existing folder keeps its position.
+				folder, err = h.store.GetOrCreateFolder(ctx, database.GetOrCreateFolderParams{
+					UserID:   userID,
+					Name:     sub.Folder,
+					Position: folderPosition,
+				})
+				if err != nil {
+					return fmt.Errorf("get or create folder %q: %w", sub.Folder, err)
+				}
+				folderPosition++
+				folders[sub.Folder] = folder
+			}
+			folderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+		}
+
+		position, ok := positions[sub.Folder]
+		if !ok {
+			position, err = h.store.GetNextFeedFollowPosition(ctx, database.GetNextFeedFollowPositionParams{
+				UserID:   userID,
+				FolderID: folderID,
+			})
+			if err != nil {
+				return fmt.Errorf("get next feed follow position: %w", err)
+			}
+			positions[sub.Folder] = position
+		}
+
+		status, err := h.importSubscription(ctx, userID, folderID, position, sub, &entry)
+		if err != nil {
+			return fmt.Errorf("import subscription %q: %w", sub.XMLURL, err)
+		}
+		if status == importCreated || status == importFollowed {
+			positions[sub.Folder]++
+		}
+		entry.Status = status
+		report.add(entry)
+	}
+
+	return nil
+}
+
+// importSubscription follows the feed of the subscription, creating the feed if needed.
+// It returns the status of the subscription and fills the ids of the entry.
+func (h *FeedFollowsHandler) importSubscription(ctx context.Context, userID uuid.UUID, folderID uuid.NullUUID, position int32, sub opml.Subscription, entry *importEntry) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// The private feeds of the other users are ignored like the unknown urls, a feed of the user is created instead.
+	status := importFollowed
+	byURL := database.GetFeedByURLParams{Url: sub.XMLURL, UserID: userID}
+	feed, err := h.store.GetFeedByURL(ctx, byURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		name := sub.Title
+		if name == "" {
+			name = sub.XMLURL
+		}
+		feed, err = h.store.CreateFeed(ctx, database.CreateFeedParams{
+			Name:   name,
+			Url:    sub.XMLURL,
+			UserID: uuid.NullUUID{UUID: userID, Valid: true},
+		})
+		status = importCreated
+		if database.IsUniqueViolation(err) {
+			// The feed has been created in the meantime.
+			feed, err = h.store.GetFeedByURL(ctx, byURL)
+			status = importFollowed
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	entry.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+
+	// The title of the subscription only overrides the name of the feed when they differ.
+	title := sql.NullString{}
+	if sub.Title != "" && sub.Title != feed.Name {
+		title = sql.NullString{String: sub.Title, Valid: true}
+	}
+
+	follow, err := h.store.CreateFeedFollowsIfNotExists(ctx, database.CreateFeedFollowsIfNotExistsParams{
+		UserID:   userID,
+		FeedID:   feed.ID,
+		FolderID: folderID,
+		Position: position,
+		Title:    title,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return importDuplicate, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	entry.FeedFollowID = uuid.NullUUID{UUID: follow.ID, Valid: true}
+
+	return status, nil
+}
+
+// ExportFeedFollows renders the feed follows of the authenticated user as an OPML document.
+// The folders become outlines and the custom titles replace the names of the feeds.
+func (h *FeedFollowsHandler) ExportFeedFollows(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	folders, err := h.store.ListFolders(ctx, userID)
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "list folders", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	follows, err := h.store.ListFeedFollowsWithFeeds(ctx, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "list feed follows with feeds", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	doc := opml.NewDocument("Subscriptions", folderNames(folders), opmlSubscriptions(folders, follows))
+
+	// Render in a buffer to be able to report an error before the headers are sent.
+	var buf bytes.Buffer
+	if err := opml.Write(&buf, doc); err != nil {
+		slog.Log(r.Context(), slog.LevelError, "write opml", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/x-opml; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="subscriptions.opml"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// folderNames returns the names of the folders.
+func folderNames(folders []database.Folder) []string {
+	names := make([]string, 0, len(folders))
+	for _, folder := range folders {
+		names = append(names, folder.Name)
+	}
+
+	return names
+}
+
+// opmlSubscriptions converts the feed follows to OPML subscriptions.
+func opmlSubscriptions(folders []database.Folder, follows []database.ListFeedFollowsWithFeedsRow) []opml.Subscription {
+	names := make(map[uuid.UUID]string, len(folders))
+	for _, folder := range folders {
+		names[folder.ID] = folder.Name
+	}
+
+	subs := make([]opml.Subscription, 0, len(follows))
+	for _, follow := range follows {
+		title := follow.FeedName
+		if follow.Title.Valid {
+			title = follow.Title.String
+		}
+		subs = append(subs, opml.Subscription{
+			Title:   title,
+			XMLURL:  follow.FeedUrl,
+			HTMLURL: follow.FeedSiteUrl,
+			Folder:  names[follow.FolderID.UUID],
+		})
+	}
+
+	return subs
+}
+
+// validateFeedURL checks the url is an absolute http(s) url.
+func validateFeedURL(feedURL string) error {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %q", feedURL)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url: %q, an absolute http(s) url is expected", feedURL)
+	}
+
+	return nil
+}
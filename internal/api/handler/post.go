@@ -2,10 +2,16 @@ package handler
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
 	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
@@ -13,7 +19,10 @@ import (
 
 // PostStore represents a store for managing post data.
 type PostStore interface {
-	GetPostsByUser(ctx context.Context, userID uuid.NullUUID, limit int32) ([]database.Post, error)
+	GetPostsByUser(ctx context.Context, arg database.GetPostsByUserParams) ([]database.Post, error)
+	StarPost(ctx context.Context, arg database.StarPostParams) (database.StarredPost, error)
+	ListStarredPosts(ctx context.Context, arg database.ListStarredPostsParams) ([]database.StarredPost, error)
+	UnstarPost(ctx context.Context, arg database.UnstarPostParams) error
 }
 
 // PostHandler is the handler for feed related requests.
@@ -47,7 +56,24 @@ func (h *PostHandler) GetPostsByUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	posts, err := h.store.GetPostsByUser(ctx, uuid.NullUUID{UUID: userID, Valid: true}, int32(limit))
+	// The timeline can be restricted to a folder or a feed.
+	folderID, err := getOptionalUUID(r, "folder_id")
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	feedID, err := getOptionalUUID(r, "feed_id")
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	posts, err := h.store.GetPostsByUser(ctx, database.GetPostsByUserParams{
+		UserID:   uuid.NullUUID{UUID: userID, Valid: true},
+		FolderID: folderID,
+		FeedID:   feedID,
+		Limit:    int32(limit),
+	})
 	if err != nil {
 		respond.WithJSONError(w, http.StatusInternalServerError, "error getting posts")
 		return
@@ -55,3 +81,104 @@ func (h *PostHandler) GetPostsByUser(w http.ResponseWriter, r *http.Request) {
 
 	respond.WithJSON(w, http.StatusOK, posts)
 }
+
+// starPostReq is the request to star a post.
+type starPostReq struct {
+	PostID int32 `json:"post_id"`
+}
+
+// StarPost stars a post from a followed feed for the authenticated user.
+func (h *PostHandler) StarPost(w http.ResponseWriter, r *http.Request) {
+	var req starPostReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.Log(r.Context(), slog.LevelInfo, "decode starred post", "error", err)
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	starred, err := h.store.StarPost(ctx, database.StarPostParams{
+		UserID: userID,
+		PostID: req.PostID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The post does not exist or is not part of a followed feed.
+			respond.WithJSONError(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "star post", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, starred)
+}
+
+// ListStarredPosts returns the starred posts of the authenticated user, the most recently starred first.
+func (h *PostHandler) ListStarredPosts(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	starred, err := h.store.ListStarredPosts(ctx, database.ListStarredPostsParams{
+		UserID: userID,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "list starred posts", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, starred)
+}
+
+// UnstarPost removes a starred post of the authenticated user.
+func (h *PostHandler) UnstarPost(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid id: %q", chi.URLParam(r, "id")))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.UnstarPost(ctx, database.UnstarPostParams{
+		ID:     id,
+		UserID: userID,
+	}); err != nil {
+		slog.Log(r.Context(), slog.LevelError, "unstar post", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// getPagination gets the values of 'offset' and 'limit' from the URL query parameters.
+// The limit defaults to 10 and must be between 1 and 100.
+func getPagination(r *http.Request) (limit int32, offset int32, err error) {
+	offsetStr := r.URL.Query().Get("offset")
+	if offsetStr == "" {
+		offsetStr = "0"
+	}
+	o, err := strconv.Atoi(offsetStr)
+	if err != nil || o < 0 {
+		return 0, 0, fmt.Errorf("invalid offset: %q", offsetStr)
+	}
+
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr == "" {
+		limitStr = "10"
+	}
+	l, err := strconv.Atoi(limitStr)
+	if err != nil || l <= 0 || l > 100 {
+		return 0, 0, fmt.Errorf("invalid limit: %q", limitStr)
+	}
+
+	return int32(l), int32(o), nil
+}
+
+// getOptionalUUID gets an optional uuid from the URL query parameters.
+func getOptionalUUID(r *http.Request, key string) (uuid.NullUUID, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.NullUUID{}, fmt.Errorf("invalid %s: %q", key, value)
+	}
+
+	return uuid.NullUUID{UUID: id, Valid: true}, nil
+}
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/middleware"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/password"
+)
+
+const (
+	// sessionDuration is the time a session of the web interface lasts, the user logs in again after it.
+	sessionDuration = 14 * 24 * time.Hour
+	// minPasswordLength is the length of the shortest password accepted at the registration.
+	minPasswordLength = 8
+	// maxPasswordLength is the length of the longest password, it bounds the hashing work of a request.
+	maxPasswordLength = 256
+)
+
+// unknownUserHash is verified when no user has the email of a login,
+// so the response takes as long as with a wrong password and does not tell which emails are registered.
+var unknownUserHash = sync.OnceValue(func() string {
+	hash, _ := password.Hash("unknown user")
+	return hash
+})
+
+// registerReq is the request to register a user with an email and a password.
+type registerReq struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// validate checks the name, the email and the length of the password.
+func (req registerReq) validate() error {
+	if strings.TrimSpace(req.Name) == "" {
+		return errors.New("name is required")
+	}
+	if err := validateEmail(req.Email); err != nil {
+		return err
+	}
+	if len(req.Password) < minPasswordLength || len(req.Password) > maxPasswordLength {
+		return fmt.Errorf("password must be between %d and %d characters", minPasswordLength, maxPasswordLength)
+	}
+
+	return nil
+}
+
+// validateEmail checks that the email is a bare address.
+func validateEmail(email string) error {
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != strings.TrimSpace(email) {
+		return fmt.Errorf("invalid email: %q", email)
+	}
+
+	return nil
+}
+
+// loginReq is the request to open a session.
+type loginReq struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// sessionResp is the response opening a session.
+// The CSRF token must be sent back in the X-CSRF-Token header of the requests changing data,
+// it is also set in a cookie readable by the scripts.
+type sessionResp struct {
+	User      database.User `json:"user"`
+	CsrfToken string        `json:"csrf_token"`
+	ExpiresAt time.Time     `json:"expires_at"`
+}
+
+// Register creates a user with an email and a password, then opens a session.
+// The user has no API key, it can create some once logged in.
+func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req registerReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := req.validate(); err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hash, err := password.Hash(req.Password)
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "hash password", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.store.RegisterUser(ctx, database.RegisterUserParams{
+		Name:         strings.TrimSpace(req.Name),
+		Email:        strings.TrimSpace(req.Email),
+		PasswordHash: hash,
+	})
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			respond.WithJSONError(w, http.StatusConflict, "email already registered")
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "register user", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	resp, err := openSession(ctx, w, r, h.store, user)
+	if err != nil {
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, resp)
+}
+
+// Login opens a session of the user with the given email and password.
+func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req loginReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(req.Password) > maxPasswordLength {
+		respond.WithJSONError(w, http.StatusUnauthorized, "invalid email or password")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.store.GetUserFromEmail(ctx, strings.TrimSpace(req.Email))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Log(r.Context(), slog.LevelError, "get user from email", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	hash := user.PasswordHash
+	if err != nil {
+		hash = unknownUserHash()
+	}
+	// The users created with an API key have no password, their empty hash is invalid.
+	ok, verifyErr := password.Verify(req.Password, hash)
+	if err != nil || verifyErr != nil || !ok {
+		respond.WithJSONError(w, http.StatusUnauthorized, "invalid email or password")
+		return
+	}
+	if user.SuspendedAt.Valid {
+		respond.WithJSONError(w, http.StatusForbidden, "account suspended")
+		return
+	}
+
+	resp, err := openSession(ctx, w, r, h.store, user)
+	if err != nil {
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, resp)
+}
+
+// sessionCreator represents a store opening the sessions of the web interface.
+type sessionCreator interface {
+	CreateSession(ctx context.Context, arg database.CreateSessionParams) (database.CreateSessionRow, error)
+}
+
+// openSession creates a session of the user and sets its cookies.
+// On error, the response is written.
+func openSession(ctx context.Context, w http.ResponseWriter, r *http.Request, store sessionCreator, user database.User) (sessionResp, error) {
+	session, err := store.CreateSession(ctx, database.CreateSessionParams{
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(sessionDuration),
+	})
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "create session", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return sessionResp{}, err
+	}
+
+	setSessionCookies(w, session.Token, session.CsrfToken, session.ExpiresAt)
+
+	return sessionResp{
+		User:      user,
+		CsrfToken: session.CsrfToken,
+		ExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// Logout closes the session authenticating the request and removes its cookies.
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(middleware.SessionCookie)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, "not authenticated by a session")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.DeleteSession(ctx, cookie.Value); err != nil {
+		slog.Log(r.Context(), slog.LevelError, "delete session", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	// The cookies expire at once.
+	setSessionCookies(w, "", "", time.Unix(0, 0))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setSessionCookies sets the cookies of the session token and of its CSRF token.
+// Both are only sent over HTTPS, and not along the requests made from other sites but the top-level navigations.
+func setSessionCookies(w http.ResponseWriter, token, csrfToken string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     middleware.SessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     middleware.CSRFCookie,
+		Value:    csrfToken,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/pubsub"
+)
+
+// streamBatchSize is the maximum number of posts sent at once in a stream.
+const streamBatchSize = 100
+
+// StreamStore represents a store to stream the timeline of a user.
+type StreamStore interface {
+	GetPostsByUser(ctx context.Context, arg database.GetPostsByUserParams) ([]database.Post, error)
+	GetLastPostID(ctx context.Context) (int32, error)
+}
+
+// Subscriber represents a source of the created posts.
+type Subscriber interface {
+	Subscribe() *pubsub.Subscription
+}
+
+// StreamHandler is the handler streaming the new posts of the timeline.
+type StreamHandler struct {
+	store      StreamStore
+	subscriber Subscriber
+	keepAlive  time.Duration
+}
+
+// NewStreamHandler returns a new stream handler.
+// The posts are streamed when they are published to the subscriber.
+func NewStreamHandler(store StreamStore, subscriber Subscriber) *StreamHandler {
+	return &StreamHandler{
+		store:      store,
+		subscriber: subscriber,
+		keepAlive:  30 * time.Second,
+	}
+}
+
+// StreamPosts streams the new posts of the timeline of the authenticated user as Server-Sent Events.
+// The id of an event is the id of the post, a client resumes the stream from a post
+// with the Last-Event-ID header or the 'last_event_id' query parameter.
+// Like the timeline, the stream can be restricted to a folder or a feed.
+func (h *StreamHandler) StreamPosts(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respond.WithJSONError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	folderID, err := getOptionalUUID(r, "folder_id")
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	feedID, err := getOptionalUUID(r, "feed_id")
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	// Subscribe before looking for the last post to not miss the posts created meanwhile.
+	sub := h.subscriber.Subscribe()
+	defer sub.Close()
+
+	var lastID int32
+	resume := lastEventID != ""
+	if resume {
+		id, err := strconv.ParseInt(lastEventID, 10, 32)
+		if err != nil || id < 0 {
+			respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid last event id: %q", lastEventID))
+			return
+		}
+		lastID = int32(id)
+	} else {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		lastID, err = h.store.GetLastPostID(ctx)
+		cancel()
+		if err != nil {
+			slog.Log(r.Context(), slog.LevelError, "get last post id", "error", err)
+			respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Disable the buffering of the reverse proxies.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// send sends the posts of the timeline created after the last sent post, batch by batch.
+	// The posts are sent in creation order, so the id of the last event is the one to resume from.
+	send := func() error {
+		for {
+			ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+			posts, err := h.store.GetPostsByUser(ctx, database.GetPostsByUserParams{
+				UserID:   uuid.NullUUID{UUID: userID, Valid: true},
+				FolderID: folderID,
+				FeedID:   feedID,
+				AfterID:  sql.NullInt32{Int32: lastID, Valid: true},
+				Limit:    streamBatchSize,
+			})
+			cancel()
+			if err != nil {
+				return fmt.Errorf("get posts by user: %w", err)
+			}
+
+			for _, post := range posts {
+				if err := writeEvent(w, post.ID, "post", post); err != nil {
+					return err
+				}
+				lastID = post.ID
+			}
+			flusher.Flush()
+
+			if len(posts) < streamBatchSize {
+				return nil
+			}
+		}
+	}
+
+	if resume {
+		if err := send(); err != nil {
+			slog.Log(r.Context(), slog.LevelError, "stream posts", "error", err)
+			return
+		}
+	}
+
+	ticker := time.NewTicker(h.keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case _, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			// The published posts only wake the stream up, the timeline query applies the follows and the filters.
+			drain(sub.C)
+			if err := send(); err != nil {
+				slog.Log(r.Context(), slog.LevelError, "stream posts", "error", err)
+				return
+			}
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes a Server-Sent Event with the JSON encoded data.
+func writeEvent(w io.Writer, id int32, event string, data any) error {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, content); err != nil {
+		return fmt.Errorf("write event: %w", err)
+	}
+
+	return nil
+}
+
+// drain empties the channel without blocking.
+func drain(c <-chan database.Post) {
+	for {
+		select {
+		case _, ok := <-c:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/syndication"
+)
+
+// FeedTokenStore represents a store to authenticate a user by its feed token.
+type FeedTokenStore interface {
+	GetUserFromFeedToken(ctx context.Context, arg database.GetUserFromFeedTokenParams) (database.User, error)
+}
+
+// TimelineStore represents a store to get the timeline of a user.
+type TimelineStore interface {
+	GetPostsByUser(ctx context.Context, arg database.GetPostsByUserParams) ([]database.Post, error)
+}
+
+// SyndicationHandler is the handler exporting the user timelines as feed documents.
+type SyndicationHandler struct {
+	userStore FeedTokenStore
+	postStore TimelineStore
+	hubURL    string
+}
+
+// NewSyndicationHandler returns a new syndication handler.
+// The feed documents advertise the WebSub hub when its url is not empty.
+func NewSyndicationHandler(userStore FeedTokenStore, postStore TimelineStore, hubURL string) *SyndicationHandler {
+	return &SyndicationHandler{userStore: userStore, postStore: postStore, hubURL: hubURL}
+}
+
+// GetUserFeed renders the timeline of a user as an RSS, Atom or JSON feed.
+// The request is authenticated by the feed token of the user given in the 'token' query parameter,
+// so feed readers can subscribe to the timeline without sending an API key.
+func (h *SyndicationHandler) GetUserFeed(w http.ResponseWriter, r *http.Request) {
+	format := chi.URLParam(r, "format")
+	if !syndication.IsFormat(format) {
+		respond.WithJSONError(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	}
+
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	limit, _, err := getPagination(r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	folderID, err := getOptionalUUID(r, "folder_id")
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	feedID, err := getOptionalUUID(r, "feed_id")
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.userStore.GetUserFromFeedToken(ctx, database.GetUserFromFeedTokenParams{
+		ID:        userID,
+		FeedToken: token,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "get user from feed token", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	posts, err := h.postStore.GetPostsByUser(ctx, database.GetPostsByUserParams{
+		UserID:   uuid.NullUUID{UUID: user.ID, Valid: true},
+		FolderID: folderID,
+		FeedID:   feedID,
+		Limit:    limit,
+	})
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "get posts by user", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, "error getting posts")
+		return
+	}
+
+	feed := syndication.Timeline(user, requestURL(r), posts)
+	feed.Hub = h.hubURL
+
+	// Render in a buffer to be able to report an error before the headers are sent.
+	var buf bytes.Buffer
+	contentType, err := syndication.Render(&buf, format, feed)
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "render feed", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if feed.Hub != "" {
+		// The subscribers discover the hub from the headers as well as from the document.
+		w.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"hub\"", feed.Hub))
+		w.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"self\"", feed.FeedURL))
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// requestURL rebuilds the absolute URL of the request, honoring the X-Forwarded-Proto header of a reverse proxy.
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	u := url.URL{
+		Scheme:   scheme,
+		Host:     r.Host,
+		Path:     r.URL.Path,
+		RawQuery: r.URL.RawQuery,
+	}
+
+	return u.String()
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/jwt"
+)
+
+// refreshTokenDuration is the time a refresh token is valid, a new one is returned at each refresh.
+const refreshTokenDuration = 30 * 24 * time.Hour
+
+// TokenIssuer represents an issuer of access tokens.
+type TokenIssuer interface {
+	IssueAccessToken(userID uuid.UUID, scopes []string) (string, time.Time, error)
+	JWKS() jwt.JWKS
+}
+
+// TokenStore represents a store for the refresh tokens.
+type TokenStore interface {
+	CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.CreateRefreshTokenRow, error)
+	RotateRefreshToken(ctx context.Context, token string, expiresAt time.Time) (database.CreateRefreshTokenRow, error)
+	DeleteRefreshToken(ctx context.Context, token string) error
+}
+
+// TokenHandler is the handler issuing the access tokens, verified without looking up the user.
+type TokenHandler struct {
+	store  TokenStore
+	issuer TokenIssuer
+}
+
+// NewTokenHandler returns a new token handler.
+func NewTokenHandler(store TokenStore, issuer TokenIssuer) *TokenHandler {
+	return &TokenHandler{
+		store:  store,
+		issuer: issuer,
+	}
+}
+
+// tokenResp is the response issuing an access token, with the refresh token to get the next one.
+type tokenResp struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+// refreshTokenReq is the request to refresh or to revoke a refresh token.
+type refreshTokenReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// CreateToken issues an access token and a refresh token in exchange for the API key or the session
+// authenticating the request, with its scopes. The refresh token is revoked with the key or the session.
+func (h *TokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+	scopes, _ := r.Context().Value("scopes").([]string)
+
+	params := database.CreateRefreshTokenParams{
+		UserID:    userID,
+		Scopes:    scopes,
+		ExpiresAt: time.Now().Add(refreshTokenDuration),
+	}
+	// An access token cannot be exchanged for another one, it would never expire.
+	if keyID, err := GetApiKeyIDFromContext(r); err == nil {
+		params.ApiKeyID = uuid.NullUUID{UUID: keyID, Valid: true}
+	} else if sessionID, ok := r.Context().Value("session").(uuid.UUID); ok {
+		params.SessionID = uuid.NullUUID{UUID: sessionID, Valid: true}
+	} else {
+		respond.WithJSONError(w, http.StatusForbidden, "an API key or a session is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	refreshToken, err := h.store.CreateRefreshToken(ctx, params)
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "create refresh token", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	h.respondWithToken(w, r, refreshToken)
+}
+
+// RefreshToken issues a new access token and a new refresh token in exchange for a refresh token,
+// which is only used once.
+func (h *TokenHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req refreshTokenReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.RefreshToken == "" {
+		respond.WithJSONError(w, http.StatusBadRequest, "refresh_token is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	refreshToken, err := h.store.RotateRefreshToken(ctx, req.RefreshToken, time.Now().Add(refreshTokenDuration))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respond.WithJSONError(w, http.StatusUnauthorized, "invalid refresh token")
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "rotate refresh token", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	h.respondWithToken(w, r, refreshToken)
+}
+
+// RevokeToken revokes a refresh token, the access tokens already issued stay valid until they expire.
+// An unknown refresh token is not an error.
+func (h *TokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	var req refreshTokenReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.DeleteRefreshToken(ctx, req.RefreshToken); err != nil {
+		slog.Log(r.Context(), slog.LevelError, "delete refresh token", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// JWKS returns the public keys verifying the access tokens, for the services trusting them.
+func (h *TokenHandler) JWKS(w http.ResponseWriter, _ *http.Request) {
+	respond.WithJSON(w, http.StatusOK, h.issuer.JWKS())
+}
+
+// respondWithToken issues an access token of the user of the refresh token, with its scopes.
+func (h *TokenHandler) respondWithToken(w http.ResponseWriter, r *http.Request, refreshToken database.CreateRefreshTokenRow) {
+	accessToken, expiresAt, err := h.issuer.IssueAccessToken(refreshToken.UserID, refreshToken.Scopes)
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "issue access token", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, tokenResp{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(time.Until(expiresAt).Round(time.Second).Seconds()),
+		RefreshToken: refreshToken.Token,
+		Scope:        strings.Join(refreshToken.Scopes, " "),
+	})
+}
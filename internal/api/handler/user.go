@@ -7,16 +7,31 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/middleware"
 	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
 	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/password"
 )
 
 // UserStore represents a store for managing user data.
 type UserStore interface {
-	CreateUser(ctx context.Context, name string) (database.User, error)
+	CreateUser(ctx context.Context, name string) (database.CreateUserRow, error)
 	GetUserFromId(ctx context.Context, id uuid.UUID) (database.User, error)
+	CreateApiKey(ctx context.Context, arg database.CreateApiKeyParams) (database.CreateApiKeyRow, error)
+	ListApiKeys(ctx context.Context, userID uuid.UUID) ([]database.ApiKey, error)
+	DeleteApiKey(ctx context.Context, arg database.DeleteApiKeyParams) error
+	RotateApiKey(ctx context.Context, arg database.RotateApiKeyParams) (database.RotateApiKeyRow, error)
+	RegisterUser(ctx context.Context, arg database.RegisterUserParams) (database.User, error)
+	GetUserFromEmail(ctx context.Context, email string) (database.User, error)
+	CreateSession(ctx context.Context, arg database.CreateSessionParams) (database.CreateSessionRow, error)
+	DeleteSession(ctx context.Context, token string) error
+	UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error)
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+	RotateFeedToken(ctx context.Context, id uuid.UUID) (database.User, error)
 }
 
 // UserHandler is the handler for user related requests.
@@ -35,6 +50,7 @@ type createUserReq struct {
 }
 
 // CreateUser creates a new user.
+// The API key is only returned in the response, it cannot be retrieved later.
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var req createUserReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -48,6 +64,7 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		// Error should be filtered here.
 		slog.Log(r.Context(), slog.LevelError, "create user: %v", err)
 		respond.WithJSONError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
 	respond.WithJSON(w, http.StatusOK, user)
@@ -77,6 +94,133 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	respond.WithJSON(w, http.StatusOK, user)
 }
 
+// RotateFeedToken replaces the feed token of the authenticated user, and returns the user with the new one.
+// The previous timeline feed urls stop working at once, and the WebSub subscriptions to them are removed.
+func (h *UserHandler) RotateFeedToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.store.RotateFeedToken(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "rotate feed token", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, user)
+}
+
+// updateUserReq is the request to update the profile of a user, the fields not given are kept.
+type updateUserReq struct {
+	Name  *string `json:"name"`
+	Email *string `json:"email"`
+	// CurrentPassword is required to change the email.
+	CurrentPassword string `json:"current_password"`
+}
+
+// UpdateUser updates the name of the authenticated user, and the email of the users who registered with one.
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	var req updateUserReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.store.GetUserFromId(ctx, userID)
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "get user", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	params := database.UpdateUserParams{
+		ID:   userID,
+		Name: user.Name,
+	}
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			respond.WithJSONError(w, http.StatusBadRequest, "name cannot be empty")
+			return
+		}
+		params.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Email != nil {
+		// The users of the API have no email, and the users of the single sign-on get theirs from the provider.
+		if user.Email == "" {
+			respond.WithJSONError(w, http.StatusBadRequest, "the account has no email")
+			return
+		}
+		if err := validateEmail(*req.Email); err != nil {
+			respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		// A stolen session must not be enough to take over the account.
+		ok, err := password.Verify(req.CurrentPassword, user.PasswordHash)
+		if err != nil || !ok {
+			respond.WithJSONError(w, http.StatusForbidden, "invalid current_password")
+			return
+		}
+		params.Email = sql.NullString{String: strings.TrimSpace(*req.Email), Valid: true}
+	}
+
+	user, err = h.store.UpdateUser(ctx, params)
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			respond.WithJSONError(w, http.StatusConflict, "email already registered")
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "update user", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, user)
+}
+
+// DeleteUser deletes the account of the authenticated user with all its data.
+// The feeds it created which other users still follow are kept without creator.
+func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.store.DeleteUser(ctx, userID); err != nil {
+		slog.Log(r.Context(), slog.LevelError, "delete user", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	// The sessions are deleted with the user, their cookies expire at once.
+	if _, err := r.Cookie(middleware.SessionCookie); err == nil {
+		setSessionCookies(w, "", "", time.Unix(0, 0))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // GetUserIDFromContext gets the user id from the context.
 func GetUserIDFromContext(w http.ResponseWriter, r *http.Request) (uuid.UUID, error) {
 	userIDVal := r.Context().Value("user")
@@ -88,3 +232,13 @@ func GetUserIDFromContext(w http.ResponseWriter, r *http.Request) (uuid.UUID, er
 
 	return userID, nil
 }
+
+// GetApiKeyIDFromContext gets the id of the API key authenticating the request from the context.
+func GetApiKeyIDFromContext(r *http.Request) (uuid.UUID, error) {
+	keyID, ok := r.Context().Value("api_key").(uuid.UUID)
+	if !ok {
+		return uuid.UUID{}, errors.New("cannot get api key id from context")
+	}
+
+	return keyID, nil
+}
@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/netguard"
+)
+
+// WebhookStore represents a store for managing webhooks data.
+type WebhookStore interface {
+	CreateWebhook(ctx context.Context, arg database.CreateWebhookParams) (database.Webhook, error)
+	ListWebhooks(ctx context.Context, userID uuid.UUID) ([]database.Webhook, error)
+	GetWebhook(ctx context.Context, arg database.GetWebhookParams) (database.Webhook, error)
+	UpdateWebhook(ctx context.Context, arg database.UpdateWebhookParams) (database.Webhook, error)
+	DeleteWebhook(ctx context.Context, arg database.DeleteWebhookParams) error
+	ListWebhookDeliveries(ctx context.Context, arg database.ListWebhookDeliveriesParams) ([]database.WebhookDelivery, error)
+}
+
+// WebhookHandler is the handler for webhooks related requests.
+// The new posts matching the scope of a webhook are sent to its url.
+type WebhookHandler struct {
+	store WebhookStore
+}
+
+// NewWebhookHandler returns a new webhook handler.
+func NewWebhookHandler(store WebhookStore) *WebhookHandler {
+	return &WebhookHandler{store: store}
+}
+
+// webhookReq is the request to create or update a webhook.
+type webhookReq struct {
+	URL string `json:"url"`
+	// Secret signs the payloads, it is generated when empty. It cannot be updated.
+	Secret string `json:"secret"`
+	// FeedID and FolderID restrict the webhook to a feed or a folder.
+	FeedID   uuid.NullUUID `json:"feed_id"`
+	FolderID uuid.NullUUID `json:"folder_id"`
+	// ApplyFilters applies the filter rules of the user to the posts, it defaults to true.
+	ApplyFilters *bool `json:"apply_filters"`
+	// Active enables the webhook, it defaults to true.
+	Active *bool `json:"active"`
+}
+
+// createdWebhook is the response to create a webhook, the only one including the secret.
+type createdWebhook struct {
+	database.Webhook
+	Secret string `json:"secret"`
+}
+
+// decodeWebhookReq decodes and validates a webhook request, responding on error.
+func decodeWebhookReq(w http.ResponseWriter, r *http.Request) (webhookReq, bool) {
+	var req webhookReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.Log(r.Context(), slog.LevelInfo, "decode webhook", "error", err)
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return webhookReq{}, false
+	}
+	if err := validateFeedURL(req.URL); err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return webhookReq{}, false
+	}
+	// The payloads are not sent to the internal network.
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	if err := netguard.CheckURL(ctx, req.URL); err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return webhookReq{}, false
+	}
+	if req.ApplyFilters == nil {
+		req.ApplyFilters = new(bool)
+		*req.ApplyFilters = true
+	}
+	if req.Active == nil {
+		req.Active = new(bool)
+		*req.Active = true
+	}
+
+	return req, true
+}
+
+// generateSecret returns a random hex encoded secret.
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate secret: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// CreateWebhook creates a new webhook for the authenticated user.
+// Its feed must be followed by the user and its folder must belong to the user.
+// The secret is only part of this response.
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	req, ok := decodeWebhookReq(w, r)
+	if !ok {
+		return
+	}
+	if req.Secret == "" {
+		req.Secret, err = generateSecret()
+		if err != nil {
+			slog.Log(r.Context(), slog.LevelError, "create webhook", "error", err)
+			respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	webhook, err := h.store.CreateWebhook(ctx, database.CreateWebhookParams{
+		UserID:       userID,
+		Url:          req.URL,
+		Secret:       req.Secret,
+		FeedID:       req.FeedID,
+		FolderID:     req.FolderID,
+		ApplyFilters: *req.ApplyFilters,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The feed is not followed or the folder does not exist for this user.
+			respond.WithJSONError(w, http.StatusNotFound, "feed or folder not found")
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "create webhook", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, createdWebhook{Webhook: webhook, Secret: webhook.Secret})
+}
+
+// ListWebhooks lists the webhooks of the authenticated user.
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	webhooks, err := h.store.ListWebhooks(ctx, userID)
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "list webhooks", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, webhooks)
+}
+
+// UpdateWebhook replaces the url, the scope and the state of a webhook of the authenticated user.
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid id: %q", chi.URLParam(r, "id")))
+		return
+	}
+
+	req, ok := decodeWebhookReq(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	webhook, err := h.store.UpdateWebhook(ctx, database.UpdateWebhookParams{
+		ID:           id,
+		UserID:       userID,
+		Url:          req.URL,
+		FeedID:       req.FeedID,
+		FolderID:     req.FolderID,
+		ApplyFilters: *req.ApplyFilters,
+		Active:       *req.Active,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The webhook, the followed feed or the folder does not exist for this user.
+			respond.WithJSONError(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "update webhook", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, webhook)
+}
+
+// DeleteWebhook deletes a webhook of the authenticated user, with its deliveries.
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid id: %q", chi.URLParam(r, "id")))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.DeleteWebhook(ctx, database.DeleteWebhookParams{
+		ID:     id,
+		UserID: userID,
+	}); err != nil {
+		slog.Log(r.Context(), slog.LevelError, "delete webhook", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries lists the deliveries of a webhook of the authenticated user, the most recent first.
+// It supports the 'offset' and 'limit' query parameters.
+func (h *WebhookHandler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid id: %q", chi.URLParam(r, "id")))
+		return
+	}
+
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	// Distinguish an unknown webhook from a webhook without deliveries.
+	if _, err := h.store.GetWebhook(ctx, database.GetWebhookParams{ID: id, UserID: userID}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respond.WithJSONError(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "get webhook", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	deliveries, err := h.store.ListWebhookDeliveries(ctx, database.ListWebhookDeliveriesParams{
+		WebhookID: id,
+		UserID:    userID,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "list webhook deliveries", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	respond.WithJSON(w, http.StatusOK, deliveries)
+}
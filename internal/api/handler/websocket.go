@@ -0,0 +1,379 @@
+package handler
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+)
+
+const (
+	// wsPongWait is the time allowed to read the next pong from the client.
+	wsPongWait = 60 * time.Second
+	// wsPingPeriod is the period of the pings sent to the client, it must be less than wsPongWait.
+	wsPingPeriod = wsPongWait * 9 / 10
+	// wsWriteWait is the time allowed to write a message to the client.
+	wsWriteWait = 10 * time.Second
+	// wsMaxMessageSize is the maximum size of a message sent by the client.
+	wsMaxMessageSize = 64 << 10
+	// wsMaxScopeSize is the maximum number of feeds and folders a client subscribes to,
+	// the timeline of each of them is queried for every new post.
+	wsMaxScopeSize = 100
+)
+
+// Types of the WebSocket messages.
+const (
+	// wsSubscribe is sent by the client to choose the feeds and the folders it receives the posts from.
+	wsSubscribe = "subscribe"
+	// wsMarkRead is sent by the client to mark posts as read.
+	wsMarkRead = "mark_read"
+	// wsPost is sent by the server for each new post.
+	wsPost = "post"
+	// wsUnreadCounts is sent by the server when the unread counts change.
+	wsUnreadCounts = "unread_counts"
+	// wsError is sent by the server when a message of the client cannot be handled.
+	wsError = "error"
+)
+
+// WebSocketStore represents a store to sync the timeline of a user over a WebSocket.
+type WebSocketStore interface {
+	GetPostsByUser(ctx context.Context, arg database.GetPostsByUserParams) ([]database.Post, error)
+	GetLastPostID(ctx context.Context) (int32, error)
+	MarkPostsRead(ctx context.Context, arg database.MarkPostsReadParams) ([]int32, error)
+	CountUnreadPosts(ctx context.Context, userID uuid.NullUUID) ([]database.CountUnreadPostsRow, error)
+}
+
+// WebSocketHandler is the handler syncing the timeline over a WebSocket.
+type WebSocketHandler struct {
+	store      WebSocketStore
+	subscriber Subscriber
+	upgrader   websocket.Upgrader
+}
+
+// NewWebSocketHandler returns a new WebSocket handler.
+// The posts are sent when they are published to the subscriber.
+func NewWebSocketHandler(store WebSocketStore, subscriber Subscriber) *WebSocketHandler {
+	return &WebSocketHandler{
+		store:      store,
+		subscriber: subscriber,
+	}
+}
+
+// wsClientMessage is a message sent by the client.
+type wsClientMessage struct {
+	Type      string      `json:"type"`
+	FeedIDs   []uuid.UUID `json:"feed_ids"`
+	FolderIDs []uuid.UUID `json:"folder_ids"`
+	PostIDs   []int32     `json:"post_ids"`
+}
+
+// wsServerMessage is a message sent by the server.
+type wsServerMessage struct {
+	Type         string                          `json:"type"`
+	Post         *database.Post                  `json:"post,omitempty"`
+	UnreadCounts *[]database.CountUnreadPostsRow `json:"unread_counts,omitempty"`
+	Error        string                          `json:"error,omitempty"`
+}
+
+// wsScope is the set of feeds and folders a client is subscribed to.
+// An empty scope is the whole timeline.
+type wsScope struct {
+	feedIDs   []uuid.UUID
+	folderIDs []uuid.UUID
+}
+
+// timelineParams returns the timeline queries of the scope.
+func (s wsScope) timelineParams(userID uuid.UUID) []database.GetPostsByUserParams {
+	user := uuid.NullUUID{UUID: userID, Valid: true}
+	if len(s.feedIDs) == 0 && len(s.folderIDs) == 0 {
+		return []database.GetPostsByUserParams{{UserID: user}}
+	}
+
+	params := make([]database.GetPostsByUserParams, 0, len(s.feedIDs)+len(s.folderIDs))
+	for _, id := range s.feedIDs {
+		params = append(params, database.GetPostsByUserParams{UserID: user, FeedID: uuid.NullUUID{UUID: id, Valid: true}})
+	}
+	for _, id := range s.folderIDs {
+		params = append(params, database.GetPostsByUserParams{UserID: user, FolderID: uuid.NullUUID{UUID: id, Valid: true}})
+	}
+
+	return params
+}
+
+// contains reports whether the unread count of a feed is part of the scope.
+func (s wsScope) contains(count database.CountUnreadPostsRow) bool {
+	if len(s.feedIDs) == 0 && len(s.folderIDs) == 0 {
+		return true
+	}
+
+	return slices.Contains(s.feedIDs, count.FeedID.UUID) ||
+		(count.FolderID.Valid && slices.Contains(s.folderIDs, count.FolderID.UUID))
+}
+
+// ServeWebSocket syncs the timeline of the authenticated user over a WebSocket.
+// The client subscribes to feeds and folders, then receives their new posts and the unread counts,
+// and marks posts as read over the same connection.
+func (h *WebSocketHandler) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetUserIDFromContext(w, r)
+	if err != nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	// Subscribe before looking for the last post to not miss the posts created meanwhile.
+	sub := h.subscriber.Subscribe()
+	defer sub.Close()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	lastID, err := h.store.GetLastPostID(ctx)
+	cancel()
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelError, "get last post id", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	// The upgrader responds with an error on failure.
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.Log(r.Context(), slog.LevelInfo, "upgrade websocket", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// The connection is read in its own goroutine, the messages are handled with the posts.
+	messages := make(chan wsClientMessage)
+	done := make(chan struct{})
+	quit := make(chan struct{})
+	defer close(quit)
+	go func() {
+		defer close(done)
+		readMessages(conn, messages, quit)
+	}()
+
+	c := &wsConn{
+		conn:    conn,
+		store:   h.store,
+		userID:  userID,
+		lastID:  lastID,
+		context: r.Context(),
+	}
+	if err := c.sendUnreadCounts(); err != nil {
+		slog.Log(r.Context(), slog.LevelError, "send unread counts", "error", err)
+		return
+	}
+
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case msg := <-messages:
+			if err := c.handleMessage(msg); err != nil {
+				slog.Log(r.Context(), slog.LevelError, "handle websocket message", "error", err)
+				return
+			}
+		case _, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			drain(sub.C)
+			if err := c.sendNewPosts(); err != nil {
+				slog.Log(r.Context(), slog.LevelError, "send new posts", "error", err)
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readMessages reads the messages of the client until the connection is closed or quit is closed.
+func readMessages(conn *websocket.Conn, messages chan<- wsClientMessage, quit <-chan struct{}) {
+	conn.SetReadLimit(wsMaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		// An invalid message has no type, it is reported to the client.
+		var msg wsClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			msg = wsClientMessage{}
+		}
+		select {
+		case messages <- msg:
+		case <-quit:
+			return
+		}
+	}
+}
+
+// wsConn is the state of a WebSocket connection.
+// It is only used by the goroutine writing to the connection.
+type wsConn struct {
+	conn    *websocket.Conn
+	store   WebSocketStore
+	userID  uuid.UUID
+	scope   wsScope
+	lastID  int32
+	context context.Context
+}
+
+// write sends a message to the client.
+func (c *wsConn) write(msg wsServerMessage) error {
+	_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	if err := c.conn.WriteJSON(msg); err != nil {
+		return fmt.Errorf("write %s message: %w", msg.Type, err)
+	}
+
+	return nil
+}
+
+// handleMessage handles a message of the client.
+// The invalid messages are reported to the client, only the connection errors are returned.
+func (c *wsConn) handleMessage(msg wsClientMessage) error {
+	switch msg.Type {
+	case wsSubscribe:
+		// A rejected subscription keeps the previous scope.
+		if len(msg.FeedIDs)+len(msg.FolderIDs) > wsMaxScopeSize {
+			return c.write(wsServerMessage{
+				Type:  wsError,
+				Error: fmt.Sprintf("too many feed_ids and folder_ids, at most %d are allowed", wsMaxScopeSize),
+			})
+		}
+		c.scope = wsScope{feedIDs: msg.FeedIDs, folderIDs: msg.FolderIDs}
+		return c.sendUnreadCounts()
+	case wsMarkRead:
+		if len(msg.PostIDs) == 0 {
+			return c.write(wsServerMessage{Type: wsError, Error: "missing post_ids"})
+		}
+
+		ctx, cancel := context.WithTimeout(c.context, 5*time.Second)
+		defer cancel()
+
+		if _, err := c.store.MarkPostsRead(ctx, database.MarkPostsReadParams{
+			UserID:  c.userID,
+			PostIds: msg.PostIDs,
+		}); err != nil {
+			slog.Log(c.context, slog.LevelError, "mark posts read", "error", err)
+			return c.write(wsServerMessage{Type: wsError, Error: "error marking posts read"})
+		}
+		return c.sendUnreadCounts()
+	case "":
+		return c.write(wsServerMessage{Type: wsError, Error: "invalid message, a JSON object with a type is expected"})
+	default:
+		return c.write(wsServerMessage{Type: wsError, Error: fmt.Sprintf("unknown message type: %q", msg.Type)})
+	}
+}
+
+// sendNewPosts sends the posts of the scope created after the last sent post, then the unread counts.
+func (c *wsConn) sendNewPosts() error {
+	sent := false
+	for {
+		posts, more, err := c.nextPosts()
+		if err != nil {
+			slog.Log(c.context, slog.LevelError, "get posts by user", "error", err)
+			return c.write(wsServerMessage{Type: wsError, Error: "error getting posts"})
+		}
+		for _, post := range posts {
+			if err := c.write(wsServerMessage{Type: wsPost, Post: &post}); err != nil {
+				return err
+			}
+			c.lastID = post.ID
+			sent = true
+		}
+		if !more {
+			break
+		}
+	}
+	if !sent {
+		return nil
+	}
+
+	return c.sendUnreadCounts()
+}
+
+// nextPosts returns the next batch of posts of the scope in creation order, and whether more posts remain.
+// A post can be part of several feeds and folders of the scope, it is returned once.
+// When a feed or a folder has more posts than a batch, the posts are only returned up to the last one
+// of its batch: the following posts of the other feeds and folders are not all read yet.
+func (c *wsConn) nextPosts() ([]database.Post, bool, error) {
+	ctx, cancel := context.WithTimeout(c.context, 5*time.Second)
+	defer cancel()
+
+	posts := make(map[int32]database.Post)
+	more := false
+	last := int32(math.MaxInt32)
+	for _, params := range c.scope.timelineParams(c.userID) {
+		params.AfterID = sql.NullInt32{Int32: c.lastID, Valid: true}
+		params.Limit = streamBatchSize
+		scoped, err := c.store.GetPostsByUser(ctx, params)
+		if err != nil {
+			return nil, false, fmt.Errorf("get posts by user: %w", err)
+		}
+		for _, post := range scoped {
+			posts[post.ID] = post
+		}
+		if len(scoped) == streamBatchSize {
+			more = true
+			last = min(last, scoped[len(scoped)-1].ID)
+		}
+	}
+
+	ids := make([]int32, 0, len(posts))
+	for id := range posts {
+		if id <= last {
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, cmp.Compare[int32])
+
+	batch := make([]database.Post, 0, len(ids))
+	for _, id := range ids {
+		batch = append(batch, posts[id])
+	}
+
+	return batch, more, nil
+}
+
+// sendUnreadCounts sends the unread counts of the feeds of the scope.
+func (c *wsConn) sendUnreadCounts() error {
+	ctx, cancel := context.WithTimeout(c.context, 5*time.Second)
+	defer cancel()
+
+	counts, err := c.store.CountUnreadPosts(ctx, uuid.NullUUID{UUID: c.userID, Valid: true})
+	if err != nil {
+		slog.Log(c.context, slog.LevelError, "count unread posts", "error", err)
+		return c.write(wsServerMessage{Type: wsError, Error: "error counting unread posts"})
+	}
+
+	scoped := make([]database.CountUnreadPostsRow, 0, len(counts))
+	for _, count := range counts {
+		if c.scope.contains(count) {
+			scoped = append(scoped, count)
+		}
+	}
+
+	return c.write(wsServerMessage{Type: wsUnreadCounts, UnreadCounts: &scoped})
+}
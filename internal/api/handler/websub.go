@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/websub"
+)
+
+// maxPushedContentSize is the maximum size of a feed document pushed by a hub.
+const maxPushedContentSize = 5 << 20
+
+// WebSubStore represents a store of the WebSub subscriptions of the feeds.
+type WebSubStore interface {
+	GetWebSubSubscription(ctx context.Context, feedID uuid.UUID) (database.GetWebSubSubscriptionRow, error)
+	ActivateWebSubSubscription(ctx context.Context, arg database.ActivateWebSubSubscriptionParams) (database.WebSubSubscription, error)
+	DenyWebSubSubscription(ctx context.Context, arg database.DenyWebSubSubscriptionParams) error
+}
+
+// Ingester represents the pipeline creating the posts of a feed document.
+type Ingester interface {
+	Ingest(ctx context.Context, feed database.Feed, data []byte) error
+}
+
+// WebSubHandler is the handler of the WebSub callbacks of the feeds.
+// The hubs verify the subscriptions, then push the new content of the feeds.
+type WebSubHandler struct {
+	store    WebSubStore
+	ingester Ingester
+}
+
+// NewWebSubHandler returns a new WebSub handler, the pushed content is given to the ingester.
+func NewWebSubHandler(store WebSubStore, ingester Ingester) *WebSubHandler {
+	return &WebSubHandler{
+		store:    store,
+		ingester: ingester,
+	}
+}
+
+// VerifyIntent answers the verification of intent of a hub, for the feed of the callback.
+// The challenge is echoed when the request matches the subscription, and the lease is recorded.
+func (h *WebSubHandler) VerifyIntent(w http.ResponseWriter, r *http.Request) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	}
+
+	query := r.URL.Query()
+	mode := query.Get("hub.mode")
+	topic := query.Get("hub.topic")
+	challenge := query.Get("hub.challenge")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	current, err := h.store.GetWebSubSubscription(ctx, feedID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Log(r.Context(), slog.LevelError, "get websub subscription", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	// A disabled feed is not subscribed anymore, its unsubscription is confirmed.
+	subscribed := err == nil && current.WebSubSubscription.TopicUrl == topic && !current.Feed.DisabledAt.Valid
+
+	switch mode {
+	case websub.ModeSubscribe:
+		if !subscribed || challenge == "" {
+			respond.WithJSONError(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			return
+		}
+		lease, err := strconv.Atoi(query.Get("hub.lease_seconds"))
+		if err != nil || lease <= 0 {
+			respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid lease seconds: %q", query.Get("hub.lease_seconds")))
+			return
+		}
+		if _, err := h.store.ActivateWebSubSubscription(ctx, database.ActivateWebSubSubscriptionParams{
+			FeedID:         feedID,
+			TopicUrl:       topic,
+			LeaseExpiresAt: sql.NullTime{Time: time.Now().Add(time.Duration(lease) * time.Second), Valid: true},
+		}); err != nil {
+			slog.Log(r.Context(), slog.LevelError, "activate websub subscription", "error", err)
+			respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+	case websub.ModeUnsubscribe:
+		// Only the unsubscriptions of the topics not wanted anymore are confirmed.
+		if subscribed || challenge == "" {
+			respond.WithJSONError(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			return
+		}
+	case websub.ModeDenied:
+		if subscribed {
+			if err := h.store.DenyWebSubSubscription(ctx, database.DenyWebSubSubscriptionParams{
+				FeedID:   feedID,
+				TopicUrl: topic,
+			}); err != nil {
+				slog.Log(r.Context(), slog.LevelError, "deny websub subscription", "error", err)
+				respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+				return
+			}
+			slog.Log(r.Context(), slog.LevelInfo, "websub subscription denied", "feed_id", feedID, "reason", query.Get("hub.reason"))
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	default:
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid mode: %q", mode))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, challenge)
+}
+
+// ReceiveContent ingests the feed document pushed by a hub for the feed of the callback.
+// The content is ignored when its signature is invalid, but it is still acknowledged as the protocol requires.
+// The hub is asked to stop pushing the content of a disabled feed.
+func (h *WebSubHandler) ReceiveContent(w http.ResponseWriter, r *http.Request) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusGone, http.StatusText(http.StatusGone))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPushedContentSize))
+	if err != nil {
+		respond.WithJSONError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+
+	// The ingestion is not bounded by the usual timeout, a document can contain many posts.
+	ctx := r.Context()
+
+	current, err := h.store.GetWebSubSubscription(ctx, feedID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Asks the hub to stop pushing the content of the feed.
+			respond.WithJSONError(w, http.StatusGone, http.StatusText(http.StatusGone))
+			return
+		}
+		slog.Log(ctx, slog.LevelError, "get websub subscription", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	if current.Feed.DisabledAt.Valid {
+		respond.WithJSONError(w, http.StatusGone, http.StatusText(http.StatusGone))
+		return
+	}
+
+	if !websub.VerifySignature(current.WebSubSubscription.Secret, r.Header.Get("X-Hub-Signature"), body) {
+		slog.Log(ctx, slog.LevelWarn, "invalid websub signature", "feed_id", feedID)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if err := h.ingester.Ingest(ctx, current.Feed, body); err != nil {
+		slog.Log(ctx, slog.LevelInfo, "ingest websub content", "feed_id", feedID, "error", err)
+		respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// WebSubHub represents the WebSub hub of the timeline feeds.
+type WebSubHub interface {
+	Request(ctx context.Context, req websub.HubRequest) error
+}
+
+// WebSubHubHandler is the handler of the subscription requests sent to the hub of the timeline feeds.
+type WebSubHubHandler struct {
+	hub WebSubHub
+}
+
+// NewWebSubHubHandler returns a new WebSub hub handler.
+func NewWebSubHubHandler(hub WebSubHub) *WebSubHubHandler {
+	return &WebSubHubHandler{hub: hub}
+}
+
+// HandleRequest accepts a subscription, or an unsubscription, to a timeline feed.
+// The request is a form with the 'hub.mode', 'hub.topic', 'hub.callback',
+// and the optional 'hub.secret' and 'hub.lease_seconds' fields.
+// The intent of the subscriber is verified asynchronously, once the request is accepted.
+func (h *WebSubHubHandler) HandleRequest(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	if err := r.ParseForm(); err != nil {
+		respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid form: %v", err))
+		return
+	}
+
+	req := websub.HubRequest{
+		Mode:     r.PostForm.Get("hub.mode"),
+		Topic:    r.PostForm.Get("hub.topic"),
+		Callback: r.PostForm.Get("hub.callback"),
+		Secret:   r.PostForm.Get("hub.secret"),
+	}
+	if value := r.PostForm.Get("hub.lease_seconds"); value != "" {
+		lease, err := strconv.Atoi(value)
+		if err != nil || lease <= 0 {
+			respond.WithJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid lease seconds: %q", value))
+			return
+		}
+		req.Lease = time.Duration(lease) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.hub.Request(ctx, req); err != nil {
+		if errors.Is(err, websub.ErrInvalidRequest) {
+			respond.WithJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, websub.ErrHubBusy) {
+			respond.WithJSONError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		slog.Log(r.Context(), slog.LevelError, "websub hub request", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -2,42 +2,125 @@ package middleware
 
 import (
 	"context"
+	"crypto/subtle"
 	"database/sql"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/jbdoumenjou/go-rssaggregator/internal/api/respond"
 
 	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
 )
 
+// Scopes of the API keys, a route restricted by a scope is only accessible with a key granting it.
+const (
+	ScopeFeedsRead    = "feeds:read"
+	ScopeFeedsWrite   = "feeds:write"
+	ScopePostsRead    = "posts:read"
+	ScopeFollowsWrite = "follows:write"
+)
+
+// Scopes are all the scopes, granted by the default key of a user.
+// The routes managing the account are restricted to the keys granting all of them.
+var Scopes = []string{ScopeFeedsRead, ScopeFeedsWrite, ScopePostsRead, ScopeFollowsWrite}
+
+// HasScopes reports whether the granted scopes include all the required scopes.
+func HasScopes(granted []string, required ...string) bool {
+	for _, scope := range required {
+		if !slices.Contains(granted, scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Roles of the users, the admins manage the users and the feeds of the instance.
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// Cookies and header of the sessions of the web interface.
+const (
+	// SessionCookie holds the session token, it is not readable by the scripts.
+	SessionCookie = "session"
+	// CSRFCookie holds the CSRF token of the session, for the scripts to send it back in the CSRFHeader.
+	CSRFCookie = "csrf_token"
+	// CSRFHeader must hold the CSRF token of the session in the requests changing data.
+	CSRFHeader = "X-CSRF-Token"
+)
+
 // UserStore represents a store for managing user data.
 type UserStore interface {
-	GetUserFromApiKey(ctx context.Context, apiKey string) (database.User, error)
+	GetUserFromApiKey(ctx context.Context, apiKey string) (database.GetUserFromApiKeyRow, error)
+	GetUserFromSession(ctx context.Context, token string) (database.GetUserFromSessionRow, error)
+	GetUserFromId(ctx context.Context, id uuid.UUID) (database.User, error)
+}
+
+// AccessTokenVerifier verifies the access tokens, without looking up the user.
+type AccessTokenVerifier interface {
+	VerifyAccessToken(raw string) (userID uuid.UUID, scopes []string, err error)
 }
 
 // AuthHandler represents an HTTP API handler for user authentication.
 type AuthHandler struct {
 	store UserStore
+	// accessTokens verifies the access tokens of the 'Authorization: Bearer <token>' header, they are refused without it.
+	accessTokens AccessTokenVerifier
+}
+
+// AuthOption configures an AuthHandler.
+type AuthOption func(a *AuthHandler)
+
+// WithAccessTokens accepts the access tokens verified by the verifier.
+func WithAccessTokens(v AccessTokenVerifier) AuthOption {
+	return func(a *AuthHandler) {
+		a.accessTokens = v
+	}
 }
 
 // NewAuthMiddleware creates a new AuthHandler.
-func NewAuthMiddleware(store UserStore) *AuthHandler {
-	return &AuthHandler{store: store}
+func NewAuthMiddleware(store UserStore, opts ...AuthOption) *AuthHandler {
+	a := &AuthHandler{store: store}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
 }
 
-func (a *AuthHandler) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+// Authenticate authenticates the user by the API key of the 'Authorization: ApiKey <key>' header.
+// The key must grant the required scopes of the route, then the ids of the user and the key are added to the context.
+// The scopes granted by the key are added too.
+// The user can also be authenticated by an access token, see authenticateAccessToken,
+// and, without the header, by the session cookie of the web interface, see authenticateSession.
+func (a *AuthHandler) Authenticate(next http.HandlerFunc, scopes ...string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			if cookie, err := r.Cookie(SessionCookie); err == nil && cookie.Value != "" {
+				a.authenticateSession(w, r, next, cookie.Value)
+				return
+			}
+		}
+		if scheme, raw, ok := strings.Cut(authHeader, " "); ok && strings.EqualFold(scheme, "bearer") {
+			a.authenticateAccessToken(w, r, next, raw, scopes)
+			return
+		}
+
 		token, err := getAuthHeader(r.Header)
 		if err != nil || token == "" {
 			respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
 			return
 		}
 
-		user, err := a.store.GetUserFromApiKey(r.Context(), token)
+		key, err := a.store.GetUserFromApiKey(r.Context(), token)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
@@ -50,7 +133,108 @@ func (a *AuthHandler) Authenticate(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "user", user.ID)))
+		if !HasScopes(key.Scopes, scopes...) {
+			respond.WithJSONError(w, http.StatusForbidden, fmt.Sprintf("the API key requires the scopes: %s", strings.Join(scopes, ", ")))
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "user", key.User.ID)
+		ctx = context.WithValue(ctx, "api_key", key.ApiKeyID)
+		ctx = context.WithValue(ctx, "scopes", key.Scopes)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+// authenticateSession authenticates the user by the token of a session cookie.
+// A session grants all the scopes, but the requests changing data must hold its CSRF token in the CSRFHeader,
+// the cookies being sent by the browsers whichever site makes the request.
+// The ids of the user and the session are added to the context.
+func (a *AuthHandler) authenticateSession(w http.ResponseWriter, r *http.Request, next http.HandlerFunc, token string) {
+	session, err := a.store.GetUserFromSession(r.Context(), token)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+			return
+		}
+
+		slog.Log(r.Context(), slog.LevelError, "get user from session", "error", err)
+		respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		csrfToken := r.Header.Get(CSRFHeader)
+		if subtle.ConstantTimeCompare([]byte(csrfToken), []byte(session.CsrfToken)) != 1 {
+			respond.WithJSONError(w, http.StatusForbidden, "invalid CSRF token")
+			return
+		}
+	}
+
+	ctx := context.WithValue(r.Context(), "user", session.User.ID)
+	ctx = context.WithValue(ctx, "session", session.SessionID)
+	ctx = context.WithValue(ctx, "scopes", Scopes)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// authenticateAccessToken authenticates the user by an access token, verified without looking up the user.
+// The token must grant the required scopes of the route, then the id of the user and the scopes are added to the context.
+func (a *AuthHandler) authenticateAccessToken(w http.ResponseWriter, r *http.Request, next http.HandlerFunc, raw string, scopes []string) {
+	if a.accessTokens == nil {
+		respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	userID, granted, err := a.accessTokens.VerifyAccessToken(strings.TrimSpace(raw))
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		respond.WithJSONError(w, http.StatusUnauthorized, "invalid access token")
+		return
+	}
+	if !HasScopes(granted, scopes...) {
+		respond.WithJSONError(w, http.StatusForbidden, fmt.Sprintf("the access token requires the scopes: %s", strings.Join(scopes, ", ")))
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), "user", userID)
+	ctx = context.WithValue(ctx, "scopes", granted)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// AuthenticateAdmin authenticates the user like Authenticate, with all the scopes, then only lets the admins through.
+// The role is looked up at each request, so a demoted or suspended admin loses the access at once,
+// even with an access token.
+func (a *AuthHandler) AuthenticateAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return a.Authenticate(a.requireAdmin(next), Scopes...)
+}
+
+// requireAdmin only lets the authenticated admins through.
+func (a *AuthHandler) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value("user").(uuid.UUID)
+		if !ok {
+			respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+			return
+		}
+
+		user, err := a.store.GetUserFromId(r.Context(), userID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respond.WithJSONError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+				return
+			}
+
+			slog.Log(r.Context(), slog.LevelError, "get user from id", "error", err)
+			respond.WithJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+		if user.Role != RoleAdmin || user.SuspendedAt.Valid {
+			respond.WithJSONError(w, http.StatusForbidden, "admin role required")
+			return
+		}
+
+		next.ServeHTTP(w, r)
 	}
 }
 
@@ -18,10 +18,127 @@ type Router struct {
 	feedHandler        *handler.FeedHandler
 	feedFollowsHandler *handler.FeedFollowsHandler
 	postHandler        *handler.PostHandler
+
+	// Optional handlers, their routes are only added when they are set.
+	filterRuleHandler  *handler.FilterRuleHandler
+	syndicationHandler *handler.SyndicationHandler
+	streamHandler      *handler.StreamHandler
+	webSocketHandler   *handler.WebSocketHandler
+	webhookHandler     *handler.WebhookHandler
+	digestHandler      *handler.DigestHandler
+	webSubHandler      *handler.WebSubHandler
+	webSubHubHandler   *handler.WebSubHubHandler
+	feverHandler       *handler.FeverHandler
+	gReaderHandler     *handler.GReaderHandler
+	oidcHandler        *handler.OIDCHandler
+	tokenHandler       *handler.TokenHandler
+	adminHandler       *handler.AdminHandler
+	exportHandler      *handler.ExportHandler
+}
+
+// Option configures an optional handler of the router.
+type Option func(r *Router)
+
+// WithFilterRuleHandler adds the filter rules routes.
+func WithFilterRuleHandler(h *handler.FilterRuleHandler) Option {
+	return func(r *Router) {
+		r.filterRuleHandler = h
+	}
+}
+
+// WithSyndicationHandler adds the routes exporting the user timelines as feed documents.
+func WithSyndicationHandler(h *handler.SyndicationHandler) Option {
+	return func(r *Router) {
+		r.syndicationHandler = h
+	}
+}
+
+// WithStreamHandler adds the route streaming the new posts.
+func WithStreamHandler(h *handler.StreamHandler) Option {
+	return func(r *Router) {
+		r.streamHandler = h
+	}
+}
+
+// WithWebSocketHandler adds the WebSocket route syncing the timeline.
+func WithWebSocketHandler(h *handler.WebSocketHandler) Option {
+	return func(r *Router) {
+		r.webSocketHandler = h
+	}
+}
+
+// WithWebhookHandler adds the webhooks routes.
+func WithWebhookHandler(h *handler.WebhookHandler) Option {
+	return func(r *Router) {
+		r.webhookHandler = h
+	}
+}
+
+// WithDigestHandler adds the email digest settings routes.
+func WithDigestHandler(h *handler.DigestHandler) Option {
+	return func(r *Router) {
+		r.digestHandler = h
+	}
+}
+
+// WithWebSubHandler adds the WebSub callback routes of the feeds.
+func WithWebSubHandler(h *handler.WebSubHandler) Option {
+	return func(r *Router) {
+		r.webSubHandler = h
+	}
+}
+
+// WithWebSubHubHandler adds the route of the WebSub hub of the timeline feeds.
+func WithWebSubHubHandler(h *handler.WebSubHubHandler) Option {
+	return func(r *Router) {
+		r.webSubHubHandler = h
+	}
+}
+
+// WithFeverHandler adds the route of the Fever API.
+func WithFeverHandler(h *handler.FeverHandler) Option {
+	return func(r *Router) {
+		r.feverHandler = h
+	}
+}
+
+// WithGReaderHandler adds the routes of the Google Reader API.
+func WithGReaderHandler(h *handler.GReaderHandler) Option {
+	return func(r *Router) {
+		r.gReaderHandler = h
+	}
+}
+
+// WithOIDCHandler adds the routes of the single sign-on with an OpenID Connect provider.
+func WithOIDCHandler(h *handler.OIDCHandler) Option {
+	return func(r *Router) {
+		r.oidcHandler = h
+	}
+}
+
+// WithTokenHandler adds the routes issuing the access tokens, and the route of their public keys.
+func WithTokenHandler(h *handler.TokenHandler) Option {
+	return func(r *Router) {
+		r.tokenHandler = h
+	}
+}
+
+// WithAdminHandler adds the administration routes, restricted to the admins.
+func WithAdminHandler(h *handler.AdminHandler) Option {
+	return func(r *Router) {
+		r.adminHandler = h
+	}
+}
+
+// WithExportHandler adds the route exporting all the data of the user.
+func WithExportHandler(h *handler.ExportHandler) Option {
+	return func(r *Router) {
+		r.exportHandler = h
+	}
 }
 
 // NewRouter creates a new router.
-func NewRouter(authHandler *middleware.AuthHandler, userHandler *handler.UserHandler, feedHandler *handler.FeedHandler, feedFollowsHandler *handler.FeedFollowsHandler, postHandler *handler.PostHandler) http.Handler {
+func NewRouter(authHandler *middleware.AuthHandler, userHandler *handler.UserHandler, feedHandler *handler.FeedHandler, feedFollowsHandler *handler.FeedFollowsHandler, postHandler *handler.PostHandler, opts ...Option) http.Handler {
 	r := chi.NewRouter()
 
 	// Basic CORS
@@ -30,7 +147,7 @@ func NewRouter(authHandler *middleware.AuthHandler, userHandler *handler.UserHan
 		// AllowedOrigins:   []string{"https://foo.com"}, // Use this to allow specific origin hosts
 		AllowedOrigins: []string{"https://*", "http://*"},
 		// AllowOriginFunc:  func(r *http.Request, origin string) bool { return true },
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: false,
@@ -45,8 +162,12 @@ func NewRouter(authHandler *middleware.AuthHandler, userHandler *handler.UserHan
 		feedFollowsHandler: feedFollowsHandler,
 		postHandler:        postHandler,
 	}
+	for _, opt := range opts {
+		opt(router)
+	}
 
 	router.addV1Routes()
+	router.addCompatibilityRoutes()
 
 	return r
 }
@@ -59,14 +180,153 @@ func (r Router) addV1Routes() {
 	v1.Get("/err", handler.Error)
 
 	v1.Post("/users", r.userHandler.CreateUser)
+	// The users of the web interface register with an email and a password, then are authenticated by a session cookie.
+	v1.Post("/users/register", r.userHandler.Register)
+	v1.Post("/sessions", r.userHandler.Login)
+	v1.Delete("/sessions", r.authHandler.Authenticate(r.userHandler.Logout))
+	if r.oidcHandler != nil {
+		// The users of the web interface can also sign in with the OpenID Connect provider.
+		v1.Get("/oidc/login", r.oidcHandler.Login)
+		v1.Get("/oidc/callback", r.oidcHandler.Callback)
+	}
+	if r.tokenHandler != nil {
+		// The access tokens are issued in exchange for an API key or a session, then refreshed with their refresh token.
+		v1.Post("/tokens", r.authHandler.Authenticate(r.tokenHandler.CreateToken))
+		v1.Post("/tokens/refresh", r.tokenHandler.RefreshToken)
+		v1.Post("/tokens/revoke", r.tokenHandler.RevokeToken)
+		r.mux.Get("/.well-known/jwks.json", r.tokenHandler.JWKS)
+	}
 	v1.Get("/users", r.authHandler.Authenticate(r.userHandler.GetUser))
+	// The account is managed with the keys granting all the scopes.
+	v1.Patch("/users", r.authHandler.Authenticate(r.userHandler.UpdateUser, middleware.Scopes...))
+	v1.Delete("/users", r.authHandler.Authenticate(r.userHandler.DeleteUser, middleware.Scopes...))
+	if r.exportHandler != nil {
+		v1.Get("/users/export", r.authHandler.Authenticate(r.exportHandler.ExportUser, middleware.Scopes...))
+	}
+	v1.Post("/users/api_key/rotate", r.authHandler.Authenticate(r.userHandler.RotateApiKey))
+	// A leaked timeline feed url is revoked by rotating the feed token.
+	v1.Post("/users/feed_token/rotate", r.authHandler.Authenticate(r.userHandler.RotateFeedToken, middleware.Scopes...))
+	// The API keys are managed with the keys granting all the scopes.
+	v1.Post("/users/api_keys", r.authHandler.Authenticate(r.userHandler.CreateApiKey, middleware.Scopes...))
+	v1.Get("/users/api_keys", r.authHandler.Authenticate(r.userHandler.ListApiKeys, middleware.Scopes...))
+	v1.Delete("/users/api_keys/{id}", r.authHandler.Authenticate(r.userHandler.DeleteApiKey, middleware.Scopes...))
+	if r.syndicationHandler != nil {
+		// Authenticated by the feed token of the user, given in the query.
+		v1.Get("/users/{id}/feed.{format}", r.syndicationHandler.GetUserFeed)
+	}
 
-	v1.Post("/feeds", r.authHandler.Authenticate(r.feedHandler.CreateFeed))
+	v1.Post("/feeds", r.authHandler.Authenticate(r.feedHandler.CreateFeed, middleware.ScopeFeedsWrite))
 	v1.Get("/feeds", r.feedHandler.ListFeeds)
+	// The feeds are managed by their creator and the admins.
+	v1.Get("/feeds/{id}", r.authHandler.Authenticate(r.feedHandler.GetFeed, middleware.ScopeFeedsRead))
+	v1.Patch("/feeds/{id}", r.authHandler.Authenticate(r.feedHandler.UpdateFeed, middleware.ScopeFeedsWrite))
+	v1.Delete("/feeds/{id}", r.authHandler.Authenticate(r.feedHandler.DeleteFeed, middleware.ScopeFeedsWrite))
+	// The private feeds are followed by the users they are shared with.
+	v1.Post("/feeds/{id}/shares", r.authHandler.Authenticate(r.feedHandler.ShareFeed, middleware.ScopeFeedsWrite))
+	v1.Get("/feeds/{id}/shares", r.authHandler.Authenticate(r.feedHandler.ListFeedShares, middleware.ScopeFeedsRead))
+	v1.Delete("/feeds/{id}/shares/{user_id}", r.authHandler.Authenticate(r.feedHandler.UnshareFeed, middleware.ScopeFeedsWrite))
+	if r.webSubHubHandler != nil {
+		// Called by the subscribers of the timeline feeds, the topics are authenticated by their feed token.
+		v1.Post("/websub/hub", r.webSubHubHandler.HandleRequest)
+	}
+	if r.webSubHandler != nil {
+		// Called by the WebSub hubs, the pushed content is authenticated by its signature.
+		v1.Get("/websub/{id}", r.webSubHandler.VerifyIntent)
+		v1.Post("/websub/{id}", r.webSubHandler.ReceiveContent)
+	}
+
+	v1.Post("/feed_follows", r.authHandler.Authenticate(r.feedFollowsHandler.CreateFeedFollows, middleware.ScopeFollowsWrite))
+	v1.Get("/feed_follows", r.authHandler.Authenticate(r.feedFollowsHandler.ListFeedFollows, middleware.ScopeFeedsRead))
+	v1.Post("/feed_follows/import", r.authHandler.Authenticate(r.feedFollowsHandler.ImportFeedFollows, middleware.ScopeFollowsWrite))
+	v1.Get("/feed_follows/export.opml", r.authHandler.Authenticate(r.feedFollowsHandler.ExportFeedFollows, middleware.ScopeFeedsRead))
+	v1.Put("/feed_follows/{id}", r.authHandler.Authenticate(r.feedFollowsHandler.UpdateFeedFollows, middleware.ScopeFollowsWrite))
+	v1.Delete("/feed_follows/{id}", r.authHandler.Authenticate(r.feedFollowsHandler.DeleteFeedFollows, middleware.ScopeFollowsWrite))
+
+	v1.Post("/folders", r.authHandler.Authenticate(r.feedFollowsHandler.CreateFolder, middleware.ScopeFollowsWrite))
+	v1.Get("/folders", r.authHandler.Authenticate(r.feedFollowsHandler.ListFolders, middleware.ScopeFeedsRead))
+	v1.Put("/folders/{id}", r.authHandler.Authenticate(r.feedFollowsHandler.UpdateFolder, middleware.ScopeFollowsWrite))
+	v1.Delete("/folders/{id}", r.authHandler.Authenticate(r.feedFollowsHandler.DeleteFolder, middleware.ScopeFollowsWrite))
+
+	// The read and starred states of the posts are part of reading them.
+	v1.Get("/posts", r.authHandler.Authenticate(r.postHandler.GetPostsByUser, middleware.ScopePostsRead))
+	if r.streamHandler != nil {
+		v1.Get("/posts/stream", r.authHandler.Authenticate(r.streamHandler.StreamPosts, middleware.ScopePostsRead))
+	}
+	if r.webSocketHandler != nil {
+		v1.Get("/ws", r.authHandler.Authenticate(r.webSocketHandler.ServeWebSocket, middleware.ScopePostsRead))
+	}
+	v1.Post("/posts/starred", r.authHandler.Authenticate(r.postHandler.StarPost, middleware.ScopePostsRead))
+	v1.Get("/posts/starred", r.authHandler.Authenticate(r.postHandler.ListStarredPosts, middleware.ScopePostsRead))
+	v1.Delete("/posts/starred/{id}", r.authHandler.Authenticate(r.postHandler.UnstarPost, middleware.ScopePostsRead))
+
+	// The settings of the account are managed with the keys granting all the scopes.
+	if r.filterRuleHandler != nil {
+		v1.Post("/filters", r.authHandler.Authenticate(r.filterRuleHandler.CreateFilterRule, middleware.Scopes...))
+		v1.Get("/filters", r.authHandler.Authenticate(r.filterRuleHandler.ListFilterRules, middleware.Scopes...))
+		v1.Put("/filters/{id}", r.authHandler.Authenticate(r.filterRuleHandler.UpdateFilterRule, middleware.Scopes...))
+		v1.Delete("/filters/{id}", r.authHandler.Authenticate(r.filterRuleHandler.DeleteFilterRule, middleware.Scopes...))
+	}
+
+	if r.webhookHandler != nil {
+		v1.Post("/webhooks", r.authHandler.Authenticate(r.webhookHandler.CreateWebhook, middleware.Scopes...))
+		v1.Get("/webhooks", r.authHandler.Authenticate(r.webhookHandler.ListWebhooks, middleware.Scopes...))
+		v1.Put("/webhooks/{id}", r.authHandler.Authenticate(r.webhookHandler.UpdateWebhook, middleware.Scopes...))
+		v1.Delete("/webhooks/{id}", r.authHandler.Authenticate(r.webhookHandler.DeleteWebhook, middleware.Scopes...))
+		v1.Get("/webhooks/{id}/deliveries", r.authHandler.Authenticate(r.webhookHandler.ListWebhookDeliveries, middleware.Scopes...))
+	}
+
+	if r.adminHandler != nil {
+		r.addAdminRoutes(v1)
+	}
+
+	if r.digestHandler != nil {
+		v1.Put("/digest", r.authHandler.Authenticate(r.digestHandler.SetDigestSettings, middleware.Scopes...))
+		v1.Get("/digest", r.authHandler.Authenticate(r.digestHandler.GetDigestSettings, middleware.Scopes...))
+		v1.Delete("/digest", r.authHandler.Authenticate(r.digestHandler.DeleteDigestSettings, middleware.Scopes...))
+	}
+}
+
+// addAdminRoutes adds the administration routes, the role of the user is checked at each request.
+func (r Router) addAdminRoutes(v1 chi.Router) {
+	h := r.adminHandler
+	admin := chi.NewRouter()
+	v1.Mount("/admin", admin)
 
-	v1.Post("/feed_follows", r.authHandler.Authenticate(r.feedFollowsHandler.CreateFeedFollows))
-	v1.Get("/feed_follows", r.authHandler.Authenticate(r.feedFollowsHandler.ListFeedFollows))
-	v1.Delete("/feed_follows/{id}", r.authHandler.Authenticate(r.feedFollowsHandler.DeleteFeedFollows))
+	admin.Get("/users", r.authHandler.AuthenticateAdmin(h.ListUsers))
+	admin.Put("/users/{id}/role", r.authHandler.AuthenticateAdmin(h.SetUserRole))
+	admin.Post("/users/{id}/suspend", r.authHandler.AuthenticateAdmin(h.SuspendUser))
+	admin.Post("/users/{id}/unsuspend", r.authHandler.AuthenticateAdmin(h.UnsuspendUser))
+	admin.Delete("/users/{id}", r.authHandler.AuthenticateAdmin(h.DeleteUser))
 
-	v1.Get("/posts", r.authHandler.Authenticate(r.postHandler.GetPostsByUser))
+	admin.Get("/feeds", r.authHandler.AuthenticateAdmin(h.ListFeeds))
+	admin.Post("/feeds/{id}/refresh", r.authHandler.AuthenticateAdmin(h.RefreshFeed))
+	admin.Post("/feeds/{id}/disable", r.authHandler.AuthenticateAdmin(h.DisableFeed))
+	admin.Post("/feeds/{id}/enable", r.authHandler.AuthenticateAdmin(h.EnableFeed))
+
+	admin.Delete("/posts", r.authHandler.AuthenticateAdmin(h.PurgePosts))
+}
+
+// addCompatibilityRoutes adds the routes of the APIs of other aggregators, used by the existing clients.
+func (r Router) addCompatibilityRoutes() {
+	if r.feverHandler != nil {
+		// The Fever clients authenticate with the 'api_key' field of the requests.
+		r.mux.HandleFunc("/fever/", r.feverHandler.HandleAPI)
+	}
+	if r.gReaderHandler != nil {
+		h := r.gReaderHandler
+		r.mux.HandleFunc("/accounts/ClientLogin", h.ClientLogin)
+
+		// The Google Reader clients authenticate with the token returned by ClientLogin.
+		reader := chi.NewRouter()
+		r.mux.Mount("/reader/api/0", reader)
+		reader.Get("/token", h.Authenticate(h.Token))
+		reader.Get("/user-info", h.Authenticate(h.UserInfo))
+		reader.Get("/tag/list", h.Authenticate(h.ListTags))
+		reader.Get("/subscription/list", h.Authenticate(h.ListSubscriptions))
+		reader.Get("/stream/contents/*", h.Authenticate(h.StreamContents))
+		reader.Get("/stream/items/ids", h.Authenticate(h.StreamItemIDs))
+		reader.Post("/stream/items/contents", h.Authenticate(h.StreamItemsContents))
+		reader.Post("/edit-tag", h.Authenticate(h.EditTag))
+		reader.Post("/mark-all-as-read", h.Authenticate(h.MarkAllAsRead))
+	}
 }
@@ -1,22 +1,47 @@
 package api
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
 	"github.com/jbdoumenjou/go-rssaggregator/internal/api/handler"
 	"github.com/jbdoumenjou/go-rssaggregator/internal/api/middleware"
 	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
 	"github.com/jbdoumenjou/go-rssaggregator/internal/generator"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/jwt"
 	mockdb "github.com/jbdoumenjou/go-rssaggregator/internal/mock"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/oidc"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/oidc/oidctest"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/opml"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/pubsub"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/token"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/websub"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
@@ -63,9 +88,12 @@ func TestErr(t *testing.T) {
 }
 
 type user struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	ApiKey    string `json:"api_key"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// ApiKey is only returned at the creation of the user.
+	ApiKey    string `json:"api_key,omitempty"`
+	Email     string `json:"email"`
+	FeedToken string `json:"feed_token"`
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
 }
@@ -73,12 +101,12 @@ type user struct {
 func createUser(t *testing.T, r http.Handler) user {
 	t.Helper()
 
-	user := struct {
+	newUser := struct {
 		Name string `json:"name"`
 	}{
 		Name: "John Doe",
 	}
-	data, err := json.Marshal(user)
+	data, err := json.Marshal(newUser)
 	require.NoError(t, err)
 	req, err := http.NewRequest(http.MethodPost, "/v1/users", bytes.NewReader(data))
 	if err != nil {
@@ -89,20 +117,15 @@ func createUser(t *testing.T, r http.Handler) user {
 	r.ServeHTTP(rr, req)
 	require.Equal(t, http.StatusOK, rr.Code)
 
-	var actualUser struct {
-		ID        string `json:"id"`
-		Name      string `json:"name"`
-		ApiKey    string `json:"api_key"`
-		CreatedAt string `json:"created_at"`
-		UpdatedAt string `json:"updated_at"`
-	}
+	var actualUser user
 	err = json.Unmarshal(rr.Body.Bytes(), &actualUser)
 	require.NoError(t, err)
 
 	require.NotEmpty(t, actualUser.ID)
-	require.Equal(t, user.Name, actualUser.Name)
+	require.Equal(t, newUser.Name, actualUser.Name)
 	require.NotEmpty(t, actualUser.CreatedAt)
 	require.NotEmpty(t, actualUser.UpdatedAt)
+	require.NotEmpty(t, actualUser.ApiKey)
 
 	return actualUser
 }
@@ -125,11 +148,12 @@ func TestUserHandler_mock_CreateUser(t *testing.T) {
 	now := time.Now()
 
 	querier := mockdb.NewMockQuerier(ctrl)
-	querier.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(database.User{
+	querier.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(database.CreateUserRow{
 		ID:        uuid.New(),
 		Name:      "John Doe",
 		CreatedAt: now,
 		UpdatedAt: now,
+		ApiKey:    generator.RandomString(64),
 	}, nil)
 
 	userHandler := handler.NewUserHandler(querier)
@@ -160,7 +184,9 @@ func TestUserHandler_GetUser(t *testing.T) {
 			},
 			expectedStatusCode: http.StatusOK,
 			expectedBody: func() string {
-				b, err := json.Marshal(user1)
+				withoutApiKey := user1
+				withoutApiKey.ApiKey = ""
+				b, err := json.Marshal(withoutApiKey)
 				require.NoError(t, err)
 				return string(b)
 			}(),
@@ -215,6 +241,607 @@ func TestUserHandler_GetUser(t *testing.T) {
 	}
 }
 
+func TestUserHandler_RotateApiKey(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	r := NewRouter(authMiddleware, userHandler, nil, nil, nil)
+
+	u := createUser(t, r)
+
+	getUser := func(apiKey string) int {
+		req, err := http.NewRequest(http.MethodGet, "/v1/users", http.NoBody)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "ApiKey "+apiKey)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	rotate := func(apiKey, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, "/v1/users/api_key/rotate", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "ApiKey "+apiKey)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+	type apiKey struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+		ApiKey string   `json:"api_key"`
+	}
+
+	rr := rotate(u.ApiKey, `{"grace_period_hours": 1000}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// The previous key still works during the grace period.
+	rr = rotate(u.ApiKey, `{"grace_period_hours": 1}`)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var rotated apiKey
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rotated))
+	assert.Equal(t, "default", rotated.Name)
+	require.NotEmpty(t, rotated.ApiKey)
+	assert.NotEqual(t, u.ApiKey, rotated.ApiKey)
+	assert.Equal(t, http.StatusOK, getUser(u.ApiKey))
+	assert.Equal(t, http.StatusOK, getUser(rotated.ApiKey))
+
+	// Without grace period, the previous key is revoked at once.
+	rr = rotate(rotated.ApiKey, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var next apiKey
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &next))
+	assert.Equal(t, http.StatusForbidden, getUser(rotated.ApiKey))
+	assert.Equal(t, http.StatusOK, getUser(next.ApiKey))
+}
+
+func TestUserHandler_ApiKeys(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	feedRepository := database.NewFeedRepository(testDB)
+	feedHandler := handler.NewFeedHandler(feedRepository)
+	feedFollowsHandler := handler.NewFeedFollowsHandler(feedRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	r := NewRouter(authMiddleware, userHandler, feedHandler, feedFollowsHandler, nil)
+
+	u := createUser(t, r)
+
+	request := func(method, path, apiKey, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "ApiKey "+apiKey)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := request(http.MethodPost, "/v1/users/api_keys", u.ApiKey, `{"name": "CI bot", "scopes": ["admin"]}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = request(http.MethodPost, "/v1/users/api_keys", u.ApiKey, `{"name": "CI bot", "scopes": ["feeds:read"]}`)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var key struct {
+		ID     string   `json:"id"`
+		Scopes []string `json:"scopes"`
+		ApiKey string   `json:"api_key"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &key))
+	require.NotEmpty(t, key.ApiKey)
+
+	// The key only grants its scopes.
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/v1/feed_follows", key.ApiKey, "").Code)
+	assert.Equal(t, http.StatusForbidden, request(http.MethodPost, "/v1/feeds", key.ApiKey, `{"name": "n", "url": "https://example.com/feed"}`).Code)
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/v1/users/api_keys", key.ApiKey, "").Code)
+
+	rr = request(http.MethodGet, "/v1/users/api_keys", u.ApiKey, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var keys []map[string]any
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &keys))
+	require.Len(t, keys, 2)
+	assert.Equal(t, key.ID, keys[1]["id"])
+	assert.NotContains(t, keys[1], "api_key")
+	assert.NotContains(t, keys[1], "hash")
+
+	rr = request(http.MethodDelete, "/v1/users/api_keys/"+key.ID, u.ApiKey, "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/v1/feed_follows", key.ApiKey, "").Code)
+}
+
+func TestUserHandler_Sessions(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	r := NewRouter(authMiddleware, userHandler, nil, nil, nil)
+
+	email := generator.RandomString(12) + "@example.com"
+	request := func(method, path, body string, cookies []*http.Cookie, csrfToken string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		require.NoError(t, err)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		if csrfToken != "" {
+			req.Header.Set(middleware.CSRFHeader, csrfToken)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+	type session struct {
+		User      user   `json:"user"`
+		CsrfToken string `json:"csrf_token"`
+	}
+
+	rr := request(http.MethodPost, "/v1/users/register", `{"name": "Jane Doe", "email": "jane", "password": "correct horse"}`, nil, "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = request(http.MethodPost, "/v1/users/register", `{"name": "Jane Doe", "email": "`+email+`", "password": "short"}`, nil, "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// The registration opens a session.
+	rr = request(http.MethodPost, "/v1/users/register", `{"name": "Jane Doe", "email": "`+email+`", "password": "correct horse"}`, nil, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var registered session
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &registered))
+	assert.Equal(t, email, registered.User.Email)
+	assert.NotContains(t, rr.Body.String(), "password")
+	require.NotEmpty(t, registered.CsrfToken)
+	cookies := rr.Result().Cookies()
+	require.Len(t, cookies, 2)
+	assert.Equal(t, middleware.SessionCookie, cookies[0].Name)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.True(t, cookies[0].Secure)
+	assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	assert.Equal(t, registered.CsrfToken, cookies[1].Value)
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/v1/users", "", cookies, "").Code)
+
+	rr = request(http.MethodPost, "/v1/users/register", `{"name": "Jane Doe", "email": "`+strings.ToUpper(email)+`", "password": "correct horse"}`, nil, "")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = request(http.MethodPost, "/v1/sessions", `{"email": "`+email+`", "password": "wrong horse"}`, nil, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	rr = request(http.MethodPost, "/v1/sessions", `{"email": "unknown@example.com", "password": "correct horse"}`, nil, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = request(http.MethodPost, "/v1/sessions", `{"email": "`+strings.ToUpper(email)+`", "password": "correct horse"}`, nil, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var loggedIn session
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &loggedIn))
+	assert.Equal(t, registered.User.ID, loggedIn.User.ID)
+	cookies = rr.Result().Cookies()
+
+	// A session grants all the scopes, the requests changing data require its CSRF token.
+	rr = request(http.MethodPost, "/v1/users/api_keys", `{"name": "CI bot", "scopes": ["feeds:read"]}`, cookies, "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = request(http.MethodPost, "/v1/users/api_keys", `{"name": "CI bot", "scopes": ["feeds:read"]}`, cookies, registered.CsrfToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = request(http.MethodPost, "/v1/users/api_keys", `{"name": "CI bot", "scopes": ["feeds:read"]}`, cookies, loggedIn.CsrfToken)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	// The logout closes the session only.
+	rr = request(http.MethodDelete, "/v1/sessions", "", cookies, "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = request(http.MethodDelete, "/v1/sessions", "", cookies, loggedIn.CsrfToken)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	for _, cookie := range rr.Result().Cookies() {
+		assert.Empty(t, cookie.Value)
+	}
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/v1/users", "", cookies, "").Code)
+}
+
+func TestUserHandler_UpdateUser(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	r := NewRouter(authMiddleware, userHandler, nil, nil, nil)
+
+	request := func(method, path, body string, header http.Header, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	u := createUser(t, r)
+	apiKey := http.Header{"Authorization": {"ApiKey " + u.ApiKey}}
+
+	rr := request(http.MethodPatch, "/v1/users", `{"name": "Jane Doe"}`, apiKey, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var updated user
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &updated))
+	assert.Equal(t, "Jane Doe", updated.Name)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPatch, "/v1/users", `{"name": " "}`, apiKey, nil).Code)
+	// The users of the API have no email.
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPatch, "/v1/users", `{"email": "jane@example.com"}`, apiKey, nil).Code)
+
+	// The email is changed with the current password.
+	email := generator.RandomString(12) + "@example.com"
+	rr = request(http.MethodPost, "/v1/users/register", `{"name": "Jane Doe", "email": "`+email+`", "password": "correct horse"}`, nil, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var session struct {
+		CsrfToken string `json:"csrf_token"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &session))
+	cookies := rr.Result().Cookies()
+	csrf := http.Header{middleware.CSRFHeader: {session.CsrfToken}}
+
+	newEmail := generator.RandomString(12) + "@example.com"
+	rr = request(http.MethodPatch, "/v1/users", `{"email": "`+newEmail+`", "current_password": "wrong horse"}`, csrf, cookies)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = request(http.MethodPatch, "/v1/users", `{"email": "`+newEmail+`", "current_password": "correct horse"}`, csrf, cookies)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &updated))
+	assert.Equal(t, newEmail, updated.Email)
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/v1/sessions", `{"email": "`+newEmail+`", "password": "correct horse"}`, nil, nil).Code)
+
+	// The account is deleted with its sessions.
+	rr = request(http.MethodDelete, "/v1/users", "", csrf, cookies)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/v1/users", "", nil, cookies).Code)
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodPost, "/v1/sessions", `{"email": "`+newEmail+`", "password": "correct horse"}`, nil, nil).Code)
+}
+
+func TestOIDCHandler(t *testing.T) {
+	provider := oidctest.NewProvider("rss", "secret")
+	defer provider.Close()
+	provider.SetIdentity(oidctest.Identity{
+		Subject:       generator.RandomString(12),
+		Email:         generator.RandomString(12) + "@example.com",
+		EmailVerified: true,
+		Name:          "Jane Doe",
+	})
+
+	client, err := oidc.Discover(context.Background(), http.DefaultClient, provider.Config("https://rss.example.com/v1/oidc/callback"))
+	require.NoError(t, err)
+
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+	r := NewRouter(authMiddleware, userHandler, nil, nil, nil,
+		WithOIDCHandler(handler.NewOIDCHandler(userRepository, client, "")),
+	)
+
+	// login follows the redirections to the provider and back, and returns the callback request.
+	login := func() *http.Request {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/oidc/login", http.NoBody))
+		require.Equal(t, http.StatusFound, rr.Code)
+		cookies := rr.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.True(t, cookies[0].HttpOnly)
+
+		noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}}
+		resp, err := noRedirect.Get(rr.Header().Get("Location"))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+
+		req := httptest.NewRequest(http.MethodGet, resp.Header.Get("Location"), http.NoBody)
+		req.AddCookie(cookies[0])
+		return req
+	}
+	type session struct {
+		User      user   `json:"user"`
+		CsrfToken string `json:"csrf_token"`
+	}
+
+	// A user is created at the first login, then a session is opened.
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, login())
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var first session
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &first))
+	assert.Equal(t, "Jane Doe", first.User.Name)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/users", http.NoBody)
+	for _, cookie := range rr.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// The same user is found at the next login.
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, login())
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var second session
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &second))
+	assert.Equal(t, first.User.ID, second.User.ID)
+
+	// The callback must be the one of the login of the browser.
+	req = login()
+	q := req.URL.Query()
+	q.Set("state", "forged")
+	req.URL.RawQuery = q.Encode()
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/oidc/callback?code=code&state=state", http.NoBody)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestTokenHandler(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	issuer, err := token.NewIssuer("https://rss.example.com", jwt.ES256, key)
+	require.NoError(t, err)
+
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	feedRepository := database.NewFeedRepository(testDB)
+	feedFollowsHandler := handler.NewFeedFollowsHandler(feedRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository, middleware.WithAccessTokens(issuer))
+	r := NewRouter(authMiddleware, userHandler, nil, feedFollowsHandler, nil,
+		WithTokenHandler(handler.NewTokenHandler(userRepository, issuer)),
+	)
+
+	u := createUser(t, r)
+
+	request := func(method, path, authorization, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+	type tokens struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int    `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+		Scope        string `json:"scope"`
+	}
+	decode := func(rr *httptest.ResponseRecorder) tokens {
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var tk tokens
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &tk))
+		require.NotEmpty(t, tk.AccessToken)
+		require.NotEmpty(t, tk.RefreshToken)
+		return tk
+	}
+
+	// The API key is exchanged for an access token with its scopes.
+	first := decode(request(http.MethodPost, "/v1/tokens", "ApiKey "+u.ApiKey, ""))
+	assert.Equal(t, "Bearer", first.TokenType)
+	assert.Equal(t, int(token.AccessTokenDuration.Seconds()), first.ExpiresIn)
+	assert.Equal(t, strings.Join(middleware.Scopes, " "), first.Scope)
+
+	rr := request(http.MethodGet, "/v1/users", "Bearer "+first.AccessToken, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var got user
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Equal(t, u.ID, got.ID)
+
+	rr = request(http.MethodGet, "/v1/users", "Bearer "+first.AccessToken+"x", "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Header().Get("WWW-Authenticate"), "invalid_token")
+
+	// An access token cannot be exchanged for another one.
+	assert.Equal(t, http.StatusForbidden, request(http.MethodPost, "/v1/tokens", "Bearer "+first.AccessToken, "").Code)
+
+	// The access tokens of a restricted key only grant its scopes.
+	rr = request(http.MethodPost, "/v1/users/api_keys", "ApiKey "+u.ApiKey, `{"name": "reader", "scopes": ["feeds:read"]}`)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var restricted struct {
+		ApiKey string `json:"api_key"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &restricted))
+	reader := decode(request(http.MethodPost, "/v1/tokens", "ApiKey "+restricted.ApiKey, ""))
+	assert.Equal(t, "feeds:read", reader.Scope)
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/v1/feed_follows", "Bearer "+reader.AccessToken, "").Code)
+	assert.Equal(t, http.StatusForbidden, request(http.MethodPost, "/v1/feed_follows", "Bearer "+reader.AccessToken, `{}`).Code)
+
+	// A refresh token is only used once.
+	second := decode(request(http.MethodPost, "/v1/tokens/refresh", "", `{"refresh_token": "`+first.RefreshToken+`"}`))
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	assert.Equal(t, first.Scope, second.Scope)
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodPost, "/v1/tokens/refresh", "", `{"refresh_token": "`+first.RefreshToken+`"}`).Code)
+
+	rr = request(http.MethodPost, "/v1/tokens/revoke", "", `{"refresh_token": "`+second.RefreshToken+`"}`)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodPost, "/v1/tokens/refresh", "", `{"refresh_token": "`+second.RefreshToken+`"}`).Code)
+
+	// The public key verifying the tokens is published.
+	rr = request(http.MethodGet, "/.well-known/jwks.json", "", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var jwks jwt.JWKS
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &jwks))
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, jwt.ES256, jwks.Keys[0].Alg)
+}
+
+// feedRefresherFunc is a FeedRefresher calling a function.
+type feedRefresherFunc func(ctx context.Context, feed database.Feed) error
+
+func (f feedRefresherFunc) Refresh(ctx context.Context, feed database.Feed) error {
+	return f(ctx, feed)
+}
+
+func TestAdminHandler(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	feedRepository := database.NewFeedRepository(testDB)
+	feedHandler := handler.NewFeedHandler(feedRepository)
+	postRepository := database.NewPostRepository(testDB)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	var refreshed []uuid.UUID
+	refresher := feedRefresherFunc(func(ctx context.Context, feed database.Feed) error {
+		refreshed = append(refreshed, feed.ID)
+		return nil
+	})
+	r := NewRouter(authMiddleware, userHandler, feedHandler, nil, nil,
+		WithAdminHandler(handler.NewAdminHandler(userRepository, feedRepository, postRepository, refresher)),
+	)
+
+	request := func(method, path, apiKey, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "ApiKey "+apiKey)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	admin := createUser(t, r)
+	u := createUser(t, r)
+	f, _ := createFeed(t, r, u)
+
+	// The admin routes are restricted to the admins.
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/v1/admin/users", admin.ApiKey, "").Code)
+	_, err := userRepository.SetUserRole(context.Background(), database.SetUserRoleParams{
+		ID:   uuid.MustParse(admin.ID),
+		Role: middleware.RoleAdmin,
+	})
+	require.NoError(t, err)
+	rr := request(http.MethodGet, "/v1/admin/users?limit=100", admin.ApiKey, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.NotContains(t, rr.Body.String(), "feed_token")
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/v1/admin/users", u.ApiKey, "").Code)
+
+	// A suspended user cannot authenticate until its suspension is lifted.
+	rr = request(http.MethodPost, "/v1/admin/users/"+u.ID+"/suspend", admin.ApiKey, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.NotContains(t, rr.Body.String(), "feed_token")
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/v1/users", u.ApiKey, "").Code)
+	rr = request(http.MethodPost, "/v1/admin/users/"+u.ID+"/unsuspend", admin.ApiKey, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/v1/users", u.ApiKey, "").Code)
+
+	// An admin cannot lock itself out.
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/v1/admin/users/"+admin.ID+"/suspend", admin.ApiKey, "").Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPut, "/v1/admin/users/"+u.ID+"/role", admin.ApiKey, `{"role": "owner"}`).Code)
+
+	rr = request(http.MethodGet, "/v1/admin/feeds?limit=100", admin.ApiKey, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	type feedHealth struct {
+		Feed      feed   `json:"feed"`
+		Status    string `json:"status"`
+		Followers int    `json:"followers"`
+	}
+	var feeds []feedHealth
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &feeds))
+	idx := slices.IndexFunc(feeds, func(h feedHealth) bool { return h.Feed.ID == f.ID })
+	require.NotEqual(t, -1, idx)
+	assert.Equal(t, "pending", feeds[idx].Status)
+	assert.Equal(t, 1, feeds[idx].Followers)
+
+	rr = request(http.MethodPost, "/v1/admin/feeds/"+f.ID+"/disable", admin.ApiKey, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var disabled struct {
+		DisabledAt struct {
+			Valid bool `json:"Valid"`
+		} `json:"disabled_at"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &disabled))
+	assert.True(t, disabled.DisabledAt.Valid)
+	rr = request(http.MethodPost, "/v1/admin/feeds/"+f.ID+"/enable", admin.ApiKey, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = request(http.MethodPost, "/v1/admin/feeds/"+f.ID+"/refresh", admin.ApiKey, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, []uuid.UUID{uuid.MustParse(f.ID)}, refreshed)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodPost, "/v1/admin/feeds/"+uuid.NewString()+"/refresh", admin.ApiKey, "").Code)
+
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodDelete, "/v1/admin/posts", admin.ApiKey, "").Code)
+	rr = request(http.MethodDelete, "/v1/admin/posts?feed_id="+f.ID, admin.ApiKey, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.JSONEq(t, `{"deleted": 0}`, rr.Body.String())
+
+	assert.Equal(t, http.StatusNoContent, request(http.MethodDelete, "/v1/admin/users/"+u.ID, admin.ApiKey, "").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodDelete, "/v1/admin/users/"+u.ID, admin.ApiKey, "").Code)
+}
+
+func TestAdminHandler_SuspendUser(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	issuer, err := token.NewIssuer("https://rss.example.com", jwt.ES256, key)
+	require.NoError(t, err)
+
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	feedRepository := database.NewFeedRepository(testDB)
+	postRepository := database.NewPostRepository(testDB)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository, middleware.WithAccessTokens(issuer))
+	r := NewRouter(authMiddleware, userHandler, nil, nil, nil,
+		WithTokenHandler(handler.NewTokenHandler(userRepository, issuer)),
+		WithAdminHandler(handler.NewAdminHandler(userRepository, feedRepository, postRepository, nil)),
+	)
+
+	request := func(method, path, authorization, body string, cookies []*http.Cookie, csrfToken string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		if csrfToken != "" {
+			req.Header.Set(middleware.CSRFHeader, csrfToken)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	admin := createUser(t, r)
+	_, err = userRepository.SetUserRole(context.Background(), database.SetUserRoleParams{
+		ID:   uuid.MustParse(admin.ID),
+		Role: middleware.RoleAdmin,
+	})
+	require.NoError(t, err)
+
+	// The user opens a session, creates an API key with it and exchanges the key for a refresh token.
+	email := generator.RandomString(12) + "@example.com"
+	rr := request(http.MethodPost, "/v1/users/register", `{"name": "Jane Doe", "email": "`+email+`", "password": "correct horse"}`, "", nil, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var session struct {
+		User      user   `json:"user"`
+		CsrfToken string `json:"csrf_token"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &session))
+	cookies := rr.Result().Cookies()
+
+	rr = request(http.MethodPost, "/v1/users/api_keys", "", `{"name": "reader", "scopes": ["feeds:read"]}`, cookies, session.CsrfToken)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var apiKey struct {
+		ApiKey string `json:"api_key"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &apiKey))
+
+	rr = request(http.MethodPost, "/v1/tokens", "ApiKey "+apiKey.ApiKey, "", nil, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var tokens struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &tokens))
+
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/v1/users", "", "", cookies, "").Code)
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/v1/users", "ApiKey "+apiKey.ApiKey, "", nil, "").Code)
+
+	// Once suspended, the session, the API key and the refresh token are refused.
+	rr = request(http.MethodPost, "/v1/admin/users/"+session.User.ID+"/suspend", "ApiKey "+admin.ApiKey, "", nil, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/v1/users", "", "", cookies, "").Code)
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/v1/users", "ApiKey "+apiKey.ApiKey, "", nil, "").Code)
+	rr = request(http.MethodPost, "/v1/tokens/refresh", "", `{"refresh_token": "`+tokens.RefreshToken+`"}`, nil, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
 type feed struct {
 	ID        string    `json:"id"`
 	Name      string    `json:"name"`
@@ -363,6 +990,182 @@ func TestFeedHandler_ListFeeds(t *testing.T) {
 	}
 }
 
+func TestFeedHandler_ManageFeed(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	feedRepository := database.NewFeedRepository(testDB)
+	feedHandler := handler.NewFeedHandler(feedRepository)
+	feedFollowsHandler := handler.NewFeedFollowsHandler(feedRepository)
+
+	router := NewRouter(authMiddleware, userHandler, feedHandler, feedFollowsHandler, nil)
+
+	request := func(method, path, apiKey, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "ApiKey "+apiKey)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	creator := createUser(t, router)
+	other := createUser(t, router)
+	f, _ := createFeed(t, router, creator)
+	path := "/v1/feeds/" + f.ID
+
+	rr := request(http.MethodGet, path, creator.ApiKey, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	// The feeds of the other users are not found.
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, path, other.ApiKey, "").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodPatch, path, other.ApiKey, `{"name":"mine"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodGet, "/v1/feeds/unknown", creator.ApiKey, "").Code)
+
+	// Rename, change the url and pause the feed.
+	newURL := generator.RandomURL(6)
+	rr = request(http.MethodPatch, path, creator.ApiKey, `{"name":"renamed","url":"`+newURL+`","disabled":true}`)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var updated database.Feed
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &updated))
+	assert.Equal(t, "renamed", updated.Name)
+	assert.Equal(t, newURL, updated.Url)
+	assert.True(t, updated.DisabledAt.Valid)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPatch, path, creator.ApiKey, `{"url":"ftp://example.com"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPatch, path, creator.ApiKey, `{"name":" "}`).Code)
+	taken, _ := createFeed(t, router, other)
+	assert.Equal(t, http.StatusConflict, request(http.MethodPatch, path, creator.ApiKey, `{"url":"`+taken.URL+`"}`).Code)
+
+	// Resume the feed, the other fields are kept.
+	rr = request(http.MethodPatch, path, creator.ApiKey, `{"disabled":false}`)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &updated))
+	assert.Equal(t, "renamed", updated.Name)
+	assert.False(t, updated.DisabledAt.Valid)
+
+	// The feed followed by another user cannot be deleted.
+	require.Equal(t, http.StatusOK, request(http.MethodPost, "/v1/feed_follows", other.ApiKey, `{"feed_id":"`+f.ID+`"}`).Code)
+	assert.Equal(t, http.StatusConflict, request(http.MethodDelete, path, creator.ApiKey, "").Code)
+
+	// The admins manage all the feeds.
+	admin := createUser(t, router)
+	_, err := userRepository.SetUserRole(context.Background(), database.SetUserRoleParams{
+		ID:   uuid.MustParse(admin.ID),
+		Role: middleware.RoleAdmin,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, path, admin.ApiKey, "").Code)
+	assert.Equal(t, http.StatusNoContent, request(http.MethodDelete, "/v1/feeds/"+taken.ID, admin.ApiKey, "").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/v1/feeds/"+taken.ID, admin.ApiKey, "").Code)
+}
+
+func TestFeedHandler_FeedVisibility(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	feedRepository := database.NewFeedRepository(testDB)
+	feedHandler := handler.NewFeedHandler(feedRepository)
+	feedFollowsHandler := handler.NewFeedFollowsHandler(feedRepository)
+
+	router := NewRouter(authMiddleware, userHandler, feedHandler, feedFollowsHandler, nil)
+
+	request := func(method, path, apiKey, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "ApiKey "+apiKey)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	listed := func(id uuid.UUID) bool {
+		rr := request(http.MethodGet, "/v1/feeds?limit=100", "", "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var feeds []database.Feed
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &feeds))
+		return slices.ContainsFunc(feeds, func(f database.Feed) bool { return f.ID == id })
+	}
+	follow := func(u user, id uuid.UUID) int {
+		return request(http.MethodPost, "/v1/feed_follows", u.ApiKey, `{"feed_id":"`+id.String()+`"}`).Code
+	}
+
+	creator := createUser(t, router)
+	shared := createUser(t, router)
+	other := createUser(t, router)
+
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "/v1/feeds", creator.ApiKey, `{"name":"secret","url":"`+generator.RandomURL(6)+`","visibility":"hidden"}`).Code)
+	rr := request(http.MethodPost, "/v1/feeds", creator.ApiKey, `{"name":"secret","url":"`+generator.RandomURL(6)+`","visibility":"private"}`)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var created struct {
+		Feed database.Feed `json:"feed"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	f := created.Feed
+	assert.Equal(t, "private", f.Visibility)
+	path := "/v1/feeds/" + f.ID.String()
+
+	// A private feed is neither listed nor followable by the other users.
+	assert.False(t, listed(f.ID))
+	assert.Equal(t, http.StatusNotFound, follow(other, f.ID))
+
+	// Its url is not reserved: the import of the other users creates their own feed, like for an unknown url.
+	req := httptest.NewRequest(http.MethodPost, "/v1/feed_follows/import", strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0"><body><outline text="Secret" xmlUrl="`+f.Url+`"/></body></opml>`))
+	req.Header.Set("Authorization", "ApiKey "+other.ApiKey)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var report struct {
+		Created int `json:"created"`
+		Entries []struct {
+			FeedID uuid.UUID `json:"feed_id"`
+		} `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	assert.Equal(t, 1, report.Created)
+	require.Len(t, report.Entries, 1)
+	assert.NotEqual(t, f.ID, report.Entries[0].FeedID)
+
+	// The url is now reserved by the public feed, the conflict does not tell which feed holds it.
+	rr = request(http.MethodPost, "/v1/feeds", creator.ApiKey, `{"name":"copy","url":"`+f.Url+`"}`)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Equal(t, http.StatusNoContent, request(http.MethodDelete, "/v1/feeds/"+report.Entries[0].FeedID.String(), other.ApiKey, "").Code)
+
+	// The users the feed is shared with can follow it.
+	assert.Equal(t, http.StatusNotFound, request(http.MethodPost, path+"/shares", other.ApiKey, `{"user_id":"`+shared.ID+`"}`).Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodPost, path+"/shares", creator.ApiKey, `{"user_id":"`+uuid.NewString()+`"}`).Code)
+	require.Equal(t, http.StatusOK, request(http.MethodPost, path+"/shares", creator.ApiKey, `{"user_id":"`+shared.ID+`"}`).Code)
+	assert.Equal(t, http.StatusConflict, request(http.MethodPost, path+"/shares", creator.ApiKey, `{"user_id":"`+shared.ID+`"}`).Code)
+	rr = request(http.MethodGet, path+"/shares", creator.ApiKey, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var shares []database.FeedShare
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &shares))
+	require.Len(t, shares, 1)
+	assert.Equal(t, shared.ID, shares[0].UserID.String())
+	assert.Equal(t, http.StatusOK, follow(shared, f.ID))
+
+	// The user unfollows the feed when it is not shared anymore.
+	assert.Equal(t, http.StatusNoContent, request(http.MethodDelete, path+"/shares/"+shared.ID, creator.ApiKey, "").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodDelete, path+"/shares/"+shared.ID, creator.ApiKey, "").Code)
+	assert.Equal(t, http.StatusNotFound, follow(shared, f.ID))
+
+	// An unlisted feed is followable but not listed.
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPatch, path, creator.ApiKey, `{"visibility":"hidden"}`).Code)
+	require.Equal(t, http.StatusOK, request(http.MethodPatch, path, creator.ApiKey, `{"visibility":"unlisted"}`).Code)
+	assert.False(t, listed(f.ID))
+	assert.Equal(t, http.StatusOK, follow(other, f.ID))
+
+	// The feed cannot be made private again while the users it is not shared with follow it.
+	assert.Equal(t, http.StatusConflict, request(http.MethodPatch, path, creator.ApiKey, `{"visibility":"private"}`).Code)
+	follows, err := feedRepository.ListFeedFollows(context.Background(), database.ListFeedFollowsParams{
+		UserID: uuid.NullUUID{UUID: uuid.MustParse(other.ID), Valid: true},
+		Limit:  100,
+	})
+	require.NoError(t, err)
+	assert.Len(t, follows, 1)
+
+	require.Equal(t, http.StatusOK, request(http.MethodPatch, path, creator.ApiKey, `{"visibility":"public"}`).Code)
+	assert.True(t, listed(f.ID))
+}
+
 func TestFeedHandler_CreateFeedFollows(t *testing.T) {
 	userRepository := database.NewUserRepository(testDB)
 	userHandler := handler.NewUserHandler(userRepository)
@@ -478,6 +1281,12 @@ func TestPostHandler_GetPostsByUser(t *testing.T) {
 	require.NoError(t, err)
 	require.NotEmpty(t, feed)
 
+	_, err = testQueries.CreateFeedFollows(ctx, database.CreateFeedFollowsParams{
+		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
 	var posts []database.Post
 	for i := 0; i < 11; i++ {
 		post, err := testQueries.CreatePost(ctx, database.CreatePostParams{
@@ -581,3 +1390,1570 @@ func TestPostHandler_GetPostsByUser(t *testing.T) {
 	}
 
 }
+
+func TestPostHandler_StarredPosts(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	feedRepository := database.NewFeedRepository(testDB)
+	feedHandler := handler.NewFeedHandler(feedRepository)
+
+	postRepository := database.NewPostRepository(testDB)
+	postHandler := handler.NewPostHandler(postRepository)
+
+	r := NewRouter(authMiddleware, userHandler, feedHandler, nil, postHandler)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	u := createUser(t, r)
+	f, _ := createFeed(t, r, u)
+	post, err := testQueries.CreatePost(ctx, database.CreatePostParams{
+		Title:       generator.RandomString(10),
+		Url:         generator.RandomURL(6),
+		Description: generator.RandomString(10),
+		PublishedAt: time.Now(),
+		FeedID:      uuid.NullUUID{UUID: uuid.MustParse(f.ID), Valid: true},
+	})
+	require.NoError(t, err)
+
+	// Star the post.
+	payload := strings.NewReader(`{"post_id":` + strconv.Itoa(int(post.ID)) + `}`)
+	req, err := http.NewRequest(http.MethodPost, "/v1/posts/starred", payload)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "ApiKey "+u.ApiKey)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var starred database.StarredPost
+	err = json.Unmarshal(rr.Body.Bytes(), &starred)
+	require.NoError(t, err)
+	assert.Equal(t, post.Url, starred.Url)
+
+	// Starring an unknown post fails.
+	req, err = http.NewRequest(http.MethodPost, "/v1/posts/starred", strings.NewReader(`{"post_id":-1}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "ApiKey "+u.ApiKey)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusNotFound, rr.Code)
+
+	// List the starred posts.
+	req, err = http.NewRequest(http.MethodGet, "/v1/posts/starred", http.NoBody)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "ApiKey "+u.ApiKey)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var list []database.StarredPost
+	err = json.Unmarshal(rr.Body.Bytes(), &list)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, starred.ID, list[0].ID)
+
+	// Unstar the post.
+	req, err = http.NewRequest(http.MethodDelete, "/v1/posts/starred/"+starred.ID.String(), http.NoBody)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "ApiKey "+u.ApiKey)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestFeedFollowsHandler_Folders(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	feedRepository := database.NewFeedRepository(testDB)
+	feedHandler := handler.NewFeedHandler(feedRepository)
+	feedFollowsHandler := handler.NewFeedFollowsHandler(feedRepository)
+
+	router := NewRouter(authMiddleware, userHandler, feedHandler, feedFollowsHandler, nil)
+
+	user := createUser(t, router)
+	_, follow := createFeed(t, router, user)
+	_, otherFollow := createFeed(t, router, user)
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Create a folder.
+	rr := do(http.MethodPost, "/v1/folders", `{"name":"news","position":1}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var folder database.Folder
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &folder))
+	assert.Equal(t, "news", folder.Name)
+
+	rr = do(http.MethodPost, "/v1/folders", `{"name":"news"}`)
+	require.Equal(t, http.StatusConflict, rr.Code)
+
+	// Rename the folder.
+	rr = do(http.MethodPut, "/v1/folders/"+folder.ID.String(), `{"name":"tech","position":1}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// Move a feed follow to the folder.
+	rr = do(http.MethodPut, "/v1/feed_follows/"+follow.ID.String(), `{"folder_id":"`+folder.ID.String()+`","position":2}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = do(http.MethodPut, "/v1/feed_follows/"+follow.ID.String(), `{"folder_id":"`+uuid.NewString()+`"}`)
+	require.Equal(t, http.StatusNotFound, rr.Code)
+
+	// The feed follows are grouped by folder.
+	rr = do(http.MethodGet, "/v1/feed_follows", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var list struct {
+		Folders []struct {
+			ID          uuid.UUID    `json:"id"`
+			Name        string       `json:"name"`
+			FeedFollows []feedFollow `json:"feed_follows"`
+		} `json:"folders"`
+		FeedFollows []feedFollow `json:"feed_follows"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &list))
+	require.Len(t, list.Folders, 1)
+	assert.Equal(t, "tech", list.Folders[0].Name)
+	require.Len(t, list.Folders[0].FeedFollows, 1)
+	assert.Equal(t, follow.ID, list.Folders[0].FeedFollows[0].ID)
+	require.Len(t, list.FeedFollows, 1)
+	assert.Equal(t, otherFollow.ID, list.FeedFollows[0].ID)
+
+	// The whole tree is listed, whatever the number of feed follows.
+	for i := 0; i < 10; i++ {
+		createFeed(t, router, user)
+	}
+	rr = do(http.MethodGet, "/v1/feed_follows", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &list))
+	require.Len(t, list.Folders, 1)
+	assert.Len(t, list.Folders[0].FeedFollows, 1)
+	assert.Len(t, list.FeedFollows, 11)
+
+	// Delete the folder.
+	rr = do(http.MethodDelete, "/v1/folders/"+folder.ID.String(), "")
+	require.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = do(http.MethodGet, "/v1/folders", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[]`, rr.Body.String())
+}
+
+func TestFeedFollowsHandler_UpdateFeedFollowsSettings(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	feedRepository := database.NewFeedRepository(testDB)
+	feedHandler := handler.NewFeedHandler(feedRepository)
+	feedFollowsHandler := handler.NewFeedFollowsHandler(feedRepository)
+
+	router := NewRouter(authMiddleware, userHandler, feedHandler, feedFollowsHandler, nil)
+
+	user := createUser(t, router)
+	_, follow := createFeed(t, router, user)
+
+	update := func(body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPut, "/v1/feed_follows/"+follow.ID.String(), strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := update(`{"title":"My feed","muted":true,"retention_days":7}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var actual database.FeedFollow
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &actual))
+	assert.Equal(t, "My feed", actual.Title.String)
+	assert.True(t, actual.Muted)
+	assert.True(t, actual.Notify)
+	assert.Equal(t, int32(7), actual.RetentionDays.Int32)
+
+	// The omitted fields keep their value.
+	rr = update(`{"notify":false,"retention_days":null}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &actual))
+	assert.Equal(t, "My feed", actual.Title.String)
+	assert.True(t, actual.Muted)
+	assert.False(t, actual.Notify)
+	assert.False(t, actual.RetentionDays.Valid)
+
+	// A null title clears it.
+	rr = update(`{"title":null}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &actual))
+	assert.False(t, actual.Title.Valid)
+	assert.True(t, actual.Muted)
+
+	rr = update(`{"retention_days":0}`)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestFilterRuleHandler(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	filterRuleHandler := handler.NewFilterRuleHandler(database.NewFilterRuleRepository(testDB))
+
+	router := NewRouter(authMiddleware, userHandler, nil, nil, nil, WithFilterRuleHandler(filterRuleHandler))
+
+	user := createUser(t, router)
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/v1/filters", `{"kind":"keyword","action":"exclude","pattern":"sponsored"}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var rule database.FilterRule
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rule))
+	assert.Equal(t, "sponsored", rule.Pattern)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "invalid kind", body: `{"kind":"title","action":"exclude","pattern":"a"}`},
+		{name: "invalid action", body: `{"kind":"keyword","action":"hide","pattern":"a"}`},
+		{name: "missing pattern", body: `{"kind":"keyword","action":"exclude"}`},
+		{name: "invalid regex", body: `{"kind":"regex","action":"exclude","pattern":"(a"}`},
+	}
+	for _, test := range tests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			rr := do(http.MethodPost, "/v1/filters", tc.body)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+
+	// The feed must be followed by the user and the folder must belong to the user.
+	unknownID := uuid.New().String()
+	rr = do(http.MethodPost, "/v1/filters", `{"kind":"keyword","action":"exclude","pattern":"a","feed_id":"`+unknownID+`"}`)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = do(http.MethodPost, "/v1/filters", `{"kind":"keyword","action":"exclude","pattern":"a","folder_id":"`+unknownID+`"}`)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = do(http.MethodPut, "/v1/filters/"+rule.ID.String(), `{"kind":"keyword","action":"exclude","pattern":"a","feed_id":"`+unknownID+`"}`)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = do(http.MethodPut, "/v1/filters/"+rule.ID.String(), `{"kind":"author","action":"include","pattern":"jane"}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = do(http.MethodGet, "/v1/filters", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var rules []database.FilterRule
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rules))
+	require.Len(t, rules, 1)
+	assert.Equal(t, "author", rules[0].Kind)
+
+	rr = do(http.MethodDelete, "/v1/filters/"+rule.ID.String(), "")
+	require.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestSyndicationHandler_GetUserFeed(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	postRepository := database.NewPostRepository(testDB)
+	syndicationHandler := handler.NewSyndicationHandler(userRepository, postRepository, "")
+
+	router := NewRouter(nil, userHandler, nil, nil, nil, WithSyndicationHandler(syndicationHandler))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := testQueries.CreateUser(ctx, generator.RandomString(10))
+	require.NoError(t, err)
+	feed, err := testQueries.CreateFeed(ctx, database.CreateFeedParams{
+		Name:   generator.RandomString(10),
+		Url:    generator.RandomURL(6),
+		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	_, err = testQueries.CreateFeedFollows(ctx, database.CreateFeedFollowsParams{
+		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	post, err := testQueries.CreatePost(ctx, database.CreatePostParams{
+		Title:       generator.RandomString(10),
+		Url:         generator.RandomURL(6),
+		Description: generator.RandomString(10),
+		PublishedAt: time.Now(),
+		FeedID:      uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name                string
+		url                 string
+		expectedStatusCode  int
+		expectedContentType string
+	}{
+		{
+			name:                "rss",
+			url:                 "/v1/users/" + user.ID.String() + "/feed.rss?token=" + user.FeedToken,
+			expectedStatusCode:  http.StatusOK,
+			expectedContentType: "application/rss+xml; charset=utf-8",
+		},
+		{
+			name:                "atom",
+			url:                 "/v1/users/" + user.ID.String() + "/feed.atom?token=" + user.FeedToken,
+			expectedStatusCode:  http.StatusOK,
+			expectedContentType: "application/atom+xml; charset=utf-8",
+		},
+		{
+			name:                "json",
+			url:                 "/v1/users/" + user.ID.String() + "/feed.json?token=" + user.FeedToken,
+			expectedStatusCode:  http.StatusOK,
+			expectedContentType: "application/feed+json; charset=utf-8",
+		},
+		{
+			name:               "unknown format",
+			url:                "/v1/users/" + user.ID.String() + "/feed.xml?token=" + user.FeedToken,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "without token",
+			url:                "/v1/users/" + user.ID.String() + "/feed.rss",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "with bad token",
+			url:                "/v1/users/" + user.ID.String() + "/feed.rss?token=unknown",
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tc.url, http.NoBody)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+			require.Equal(t, tc.expectedStatusCode, rr.Code)
+			if tc.expectedContentType != "" {
+				assert.Equal(t, tc.expectedContentType, rr.Header().Get("Content-Type"))
+				assert.Contains(t, rr.Body.String(), post.Title)
+			}
+		})
+	}
+}
+
+func TestSyndicationHandler_RotateFeedToken(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+	postRepository := database.NewPostRepository(testDB)
+	syndicationHandler := handler.NewSyndicationHandler(userRepository, postRepository, "")
+
+	router := NewRouter(authMiddleware, userHandler, nil, nil, nil, WithSyndicationHandler(syndicationHandler))
+
+	u := createUser(t, router)
+	request := func(method, path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, http.NoBody)
+		if apiKey != "" {
+			req.Header.Set("Authorization", "ApiKey "+apiKey)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	feedToken := func(rr *httptest.ResponseRecorder) string {
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var got struct {
+			FeedToken string `json:"feed_token"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		require.Len(t, got.FeedToken, 64)
+		return got.FeedToken
+	}
+
+	previous := feedToken(request(http.MethodGet, "/v1/users", u.ApiKey))
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/v1/users/"+u.ID+"/feed.rss?token="+previous, "").Code)
+
+	// The previous feed url stops working at once.
+	next := feedToken(request(http.MethodPost, "/v1/users/feed_token/rotate", u.ApiKey))
+	assert.NotEqual(t, previous, next)
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/v1/users/"+u.ID+"/feed.rss?token="+previous, "").Code)
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/v1/users/"+u.ID+"/feed.rss?token="+next, "").Code)
+}
+
+func TestFeedFollowsHandler_ImportFeedFollows(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	feedRepository := database.NewFeedRepository(testDB)
+	feedHandler := handler.NewFeedHandler(feedRepository)
+	feedFollowsHandler := handler.NewFeedFollowsHandler(feedRepository)
+
+	router := NewRouter(authMiddleware, userHandler, feedHandler, feedFollowsHandler, nil)
+
+	user := createUser(t, router)
+	followed, _ := createFeed(t, router, user)
+	existing, _ := createFeed(t, router, createUser(t, router))
+	newURL := generator.RandomURL(6)
+
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <head><title>Subscriptions</title></head>
+  <body>
+    <outline text="Followed" xmlUrl="` + followed.URL + `"/>
+    <outline text="Tech">
+      <outline text="Existing" xmlUrl="` + existing.URL + `"/>
+      <outline text="Go">
+        <outline text="New" xmlUrl="` + newURL + `"/>
+      </outline>
+    </outline>
+    <outline text="Invalid" xmlUrl="ftp://example.com/feed"/>
+    <outline text="New again" xmlUrl="` + newURL + `"/>
+  </body>
+</opml>`
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", "subscriptions.opml")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(doc))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	req, err := http.NewRequest(http.MethodPost, "/v1/feed_follows/import", &body)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var report struct {
+		Created   int `json:"created"`
+		Followed  int `json:"followed"`
+		Duplicate int `json:"duplicate"`
+		Invalid   int `json:"invalid"`
+		Entries   []struct {
+			URL    string `json:"url"`
+			Folder string `json:"folder"`
+			Status string `json:"status"`
+		} `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Followed)
+	assert.Equal(t, 2, report.Duplicate)
+	assert.Equal(t, 1, report.Invalid)
+	require.Len(t, report.Entries, 5)
+	assert.Equal(t, "duplicate", report.Entries[0].Status)
+	assert.Equal(t, "followed", report.Entries[1].Status)
+	assert.Equal(t, "Tech", report.Entries[1].Folder)
+	assert.Equal(t, "created", report.Entries[2].Status)
+	assert.Equal(t, "Tech / Go", report.Entries[2].Folder)
+	assert.Equal(t, "invalid", report.Entries[3].Status)
+	assert.Equal(t, "duplicate", report.Entries[4].Status)
+
+	// The nested outlines are imported as folders.
+	req, err = http.NewRequest(http.MethodGet, "/v1/folders", http.NoBody)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var folders []database.Folder
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &folders))
+	require.Len(t, folders, 2)
+
+	// The document can also be sent as the request body, an invalid one is rejected.
+	req, err = http.NewRequest(http.MethodPost, "/v1/feed_follows/import", strings.NewReader("not an opml document"))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestFeedFollowsHandler_ImportFeedFollows_Positions(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	feedRepository := database.NewFeedRepository(testDB)
+	feedHandler := handler.NewFeedHandler(feedRepository)
+	feedFollowsHandler := handler.NewFeedFollowsHandler(feedRepository)
+
+	router := NewRouter(authMiddleware, userHandler, feedHandler, feedFollowsHandler, nil)
+
+	user := createUser(t, router)
+	_, follow := createFeed(t, router, user)
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/v1/folders", `{"name":"news","position":3}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// The imported follows and folders are placed after the existing ones.
+	rr = do(http.MethodPost, "/v1/feed_follows/import", `<opml version="2.0"><body>
+<outline text="First" xmlUrl="`+generator.RandomURL(6)+`"/>
+<outline text="Second" xmlUrl="`+generator.RandomURL(6)+`"/>
+<outline text="Tech"><outline text="Third" xmlUrl="`+generator.RandomURL(6)+`"/></outline>
+</body></opml>`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var report struct {
+		Entries []struct {
+			FeedFollowID uuid.UUID `json:"feed_follow_id"`
+		} `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	require.Len(t, report.Entries, 3)
+
+	rr = do(http.MethodGet, "/v1/feed_follows", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var tree struct {
+		Folders []struct {
+			database.Folder
+			FeedFollows []database.FeedFollow `json:"feed_follows"`
+		} `json:"folders"`
+		FeedFollows []database.FeedFollow `json:"feed_follows"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &tree))
+	require.Len(t, tree.FeedFollows, 3)
+	assert.Equal(t, follow.ID, tree.FeedFollows[0].ID)
+	assert.Equal(t, int32(0), tree.FeedFollows[0].Position)
+	assert.Equal(t, report.Entries[0].FeedFollowID, tree.FeedFollows[1].ID)
+	assert.Equal(t, int32(1), tree.FeedFollows[1].Position)
+	assert.Equal(t, report.Entries[1].FeedFollowID, tree.FeedFollows[2].ID)
+	assert.Equal(t, int32(2), tree.FeedFollows[2].Position)
+
+	require.Len(t, tree.Folders, 2)
+	assert.Equal(t, "Tech", tree.Folders[1].Name)
+	assert.Equal(t, int32(4), tree.Folders[1].Position)
+	require.Len(t, tree.Folders[1].FeedFollows, 1)
+	assert.Equal(t, int32(0), tree.Folders[1].FeedFollows[0].Position)
+}
+
+func TestFeedFollowsHandler_ExportFeedFollows(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	feedRepository := database.NewFeedRepository(testDB)
+	feedHandler := handler.NewFeedHandler(feedRepository)
+	feedFollowsHandler := handler.NewFeedFollowsHandler(feedRepository)
+
+	router := NewRouter(authMiddleware, userHandler, feedHandler, feedFollowsHandler, nil)
+
+	user := createUser(t, router)
+	feed1, follow := createFeed(t, router, user)
+	feed2, _ := createFeed(t, router, user)
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/v1/folders", `{"name":"news"}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var folder database.Folder
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &folder))
+
+	rr = do(http.MethodPut, "/v1/feed_follows/"+follow.ID.String(), `{"folder_id":"`+folder.ID.String()+`","title":"My feed"}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = do(http.MethodGet, "/v1/feed_follows/export.opml", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/x-opml; charset=utf-8", rr.Header().Get("Content-Type"))
+
+	doc, err := opml.Parse(rr.Body)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []opml.Subscription{
+		{Title: "My feed", XMLURL: feed1.URL, Folder: "news"},
+		{Title: feed2.Name, XMLURL: feed2.URL},
+	}, doc.Subscriptions())
+}
+
+func TestExportHandler(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	feedRepository := database.NewFeedRepository(testDB)
+	feedHandler := handler.NewFeedHandler(feedRepository)
+	postRepository := database.NewPostRepository(testDB)
+	filterRuleRepository := database.NewFilterRuleRepository(testDB)
+	exportHandler := handler.NewExportHandler(userRepository, feedRepository, postRepository, filterRuleRepository)
+
+	router := NewRouter(authMiddleware, userHandler, feedHandler, nil, handler.NewPostHandler(postRepository),
+		WithFilterRuleHandler(handler.NewFilterRuleHandler(filterRuleRepository)),
+		WithExportHandler(exportHandler),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	u := createUser(t, router)
+	f, _ := createFeed(t, router, u)
+	feedID := uuid.NullUUID{UUID: uuid.MustParse(f.ID), Valid: true}
+	post, err := testQueries.CreatePost(ctx, database.CreatePostParams{
+		Title:       generator.RandomString(10),
+		Url:         generator.RandomURL(6),
+		Description: generator.RandomString(10),
+		PublishedAt: time.Now(),
+		FeedID:      feedID,
+	})
+	require.NoError(t, err)
+	_, err = postRepository.MarkPostsRead(ctx, database.MarkPostsReadParams{UserID: uuid.MustParse(u.ID), PostIds: []int32{post.ID}})
+	require.NoError(t, err)
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "ApiKey "+u.ApiKey)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/v1/posts/starred", `{"post_id":`+strconv.Itoa(int(post.ID))+`}`).Code)
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/v1/filters", `{"kind":"keyword","action":"exclude","pattern":"sponsored"}`).Code)
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/v1/users/export?format=rar", "").Code)
+
+	rr := do(http.MethodGet, "/v1/users/export", "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "application/zip", rr.Header().Get("Content-Type"))
+	zr, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, file := range zr.File {
+		fr, err := file.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(fr)
+		require.NoError(t, err)
+		files[file.Name] = data
+	}
+
+	var profile database.User
+	require.NoError(t, json.Unmarshal(files["profile.json"], &profile))
+	assert.Equal(t, u.ID, profile.ID.String())
+	var feeds []database.Feed
+	require.NoError(t, json.Unmarshal(files["feeds.json"], &feeds))
+	require.Len(t, feeds, 1)
+	assert.Equal(t, f.URL, feeds[0].Url)
+	var follows []database.ListFeedFollowsWithFeedsRow
+	require.NoError(t, json.Unmarshal(files["follows.json"], &follows))
+	require.Len(t, follows, 1)
+	assert.Equal(t, f.URL, follows[0].FeedUrl)
+	doc, err := opml.Parse(bytes.NewReader(files["follows.opml"]))
+	require.NoError(t, err)
+	assert.Equal(t, []opml.Subscription{{Title: f.Name, XMLURL: f.URL}}, doc.Subscriptions())
+	var read []database.ListReadPostsRow
+	require.NoError(t, json.Unmarshal(files["read.json"], &read))
+	require.Len(t, read, 1)
+	assert.Equal(t, post.Url, read[0].Url)
+	var starred []database.StarredPost
+	require.NoError(t, json.Unmarshal(files["starred.json"], &starred))
+	require.Len(t, starred, 1)
+	assert.Equal(t, post.Url, starred[0].Url)
+	var rules []database.FilterRule
+	require.NoError(t, json.Unmarshal(files["filters.json"], &rules))
+	require.Len(t, rules, 1)
+	assert.Equal(t, "sponsored", rules[0].Pattern)
+
+	// The same files are in the tar.gz archive.
+	rr = do(http.MethodGet, "/v1/users/export?format=tar.gz", "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "application/gzip", rr.Header().Get("Content-Type"))
+	gr, err := gzip.NewReader(rr.Body)
+	require.NoError(t, err)
+	tr := tar.NewReader(gr)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, hdr.Name)
+	}
+	assert.ElementsMatch(t, []string{"profile.json", "folders.json", "follows.json", "follows.opml", "read.json", "starred.json", "filters.json", "feeds.json"}, names)
+}
+
+func TestStreamHandler_StreamPosts(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	postRepository := database.NewPostRepository(testDB)
+	broker := pubsub.NewBroker(10)
+	streamHandler := handler.NewStreamHandler(postRepository, broker)
+
+	router := NewRouter(authMiddleware, userHandler, nil, nil, nil, WithStreamHandler(streamHandler))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := testQueries.CreateUser(ctx, generator.RandomString(10))
+	require.NoError(t, err)
+	feed, err := testQueries.CreateFeed(ctx, database.CreateFeedParams{
+		Name:   generator.RandomString(10),
+		Url:    generator.RandomURL(6),
+		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	_, err = testQueries.CreateFeedFollows(ctx, database.CreateFeedFollowsParams{
+		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	createPost := func() database.Post {
+		post, err := testQueries.CreatePost(ctx, database.CreatePostParams{
+			Title:       generator.RandomString(10),
+			Url:         generator.RandomURL(6),
+			Description: generator.RandomString(10),
+			PublishedAt: time.Now(),
+			FeedID:      uuid.NullUUID{UUID: feed.ID, Valid: true},
+		})
+		require.NoError(t, err)
+		return post
+	}
+	missed := createPost()
+
+	// readEvent reads the next event, skipping the comments.
+	readEvent := func(scanner *bufio.Scanner) (id string, data string) {
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				data = strings.TrimPrefix(line, "data: ")
+			case line == "" && id != "":
+				return id, data
+			}
+		}
+		require.NoError(t, scanner.Err())
+		return id, data
+	}
+
+	// Resume the stream after a post: the missed posts are sent first.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/v1/posts/stream", http.NoBody)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+	req.Header.Set("Last-Event-ID", strconv.Itoa(int(missed.ID-1)))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	scanner := bufio.NewScanner(resp.Body)
+	id, data := readEvent(scanner)
+	assert.Equal(t, strconv.Itoa(int(missed.ID)), id)
+	var post database.Post
+	require.NoError(t, json.Unmarshal([]byte(data), &post))
+	assert.Equal(t, missed.Title, post.Title)
+
+	// The new posts are pushed when they are published.
+	created := createPost()
+	broker.Publish(created)
+	id, _ = readEvent(scanner)
+	assert.Equal(t, strconv.Itoa(int(created.ID)), id)
+}
+
+func TestStreamHandler_StreamPosts_Resume(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	postRepository := database.NewPostRepository(testDB)
+	broker := pubsub.NewBroker(10)
+	streamHandler := handler.NewStreamHandler(postRepository, broker)
+
+	router := NewRouter(authMiddleware, userHandler, nil, nil, nil, WithStreamHandler(streamHandler))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	user, err := testQueries.CreateUser(ctx, generator.RandomString(10))
+	require.NoError(t, err)
+	feed, err := testQueries.CreateFeed(ctx, database.CreateFeedParams{
+		Name:   generator.RandomString(10),
+		Url:    generator.RandomURL(6),
+		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	_, err = testQueries.CreateFeedFollows(ctx, database.CreateFeedFollowsParams{
+		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// More missed posts than a batch, the last created being the first published.
+	var missed []int32
+	now := time.Now()
+	for i := range 250 {
+		post, err := testQueries.CreatePost(ctx, database.CreatePostParams{
+			Title:       generator.RandomString(10),
+			Url:         generator.RandomURL(6),
+			Description: generator.RandomString(10),
+			PublishedAt: now.Add(-time.Duration(i) * time.Minute),
+			FeedID:      uuid.NullUUID{UUID: feed.ID, Valid: true},
+		})
+		require.NoError(t, err)
+		missed = append(missed, post.ID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/v1/posts/stream", http.NoBody)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+	req.Header.Set("Last-Event-ID", strconv.Itoa(int(missed[0]-1)))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// All the missed posts are sent, in creation order.
+	var ids []int32
+	scanner := bufio.NewScanner(resp.Body)
+	for len(ids) < len(missed) && scanner.Scan() {
+		if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+			n, err := strconv.Atoi(id)
+			require.NoError(t, err)
+			ids = append(ids, int32(n))
+		}
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, missed, ids)
+}
+
+func TestWebSocketHandler_ServeWebSocket(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	postRepository := database.NewPostRepository(testDB)
+	broker := pubsub.NewBroker(10)
+	webSocketHandler := handler.NewWebSocketHandler(postRepository, broker)
+
+	router := NewRouter(authMiddleware, userHandler, nil, nil, nil, WithWebSocketHandler(webSocketHandler))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := testQueries.CreateUser(ctx, generator.RandomString(10))
+	require.NoError(t, err)
+	feed, err := testQueries.CreateFeed(ctx, database.CreateFeedParams{
+		Name:   generator.RandomString(10),
+		Url:    generator.RandomURL(6),
+		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	_, err = testQueries.CreateFeedFollows(ctx, database.CreateFeedFollowsParams{
+		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	header := http.Header{}
+	header.Set("Authorization", "ApiKey "+user.ApiKey)
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, "ws"+strings.TrimPrefix(server.URL, "http")+"/v1/ws", header)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	defer conn.Close()
+
+	type message struct {
+		Type         string                         `json:"type"`
+		Post         database.Post                  `json:"post"`
+		UnreadCounts []database.CountUnreadPostsRow `json:"unread_counts"`
+		Error        string                         `json:"error"`
+	}
+	read := func() message {
+		var msg message
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		require.NoError(t, conn.ReadJSON(&msg))
+		return msg
+	}
+
+	// The unread counts are sent on connection and after a subscription.
+	msg := read()
+	assert.Equal(t, "unread_counts", msg.Type)
+	assert.Empty(t, msg.UnreadCounts)
+
+	require.NoError(t, conn.WriteJSON(map[string]any{"type": "subscribe", "feed_ids": []uuid.UUID{feed.ID}}))
+	msg = read()
+	assert.Equal(t, "unread_counts", msg.Type)
+
+	// The new posts of the subscribed feeds are pushed with the new unread counts.
+	post, err := testQueries.CreatePost(ctx, database.CreatePostParams{
+		Title:       generator.RandomString(10),
+		Url:         generator.RandomURL(6),
+		Description: generator.RandomString(10),
+		PublishedAt: time.Now(),
+		FeedID:      uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	broker.Publish(post)
+
+	msg = read()
+	assert.Equal(t, "post", msg.Type)
+	assert.Equal(t, post.ID, msg.Post.ID)
+	msg = read()
+	assert.Equal(t, "unread_counts", msg.Type)
+	require.Len(t, msg.UnreadCounts, 1)
+	assert.Equal(t, int32(1), msg.UnreadCounts[0].Unread)
+
+	// Mark the post as read.
+	require.NoError(t, conn.WriteJSON(map[string]any{"type": "mark_read", "post_ids": []int32{post.ID}}))
+	msg = read()
+	assert.Equal(t, "unread_counts", msg.Type)
+	assert.Empty(t, msg.UnreadCounts)
+
+	require.NoError(t, conn.WriteJSON(map[string]any{"type": "unknown"}))
+	msg = read()
+	assert.Equal(t, "error", msg.Type)
+
+	// The number of feeds and folders of a subscription is limited.
+	feedIDs := make([]uuid.UUID, 60)
+	folderIDs := make([]uuid.UUID, 41)
+	for i := range feedIDs {
+		feedIDs[i] = uuid.New()
+	}
+	for i := range folderIDs {
+		folderIDs[i] = uuid.New()
+	}
+	require.NoError(t, conn.WriteJSON(map[string]any{"type": "subscribe", "feed_ids": feedIDs, "folder_ids": folderIDs}))
+	msg = read()
+	assert.Equal(t, "error", msg.Type)
+	assert.Contains(t, msg.Error, "at most 100")
+}
+
+func TestWebSocketHandler_ServeWebSocket_Batches(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	postRepository := database.NewPostRepository(testDB)
+	broker := pubsub.NewBroker(10)
+	webSocketHandler := handler.NewWebSocketHandler(postRepository, broker)
+
+	router := NewRouter(authMiddleware, userHandler, nil, nil, nil, WithWebSocketHandler(webSocketHandler))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	user, err := testQueries.CreateUser(ctx, generator.RandomString(10))
+	require.NoError(t, err)
+	var feedIDs []uuid.UUID
+	for range 2 {
+		feed, err := testQueries.CreateFeed(ctx, database.CreateFeedParams{
+			Name:   generator.RandomString(10),
+			Url:    generator.RandomURL(6),
+			UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		})
+		require.NoError(t, err)
+		_, err = testQueries.CreateFeedFollows(ctx, database.CreateFeedFollowsParams{
+			UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+			FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+		})
+		require.NoError(t, err)
+		feedIDs = append(feedIDs, feed.ID)
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "ApiKey "+user.ApiKey)
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, "ws"+strings.TrimPrefix(server.URL, "http")+"/v1/ws", header)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	defer conn.Close()
+
+	type message struct {
+		Type string        `json:"type"`
+		Post database.Post `json:"post"`
+	}
+	read := func() message {
+		var msg message
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		require.NoError(t, conn.ReadJSON(&msg))
+		return msg
+	}
+	assert.Equal(t, "unread_counts", read().Type)
+	require.NoError(t, conn.WriteJSON(map[string]any{"type": "subscribe", "feed_ids": feedIDs}))
+	assert.Equal(t, "unread_counts", read().Type)
+
+	// More posts than a batch in each feed, the last created being the first published.
+	var created []int32
+	var post database.Post
+	now := time.Now()
+	for i := range 300 {
+		post, err = testQueries.CreatePost(ctx, database.CreatePostParams{
+			Title:       generator.RandomString(10),
+			Url:         generator.RandomURL(6),
+			Description: generator.RandomString(10),
+			PublishedAt: now.Add(-time.Duration(i) * time.Minute),
+			FeedID:      uuid.NullUUID{UUID: feedIDs[i%2], Valid: true},
+		})
+		require.NoError(t, err)
+		created = append(created, post.ID)
+	}
+	broker.Publish(post)
+
+	// All the posts are sent in creation order, then the unread counts.
+	var ids []int32
+	for range created {
+		msg := read()
+		require.Equal(t, "post", msg.Type)
+		ids = append(ids, msg.Post.ID)
+	}
+	assert.Equal(t, created, ids)
+	assert.Equal(t, "unread_counts", read().Type)
+}
+
+func TestWebhookHandler(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	webhookHandler := handler.NewWebhookHandler(database.NewWebhookRepository(testDB))
+
+	router := NewRouter(authMiddleware, userHandler, nil, nil, nil, WithWebhookHandler(webhookHandler))
+
+	user := createUser(t, router)
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// The secret is generated and only returned on creation.
+	rr := do(http.MethodPost, "/v1/webhooks", `{"url":"https://93.184.215.14/hook"}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var created struct {
+		ID           uuid.UUID `json:"id"`
+		URL          string    `json:"url"`
+		Secret       string    `json:"secret"`
+		ApplyFilters bool      `json:"apply_filters"`
+		Active       bool      `json:"active"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, "https://93.184.215.14/hook", created.URL)
+	assert.Len(t, created.Secret, 64)
+	assert.True(t, created.ApplyFilters)
+	assert.True(t, created.Active)
+
+	rr = do(http.MethodPost, "/v1/webhooks", `{"url":"example.com/hook"}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// The webhooks cannot target the internal network.
+	for _, hookURL := range []string{"http://127.0.0.1:8080/hook", "http://localhost/hook", "http://169.254.169.254/latest/meta-data", "http://[::1]/hook"} {
+		rr = do(http.MethodPost, "/v1/webhooks", `{"url":"`+hookURL+`"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, hookURL)
+	}
+
+	// The feed must be followed by the user and the folder must belong to the user.
+	rr = do(http.MethodPost, "/v1/webhooks", `{"url":"https://93.184.215.14/hook","feed_id":"`+uuid.NewString()+`"}`)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = do(http.MethodPost, "/v1/webhooks", `{"url":"https://93.184.215.14/hook","folder_id":"`+uuid.NewString()+`"}`)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = do(http.MethodPut, "/v1/webhooks/"+created.ID.String(), `{"url":"https://93.184.215.14/hook","folder_id":"`+uuid.NewString()+`"}`)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = do(http.MethodPut, "/v1/webhooks/"+created.ID.String(), `{"url":"https://93.184.215.14/other","active":false}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = do(http.MethodGet, "/v1/webhooks", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), created.Secret)
+	var webhooks []database.Webhook
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &webhooks))
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, "https://93.184.215.14/other", webhooks[0].Url)
+	assert.False(t, webhooks[0].Active)
+
+	rr = do(http.MethodGet, "/v1/webhooks/"+created.ID.String()+"/deliveries", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[]`, rr.Body.String())
+
+	rr = do(http.MethodGet, "/v1/webhooks/"+uuid.NewString()+"/deliveries", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = do(http.MethodDelete, "/v1/webhooks/"+created.ID.String(), "")
+	require.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestDigestHandler(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	digestHandler := handler.NewDigestHandler(database.NewDigestRepository(testDB))
+
+	router := NewRouter(authMiddleware, userHandler, nil, nil, nil, WithDigestHandler(digestHandler))
+
+	user := createUser(t, router)
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do(http.MethodGet, "/v1/digest", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "invalid email", body: `{"email":"jane","frequency":"daily","hour":8}`},
+		{name: "invalid frequency", body: `{"email":"jane@example.com","frequency":"monthly","hour":8}`},
+		{name: "invalid hour", body: `{"email":"jane@example.com","frequency":"daily","hour":24}`},
+		{name: "invalid weekday", body: `{"email":"jane@example.com","frequency":"weekly","hour":8,"weekday":7}`},
+		{name: "invalid timezone", body: `{"email":"jane@example.com","frequency":"daily","hour":8,"timezone":"Mars/Olympus"}`},
+	}
+	for _, test := range tests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			rr := do(http.MethodPut, "/v1/digest", tc.body)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+
+	rr = do(http.MethodPut, "/v1/digest", `{"email":"jane@example.com","frequency":"weekly","hour":8,"weekday":5,"timezone":"Europe/Paris"}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var settings database.DigestSetting
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &settings))
+	assert.Equal(t, "weekly", settings.Frequency)
+	assert.True(t, settings.Enabled)
+	assert.True(t, settings.NextSendAt.After(time.Now()))
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	assert.Equal(t, time.Friday, settings.NextSendAt.In(paris).Weekday())
+	assert.Equal(t, 8, settings.NextSendAt.In(paris).Hour())
+
+	rr = do(http.MethodGet, "/v1/digest", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = do(http.MethodDelete, "/v1/digest", "")
+	require.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+type fakeIngester struct {
+	feeds []database.Feed
+	data  [][]byte
+}
+
+func (i *fakeIngester) Ingest(_ context.Context, feed database.Feed, data []byte) error {
+	i.feeds = append(i.feeds, feed)
+	i.data = append(i.data, data)
+	return nil
+}
+
+func TestWebSubHandler(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	feedRepository := database.NewFeedRepository(testDB)
+	webSubRepository := database.NewWebSubRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	feedHandler := handler.NewFeedHandler(feedRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+
+	ingester := &fakeIngester{}
+	webSubHandler := handler.NewWebSubHandler(webSubRepository, ingester)
+
+	router := NewRouter(authMiddleware, userHandler, feedHandler, nil, nil, WithWebSubHandler(webSubHandler))
+
+	user := createUser(t, router)
+	f, _ := createFeed(t, router, user)
+	feedID := uuid.MustParse(f.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := webSubRepository.UpsertWebSubSubscription(ctx, database.UpsertWebSubSubscriptionParams{
+		FeedID:   feedID,
+		HubUrl:   "https://hub.example.com/",
+		TopicUrl: f.URL,
+		Secret:   "secret",
+	})
+	require.NoError(t, err)
+
+	verify := func(mode, topic string) *httptest.ResponseRecorder {
+		query := url.Values{
+			"hub.mode":          {mode},
+			"hub.topic":         {topic},
+			"hub.challenge":     {"challenge"},
+			"hub.lease_seconds": {"3600"},
+		}
+		req, err := http.NewRequest(http.MethodGet, "/v1/websub/"+f.ID+"?"+query.Encode(), nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// The intent is only verified for the topic of the subscription.
+	rr := verify("subscribe", "https://other.example.com/feed.xml")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = verify("unsubscribe", f.URL)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = verify("subscribe", f.URL)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "challenge", rr.Body.String())
+
+	row, err := webSubRepository.GetWebSubSubscription(ctx, feedID)
+	require.NoError(t, err)
+	assert.Equal(t, "active", row.WebSubSubscription.State)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), row.WebSubSubscription.LeaseExpiresAt.Time, time.Minute)
+
+	push := func(id, signature, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, "/v1/websub/"+id, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/rss+xml")
+		req.Header.Set("X-Hub-Signature", signature)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	body := `<rss><channel><title>pushed</title></channel></rss>`
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(body))
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	// The content with an invalid signature is acknowledged but ignored.
+	rr = push(f.ID, "sha256=00", body)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	assert.Empty(t, ingester.feeds)
+
+	rr = push(f.ID, signature, body)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	require.Len(t, ingester.feeds, 1)
+	assert.Equal(t, feedID, ingester.feeds[0].ID)
+	assert.Equal(t, body, string(ingester.data[0]))
+
+	// The hub is asked to stop pushing the unknown feeds.
+	rr = push(uuid.NewString(), signature, body)
+	assert.Equal(t, http.StatusGone, rr.Code)
+
+	// The content of a disabled feed is refused, and its unsubscription is confirmed.
+	_, err = feedRepository.SetFeedDisabled(ctx, database.SetFeedDisabledParams{ID: feedID, Disabled: true})
+	require.NoError(t, err)
+	rr = push(f.ID, signature, body)
+	assert.Equal(t, http.StatusGone, rr.Code)
+	assert.Len(t, ingester.feeds, 1)
+	rr = verify("unsubscribe", f.URL)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "challenge", rr.Body.String())
+}
+
+type fakeWebSubHub struct {
+	requests []websub.HubRequest
+	busy     bool
+}
+
+func (h *fakeWebSubHub) Request(_ context.Context, req websub.HubRequest) error {
+	if req.Mode != websub.ModeSubscribe && req.Mode != websub.ModeUnsubscribe {
+		return fmt.Errorf("%w: invalid mode: %q", websub.ErrInvalidRequest, req.Mode)
+	}
+	if h.busy {
+		return websub.ErrHubBusy
+	}
+	h.requests = append(h.requests, req)
+	return nil
+}
+
+func TestWebSubHubHandler(t *testing.T) {
+	hub := &fakeWebSubHub{}
+	router := NewRouter(nil, nil, nil, nil, nil, WithWebSubHubHandler(handler.NewWebSubHubHandler(hub)))
+
+	request := func(form url.Values) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, "/v1/websub/hub", strings.NewReader(form.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := request(url.Values{
+		"hub.mode":          {"subscribe"},
+		"hub.topic":         {"https://rss.example.com/v1/users/1/feed.rss?token=token"},
+		"hub.callback":      {"https://reader.example.com/callback"},
+		"hub.secret":        {"secret"},
+		"hub.lease_seconds": {"3600"},
+	})
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	require.Len(t, hub.requests, 1)
+	assert.Equal(t, websub.HubRequest{
+		Mode:     "subscribe",
+		Topic:    "https://rss.example.com/v1/users/1/feed.rss?token=token",
+		Callback: "https://reader.example.com/callback",
+		Secret:   "secret",
+		Lease:    time.Hour,
+	}, hub.requests[0])
+
+	rr = request(url.Values{"hub.mode": {"subscribe"}, "hub.lease_seconds": {"forever"}})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = request(url.Values{"hub.mode": {"publish"}})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "invalid mode")
+
+	// The subscribers retry later when too many verifications are pending.
+	hub.busy = true
+	rr = request(url.Values{"hub.mode": {"subscribe"}})
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
+
+func TestFeverHandler(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	feedRepository := database.NewFeedRepository(testDB)
+	postRepository := database.NewPostRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	feedHandler := handler.NewFeedHandler(feedRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+	feverHandler := handler.NewFeverHandler(userRepository, feedRepository, postRepository)
+
+	router := NewRouter(authMiddleware, userHandler, feedHandler, nil, nil, WithFeverHandler(feverHandler))
+
+	user := createUser(t, router)
+	f, _ := createFeed(t, router, user)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	post, err := postRepository.CreatePost(ctx, database.CreatePostParams{
+		Title:       generator.RandomString(10),
+		Url:         generator.RandomURL(6),
+		Description: "<p>content</p>",
+		PublishedAt: time.Now().UTC(),
+		FeedID:      uuid.NullUUID{UUID: uuid.MustParse(f.ID), Valid: true},
+	})
+	require.NoError(t, err)
+
+	sum := md5.Sum([]byte(user.ID + ":" + user.ApiKey))
+	apiKey := hex.EncodeToString(sum[:])
+
+	fever := func(query, key string, form url.Values) map[string]any {
+		if form == nil {
+			form = url.Values{}
+		}
+		form.Set("api_key", key)
+		req, err := http.NewRequest(http.MethodPost, "/fever/?api&"+query, strings.NewReader(form.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		return resp
+	}
+
+	resp := fever("", "unknown", nil)
+	assert.Equal(t, float64(0), resp["auth"])
+
+	resp = fever("feeds&items&since_id=0&unread_item_ids", apiKey, nil)
+	assert.Equal(t, float64(1), resp["auth"])
+	assert.Equal(t, float64(3), resp["api_version"])
+	feeds := resp["feeds"].([]any)
+	require.Len(t, feeds, 1)
+	assert.Equal(t, f.URL, feeds[0].(map[string]any)["url"])
+	items := resp["items"].([]any)
+	require.Len(t, items, 1)
+	item := items[0].(map[string]any)
+	assert.Equal(t, float64(post.ID), item["id"])
+	assert.Equal(t, feeds[0].(map[string]any)["id"], item["feed_id"])
+	assert.Equal(t, "<p>content</p>", item["html"])
+	assert.Equal(t, float64(0), item["is_read"])
+	assert.Equal(t, strconv.Itoa(int(post.ID)), resp["unread_item_ids"])
+
+	// The mark operations return the updated ids.
+	resp = fever("", apiKey, url.Values{"mark": {"item"}, "as": {"read"}, "id": {strconv.Itoa(int(post.ID))}})
+	assert.Equal(t, "", resp["unread_item_ids"])
+	resp = fever("", apiKey, url.Values{"mark": {"item"}, "as": {"saved"}, "id": {strconv.Itoa(int(post.ID))}})
+	assert.Equal(t, strconv.Itoa(int(post.ID)), resp["saved_item_ids"])
+
+	resp = fever("", apiKey, url.Values{"mark": {"item"}, "as": {"unread"}, "id": {strconv.Itoa(int(post.ID))}})
+	assert.Equal(t, strconv.Itoa(int(post.ID)), resp["unread_item_ids"])
+	feedID := strconv.FormatInt(int64(feeds[0].(map[string]any)["id"].(float64)), 10)
+	resp = fever("", apiKey, url.Values{"mark": {"feed"}, "as": {"read"}, "id": {feedID}, "before": {strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)}})
+	assert.Equal(t, "", resp["unread_item_ids"])
+
+	// The Fever key does not depend on the name of the user.
+	req, err := http.NewRequest(http.MethodPatch, "/v1/users", strings.NewReader(`{"name":"`+generator.RandomString(10)+`"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "ApiKey "+user.ApiKey)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	resp = fever("", apiKey, nil)
+	assert.Equal(t, float64(1), resp["auth"])
+}
+
+func TestGReaderHandler(t *testing.T) {
+	userRepository := database.NewUserRepository(testDB)
+	feedRepository := database.NewFeedRepository(testDB)
+	postRepository := database.NewPostRepository(testDB)
+	userHandler := handler.NewUserHandler(userRepository)
+	feedHandler := handler.NewFeedHandler(feedRepository)
+	authMiddleware := middleware.NewAuthMiddleware(userRepository)
+	gReaderHandler := handler.NewGReaderHandler(userRepository, feedRepository, postRepository)
+
+	router := NewRouter(authMiddleware, userHandler, feedHandler, nil, nil, WithGReaderHandler(gReaderHandler))
+
+	user := createUser(t, router)
+	f, _ := createFeed(t, router, user)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	post, err := postRepository.CreatePost(ctx, database.CreatePostParams{
+		Title:       generator.RandomString(10),
+		Url:         generator.RandomURL(6),
+		Description: "<p>content</p>",
+		PublishedAt: time.Now().UTC(),
+		FeedID:      uuid.NullUUID{UUID: uuid.MustParse(f.ID), Valid: true},
+	})
+	require.NoError(t, err)
+
+	login := func(name, apiKey string) *httptest.ResponseRecorder {
+		form := url.Values{"Email": {name}, "Passwd": {apiKey}}
+		req, err := http.NewRequest(http.MethodPost, "/accounts/ClientLogin", strings.NewReader(form.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	rr := login(user.ID, "unknown")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	rr = login(user.Name, user.ApiKey)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	rr = login(user.ID, user.ApiKey)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Auth="+user.ApiKey)
+
+	reader := func(method, path string, form url.Values) *httptest.ResponseRecorder {
+		var body io.Reader
+		if form != nil {
+			body = strings.NewReader(form.Encode())
+		}
+		req, err := http.NewRequest(method, "/reader/api/0"+path, body)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", "GoogleLogin auth="+user.ApiKey)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		return rr
+	}
+
+	var subs struct {
+		Subscriptions []struct {
+			ID  string `json:"id"`
+			URL string `json:"url"`
+		} `json:"subscriptions"`
+	}
+	require.NoError(t, json.Unmarshal(reader(http.MethodGet, "/subscription/list?output=json", nil).Body.Bytes(), &subs))
+	require.Len(t, subs.Subscriptions, 1)
+	assert.Equal(t, "feed/"+f.ID, subs.Subscriptions[0].ID)
+	assert.Equal(t, f.URL, subs.Subscriptions[0].URL)
+
+	unread := "/stream/items/ids?s=user/-/state/com.google/reading-list&xt=user/-/state/com.google/read"
+	var ids struct {
+		ItemRefs []struct {
+			ID string `json:"id"`
+		} `json:"itemRefs"`
+	}
+	require.NoError(t, json.Unmarshal(reader(http.MethodGet, unread, nil).Body.Bytes(), &ids))
+	require.Len(t, ids.ItemRefs, 1)
+	assert.Equal(t, strconv.Itoa(int(post.ID)), ids.ItemRefs[0].ID)
+
+	var contents struct {
+		Items []struct {
+			ID      string `json:"id"`
+			Summary struct {
+				Content string `json:"content"`
+			} `json:"summary"`
+			Origin struct {
+				StreamID string `json:"streamId"`
+			} `json:"origin"`
+		} `json:"items"`
+	}
+	rr = reader(http.MethodGet, "/stream/contents/"+url.PathEscape("feed/"+f.ID), nil)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &contents))
+	require.Len(t, contents.Items, 1)
+	assert.Equal(t, fmt.Sprintf("tag:google.com,2005:reader/item/%016x", post.ID), contents.Items[0].ID)
+	assert.Equal(t, "<p>content</p>", contents.Items[0].Summary.Content)
+	assert.Equal(t, "feed/"+f.ID, contents.Items[0].Origin.StreamID)
+
+	// The items are marked as read with their long id, then the whole feed is marked as read.
+	rr = reader(http.MethodPost, "/edit-tag", url.Values{"i": {contents.Items[0].ID}, "a": {"user/-/state/com.google/read"}})
+	assert.Equal(t, "OK", rr.Body.String())
+	require.NoError(t, json.Unmarshal(reader(http.MethodGet, unread, nil).Body.Bytes(), &ids))
+	assert.Empty(t, ids.ItemRefs)
+
+	reader(http.MethodPost, "/edit-tag", url.Values{"i": {strconv.Itoa(int(post.ID))}, "r": {"user/-/state/com.google/read"}})
+	require.NoError(t, json.Unmarshal(reader(http.MethodGet, unread, nil).Body.Bytes(), &ids))
+	assert.Len(t, ids.ItemRefs, 1)
+
+	reader(http.MethodPost, "/mark-all-as-read", url.Values{"s": {"feed/" + f.ID}})
+	require.NoError(t, json.Unmarshal(reader(http.MethodGet, unread, nil).Body.Bytes(), &ids))
+	assert.Empty(t, ids.ItemRefs)
+
+	// The starred stream keeps the starred posts of an unfollowed feed, the reading list does not.
+	reader(http.MethodPost, "/edit-tag", url.Values{"i": {strconv.Itoa(int(post.ID))}, "a": {"user/-/state/com.google/starred"}})
+	queries := database.New(testDB)
+	follows, err := queries.ListAllFeedFollows(ctx, uuid.NullUUID{UUID: uuid.MustParse(user.ID), Valid: true})
+	require.NoError(t, err)
+	require.Len(t, follows, 1)
+	require.NoError(t, queries.DeleteFeedFollows(ctx, database.DeleteFeedFollowsParams{ID: follows[0].ID, UserID: follows[0].UserID}))
+
+	require.NoError(t, json.Unmarshal(reader(http.MethodGet, "/stream/items/ids?s=user/-/state/com.google/starred", nil).Body.Bytes(), &ids))
+	require.Len(t, ids.ItemRefs, 1)
+	assert.Equal(t, strconv.Itoa(int(post.ID)), ids.ItemRefs[0].ID)
+	require.NoError(t, json.Unmarshal(reader(http.MethodGet, "/stream/items/ids?s=user/-/state/com.google/reading-list", nil).Body.Bytes(), &ids))
+	assert.Empty(t, ids.ItemRefs)
+}
@@ -23,47 +23,120 @@ type RSSFeed struct {
 
 // RSSFeedChannel represents the structure of an RSS feed channel.
 type RSSFeedChannel struct {
-	Title       string        `xml:"title"`
+	Title string `xml:"title"`
+	// AtomLinks must be declared before Link, otherwise the atom:link elements would be decoded in Link.
+	AtomLinks   []AtomLink    `xml:"http://www.w3.org/2005/Atom link"`
+	Link        string        `xml:"link"`
 	Description string        `xml:"description"`
 	Language    string        `xml:"language"`
 	Items       []RSSFeedItem `xml:"item"`
 }
 
+// AtomLink represents an atom:link element of an RSS feed channel.
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// AtomLink returns the href of the first atom:link of the channel with the given relation.
+func (c RSSFeedChannel) AtomLink(rel string) string {
+	for _, link := range c.AtomLinks {
+		if link.Rel == rel {
+			return link.Href
+		}
+	}
+
+	return ""
+}
+
 // RSSFeedItem represents the structure of an RSS feed item.
 type RSSFeedItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	PubDate     string   `xml:"pubDate"`
+	Author      string   `xml:"author"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string `xml:"category"`
+}
+
+// AuthorName returns the author of the item, from the author or the dc:creator element.
+func (i RSSFeedItem) AuthorName() string {
+	if i.Author != "" {
+		return i.Author
+	}
+
+	return i.Creator
 }
 
 // FeedStore represents a feedRepository for managing feed data.
 type FeedStore interface {
 	GetNextFeedsToFetch(ctx context.Context, limit int32) ([]database.Feed, error)
-	MarkFeedFetched(ctx context.Context, id uuid.UUID) error
+	MarkFeedFetched(ctx context.Context, arg database.MarkFeedFetchedParams) error
+	MarkFeedFetchFailed(ctx context.Context, arg database.MarkFeedFetchFailedParams) error
 }
 
 type PostRepository interface {
 	CreatePost(ctx context.Context, arg database.CreatePostParams) (database.Post, error)
 }
 
+// Publisher represents a publisher of the created posts.
+type Publisher interface {
+	Publish(post database.Post)
+}
+
+// HubSubscriber represents a subscriber to the WebSub hubs advertised by the feeds.
+type HubSubscriber interface {
+	Discover(ctx context.Context, feed database.Feed, hubURL, topicURL string) error
+}
+
+// Publishers publishes the posts to several publishers, in order.
+type Publishers []Publisher
+
+// Publish publishes the post to all the publishers.
+func (p Publishers) Publish(post database.Post) {
+	for _, publisher := range p {
+		publisher.Publish(post)
+	}
+}
+
 // FeedFetcher represents a feed fetcher.
 type FeedFetcher struct {
 	feedRepository FeedStore
 	postRepository PostRepository
+	publisher      Publisher
+	hubSubscriber  HubSubscriber
 	interval       time.Duration
 	limit          int32
 }
 
+// Option configures an optional dependency of the feed fetcher.
+type Option func(f *FeedFetcher)
+
+// WithHubSubscriber subscribes the fetched feeds to the WebSub hubs they advertise.
+func WithHubSubscriber(s HubSubscriber) Option {
+	return func(f *FeedFetcher) {
+		f.hubSubscriber = s
+	}
+}
+
 // NewFeedFetcher returns a new feed fetcher.
 // It fetches feeds from the feedRepository at the given interval and limits the number of feeds to fetch.
-func NewFeedFetcher(feedRepository FeedStore, postRepository PostRepository, limit int32, interval time.Duration) *FeedFetcher {
-	return &FeedFetcher{
+// The created posts are published to the publisher, when it is not nil.
+func NewFeedFetcher(feedRepository FeedStore, postRepository PostRepository, publisher Publisher, limit int32, interval time.Duration, opts ...Option) *FeedFetcher {
+	f := &FeedFetcher{
 		feedRepository: feedRepository,
 		postRepository: postRepository,
+		publisher:      publisher,
 		interval:       interval,
 		limit:          limit,
 	}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f
 }
 
 // Start starts the feed fetcher.
@@ -105,44 +178,8 @@ func (f *FeedFetcher) processFeeds(ctx context.Context, limit int32) error {
 		go func(feed database.Feed) {
 			defer wg.Done()
 
-			rssFeed, err := fetchRSSFeed(feed.Url)
-			if err != nil {
+			if err := f.fetchFeed(ctx, feed); err != nil {
 				log.Printf("error fetching rss feed: %v", err)
-				return
-			}
-			log.Printf("Process rss feed: " + rssFeed.Channel.Title)
-			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-			defer cancel()
-			rssLayout := "Mon, 02 Jan 2006 15:04:05 -0700"
-
-			for _, item := range rssFeed.Channel.Items {
-
-				pubDate, err := time.Parse(rssLayout, item.PubDate)
-				if err != nil {
-					log.Printf("error parsing timestamp: %v", err)
-					continue
-				}
-
-				post, err := f.postRepository.CreatePost(ctx, database.CreatePostParams{
-					Title:       item.Title,
-					Url:         item.Link,
-					Description: item.Description,
-					PublishedAt: pubDate,
-					FeedID: uuid.NullUUID{
-						UUID:  feed.ID,
-						Valid: true,
-					},
-				})
-				if err != nil {
-					log.Printf("error creating post: %v", err)
-					return
-				}
-				fmt.Println("Create post: " + post.Title)
-			}
-
-			if err := f.feedRepository.MarkFeedFetched(ctx, feed.ID); err != nil {
-				log.Printf("error marking feed fetched: %v", err)
-				return
 			}
 		}(feed)
 	}
@@ -153,6 +190,110 @@ func (f *FeedFetcher) processFeeds(ctx context.Context, limit int32) error {
 	return nil
 }
 
+// Refresh fetches a feed at once, whether it is disabled or not.
+func (f *FeedFetcher) Refresh(ctx context.Context, feed database.Feed) error {
+	return f.fetchFeed(ctx, feed)
+}
+
+// fetchFeed fetches a feed, creates its new posts and marks it as fetched.
+// The error of a failed fetch is recorded in the health of the feed.
+func (f *FeedFetcher) fetchFeed(ctx context.Context, feed database.Feed) error {
+	rssFeed, err := fetchRSSFeed(feed.Url)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err != nil {
+		if markErr := f.feedRepository.MarkFeedFetchFailed(ctx, database.MarkFeedFetchFailedParams{
+			ID:        feed.ID,
+			LastError: err.Error(),
+		}); markErr != nil {
+			log.Printf("error marking feed fetch failed: %v", markErr)
+		}
+		return err
+	}
+	log.Printf("Process rss feed: " + rssFeed.Channel.Title)
+
+	f.createPosts(ctx, feed, rssFeed.Channel.Items)
+
+	if err := f.feedRepository.MarkFeedFetched(ctx, database.MarkFeedFetchedParams{
+		ID:      feed.ID,
+		SiteUrl: rssFeed.Channel.Link,
+	}); err != nil {
+		return fmt.Errorf("error marking feed fetched: %w", err)
+	}
+
+	f.discoverHub(ctx, feed, rssFeed.Channel)
+
+	return nil
+}
+
+// discoverHub subscribes the feed to the WebSub hub it advertises, if any.
+// The topic is the self link of the feed, or its url.
+func (f *FeedFetcher) discoverHub(ctx context.Context, feed database.Feed, channel RSSFeedChannel) {
+	hub := channel.AtomLink("hub")
+	if f.hubSubscriber == nil || hub == "" {
+		return
+	}
+
+	topic := channel.AtomLink("self")
+	if topic == "" {
+		topic = feed.Url
+	}
+	if err := f.hubSubscriber.Discover(ctx, feed, hub, topic); err != nil {
+		log.Printf("error subscribing to websub hub %s: %v", hub, err)
+	}
+}
+
+// Ingest creates the posts of a feed document pushed by a WebSub hub, like fetched ones.
+func (f *FeedFetcher) Ingest(ctx context.Context, feed database.Feed, data []byte) error {
+	rssFeed, err := parseFeed(data)
+	if err != nil {
+		return err
+	}
+	f.createPosts(ctx, feed, rssFeed.Channel.Items)
+
+	return nil
+}
+
+// createPosts creates the posts of the feed items and publishes them.
+// The items already stored are skipped.
+func (f *FeedFetcher) createPosts(ctx context.Context, feed database.Feed, items []RSSFeedItem) {
+	rssLayout := "Mon, 02 Jan 2006 15:04:05 -0700"
+
+	for _, item := range items {
+		pubDate, err := time.Parse(rssLayout, item.PubDate)
+		if err != nil {
+			log.Printf("error parsing timestamp: %v", err)
+			continue
+		}
+
+		post, err := f.postRepository.CreatePost(ctx, database.CreatePostParams{
+			Title:       item.Title,
+			Url:         item.Link,
+			Description: item.Description,
+			PublishedAt: pubDate,
+			FeedID: uuid.NullUUID{
+				UUID:  feed.ID,
+				Valid: true,
+			},
+			Author:     item.AuthorName(),
+			Categories: item.Categories,
+		})
+		if err != nil {
+			if database.IsUniqueViolation(err) {
+				continue
+			}
+			log.Printf("error creating post: %v", err)
+			return
+		}
+		fmt.Println("Create post: " + post.Title)
+
+		if f.publisher != nil {
+			f.publisher.Publish(post)
+		}
+	}
+}
+
 // fetchRSSFeed fetches data from an RSS feed URL and returns the parsed data in a Go struct.
 func fetchRSSFeed(feedURL string) (*RSSFeed, error) {
 	// Fetch the RSS feed from the URL
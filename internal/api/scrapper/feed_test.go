@@ -1,9 +1,15 @@
 package scrapper
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -18,12 +24,132 @@ func Test_parseFeed(t *testing.T) {
 	require.Equal(t, "Boot.dev Blog", rssFeed.Channel.Title)
 	require.Equal(t, "Recent content on Boot.dev Blog", rssFeed.Channel.Description)
 	require.Equal(t, "en-us", rssFeed.Channel.Language)
+	require.Equal(t, "https://blog.boot.dev/", rssFeed.Channel.Link)
+	require.Len(t, rssFeed.Channel.AtomLinks, 1)
+	assert.Equal(t, "self", rssFeed.Channel.AtomLinks[0].Rel)
+	assert.Equal(t, "https://blog.boot.dev/index.xml", rssFeed.Channel.AtomLinks[0].Href)
 
 	require.Len(t, rssFeed.Channel.Items, 2)
-	item := rssFeed.Channel.Items[1]
+	item := rssFeed.Channel.Items[0]
+	assert.Equal(t, "Lane Wagner", item.AuthorName())
+	assert.Equal(t, []string{"news", "community"}, item.Categories)
+
+	item = rssFeed.Channel.Items[1]
 	assert.Equal(t, "The Boot.dev Beat. February 2024", item.Title)
 
 	assert.Equal(t, "Wed, 31 Jan 2024 00:00:00 +0000", item.PubDate)
 	assert.Equal(t, "https://blog.boot.dev/news/bootdev-beat-2024-02/", item.Link)
+	assert.Empty(t, item.AuthorName())
+	assert.Empty(t, item.Categories)
 	assert.Equal(t, `609,179. That&rsquo;s the number of lessons you crazy folks have completed on Boot.dev in the last 30 days.`, item.Description)
 }
+
+type fakePostRepository struct {
+	posts []database.Post
+}
+
+func (r *fakePostRepository) CreatePost(_ context.Context, arg database.CreatePostParams) (database.Post, error) {
+	for _, post := range r.posts {
+		if post.Url == arg.Url {
+			return database.Post{}, fmt.Errorf("error creating post: %w", &pq.Error{Code: "23505"})
+		}
+	}
+
+	post := database.Post{
+		ID:    int32(len(r.posts) + 1),
+		Title: arg.Title,
+		Url:   arg.Url,
+	}
+	r.posts = append(r.posts, post)
+
+	return post, nil
+}
+
+type fakePublisher struct {
+	posts []database.Post
+}
+
+func (p *fakePublisher) Publish(post database.Post) {
+	p.posts = append(p.posts, post)
+}
+
+func TestFeedFetcher_createPosts(t *testing.T) {
+	content, err := os.ReadFile("testdata/feed.xml")
+	require.NoError(t, err)
+	rssFeed, err := parseFeed(content)
+	require.NoError(t, err)
+
+	postRepository := &fakePostRepository{}
+	publisher := &fakePublisher{}
+	fetcher := NewFeedFetcher(nil, postRepository, publisher, 10, time.Hour)
+
+	feed := database.Feed{ID: uuid.New()}
+	fetcher.createPosts(context.Background(), feed, rssFeed.Channel.Items)
+	require.Len(t, postRepository.posts, 2)
+	assert.Equal(t, postRepository.posts, publisher.posts)
+
+	// The items already stored are skipped and not published again.
+	fetcher.createPosts(context.Background(), feed, rssFeed.Channel.Items)
+	assert.Len(t, postRepository.posts, 2)
+	assert.Len(t, publisher.posts, 2)
+}
+
+type fakeHubSubscriber struct {
+	hub   string
+	topic string
+}
+
+func (s *fakeHubSubscriber) Discover(_ context.Context, _ database.Feed, hubURL, topicURL string) error {
+	s.hub = hubURL
+	s.topic = topicURL
+	return nil
+}
+
+func TestFeedFetcher_discoverHub(t *testing.T) {
+	content := []byte(`<?xml version="1.0"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+<channel>
+<title>Blog</title>
+<atom:link href="https://hub.example.com/" rel="hub"/>
+<link>https://blog.example.com/</link>
+</channel>
+</rss>`)
+	rssFeed, err := parseFeed(content)
+	require.NoError(t, err)
+	assert.Equal(t, "https://hub.example.com/", rssFeed.Channel.AtomLink("hub"))
+	assert.Empty(t, rssFeed.Channel.AtomLink("self"))
+
+	subscriber := &fakeHubSubscriber{}
+	fetcher := NewFeedFetcher(nil, nil, nil, 10, time.Hour, WithHubSubscriber(subscriber))
+
+	// Without self link, the topic is the url of the feed.
+	feed := database.Feed{ID: uuid.New(), Url: "https://blog.example.com/feed.xml"}
+	fetcher.discoverHub(context.Background(), feed, rssFeed.Channel)
+	assert.Equal(t, "https://hub.example.com/", subscriber.hub)
+	assert.Equal(t, feed.Url, subscriber.topic)
+
+	// The feeds without hub are not subscribed.
+	content, err = os.ReadFile("testdata/feed.xml")
+	require.NoError(t, err)
+	rssFeed, err = parseFeed(content)
+	require.NoError(t, err)
+	subscriber = &fakeHubSubscriber{}
+	fetcher = NewFeedFetcher(nil, nil, nil, 10, time.Hour, WithHubSubscriber(subscriber))
+	fetcher.discoverHub(context.Background(), feed, rssFeed.Channel)
+	assert.Empty(t, subscriber.hub)
+}
+
+func TestFeedFetcher_Ingest(t *testing.T) {
+	content, err := os.ReadFile("testdata/feed.xml")
+	require.NoError(t, err)
+
+	postRepository := &fakePostRepository{}
+	publisher := &fakePublisher{}
+	fetcher := NewFeedFetcher(nil, postRepository, publisher, 10, time.Hour)
+
+	require.NoError(t, fetcher.Ingest(context.Background(), database.Feed{ID: uuid.New()}, content))
+	assert.Len(t, postRepository.posts, 2)
+	assert.Len(t, publisher.posts, 2)
+
+	assert.Error(t, fetcher.Ingest(context.Background(), database.Feed{ID: uuid.New()}, []byte("not a feed")))
+}
@@ -0,0 +1,194 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.25.0
+// source: api_keys.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createApiKey = `-- name: CreateApiKey :one
+WITH key AS (
+    SELECT encode(sha256(convert_to(gen_random_uuid()::text || gen_random_uuid()::text, 'UTF8')), 'hex')::text AS api_key
+), k AS (
+    INSERT INTO api_keys (user_id, name, prefix, hash, fever_key, scopes, expires_at)
+    SELECT users.id, $1::text,
+        left(key.api_key, 8),
+        encode(sha256(convert_to(key.api_key, 'UTF8')), 'hex'),
+        encode(sha256(convert_to(md5(users.id::text || ':' || key.api_key), 'UTF8')), 'hex'),
+        $2::text[],
+        $3::timestamptz
+    FROM users, key
+    WHERE users.id = $4
+    RETURNING id, user_id, name, prefix, hash, fever_key, scopes, expires_at, last_used_at, created_at, updated_at
+)
+SELECT k.id, k.name, k.prefix, k.scopes::text[] AS scopes, k.expires_at, k.created_at, key.api_key
+FROM k, key
+`
+
+type CreateApiKeyParams struct {
+	Name      string       `json:"name"`
+	Scopes    []string     `json:"scopes"`
+	ExpiresAt sql.NullTime `json:"expires_at"`
+	UserID    uuid.UUID    `json:"user_id"`
+}
+
+type CreateApiKeyRow struct {
+	ID        uuid.UUID    `json:"id"`
+	Name      string       `json:"name"`
+	Prefix    string       `json:"prefix"`
+	Scopes    []string     `json:"scopes"`
+	ExpiresAt sql.NullTime `json:"expires_at"`
+	CreatedAt time.Time    `json:"created_at"`
+	ApiKey    string       `json:"api_key"`
+}
+
+// The API key is only returned at the creation, its hash is stored with its prefix.
+func (q *Queries) CreateApiKey(ctx context.Context, arg CreateApiKeyParams) (CreateApiKeyRow, error) {
+	row := q.db.QueryRowContext(ctx, createApiKey,
+		arg.Name,
+		pq.Array(arg.Scopes),
+		arg.ExpiresAt,
+		arg.UserID,
+	)
+	var i CreateApiKeyRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Prefix,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.ApiKey,
+	)
+	return i, err
+}
+
+const deleteApiKey = `-- name: DeleteApiKey :exec
+DELETE FROM api_keys
+WHERE id = $1
+AND user_id = $2
+`
+
+type DeleteApiKeyParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) DeleteApiKey(ctx context.Context, arg DeleteApiKeyParams) error {
+	_, err := q.db.ExecContext(ctx, deleteApiKey, arg.ID, arg.UserID)
+	return err
+}
+
+const listApiKeys = `-- name: ListApiKeys :many
+SELECT id, user_id, name, prefix, hash, fever_key, scopes, expires_at, last_used_at, created_at, updated_at FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListApiKeys(ctx context.Context, userID uuid.UUID) ([]ApiKey, error) {
+	rows, err := q.db.QueryContext(ctx, listApiKeys, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ApiKey{}
+	for rows.Next() {
+		var i ApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Prefix,
+			&i.Hash,
+			&i.FeverKey,
+			pq.Array(&i.Scopes),
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const rotateApiKey = `-- name: RotateApiKey :one
+WITH key AS (
+    SELECT encode(sha256(convert_to(gen_random_uuid()::text || gen_random_uuid()::text, 'UTF8')), 'hex')::text AS api_key
+), k AS (
+    INSERT INTO api_keys (user_id, name, prefix, hash, fever_key, scopes, expires_at)
+    SELECT api_keys.user_id, api_keys.name,
+        left(key.api_key, 8),
+        encode(sha256(convert_to(key.api_key, 'UTF8')), 'hex'),
+        encode(sha256(convert_to(md5(users.id::text || ':' || key.api_key), 'UTF8')), 'hex'),
+        api_keys.scopes,
+        api_keys.expires_at
+    FROM api_keys JOIN users ON users.id = api_keys.user_id, key
+    WHERE api_keys.id = $1
+    AND api_keys.user_id = $2
+    RETURNING id, user_id, name, prefix, hash, fever_key, scopes, expires_at, last_used_at, created_at, updated_at
+), revoked AS (
+    DELETE FROM api_keys
+    WHERE api_keys.id = $1
+    AND api_keys.user_id = $2
+    AND $3::timestamptz IS NULL
+), graced AS (
+    UPDATE api_keys SET
+        expires_at = LEAST(api_keys.expires_at, $3::timestamptz),
+        updated_at = NOW()
+    WHERE api_keys.id = $1
+    AND api_keys.user_id = $2
+    AND $3::timestamptz IS NOT NULL
+)
+SELECT k.id, k.name, k.prefix, k.scopes::text[] AS scopes, k.expires_at, k.created_at, key.api_key
+FROM k, key
+`
+
+type RotateApiKeyParams struct {
+	ID                uuid.UUID    `json:"id"`
+	UserID            uuid.UUID    `json:"user_id"`
+	PreviousExpiresAt sql.NullTime `json:"previous_expires_at"`
+}
+
+type RotateApiKeyRow struct {
+	ID        uuid.UUID    `json:"id"`
+	Name      string       `json:"name"`
+	Prefix    string       `json:"prefix"`
+	Scopes    []string     `json:"scopes"`
+	ExpiresAt sql.NullTime `json:"expires_at"`
+	CreatedAt time.Time    `json:"created_at"`
+	ApiKey    string       `json:"api_key"`
+}
+
+// Replaces an API key by a new one with the same name, scopes and expiration, and returns it.
+// The replaced key still works until the given expiration, or is deleted at once without expiration.
+func (q *Queries) RotateApiKey(ctx context.Context, arg RotateApiKeyParams) (RotateApiKeyRow, error) {
+	row := q.db.QueryRowContext(ctx, rotateApiKey, arg.ID, arg.UserID, arg.PreviousExpiresAt)
+	var i RotateApiKeyRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Prefix,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.ApiKey,
+	)
+	return i, err
+}
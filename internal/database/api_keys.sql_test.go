@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueries_CreateApiKey(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+
+	key, err := testQueries.CreateApiKey(ctx, CreateApiKeyParams{
+		UserID: user.ID,
+		Name:   "CI bot",
+		Scopes: []string{"feeds:read"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "CI bot", key.Name)
+	assert.Equal(t, []string{"feeds:read"}, key.Scopes)
+	assert.Equal(t, key.ApiKey[:8], key.Prefix)
+	assert.False(t, key.ExpiresAt.Valid)
+
+	found, err := testQueries.GetUserFromApiKey(ctx, key.ApiKey)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.User.ID)
+	assert.Equal(t, key.ID, found.ApiKeyID)
+	assert.Equal(t, []string{"feeds:read"}, found.Scopes)
+
+	// Each key has its Fever key.
+	sum := md5.Sum([]byte(user.ID.String() + ":" + key.ApiKey))
+	feverKey := hex.EncodeToString(sum[:])
+	_, err = testQueries.GetUserFromFeverKey(ctx, feverKey)
+	require.NoError(t, err)
+
+	// Only the hash of the Fever key is stored.
+	keys, err := testQueries.ListApiKeys(ctx, user.ID)
+	require.NoError(t, err)
+	stored := sha256.Sum256([]byte(feverKey))
+	for _, k := range keys {
+		assert.NotEqual(t, feverKey, k.FeverKey)
+		if k.ID == key.ID {
+			assert.Equal(t, hex.EncodeToString(stored[:]), k.FeverKey)
+		}
+	}
+
+	// The unknown scopes are refused.
+	_, err = testQueries.CreateApiKey(ctx, CreateApiKeyParams{
+		UserID: user.ID,
+		Name:   "invalid",
+		Scopes: []string{"admin"},
+	})
+	require.Error(t, err)
+
+	// The default key and the new key, without their hash.
+	keys, err = testQueries.ListApiKeys(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "default", keys[0].Name)
+	assert.Equal(t, key.ID, keys[1].ID)
+	assert.True(t, keys[1].LastUsedAt.Valid)
+
+	require.NoError(t, testQueries.DeleteApiKey(ctx, DeleteApiKeyParams{ID: key.ID, UserID: user.ID}))
+	_, err = testQueries.GetUserFromApiKey(ctx, key.ApiKey)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestQueries_CreateApiKey_Expired(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+
+	key, err := testQueries.CreateApiKey(ctx, CreateApiKeyParams{
+		UserID:    user.ID,
+		Name:      "expired",
+		Scopes:    []string{"posts:read"},
+		ExpiresAt: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.GetUserFromApiKey(ctx, key.ApiKey)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestQueries_RotateApiKey(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+	key, err := testQueries.CreateApiKey(ctx, CreateApiKeyParams{
+		UserID: user.ID,
+		Name:   "mobile",
+		Scopes: []string{"feeds:read", "posts:read"},
+	})
+	require.NoError(t, err)
+
+	// Without grace period, the previous key is revoked at once.
+	rotated, err := testQueries.RotateApiKey(ctx, RotateApiKeyParams{ID: key.ID, UserID: user.ID})
+	require.NoError(t, err)
+	assert.NotEqual(t, key.ID, rotated.ID)
+	assert.NotEqual(t, key.ApiKey, rotated.ApiKey)
+	assert.Equal(t, key.Name, rotated.Name)
+	assert.Equal(t, key.Scopes, rotated.Scopes)
+
+	_, err = testQueries.GetUserFromApiKey(ctx, key.ApiKey)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	found, err := testQueries.GetUserFromApiKey(ctx, rotated.ApiKey)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.User.ID)
+
+	// With a grace period, the previous key works until it expires.
+	next, err := testQueries.RotateApiKey(ctx, RotateApiKeyParams{
+		ID:                rotated.ID,
+		UserID:            user.ID,
+		PreviousExpiresAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+	require.NoError(t, err)
+	for _, apiKey := range []string{rotated.ApiKey, next.ApiKey} {
+		found, err := testQueries.GetUserFromApiKey(ctx, apiKey)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, found.User.ID)
+	}
+
+	// The keys of the other users cannot be rotated.
+	other := CreateRandomUser(t)
+	_, err = testQueries.RotateApiKey(ctx, RotateApiKeyParams{ID: next.ID, UserID: other.ID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// DigestRepository is responsible for managing the email digests in the database.
+type DigestRepository struct {
+	db      *sql.DB
+	queries *Queries
+}
+
+// NewDigestRepository creates a new DigestRepository.
+func NewDigestRepository(db *sql.DB) DigestRepository {
+	return DigestRepository{
+		db:      db,
+		queries: New(db),
+	}
+}
+
+// UpsertDigestSettings creates or replaces the digest settings of a user.
+func (d DigestRepository) UpsertDigestSettings(ctx context.Context, arg UpsertDigestSettingsParams) (DigestSetting, error) {
+	settings, err := d.queries.UpsertDigestSettings(ctx, arg)
+	if err != nil {
+		return DigestSetting{}, fmt.Errorf("error upserting digest settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// GetDigestSettings returns the digest settings of a user.
+func (d DigestRepository) GetDigestSettings(ctx context.Context, userID uuid.UUID) (DigestSetting, error) {
+	settings, err := d.queries.GetDigestSettings(ctx, userID)
+	if err != nil {
+		return DigestSetting{}, fmt.Errorf("error getting digest settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// DeleteDigestSettings deletes the digest settings of a user, no digest is sent anymore.
+func (d DigestRepository) DeleteDigestSettings(ctx context.Context, userID uuid.UUID) error {
+	if err := d.queries.DeleteDigestSettings(ctx, userID); err != nil {
+		return fmt.Errorf("error deleting digest settings: %w", err)
+	}
+
+	return nil
+}
+
+// ListDueDigests returns the enabled digests to send at the given time, with the name of their user.
+func (d DigestRepository) ListDueDigests(ctx context.Context, arg ListDueDigestsParams) ([]ListDueDigestsRow, error) {
+	digests, err := d.queries.ListDueDigests(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("error listing due digests: %w", err)
+	}
+
+	return digests, nil
+}
+
+// ListDigestPosts returns the unread posts of a user not sent in a previous digest.
+func (d DigestRepository) ListDigestPosts(ctx context.Context, arg ListDigestPostsParams) ([]ListDigestPostsRow, error) {
+	posts, err := d.queries.ListDigestPosts(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("error listing digest posts: %w", err)
+	}
+
+	return posts, nil
+}
+
+// MarkDigestSent records the posts sent in a digest and schedules the next one.
+func (d DigestRepository) MarkDigestSent(ctx context.Context, arg MarkDigestSentParams, postIDs []int32) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := d.queries.WithTx(tx)
+	if len(postIDs) > 0 {
+		if err := qtx.CreateDigestItems(ctx, CreateDigestItemsParams{
+			UserID:  arg.UserID,
+			PostIds: postIDs,
+		}); err != nil {
+			return fmt.Errorf("error creating digest items: %w", err)
+		}
+	}
+	if err := qtx.MarkDigestSent(ctx, arg); err != nil {
+		return fmt.Errorf("error marking digest sent: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing digest: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,269 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.25.0
+// source: digests.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createDigestItems = `-- name: CreateDigestItems :exec
+INSERT INTO digest_items (user_id, post_id)
+SELECT $1::uuid, unnest($2::integer[])
+ON CONFLICT (user_id, post_id) DO NOTHING
+`
+
+type CreateDigestItemsParams struct {
+	UserID  uuid.UUID `json:"user_id"`
+	PostIds []int32   `json:"post_ids"`
+}
+
+func (q *Queries) CreateDigestItems(ctx context.Context, arg CreateDigestItemsParams) error {
+	_, err := q.db.ExecContext(ctx, createDigestItems, arg.UserID, pq.Array(arg.PostIds))
+	return err
+}
+
+const deleteDigestSettings = `-- name: DeleteDigestSettings :exec
+DELETE FROM digest_settings
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteDigestSettings(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteDigestSettings, userID)
+	return err
+}
+
+const getDigestSettings = `-- name: GetDigestSettings :one
+SELECT user_id, email, frequency, hour, weekday, timezone, enabled, next_send_at, last_sent_at, created_at, updated_at FROM digest_settings
+WHERE user_id = $1
+`
+
+func (q *Queries) GetDigestSettings(ctx context.Context, userID uuid.UUID) (DigestSetting, error) {
+	row := q.db.QueryRowContext(ctx, getDigestSettings, userID)
+	var i DigestSetting
+	err := row.Scan(
+		&i.UserID,
+		&i.Email,
+		&i.Frequency,
+		&i.Hour,
+		&i.Weekday,
+		&i.Timezone,
+		&i.Enabled,
+		&i.NextSendAt,
+		&i.LastSentAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listDigestPosts = `-- name: ListDigestPosts :many
+SELECT p.id, p.title, p.url, p.description, p.published_at, p.feed_id, p.created_at, p.updated_at, p.author, p.categories, COALESCE(ff.title, f.name)::text AS feed_title
+FROM posts p
+    JOIN feed_follows ff ON p.feed_id = ff.feed_id
+    JOIN feeds f ON f.id = ff.feed_id
+    LEFT JOIN post_reads pr ON pr.post_id = p.id AND pr.user_id = ff.user_id
+    LEFT JOIN digest_items di ON di.post_id = p.id AND di.user_id = ff.user_id
+WHERE ff.user_id = $1::uuid
+AND p.created_at > $2
+AND pr.post_id IS NULL
+AND di.post_id IS NULL
+AND NOT ff.muted
+AND ff.notify
+AND (ff.retention_days IS NULL OR p.published_at > NOW() - make_interval(days => ff.retention_days))
+AND post_passes_filters(ff.user_id, ff.folder_id, p)
+ORDER BY feed_title ASC, p.published_at DESC
+LIMIT $3
+`
+
+type ListDigestPostsParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	Since  time.Time `json:"since"`
+	Limit  int32     `json:"limit"`
+}
+
+type ListDigestPostsRow struct {
+	ID          int32         `json:"id"`
+	Title       string        `json:"title"`
+	Url         string        `json:"url"`
+	Description string        `json:"description"`
+	PublishedAt time.Time     `json:"published_at"`
+	FeedID      uuid.NullUUID `json:"feed_id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	Author      string        `json:"author"`
+	Categories  []string      `json:"categories"`
+	FeedTitle   string        `json:"feed_title"`
+}
+
+// Returns the unread posts of the timeline of a user created since a date, and not sent in a previous digest.
+// Like the timeline, the muted feeds are skipped, and the retention and the filter rules are applied.
+// The feeds without notifications are skipped too.
+func (q *Queries) ListDigestPosts(ctx context.Context, arg ListDigestPostsParams) ([]ListDigestPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDigestPosts, arg.UserID, arg.Since, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListDigestPostsRow{}
+	for rows.Next() {
+		var i ListDigestPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.FeedTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDueDigests = `-- name: ListDueDigests :many
+SELECT digest_settings.user_id, digest_settings.email, digest_settings.frequency, digest_settings.hour, digest_settings.weekday, digest_settings.timezone, digest_settings.enabled, digest_settings.next_send_at, digest_settings.last_sent_at, digest_settings.created_at, digest_settings.updated_at, users.name
+FROM digest_settings
+    JOIN users ON users.id = digest_settings.user_id
+WHERE digest_settings.enabled
+AND digest_settings.next_send_at <= $1
+ORDER BY digest_settings.next_send_at ASC
+LIMIT $2
+`
+
+type ListDueDigestsParams struct {
+	Now   time.Time `json:"now"`
+	Limit int32     `json:"limit"`
+}
+
+type ListDueDigestsRow struct {
+	DigestSetting DigestSetting `json:"digest_setting"`
+	Name          string        `json:"name"`
+}
+
+func (q *Queries) ListDueDigests(ctx context.Context, arg ListDueDigestsParams) ([]ListDueDigestsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDueDigests, arg.Now, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListDueDigestsRow{}
+	for rows.Next() {
+		var i ListDueDigestsRow
+		if err := rows.Scan(
+			&i.DigestSetting.UserID,
+			&i.DigestSetting.Email,
+			&i.DigestSetting.Frequency,
+			&i.DigestSetting.Hour,
+			&i.DigestSetting.Weekday,
+			&i.DigestSetting.Timezone,
+			&i.DigestSetting.Enabled,
+			&i.DigestSetting.NextSendAt,
+			&i.DigestSetting.LastSentAt,
+			&i.DigestSetting.CreatedAt,
+			&i.DigestSetting.UpdatedAt,
+			&i.Name,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markDigestSent = `-- name: MarkDigestSent :exec
+UPDATE digest_settings
+SET last_sent_at = $1, next_send_at = $2
+WHERE user_id = $3
+`
+
+type MarkDigestSentParams struct {
+	LastSentAt sql.NullTime `json:"last_sent_at"`
+	NextSendAt time.Time    `json:"next_send_at"`
+	UserID     uuid.UUID    `json:"user_id"`
+}
+
+func (q *Queries) MarkDigestSent(ctx context.Context, arg MarkDigestSentParams) error {
+	_, err := q.db.ExecContext(ctx, markDigestSent, arg.LastSentAt, arg.NextSendAt, arg.UserID)
+	return err
+}
+
+const upsertDigestSettings = `-- name: UpsertDigestSettings :one
+INSERT INTO digest_settings (user_id, email, frequency, hour, weekday, timezone, enabled, next_send_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (user_id) DO UPDATE
+SET email = EXCLUDED.email,
+    frequency = EXCLUDED.frequency,
+    hour = EXCLUDED.hour,
+    weekday = EXCLUDED.weekday,
+    timezone = EXCLUDED.timezone,
+    enabled = EXCLUDED.enabled,
+    next_send_at = EXCLUDED.next_send_at,
+    updated_at = NOW()
+RETURNING user_id, email, frequency, hour, weekday, timezone, enabled, next_send_at, last_sent_at, created_at, updated_at
+`
+
+type UpsertDigestSettingsParams struct {
+	UserID     uuid.UUID `json:"user_id"`
+	Email      string    `json:"email"`
+	Frequency  string    `json:"frequency"`
+	Hour       int32     `json:"hour"`
+	Weekday    int32     `json:"weekday"`
+	Timezone   string    `json:"timezone"`
+	Enabled    bool      `json:"enabled"`
+	NextSendAt time.Time `json:"next_send_at"`
+}
+
+func (q *Queries) UpsertDigestSettings(ctx context.Context, arg UpsertDigestSettingsParams) (DigestSetting, error) {
+	row := q.db.QueryRowContext(ctx, upsertDigestSettings,
+		arg.UserID,
+		arg.Email,
+		arg.Frequency,
+		arg.Hour,
+		arg.Weekday,
+		arg.Timezone,
+		arg.Enabled,
+		arg.NextSendAt,
+	)
+	var i DigestSetting
+	err := row.Scan(
+		&i.UserID,
+		&i.Email,
+		&i.Frequency,
+		&i.Hour,
+		&i.Weekday,
+		&i.Timezone,
+		&i.Enabled,
+		&i.NextSendAt,
+		&i.LastSentAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
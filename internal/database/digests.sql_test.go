@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueries_UpsertDigestSettings(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+	params := UpsertDigestSettingsParams{
+		UserID:     user.ID,
+		Email:      "jane@example.com",
+		Frequency:  "daily",
+		Hour:       8,
+		Weekday:    1,
+		Timezone:   "Europe/Paris",
+		Enabled:    true,
+		NextSendAt: time.Now().Add(time.Hour).UTC().Round(time.Microsecond),
+	}
+	settings, err := testQueries.UpsertDigestSettings(ctx, params)
+	require.NoError(t, err)
+	assert.Equal(t, params.Email, settings.Email)
+	assert.Equal(t, params.NextSendAt, settings.NextSendAt.UTC())
+
+	params.Frequency = "weekly"
+	settings, err = testQueries.UpsertDigestSettings(ctx, params)
+	require.NoError(t, err)
+	assert.Equal(t, "weekly", settings.Frequency)
+
+	params.Hour = 24
+	_, err = testQueries.UpsertDigestSettings(ctx, params)
+	assert.Error(t, err)
+
+	require.NoError(t, testQueries.DeleteDigestSettings(ctx, user.ID))
+	_, err = testQueries.GetDigestSettings(ctx, user.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestDigestRepository_MarkDigestSent(t *testing.T) {
+	repository := NewDigestRepository(testDB)
+	follow, post := createRandomFollowedPost(t)
+	_, otherPost := createRandomFollowedPost(t)
+	user := follow.UserID.UUID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	_, err := repository.UpsertDigestSettings(ctx, UpsertDigestSettingsParams{
+		UserID:     user,
+		Email:      "jane@example.com",
+		Frequency:  "daily",
+		Timezone:   "UTC",
+		Enabled:    true,
+		NextSendAt: now.Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	due, err := repository.ListDueDigests(ctx, ListDueDigestsParams{Now: now, Limit: 1000})
+	require.NoError(t, err)
+	assert.Contains(t, dueUsers(due), user.String())
+
+	posts, err := repository.ListDigestPosts(ctx, ListDigestPostsParams{
+		UserID: user,
+		Since:  now.Add(-time.Hour),
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	// Only the posts of the followed feeds are part of the digest.
+	require.Len(t, posts, 1)
+	assert.Equal(t, post.ID, posts[0].ID)
+	assert.NotEqual(t, otherPost.ID, posts[0].ID)
+	assert.NotEmpty(t, posts[0].FeedTitle)
+
+	err = repository.MarkDigestSent(ctx, MarkDigestSentParams{
+		UserID:     user,
+		LastSentAt: sql.NullTime{Time: now, Valid: true},
+		NextSendAt: now.Add(24 * time.Hour),
+	}, []int32{post.ID})
+	require.NoError(t, err)
+
+	// The sent posts are not sent again, and the digest is not due anymore.
+	posts, err = repository.ListDigestPosts(ctx, ListDigestPostsParams{
+		UserID: user,
+		Since:  now.Add(-time.Hour),
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, posts)
+
+	due, err = repository.ListDueDigests(ctx, ListDueDigestsParams{Now: now, Limit: 1000})
+	require.NoError(t, err)
+	assert.NotContains(t, dueUsers(due), user.String())
+}
+
+func dueUsers(rows []ListDueDigestsRow) []string {
+	users := make([]string, 0, len(rows))
+	for _, row := range rows {
+		users = append(users, row.DigestSetting.UserID.String())
+	}
+
+	return users
+}
+
+func TestQueries_ListDigestPosts_Notify(t *testing.T) {
+	follow, post := createRandomFollowedPost(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	params := ListDigestPostsParams{
+		UserID: follow.UserID.UUID,
+		Since:  time.Now().Add(-time.Hour),
+		Limit:  10,
+	}
+	posts, err := testQueries.ListDigestPosts(ctx, params)
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, post.ID, posts[0].ID)
+
+	// The feeds without notifications are not part of the digest.
+	_, err = testQueries.UpdateFeedFollows(ctx, UpdateFeedFollowsParams{ID: follow.ID, UserID: follow.UserID, Notify: sql.NullBool{Bool: false, Valid: true}})
+	require.NoError(t, err)
+	posts, err = testQueries.ListDigestPosts(ctx, params)
+	require.NoError(t, err)
+	assert.Empty(t, posts)
+}
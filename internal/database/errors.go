@@ -0,0 +1,43 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// ErrFeedFollowed is returned when deleting a feed still followed by other users than its creator.
+var ErrFeedFollowed = errors.New("the feed is followed by other users")
+
+// ErrFeedFollowedUnshared is returned when making private a feed followed by users it is not shared with.
+var ErrFeedFollowedUnshared = errors.New("the feed is followed by users it is not shared with")
+
+// IsUniqueViolation reports whether the error is caused by a unique constraint violation.
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	return pqErr.Code.Name() == "unique_violation"
+}
+
+// IsForeignKeyViolation reports whether the error is caused by a foreign key constraint violation.
+func IsForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	return pqErr.Code.Name() == "foreign_key_violation"
+}
+
+// IsInvalidRegularExpression reports whether the error is caused by an invalid regular expression.
+func IsInvalidRegularExpression(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	return pqErr.Code.Name() == "invalid_regular_expression"
+}
@@ -7,14 +7,33 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
+const countUnfollowableFeedFollows = `-- name: CountUnfollowableFeedFollows :one
+SELECT count(*)::integer FROM feed_follows ff
+    JOIN feeds ON feeds.id = ff.feed_id
+WHERE ff.feed_id = $1::uuid
+AND NOT feed_is_followable(feeds, ff.user_id)
+`
+
+// Counts the follows of the users who cannot follow the feed anymore.
+func (q *Queries) CountUnfollowableFeedFollows(ctx context.Context, feedID uuid.UUID) (int32, error) {
+	row := q.db.QueryRowContext(ctx, countUnfollowableFeedFollows, feedID)
+	var column_1 int32
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
 const createFeedFollows = `-- name: CreateFeedFollows :one
 INSERT INTO feed_follows (user_id, feed_id)
-VALUES ($1, $2)
-RETURNING id, feed_id, user_id, created_at, updated_at
+SELECT $1::uuid, feeds.id
+FROM feeds
+WHERE feeds.id = $2::uuid
+AND feed_is_followable(feeds, $1::uuid)
+RETURNING id, feed_id, user_id, created_at, updated_at, folder_id, position, title, muted, notify, retention_days
 `
 
 type CreateFeedFollowsParams struct {
@@ -22,6 +41,7 @@ type CreateFeedFollowsParams struct {
 	FeedID uuid.NullUUID `json:"feed_id"`
 }
 
+// Nothing is returned when the feed does not exist or the user cannot follow it.
 func (q *Queries) CreateFeedFollows(ctx context.Context, arg CreateFeedFollowsParams) (FeedFollow, error) {
 	row := q.db.QueryRowContext(ctx, createFeedFollows, arg.UserID, arg.FeedID)
 	var i FeedFollow
@@ -31,6 +51,60 @@ func (q *Queries) CreateFeedFollows(ctx context.Context, arg CreateFeedFollowsPa
 		&i.UserID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.FolderID,
+		&i.Position,
+		&i.Title,
+		&i.Muted,
+		&i.Notify,
+		&i.RetentionDays,
+	)
+	return i, err
+}
+
+const createFeedFollowsIfNotExists = `-- name: CreateFeedFollowsIfNotExists :one
+INSERT INTO feed_follows (user_id, feed_id, folder_id, position, title)
+SELECT $1::uuid, feeds.id, $2::uuid, $3::integer, $4::varchar
+FROM feeds
+WHERE feeds.id = $5::uuid
+AND feed_is_followable(feeds, $1::uuid)
+AND NOT EXISTS (
+    SELECT 1 FROM feed_follows
+    WHERE feed_follows.user_id = $1
+    AND feed_follows.feed_id = $5
+)
+RETURNING id, feed_id, user_id, created_at, updated_at, folder_id, position, title, muted, notify, retention_days
+`
+
+type CreateFeedFollowsIfNotExistsParams struct {
+	UserID   uuid.UUID      `json:"user_id"`
+	FolderID uuid.NullUUID  `json:"folder_id"`
+	Position int32          `json:"position"`
+	Title    sql.NullString `json:"title"`
+	FeedID   uuid.UUID      `json:"feed_id"`
+}
+
+// Nothing is returned when the user already follows the feed, or cannot follow it.
+func (q *Queries) CreateFeedFollowsIfNotExists(ctx context.Context, arg CreateFeedFollowsIfNotExistsParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, createFeedFollowsIfNotExists,
+		arg.UserID,
+		arg.FolderID,
+		arg.Position,
+		arg.Title,
+		arg.FeedID,
+	)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.FeedID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FolderID,
+		&i.Position,
+		&i.Title,
+		&i.Muted,
+		&i.Notify,
+		&i.RetentionDays,
 	)
 	return i, err
 }
@@ -51,10 +125,118 @@ func (q *Queries) DeleteFeedFollows(ctx context.Context, arg DeleteFeedFollowsPa
 	return err
 }
 
+const deleteUnfollowableFeedFollows = `-- name: DeleteUnfollowableFeedFollows :execrows
+DELETE FROM feed_follows ff
+USING feeds
+WHERE feeds.id = ff.feed_id
+AND ff.feed_id = $1::uuid
+AND NOT feed_is_followable(feeds, ff.user_id)
+`
+
+// Deletes the follows of the users who cannot follow the feed anymore.
+func (q *Queries) DeleteUnfollowableFeedFollows(ctx context.Context, feedID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUnfollowableFeedFollows, feedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFeedFollows = `-- name: GetFeedFollows :one
+SELECT id, feed_id, user_id, created_at, updated_at, folder_id, position, title, muted, notify, retention_days FROM feed_follows
+WHERE id = $1
+AND user_id = $2
+`
+
+type GetFeedFollowsParams struct {
+	ID     uuid.UUID     `json:"id"`
+	UserID uuid.NullUUID `json:"user_id"`
+}
+
+func (q *Queries) GetFeedFollows(ctx context.Context, arg GetFeedFollowsParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedFollows, arg.ID, arg.UserID)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.FeedID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FolderID,
+		&i.Position,
+		&i.Title,
+		&i.Muted,
+		&i.Notify,
+		&i.RetentionDays,
+	)
+	return i, err
+}
+
+const getNextFeedFollowPosition = `-- name: GetNextFeedFollowPosition :one
+SELECT COALESCE(MAX(position) + 1, 0)::integer AS position
+FROM feed_follows
+WHERE user_id = $1::uuid
+AND folder_id IS NOT DISTINCT FROM $2::uuid
+`
+
+type GetNextFeedFollowPositionParams struct {
+	UserID   uuid.UUID     `json:"user_id"`
+	FolderID uuid.NullUUID `json:"folder_id"`
+}
+
+// Returns the position after the last feed follow of the folder, or of the follows without folder.
+func (q *Queries) GetNextFeedFollowPosition(ctx context.Context, arg GetNextFeedFollowPositionParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getNextFeedFollowPosition, arg.UserID, arg.FolderID)
+	var position int32
+	err := row.Scan(&position)
+	return position, err
+}
+
+const listAllFeedFollows = `-- name: ListAllFeedFollows :many
+SELECT id, feed_id, user_id, created_at, updated_at, folder_id, position, title, muted, notify, retention_days FROM feed_follows
+WHERE user_id = $1
+ORDER BY position ASC, updated_at DESC
+`
+
+func (q *Queries) ListAllFeedFollows(ctx context.Context, userID uuid.NullUUID) ([]FeedFollow, error) {
+	rows, err := q.db.QueryContext(ctx, listAllFeedFollows, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FeedFollow{}
+	for rows.Next() {
+		var i FeedFollow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FeedID,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.FolderID,
+			&i.Position,
+			&i.Title,
+			&i.Muted,
+			&i.Notify,
+			&i.RetentionDays,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listFeedFollows = `-- name: ListFeedFollows :many
-SELECT id, feed_id, user_id, created_at, updated_at FROM feed_follows
+SELECT id, feed_id, user_id, created_at, updated_at, folder_id, position, title, muted, notify, retention_days FROM feed_follows
 WHERE user_id = $1
-ORDER BY updated_at DESC
+ORDER BY position ASC, updated_at DESC
 LIMIT $2
 OFFSET $3
 `
@@ -80,6 +262,64 @@ func (q *Queries) ListFeedFollows(ctx context.Context, arg ListFeedFollowsParams
 			&i.UserID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.FolderID,
+			&i.Position,
+			&i.Title,
+			&i.Muted,
+			&i.Notify,
+			&i.RetentionDays,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeedFollowsWithFeeds = `-- name: ListFeedFollowsWithFeeds :many
+SELECT ff.id, ff.feed_id, ff.folder_id, ff.title, f.name AS feed_name, f.url AS feed_url, f.site_url AS feed_site_url,
+    f.last_fetched_at AS feed_last_fetched_at
+FROM feed_follows ff
+JOIN feeds f ON f.id = ff.feed_id
+WHERE ff.user_id = $1
+ORDER BY ff.position ASC, ff.updated_at DESC
+`
+
+type ListFeedFollowsWithFeedsRow struct {
+	ID                uuid.UUID      `json:"id"`
+	FeedID            uuid.NullUUID  `json:"feed_id"`
+	FolderID          uuid.NullUUID  `json:"folder_id"`
+	Title             sql.NullString `json:"title"`
+	FeedName          string         `json:"feed_name"`
+	FeedUrl           string         `json:"feed_url"`
+	FeedSiteUrl       string         `json:"feed_site_url"`
+	FeedLastFetchedAt sql.NullTime   `json:"feed_last_fetched_at"`
+}
+
+func (q *Queries) ListFeedFollowsWithFeeds(ctx context.Context, userID uuid.NullUUID) ([]ListFeedFollowsWithFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFeedFollowsWithFeeds, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFeedFollowsWithFeedsRow{}
+	for rows.Next() {
+		var i ListFeedFollowsWithFeedsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FeedID,
+			&i.FolderID,
+			&i.Title,
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.FeedSiteUrl,
+			&i.FeedLastFetchedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -93,3 +333,69 @@ func (q *Queries) ListFeedFollows(ctx context.Context, arg ListFeedFollowsParams
 	}
 	return items, nil
 }
+
+const updateFeedFollows = `-- name: UpdateFeedFollows :one
+UPDATE feed_follows
+SET folder_id = CASE WHEN $1::boolean THEN $2::uuid ELSE folder_id END,
+    position = COALESCE($3::integer, position),
+    title = CASE WHEN $4::boolean THEN $5::varchar ELSE title END,
+    muted = COALESCE($6::boolean, muted),
+    notify = COALESCE($7::boolean, notify),
+    retention_days = CASE WHEN $8::boolean THEN $9::integer ELSE retention_days END,
+    updated_at = NOW()
+WHERE feed_follows.id = $10
+AND feed_follows.user_id = $11
+AND (
+    $2::uuid IS NULL
+    OR EXISTS (SELECT 1 FROM folders WHERE folders.id = $2::uuid AND folders.user_id = $11)
+)
+RETURNING id, feed_id, user_id, created_at, updated_at, folder_id, position, title, muted, notify, retention_days
+`
+
+type UpdateFeedFollowsParams struct {
+	SetFolderID      bool           `json:"set_folder_id"`
+	FolderID         uuid.NullUUID  `json:"folder_id"`
+	Position         sql.NullInt32  `json:"position"`
+	SetTitle         bool           `json:"set_title"`
+	Title            sql.NullString `json:"title"`
+	Muted            sql.NullBool   `json:"muted"`
+	Notify           sql.NullBool   `json:"notify"`
+	SetRetentionDays bool           `json:"set_retention_days"`
+	RetentionDays    sql.NullInt32  `json:"retention_days"`
+	ID               uuid.UUID      `json:"id"`
+	UserID           uuid.NullUUID  `json:"user_id"`
+}
+
+// The position, the muted and the notify settings are only changed when given.
+// The folder, the title and the retention are only changed when set, they are cleared when set to null.
+// The folder must belong to the user who follows the feed.
+func (q *Queries) UpdateFeedFollows(ctx context.Context, arg UpdateFeedFollowsParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, updateFeedFollows,
+		arg.SetFolderID,
+		arg.FolderID,
+		arg.Position,
+		arg.SetTitle,
+		arg.Title,
+		arg.Muted,
+		arg.Notify,
+		arg.SetRetentionDays,
+		arg.RetentionDays,
+		arg.ID,
+		arg.UserID,
+	)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.FeedID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FolderID,
+		&i.Position,
+		&i.Title,
+		&i.Muted,
+		&i.Notify,
+		&i.RetentionDays,
+	)
+	return i, err
+}
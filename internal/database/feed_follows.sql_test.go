@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
@@ -55,3 +56,91 @@ func TestQueries_CreateFeedFollows_UnknownFeed(t *testing.T) {
 	require.Error(t, err)
 	require.Empty(t, follows)
 }
+
+func TestQueries_UpdateFeedFollows_ForeignFolder(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	feed := CreateRandomFeed(t)
+	follow, err := testQueries.CreateFeedFollows(ctx, CreateFeedFollowsParams{
+		UserID: feed.UserID,
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// The folder belongs to another user.
+	folder := CreateRandomFolder(t, CreateRandomUser(t).ID)
+
+	_, err = testQueries.UpdateFeedFollows(ctx, UpdateFeedFollowsParams{
+		ID:          follow.ID,
+		UserID:      feed.UserID,
+		SetFolderID: true,
+		FolderID:    uuid.NullUUID{UUID: folder.ID, Valid: true},
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestQueries_CreateFeedFollowsIfNotExists(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	feed := CreateRandomFeed(t)
+	folder := CreateRandomFolder(t, feed.UserID.UUID)
+
+	params := CreateFeedFollowsIfNotExistsParams{
+		UserID:   feed.UserID.UUID,
+		FeedID:   feed.ID,
+		FolderID: uuid.NullUUID{UUID: folder.ID, Valid: true},
+		Position: 2,
+		Title:    sql.NullString{String: "My feed", Valid: true},
+	}
+	follow, err := testQueries.CreateFeedFollowsIfNotExists(ctx, params)
+	require.NoError(t, err)
+	assert.Equal(t, feed.UserID, follow.UserID)
+	assert.Equal(t, feed.ID, follow.FeedID.UUID)
+	assert.Equal(t, params.FolderID, follow.FolderID)
+	assert.Equal(t, params.Position, follow.Position)
+	assert.Equal(t, params.Title, follow.Title)
+
+	// The feed is already followed.
+	_, err = testQueries.CreateFeedFollowsIfNotExists(ctx, params)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestQueries_CreateFeedFollows_PrivateFeed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	feed := CreateRandomFeed(t)
+	feed, err := testQueries.UpdateFeed(ctx, UpdateFeedParams{
+		ID:         feed.ID,
+		Visibility: sql.NullString{String: "private", Valid: true},
+	})
+	require.NoError(t, err)
+	user := CreateRandomUser(t)
+	params := CreateFeedFollowsParams{
+		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	}
+
+	// A private feed is only followed by its creator and the users it is shared with.
+	_, err = testQueries.CreateFeedFollows(ctx, params)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = testQueries.CreateFeedFollows(ctx, CreateFeedFollowsParams{UserID: feed.UserID, FeedID: params.FeedID})
+	require.NoError(t, err)
+
+	_, err = testQueries.CreateFeedShare(ctx, CreateFeedShareParams{FeedID: feed.ID, UserID: user.ID})
+	require.NoError(t, err)
+	_, err = testQueries.CreateFeedFollows(ctx, params)
+	require.NoError(t, err)
+
+	// The follows of the users the feed is not shared with anymore are deleted.
+	deleted, err := testQueries.DeleteUnfollowableFeedFollows(ctx, feed.ID)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+	_, err = testQueries.DeleteFeedShare(ctx, DeleteFeedShareParams{FeedID: feed.ID, UserID: user.ID})
+	require.NoError(t, err)
+	deleted, err = testQueries.DeleteUnfollowableFeedFollows(ctx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
@@ -59,7 +59,17 @@ func (f FeedRepository) CreateFeedAndFollow(ctx context.Context, arg CreateFeedP
 	return feed, follow, tx.Commit()
 }
 
-// ListFeeds returns a list of feeds.
+// GetFeedByURL returns the feed with the given url which the user can follow.
+func (f FeedRepository) GetFeedByURL(ctx context.Context, arg GetFeedByURLParams) (Feed, error) {
+	feed, err := f.queries.GetFeedByURL(ctx, arg)
+	if err != nil {
+		return Feed{}, fmt.Errorf("error getting feed by url: %w", err)
+	}
+
+	return feed, nil
+}
+
+// ListFeeds returns a list of the public feeds.
 func (f FeedRepository) ListFeeds(ctx context.Context, arg ListFeedsParams) ([]Feed, error) {
 	feeds, err := f.queries.ListFeeds(ctx, arg)
 	if err != nil {
@@ -70,6 +80,7 @@ func (f FeedRepository) ListFeeds(ctx context.Context, arg ListFeedsParams) ([]F
 }
 
 // CreateFeedFollows creates a new feed follow.
+// sql.ErrNoRows is returned when the feed does not exist or the user cannot follow it.
 func (f FeedRepository) CreateFeedFollows(ctx context.Context, arg CreateFeedFollowsParams) (FeedFollow, error) {
 	follow, err := f.queries.CreateFeedFollows(ctx, arg)
 	if err != nil {
@@ -79,6 +90,27 @@ func (f FeedRepository) CreateFeedFollows(ctx context.Context, arg CreateFeedFol
 	return follow, nil
 }
 
+// CreateFeedFollowsIfNotExists follows a feed, unless the user already follows it.
+// It returns sql.ErrNoRows when the feed is already followed, or when the user cannot follow it.
+func (f FeedRepository) CreateFeedFollowsIfNotExists(ctx context.Context, arg CreateFeedFollowsIfNotExistsParams) (FeedFollow, error) {
+	follow, err := f.queries.CreateFeedFollowsIfNotExists(ctx, arg)
+	if err != nil {
+		return FeedFollow{}, fmt.Errorf("error creating feed follow: %w", err)
+	}
+
+	return follow, nil
+}
+
+// ListFeedFollowsWithFeeds returns all the feed follows of a user with the name and the urls of their feed.
+func (f FeedRepository) ListFeedFollowsWithFeeds(ctx context.Context, userID uuid.NullUUID) ([]ListFeedFollowsWithFeedsRow, error) {
+	follows, err := f.queries.ListFeedFollowsWithFeeds(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing feed follows with feeds: %w", err)
+	}
+
+	return follows, nil
+}
+
 // ListFeedFollows returns a list of feed follows.
 func (f FeedRepository) ListFeedFollows(ctx context.Context, arg ListFeedFollowsParams) ([]FeedFollow, error) {
 	follows, err := f.queries.ListFeedFollows(ctx, arg)
@@ -89,6 +121,16 @@ func (f FeedRepository) ListFeedFollows(ctx context.Context, arg ListFeedFollows
 	return follows, nil
 }
 
+// ListAllFeedFollows returns all the feed follows of a user.
+func (f FeedRepository) ListAllFeedFollows(ctx context.Context, userID uuid.NullUUID) ([]FeedFollow, error) {
+	follows, err := f.queries.ListAllFeedFollows(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing all feed follows: %w", err)
+	}
+
+	return follows, nil
+}
+
 // DeleteFeedFollows deletes a feed follow.
 func (f FeedRepository) DeleteFeedFollows(ctx context.Context, arg DeleteFeedFollowsParams) error {
 	err := f.queries.DeleteFeedFollows(ctx, arg)
@@ -109,12 +151,255 @@ func (f FeedRepository) GetNextFeedsToFetch(ctx context.Context, limit int32) ([
 	return feeds, nil
 }
 
-// MarkFeedFetched marks a feed as fetched.
-func (f FeedRepository) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
-	err := f.queries.MarkFeedFetched(ctx, id)
+// MarkFeedFetched marks a feed as fetched and updates its site url.
+func (f FeedRepository) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams) error {
+	err := f.queries.MarkFeedFetched(ctx, arg)
 	if err != nil {
 		return fmt.Errorf("error getting next feeds to fetch: %w", err)
 	}
 
 	return nil
 }
+
+// MarkFeedFetchFailed records the error of a failed fetch of a feed.
+func (f FeedRepository) MarkFeedFetchFailed(ctx context.Context, arg MarkFeedFetchFailedParams) error {
+	err := f.queries.MarkFeedFetchFailed(ctx, arg)
+	if err != nil {
+		return fmt.Errorf("error marking feed fetch failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetFeed returns the feed with the given id.
+func (f FeedRepository) GetFeed(ctx context.Context, id uuid.UUID) (Feed, error) {
+	feed, err := f.queries.GetFeed(ctx, id)
+	if err != nil {
+		return Feed{}, fmt.Errorf("error getting feed: %w", err)
+	}
+
+	return feed, nil
+}
+
+// ListFeedsWithHealth returns a list of all the feeds with their health status and their number of followers.
+func (f FeedRepository) ListFeedsWithHealth(ctx context.Context, arg ListFeedsWithHealthParams) ([]ListFeedsWithHealthRow, error) {
+	feeds, err := f.queries.ListFeedsWithHealth(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("error listing feeds with health: %w", err)
+	}
+
+	return feeds, nil
+}
+
+// SetFeedDisabled disables or enables a feed, a disabled feed is not fetched anymore.
+func (f FeedRepository) SetFeedDisabled(ctx context.Context, arg SetFeedDisabledParams) (Feed, error) {
+	feed, err := f.queries.SetFeedDisabled(ctx, arg)
+	if err != nil {
+		return Feed{}, fmt.Errorf("error setting feed disabled: %w", err)
+	}
+
+	return feed, nil
+}
+
+// ListFeedsByCreator returns the feeds created by the given user.
+func (f FeedRepository) ListFeedsByCreator(ctx context.Context, userID uuid.NullUUID) ([]Feed, error) {
+	feeds, err := f.queries.ListFeedsByCreator(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing feeds by creator: %w", err)
+	}
+
+	return feeds, nil
+}
+
+// GetManagedFeed returns a feed managed by the given user, as its creator or as an admin.
+func (f FeedRepository) GetManagedFeed(ctx context.Context, arg GetManagedFeedParams) (Feed, error) {
+	feed, err := f.queries.GetManagedFeed(ctx, arg)
+	if err != nil {
+		return Feed{}, fmt.Errorf("error getting managed feed: %w", err)
+	}
+
+	return feed, nil
+}
+
+// UpdateFeed renames a feed, changes its url or its visibility, or pauses its fetching.
+// A feed cannot be made private while users it is not shared with follow it,
+// the feed is kept unchanged and ErrFeedFollowedUnshared is returned.
+func (f FeedRepository) UpdateFeed(ctx context.Context, arg UpdateFeedParams) (Feed, error) {
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Feed{}, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := f.queries.WithTx(tx)
+	feed, err := qtx.UpdateFeed(ctx, arg)
+	if err != nil {
+		return Feed{}, fmt.Errorf("error updating feed: %w", err)
+	}
+	unfollowable, err := qtx.CountUnfollowableFeedFollows(ctx, feed.ID)
+	if err != nil {
+		return Feed{}, fmt.Errorf("error counting unfollowable feed follows: %w", err)
+	}
+	if unfollowable > 0 {
+		return Feed{}, ErrFeedFollowedUnshared
+	}
+
+	return feed, tx.Commit()
+}
+
+// CreateFeedShare shares a private feed with a user, who can then follow it.
+func (f FeedRepository) CreateFeedShare(ctx context.Context, arg CreateFeedShareParams) (FeedShare, error) {
+	share, err := f.queries.CreateFeedShare(ctx, arg)
+	if err != nil {
+		return FeedShare{}, fmt.Errorf("error creating feed share: %w", err)
+	}
+
+	return share, nil
+}
+
+// ListFeedShares returns the shares of a feed.
+func (f FeedRepository) ListFeedShares(ctx context.Context, feedID uuid.UUID) ([]FeedShare, error) {
+	shares, err := f.queries.ListFeedShares(ctx, feedID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing feed shares: %w", err)
+	}
+
+	return shares, nil
+}
+
+// DeleteFeedShare stops sharing a feed with a user, who unfollows it while it is private.
+// sql.ErrNoRows is returned when the feed is not shared with the user.
+func (f FeedRepository) DeleteFeedShare(ctx context.Context, arg DeleteFeedShareParams) error {
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := f.queries.WithTx(tx)
+	deleted, err := qtx.DeleteFeedShare(ctx, arg)
+	if err != nil {
+		return fmt.Errorf("error deleting feed share: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("error deleting feed share: %w", sql.ErrNoRows)
+	}
+	if _, err := qtx.DeleteUnfollowableFeedFollows(ctx, arg.FeedID); err != nil {
+		return fmt.Errorf("error deleting unfollowable feed follows: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// DeleteFeed deletes a feed with its posts and its follows.
+// A feed followed by other users than its creator is kept and ErrFeedFollowed is returned.
+func (f FeedRepository) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := f.queries.WithTx(tx)
+	followers, err := qtx.CountOtherFeedFollowers(ctx, uuid.NullUUID{UUID: id, Valid: true})
+	if err != nil {
+		return fmt.Errorf("error counting feed followers: %w", err)
+	}
+	if followers > 0 {
+		return ErrFeedFollowed
+	}
+	if err := qtx.DeleteFeed(ctx, id); err != nil {
+		return fmt.Errorf("error deleting feed: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetFeedFollows returns a feed follow of a user.
+func (f FeedRepository) GetFeedFollows(ctx context.Context, arg GetFeedFollowsParams) (FeedFollow, error) {
+	follow, err := f.queries.GetFeedFollows(ctx, arg)
+	if err != nil {
+		return FeedFollow{}, fmt.Errorf("error getting feed follow: %w", err)
+	}
+
+	return follow, nil
+}
+
+// UpdateFeedFollows updates the folder, the position and the settings of a feed follow.
+func (f FeedRepository) UpdateFeedFollows(ctx context.Context, arg UpdateFeedFollowsParams) (FeedFollow, error) {
+	follow, err := f.queries.UpdateFeedFollows(ctx, arg)
+	if err != nil {
+		return FeedFollow{}, fmt.Errorf("error updating feed follow: %w", err)
+	}
+
+	return follow, nil
+}
+
+// CreateFolder creates a new folder.
+func (f FeedRepository) CreateFolder(ctx context.Context, arg CreateFolderParams) (Folder, error) {
+	folder, err := f.queries.CreateFolder(ctx, arg)
+	if err != nil {
+		return Folder{}, fmt.Errorf("error creating folder: %w", err)
+	}
+
+	return folder, nil
+}
+
+// GetOrCreateFolder returns the folder of the user with the given name, it is created when missing.
+func (f FeedRepository) GetOrCreateFolder(ctx context.Context, arg GetOrCreateFolderParams) (Folder, error) {
+	folder, err := f.queries.GetOrCreateFolder(ctx, arg)
+	if err != nil {
+		return Folder{}, fmt.Errorf("error getting or creating folder: %w", err)
+	}
+
+	return folder, nil
+}
+
+// GetNextFolderPosition returns the position after the last folder of a user.
+func (f FeedRepository) GetNextFolderPosition(ctx context.Context, userID uuid.UUID) (int32, error) {
+	position, err := f.queries.GetNextFolderPosition(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("error getting next folder position: %w", err)
+	}
+
+	return position, nil
+}
+
+// GetNextFeedFollowPosition returns the position after the last feed follow of a folder of a user.
+func (f FeedRepository) GetNextFeedFollowPosition(ctx context.Context, arg GetNextFeedFollowPositionParams) (int32, error) {
+	position, err := f.queries.GetNextFeedFollowPosition(ctx, arg)
+	if err != nil {
+		return 0, fmt.Errorf("error getting next feed follow position: %w", err)
+	}
+
+	return position, nil
+}
+
+// ListFolders returns the folders of a user.
+func (f FeedRepository) ListFolders(ctx context.Context, userID uuid.UUID) ([]Folder, error) {
+	folders, err := f.queries.ListFolders(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing folders: %w", err)
+	}
+
+	return folders, nil
+}
+
+// UpdateFolder renames and moves a folder.
+func (f FeedRepository) UpdateFolder(ctx context.Context, arg UpdateFolderParams) (Folder, error) {
+	folder, err := f.queries.UpdateFolder(ctx, arg)
+	if err != nil {
+		return Folder{}, fmt.Errorf("error updating folder: %w", err)
+	}
+
+	return folder, nil
+}
+
+// DeleteFolder deletes a folder, the feed follows of the folder are kept without folder.
+func (f FeedRepository) DeleteFolder(ctx context.Context, arg DeleteFolderParams) error {
+	if err := f.queries.DeleteFolder(ctx, arg); err != nil {
+		return fmt.Errorf("error deleting folder: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedRepository_DeleteFeed(t *testing.T) {
+	feedRepository := NewFeedRepository(testDB)
+	ctx := context.Background()
+
+	feed := CreateRandomFeed(t)
+	_, err := testQueries.CreateFeedFollows(ctx, CreateFeedFollowsParams{
+		UserID: feed.UserID,
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	follower := CreateRandomUser(t)
+	follow, err := testQueries.CreateFeedFollows(ctx, CreateFeedFollowsParams{
+		UserID: uuid.NullUUID{UUID: follower.ID, Valid: true},
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// The feed is kept while another user follows it.
+	require.ErrorIs(t, feedRepository.DeleteFeed(ctx, feed.ID), ErrFeedFollowed)
+	_, err = testQueries.GetFeed(ctx, feed.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, testQueries.DeleteFeedFollows(ctx, DeleteFeedFollowsParams{ID: follow.ID, UserID: follow.UserID}))
+	require.NoError(t, feedRepository.DeleteFeed(ctx, feed.ID))
+	_, err = testQueries.GetFeed(ctx, feed.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestFeedRepository_UpdateFeed_Private(t *testing.T) {
+	feedRepository := NewFeedRepository(testDB)
+	ctx := context.Background()
+
+	feed := CreateRandomFeed(t)
+	shared := CreateRandomUser(t)
+	other := CreateRandomUser(t)
+	for _, userID := range []uuid.UUID{feed.UserID.UUID, shared.ID, other.ID} {
+		_, err := testQueries.CreateFeedFollows(ctx, CreateFeedFollowsParams{
+			UserID: uuid.NullUUID{UUID: userID, Valid: true},
+			FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+		})
+		require.NoError(t, err)
+	}
+	_, err := feedRepository.CreateFeedShare(ctx, CreateFeedShareParams{FeedID: feed.ID, UserID: shared.ID})
+	require.NoError(t, err)
+
+	// The feed is not made private while a user it is not shared with follows it, the follows are kept.
+	_, err = feedRepository.UpdateFeed(ctx, UpdateFeedParams{
+		ID:         feed.ID,
+		Visibility: sql.NullString{String: "private", Valid: true},
+	})
+	require.ErrorIs(t, err, ErrFeedFollowedUnshared)
+	got, err := testQueries.GetFeed(ctx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, feed.Visibility, got.Visibility)
+	for _, userID := range []uuid.UUID{feed.UserID.UUID, shared.ID, other.ID} {
+		follows, err := testQueries.ListFeedFollows(ctx, ListFeedFollowsParams{
+			UserID: uuid.NullUUID{UUID: userID, Valid: true},
+			Limit:  10,
+		})
+		require.NoError(t, err)
+		assert.Len(t, follows, 1)
+	}
+
+	// Once the user unfollows it, the feed is made private.
+	follows, err := testQueries.ListFeedFollows(ctx, ListFeedFollowsParams{
+		UserID: uuid.NullUUID{UUID: other.ID, Valid: true},
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.NoError(t, testQueries.DeleteFeedFollows(ctx, DeleteFeedFollowsParams{ID: follows[0].ID, UserID: follows[0].UserID}))
+	updated, err := feedRepository.UpdateFeed(ctx, UpdateFeedParams{
+		ID:         feed.ID,
+		Visibility: sql.NullString{String: "private", Valid: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "private", updated.Visibility)
+
+	require.NoError(t, feedRepository.DeleteFeedShare(ctx, DeleteFeedShareParams{FeedID: feed.ID, UserID: shared.ID}))
+	require.ErrorIs(t, feedRepository.DeleteFeedShare(ctx, DeleteFeedShareParams{FeedID: feed.ID, UserID: shared.ID}), sql.ErrNoRows)
+}
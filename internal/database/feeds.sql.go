@@ -7,24 +7,119 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
+const countOtherFeedFollowers = `-- name: CountOtherFeedFollowers :one
+SELECT count(*)::integer FROM feed_follows ff
+    JOIN feeds f ON f.id = ff.feed_id
+WHERE ff.feed_id = $1
+AND ff.user_id IS DISTINCT FROM f.user_id
+`
+
+// Counts the followers of a feed other than its creator.
+func (q *Queries) CountOtherFeedFollowers(ctx context.Context, feedID uuid.NullUUID) (int32, error) {
+	row := q.db.QueryRowContext(ctx, countOtherFeedFollowers, feedID)
+	var column_1 int32
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
 const createFeed = `-- name: CreateFeed :one
-INSERT INTO feeds (name, url, user_id)
-VALUES ($1, $2, $3)
-RETURNING id, name, url, user_id, created_at, updated_at, last_fetched_at
+INSERT INTO feeds (name, url, user_id, visibility)
+VALUES ($1, $2, $3, COALESCE($4::varchar, 'public'))
+RETURNING id, name, url, user_id, created_at, updated_at, last_fetched_at, site_url, last_error, error_count, disabled_at, visibility
 `
 
 type CreateFeedParams struct {
-	Name   string        `json:"name"`
-	Url    string        `json:"url"`
-	UserID uuid.NullUUID `json:"user_id"`
+	Name       string         `json:"name"`
+	Url        string         `json:"url"`
+	UserID     uuid.NullUUID  `json:"user_id"`
+	Visibility sql.NullString `json:"visibility"`
 }
 
+// A feed is public unless another visibility is given.
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, createFeed, arg.Name, arg.Url, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createFeed,
+		arg.Name,
+		arg.Url,
+		arg.UserID,
+		arg.Visibility,
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastFetchedAt,
+		&i.SiteUrl,
+		&i.LastError,
+		&i.ErrorCount,
+		&i.DisabledAt,
+		&i.Visibility,
+	)
+	return i, err
+}
+
+const createFeedShare = `-- name: CreateFeedShare :one
+INSERT INTO feed_shares (feed_id, user_id)
+VALUES ($1, $2)
+RETURNING feed_id, user_id, created_at
+`
+
+type CreateFeedShareParams struct {
+	FeedID uuid.UUID `json:"feed_id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) CreateFeedShare(ctx context.Context, arg CreateFeedShareParams) (FeedShare, error) {
+	row := q.db.QueryRowContext(ctx, createFeedShare, arg.FeedID, arg.UserID)
+	var i FeedShare
+	err := row.Scan(&i.FeedID, &i.UserID, &i.CreatedAt)
+	return i, err
+}
+
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
+const deleteFeedShare = `-- name: DeleteFeedShare :execrows
+DELETE FROM feed_shares
+WHERE feed_id = $1
+AND user_id = $2
+`
+
+type DeleteFeedShareParams struct {
+	FeedID uuid.UUID `json:"feed_id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) DeleteFeedShare(ctx context.Context, arg DeleteFeedShareParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeedShare, arg.FeedID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFeed = `-- name: GetFeed :one
+SELECT id, name, url, user_id, created_at, updated_at, last_fetched_at, site_url, last_error, error_count, disabled_at, visibility FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) GetFeed(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeed, id)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -34,16 +129,95 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.LastFetchedAt,
+		&i.SiteUrl,
+		&i.LastError,
+		&i.ErrorCount,
+		&i.DisabledAt,
+		&i.Visibility,
+	)
+	return i, err
+}
+
+const getFeedByURL = `-- name: GetFeedByURL :one
+SELECT id, name, url, user_id, created_at, updated_at, last_fetched_at, site_url, last_error, error_count, disabled_at, visibility FROM feeds
+WHERE url = $1
+AND feed_is_followable(feeds, $2::uuid)
+ORDER BY visibility = 'private', created_at
+LIMIT 1
+`
+
+type GetFeedByURLParams struct {
+	Url    string    `json:"url"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// Returns the feed of a url the user can follow, the public or unlisted one first.
+// The private feeds of the other users are ignored, as if their url was unknown.
+func (q *Queries) GetFeedByURL(ctx context.Context, arg GetFeedByURLParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByURL, arg.Url, arg.UserID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastFetchedAt,
+		&i.SiteUrl,
+		&i.LastError,
+		&i.ErrorCount,
+		&i.DisabledAt,
+		&i.Visibility,
+	)
+	return i, err
+}
+
+const getManagedFeed = `-- name: GetManagedFeed :one
+SELECT feeds.id, feeds.name, feeds.url, feeds.user_id, feeds.created_at, feeds.updated_at, feeds.last_fetched_at, feeds.site_url, feeds.last_error, feeds.error_count, feeds.disabled_at, feeds.visibility FROM feeds
+WHERE feeds.id = $1
+AND (feeds.user_id = $2::uuid OR EXISTS (
+    SELECT 1 FROM users
+    WHERE users.id = $2::uuid
+    AND users.role = 'admin'
+    AND users.suspended_at IS NULL
+))
+`
+
+type GetManagedFeedParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// Returns the feed when the user manages it, as its creator or as an admin.
+func (q *Queries) GetManagedFeed(ctx context.Context, arg GetManagedFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getManagedFeed, arg.ID, arg.UserID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastFetchedAt,
+		&i.SiteUrl,
+		&i.LastError,
+		&i.ErrorCount,
+		&i.DisabledAt,
+		&i.Visibility,
 	)
 	return i, err
 }
 
 const getNextFeedsToFetch = `-- name: GetNextFeedsToFetch :many
-SELECT id, name, url, user_id, created_at, updated_at, last_fetched_at FROM feeds
+SELECT id, name, url, user_id, created_at, updated_at, last_fetched_at, site_url, last_error, error_count, disabled_at, visibility FROM feeds
+WHERE disabled_at IS NULL
 ORDER BY last_fetched_at NULLS FIRST, last_fetched_at ASC
 LIMIT $1
 `
 
+// The disabled feeds are skipped.
 func (q *Queries) GetNextFeedsToFetch(ctx context.Context, limit int32) ([]Feed, error) {
 	rows, err := q.db.QueryContext(ctx, getNextFeedsToFetch, limit)
 	if err != nil {
@@ -61,6 +235,11 @@ func (q *Queries) GetNextFeedsToFetch(ctx context.Context, limit int32) ([]Feed,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.LastFetchedAt,
+			&i.SiteUrl,
+			&i.LastError,
+			&i.ErrorCount,
+			&i.DisabledAt,
+			&i.Visibility,
 		); err != nil {
 			return nil, err
 		}
@@ -75,8 +254,38 @@ func (q *Queries) GetNextFeedsToFetch(ctx context.Context, limit int32) ([]Feed,
 	return items, nil
 }
 
+const listFeedShares = `-- name: ListFeedShares :many
+SELECT feed_id, user_id, created_at FROM feed_shares
+WHERE feed_id = $1
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListFeedShares(ctx context.Context, feedID uuid.UUID) ([]FeedShare, error) {
+	rows, err := q.db.QueryContext(ctx, listFeedShares, feedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FeedShare{}
+	for rows.Next() {
+		var i FeedShare
+		if err := rows.Scan(&i.FeedID, &i.UserID, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listFeeds = `-- name: ListFeeds :many
-SELECT id, name, url, user_id, created_at, updated_at, last_fetched_at FROM feeds
+SELECT id, name, url, user_id, created_at, updated_at, last_fetched_at, site_url, last_error, error_count, disabled_at, visibility FROM feeds
+WHERE visibility = 'public'
 ORDER BY updated_at DESC
 LIMIT $1
 OFFSET $2
@@ -87,6 +296,7 @@ type ListFeedsParams struct {
 	Offset int32 `json:"offset"`
 }
 
+// Only the public feeds are listed.
 func (q *Queries) ListFeeds(ctx context.Context, arg ListFeedsParams) ([]Feed, error) {
 	rows, err := q.db.QueryContext(ctx, listFeeds, arg.Limit, arg.Offset)
 	if err != nil {
@@ -104,6 +314,11 @@ func (q *Queries) ListFeeds(ctx context.Context, arg ListFeedsParams) ([]Feed, e
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.LastFetchedAt,
+			&i.SiteUrl,
+			&i.LastError,
+			&i.ErrorCount,
+			&i.DisabledAt,
+			&i.Visibility,
 		); err != nil {
 			return nil, err
 		}
@@ -118,13 +333,260 @@ func (q *Queries) ListFeeds(ctx context.Context, arg ListFeedsParams) ([]Feed, e
 	return items, nil
 }
 
+const listFeedsByCreator = `-- name: ListFeedsByCreator :many
+SELECT id, name, url, user_id, created_at, updated_at, last_fetched_at, site_url, last_error, error_count, disabled_at, visibility FROM feeds
+WHERE user_id = $1
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListFeedsByCreator(ctx context.Context, userID uuid.NullUUID) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, listFeedsByCreator, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Feed{}
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LastFetchedAt,
+			&i.SiteUrl,
+			&i.LastError,
+			&i.ErrorCount,
+			&i.DisabledAt,
+			&i.Visibility,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeedsWithHealth = `-- name: ListFeedsWithHealth :many
+SELECT feeds.id, feeds.name, feeds.url, feeds.user_id, feeds.created_at, feeds.updated_at, feeds.last_fetched_at, feeds.site_url, feeds.last_error, feeds.error_count, feeds.disabled_at, feeds.visibility,
+    (CASE
+        WHEN feeds.disabled_at IS NOT NULL THEN 'disabled'
+        WHEN feeds.error_count > 0 THEN 'failing'
+        WHEN feeds.last_fetched_at IS NULL THEN 'pending'
+        ELSE 'ok'
+    END)::text AS status,
+    (SELECT count(*) FROM feed_follows WHERE feed_follows.feed_id = feeds.id)::integer AS followers
+FROM feeds
+ORDER BY feeds.error_count DESC, feeds.updated_at DESC
+LIMIT $1
+OFFSET $2
+`
+
+type ListFeedsWithHealthParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+type ListFeedsWithHealthRow struct {
+	Feed      Feed   `json:"feed"`
+	Status    string `json:"status"`
+	Followers int32  `json:"followers"`
+}
+
+// Returns all the feeds with their health status and their number of followers, the failing ones first.
+// A feed is disabled, failing when its last fetch failed, pending when it was never fetched, ok otherwise.
+func (q *Queries) ListFeedsWithHealth(ctx context.Context, arg ListFeedsWithHealthParams) ([]ListFeedsWithHealthRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFeedsWithHealth, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFeedsWithHealthRow{}
+	for rows.Next() {
+		var i ListFeedsWithHealthRow
+		if err := rows.Scan(
+			&i.Feed.ID,
+			&i.Feed.Name,
+			&i.Feed.Url,
+			&i.Feed.UserID,
+			&i.Feed.CreatedAt,
+			&i.Feed.UpdatedAt,
+			&i.Feed.LastFetchedAt,
+			&i.Feed.SiteUrl,
+			&i.Feed.LastError,
+			&i.Feed.ErrorCount,
+			&i.Feed.DisabledAt,
+			&i.Feed.Visibility,
+			&i.Status,
+			&i.Followers,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markFeedFetchFailed = `-- name: MarkFeedFetchFailed :exec
+UPDATE feeds
+SET last_fetched_at = NOW(),
+    last_error = $1::text,
+    error_count = error_count + 1
+WHERE id = $2
+`
+
+type MarkFeedFetchFailedParams struct {
+	LastError string    `json:"last_error"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// The feed is fetched again at its turn, its failed fetches are counted until one succeeds.
+func (q *Queries) MarkFeedFetchFailed(ctx context.Context, arg MarkFeedFetchFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markFeedFetchFailed, arg.LastError, arg.ID)
+	return err
+}
+
 const markFeedFetched = `-- name: MarkFeedFetched :exec
 UPDATE feeds
-SET last_fetched_at = NOW(), updated_at = NOW()
-WHERE id = $1
+SET last_fetched_at = NOW(),
+    updated_at = NOW(),
+    last_error = '',
+    error_count = 0,
+    site_url = COALESCE(NULLIF($1::varchar, ''), site_url)
+WHERE id = $2
 `
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
+type MarkFeedFetchedParams struct {
+	SiteUrl string    `json:"site_url"`
+	ID      uuid.UUID `json:"id"`
+}
+
+// The site url is kept when the fetched feed does not provide one.
+// A successful fetch clears the errors of the feed.
+func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams) error {
+	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.SiteUrl, arg.ID)
 	return err
 }
+
+const orphanFollowedFeeds = `-- name: OrphanFollowedFeeds :execrows
+UPDATE feeds
+SET user_id = NULL,
+    updated_at = NOW()
+WHERE feeds.user_id = $1
+AND EXISTS (
+    SELECT 1 FROM feed_follows ff
+    WHERE ff.feed_id = feeds.id
+    AND ff.user_id <> $1
+)
+`
+
+// The feeds created by a user which other users still follow are kept without creator,
+// instead of being deleted with the user.
+func (q *Queries) OrphanFollowedFeeds(ctx context.Context, userID uuid.NullUUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, orphanFollowedFeeds, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setFeedDisabled = `-- name: SetFeedDisabled :one
+UPDATE feeds
+SET disabled_at = CASE WHEN $1::boolean THEN COALESCE(disabled_at, NOW()) END,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING id, name, url, user_id, created_at, updated_at, last_fetched_at, site_url, last_error, error_count, disabled_at, visibility
+`
+
+type SetFeedDisabledParams struct {
+	Disabled bool      `json:"disabled"`
+	ID       uuid.UUID `json:"id"`
+}
+
+// A disabled feed keeps the date it was first disabled at.
+func (q *Queries) SetFeedDisabled(ctx context.Context, arg SetFeedDisabledParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, setFeedDisabled, arg.Disabled, arg.ID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastFetchedAt,
+		&i.SiteUrl,
+		&i.LastError,
+		&i.ErrorCount,
+		&i.DisabledAt,
+		&i.Visibility,
+	)
+	return i, err
+}
+
+const updateFeed = `-- name: UpdateFeed :one
+UPDATE feeds
+SET name = COALESCE($1::varchar, name),
+    url = COALESCE($2::varchar, url),
+    visibility = COALESCE($3::varchar, visibility),
+    last_fetched_at = CASE WHEN $2::varchar IS NULL OR $2::varchar = url THEN last_fetched_at END,
+    last_error = CASE WHEN $2::varchar IS NULL OR $2::varchar = url THEN last_error ELSE '' END,
+    error_count = CASE WHEN $2::varchar IS NULL OR $2::varchar = url THEN error_count ELSE 0 END,
+    disabled_at = CASE
+        WHEN $4::boolean IS NULL THEN disabled_at
+        WHEN $4::boolean THEN COALESCE(disabled_at, NOW())
+    END,
+    updated_at = NOW()
+WHERE id = $5
+RETURNING id, name, url, user_id, created_at, updated_at, last_fetched_at, site_url, last_error, error_count, disabled_at, visibility
+`
+
+type UpdateFeedParams struct {
+	Name       sql.NullString `json:"name"`
+	Url        sql.NullString `json:"url"`
+	Visibility sql.NullString `json:"visibility"`
+	Disabled   sql.NullBool   `json:"disabled"`
+	ID         uuid.UUID      `json:"id"`
+}
+
+// The name, the url, the visibility and the disabled state are only changed when given.
+// A new url is fetched at once, without the errors of the previous one.
+func (q *Queries) UpdateFeed(ctx context.Context, arg UpdateFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, updateFeed,
+		arg.Name,
+		arg.Url,
+		arg.Visibility,
+		arg.Disabled,
+		arg.ID,
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastFetchedAt,
+		&i.SiteUrl,
+		&i.LastError,
+		&i.ErrorCount,
+		&i.DisabledAt,
+		&i.Visibility,
+	)
+	return i, err
+}
@@ -2,8 +2,10 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
 	"sort"
 	"testing"
 	"time"
@@ -245,7 +247,7 @@ func TestQueries_MarkFeedFetched(t *testing.T) {
 	defer cancel()
 
 	start := time.Now()
-	err := testQueries.MarkFeedFetched(ctx, feed.ID)
+	err := testQueries.MarkFeedFetched(ctx, MarkFeedFetchedParams{ID: feed.ID})
 	require.NoError(t, err)
 
 	query := `
@@ -260,3 +262,240 @@ func TestQueries_MarkFeedFetched(t *testing.T) {
 	require.NotEmpty(t, lastFetchedAt)
 	require.True(t, lastFetchedAt.After(start))
 }
+
+func TestQueries_MarkFeedFetched_SiteURL(t *testing.T) {
+	feed := CreateRandomFeed(t)
+	require.Empty(t, feed.SiteUrl)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := testQueries.MarkFeedFetched(ctx, MarkFeedFetchedParams{ID: feed.ID, SiteUrl: "https://example.com"})
+	require.NoError(t, err)
+	actual, err := testQueries.GetFeed(ctx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", actual.SiteUrl)
+
+	// The site url is kept when the feed does not provide one.
+	err = testQueries.MarkFeedFetched(ctx, MarkFeedFetchedParams{ID: feed.ID})
+	require.NoError(t, err)
+	actual, err = testQueries.GetFeed(ctx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", actual.SiteUrl)
+}
+
+func TestQueries_GetFeedByURL(t *testing.T) {
+	feed := CreateRandomFeed(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+	actual, err := testQueries.GetFeedByURL(ctx, GetFeedByURLParams{Url: feed.Url, UserID: user.ID})
+	require.NoError(t, err)
+	assert.Equal(t, feed, actual)
+
+	_, err = testQueries.GetFeedByURL(ctx, GetFeedByURLParams{Url: "https://unknown.example.com/feed", UserID: user.ID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestQueries_GetFeedByURL_Private(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	creator := CreateRandomUser(t)
+	other := CreateRandomUser(t)
+	private, err := testQueries.CreateFeed(ctx, CreateFeedParams{
+		Name:       generator.RandomString(12),
+		Url:        generator.RandomURL(6),
+		UserID:     uuid.NullUUID{UUID: creator.ID, Valid: true},
+		Visibility: sql.NullString{String: "private", Valid: true},
+	})
+	require.NoError(t, err)
+
+	// The private feed is only found by the users who can follow it.
+	actual, err := testQueries.GetFeedByURL(ctx, GetFeedByURLParams{Url: private.Url, UserID: creator.ID})
+	require.NoError(t, err)
+	assert.Equal(t, private.ID, actual.ID)
+	_, err = testQueries.GetFeedByURL(ctx, GetFeedByURLParams{Url: private.Url, UserID: other.ID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Its url is not reserved, but a public feed reserves it for all the users.
+	public, err := testQueries.CreateFeed(ctx, CreateFeedParams{
+		Name:   generator.RandomString(12),
+		Url:    private.Url,
+		UserID: uuid.NullUUID{UUID: other.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	_, err = testQueries.CreateFeed(ctx, CreateFeedParams{
+		Name:   generator.RandomString(12),
+		Url:    private.Url,
+		UserID: uuid.NullUUID{UUID: creator.ID, Valid: true},
+	})
+	require.True(t, IsUniqueViolation(err))
+
+	// The public feed is found first.
+	actual, err = testQueries.GetFeedByURL(ctx, GetFeedByURLParams{Url: private.Url, UserID: creator.ID})
+	require.NoError(t, err)
+	assert.Equal(t, public.ID, actual.ID)
+}
+
+func TestQueries_MarkFeedFetchFailed(t *testing.T) {
+	feed := CreateRandomFeed(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		err := testQueries.MarkFeedFetchFailed(ctx, MarkFeedFetchFailedParams{ID: feed.ID, LastError: "unexpected Content-Type: text/html"})
+		require.NoError(t, err)
+	}
+	actual, err := testQueries.GetFeed(ctx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "unexpected Content-Type: text/html", actual.LastError)
+	assert.Equal(t, int32(3), actual.ErrorCount)
+	assert.True(t, actual.LastFetchedAt.Valid)
+
+	// The failing feeds are listed first.
+	feeds, err := testQueries.ListFeedsWithHealth(ctx, ListFeedsWithHealthParams{Limit: 100})
+	require.NoError(t, err)
+	idx := slices.IndexFunc(feeds, func(f ListFeedsWithHealthRow) bool { return f.Feed.ID == feed.ID })
+	require.NotEqual(t, -1, idx)
+	assert.Equal(t, "failing", feeds[idx].Status)
+	assert.Equal(t, int32(0), feeds[idx].Followers)
+
+	// A successful fetch clears the errors.
+	err = testQueries.MarkFeedFetched(ctx, MarkFeedFetchedParams{ID: feed.ID})
+	require.NoError(t, err)
+	actual, err = testQueries.GetFeed(ctx, feed.ID)
+	require.NoError(t, err)
+	assert.Empty(t, actual.LastError)
+	assert.Zero(t, actual.ErrorCount)
+}
+
+func TestQueries_SetFeedDisabled(t *testing.T) {
+	feed := CreateRandomFeed(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	disabled, err := testQueries.SetFeedDisabled(ctx, SetFeedDisabledParams{ID: feed.ID, Disabled: true})
+	require.NoError(t, err)
+	require.True(t, disabled.DisabledAt.Valid)
+
+	// A disabled feed is not fetched anymore.
+	feeds, err := testQueries.GetNextFeedsToFetch(ctx, 10000)
+	require.NoError(t, err)
+	assert.False(t, slices.ContainsFunc(feeds, func(f Feed) bool { return f.ID == feed.ID }))
+
+	enabled, err := testQueries.SetFeedDisabled(ctx, SetFeedDisabledParams{ID: feed.ID, Disabled: false})
+	require.NoError(t, err)
+	assert.False(t, enabled.DisabledAt.Valid)
+
+	_, err = testQueries.SetFeedDisabled(ctx, SetFeedDisabledParams{ID: uuid.New(), Disabled: true})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestQueries_ListFeedsByCreator(t *testing.T) {
+	feed := CreateRandomFeed(t)
+	CreateRandomFeed(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	feeds, err := testQueries.ListFeedsByCreator(ctx, feed.UserID)
+	require.NoError(t, err)
+	require.Len(t, feeds, 1)
+	assert.Equal(t, feed.ID, feeds[0].ID)
+}
+
+func TestQueries_GetManagedFeed(t *testing.T) {
+	feed := CreateRandomFeed(t)
+	other := CreateRandomUser(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	managed, err := testQueries.GetManagedFeed(ctx, GetManagedFeedParams{ID: feed.ID, UserID: feed.UserID.UUID})
+	require.NoError(t, err)
+	assert.Equal(t, feed.ID, managed.ID)
+
+	_, err = testQueries.GetManagedFeed(ctx, GetManagedFeedParams{ID: feed.ID, UserID: other.ID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// The admins manage all the feeds.
+	_, err = testQueries.SetUserRole(ctx, SetUserRoleParams{ID: other.ID, Role: "admin"})
+	require.NoError(t, err)
+	managed, err = testQueries.GetManagedFeed(ctx, GetManagedFeedParams{ID: feed.ID, UserID: other.ID})
+	require.NoError(t, err)
+	assert.Equal(t, feed.ID, managed.ID)
+}
+
+func TestQueries_UpdateFeed(t *testing.T) {
+	feed := CreateRandomFeed(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, testQueries.MarkFeedFetchFailed(ctx, MarkFeedFetchFailedParams{ID: feed.ID, LastError: "timeout"}))
+
+	// The fields not given are kept.
+	updated, err := testQueries.UpdateFeed(ctx, UpdateFeedParams{
+		ID:       feed.ID,
+		Name:     sql.NullString{String: "renamed", Valid: true},
+		Disabled: sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", updated.Name)
+	assert.Equal(t, feed.Url, updated.Url)
+	assert.True(t, updated.DisabledAt.Valid)
+	assert.True(t, updated.LastFetchedAt.Valid)
+	assert.Equal(t, int32(1), updated.ErrorCount)
+
+	// A new url is fetched again without the errors of the previous one.
+	newURL := generator.RandomURL(10)
+	updated, err = testQueries.UpdateFeed(ctx, UpdateFeedParams{
+		ID:  feed.ID,
+		Url: sql.NullString{String: newURL, Valid: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", updated.Name)
+	assert.Equal(t, newURL, updated.Url)
+	assert.True(t, updated.DisabledAt.Valid)
+	assert.False(t, updated.LastFetchedAt.Valid)
+	assert.Empty(t, updated.LastError)
+	assert.Zero(t, updated.ErrorCount)
+
+	updated, err = testQueries.UpdateFeed(ctx, UpdateFeedParams{
+		ID:       feed.ID,
+		Disabled: sql.NullBool{Bool: false, Valid: true},
+	})
+	require.NoError(t, err)
+	assert.False(t, updated.DisabledAt.Valid)
+}
+
+func TestQueries_ListFeeds_Visibility(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+	visible := make(map[uuid.UUID]bool)
+	for _, visibility := range []string{"public", "unlisted", "private"} {
+		feed, err := testQueries.CreateFeed(ctx, CreateFeedParams{
+			Name:       generator.RandomString(12),
+			Url:        generator.RandomURL(10),
+			UserID:     uuid.NullUUID{UUID: user.ID, Valid: true},
+			Visibility: sql.NullString{String: visibility, Valid: true},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, visibility, feed.Visibility)
+		visible[feed.ID] = visibility == "public"
+	}
+
+	// Only the public feeds are listed.
+	feeds, err := testQueries.ListFeeds(ctx, ListFeedsParams{Limit: 100})
+	require.NoError(t, err)
+	for id, public := range visible {
+		assert.Equal(t, public, slices.ContainsFunc(feeds, func(f Feed) bool { return f.ID == id }))
+	}
+}
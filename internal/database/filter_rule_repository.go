@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// FilterRuleRepository is responsible for managing the filter rules in the database.
+type FilterRuleRepository struct {
+	db      *sql.DB
+	queries *Queries
+}
+
+// NewFilterRuleRepository creates a new FilterRuleRepository.
+func NewFilterRuleRepository(db *sql.DB) FilterRuleRepository {
+	return FilterRuleRepository{
+		db:      db,
+		queries: New(db),
+	}
+}
+
+// CreateFilterRule creates a new filter rule.
+func (f FilterRuleRepository) CreateFilterRule(ctx context.Context, arg CreateFilterRuleParams) (FilterRule, error) {
+	rule, err := f.queries.CreateFilterRule(ctx, arg)
+	if err != nil {
+		return FilterRule{}, fmt.Errorf("error creating filter rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// ListFilterRules returns the filter rules of a user.
+func (f FilterRuleRepository) ListFilterRules(ctx context.Context, userID uuid.UUID) ([]FilterRule, error) {
+	rules, err := f.queries.ListFilterRules(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing filter rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// UpdateFilterRule updates a filter rule.
+func (f FilterRuleRepository) UpdateFilterRule(ctx context.Context, arg UpdateFilterRuleParams) (FilterRule, error) {
+	rule, err := f.queries.UpdateFilterRule(ctx, arg)
+	if err != nil {
+		return FilterRule{}, fmt.Errorf("error updating filter rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// DeleteFilterRule deletes a filter rule.
+func (f FilterRuleRepository) DeleteFilterRule(ctx context.Context, arg DeleteFilterRuleParams) error {
+	if err := f.queries.DeleteFilterRule(ctx, arg); err != nil {
+		return fmt.Errorf("error deleting filter rule: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.25.0
+// source: filter_rules.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createFilterRule = `-- name: CreateFilterRule :one
+INSERT INTO filter_rules (user_id, kind, action, pattern, feed_id, folder_id)
+SELECT $1::uuid, $2::varchar, $3::varchar, $4::text,
+    $5::uuid, $6::uuid
+WHERE (
+    $5::uuid IS NULL
+    OR EXISTS (SELECT 1 FROM feed_follows WHERE feed_follows.feed_id = $5 AND feed_follows.user_id = $1)
+)
+AND (
+    $6::uuid IS NULL
+    OR EXISTS (SELECT 1 FROM folders WHERE folders.id = $6 AND folders.user_id = $1)
+)
+RETURNING id, user_id, kind, action, pattern, feed_id, folder_id, created_at, updated_at
+`
+
+type CreateFilterRuleParams struct {
+	UserID   uuid.UUID     `json:"user_id"`
+	Kind     string        `json:"kind"`
+	Action   string        `json:"action"`
+	Pattern  string        `json:"pattern"`
+	FeedID   uuid.NullUUID `json:"feed_id"`
+	FolderID uuid.NullUUID `json:"folder_id"`
+}
+
+// The feed must be followed by the user and the folder must belong to the user.
+func (q *Queries) CreateFilterRule(ctx context.Context, arg CreateFilterRuleParams) (FilterRule, error) {
+	row := q.db.QueryRowContext(ctx, createFilterRule,
+		arg.UserID,
+		arg.Kind,
+		arg.Action,
+		arg.Pattern,
+		arg.FeedID,
+		arg.FolderID,
+	)
+	var i FilterRule
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Kind,
+		&i.Action,
+		&i.Pattern,
+		&i.FeedID,
+		&i.FolderID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteFilterRule = `-- name: DeleteFilterRule :exec
+DELETE FROM filter_rules
+WHERE id = $1
+AND user_id = $2
+`
+
+type DeleteFilterRuleParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) DeleteFilterRule(ctx context.Context, arg DeleteFilterRuleParams) error {
+	_, err := q.db.ExecContext(ctx, deleteFilterRule, arg.ID, arg.UserID)
+	return err
+}
+
+const listFilterRules = `-- name: ListFilterRules :many
+SELECT id, user_id, kind, action, pattern, feed_id, folder_id, created_at, updated_at FROM filter_rules
+WHERE user_id = $1
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListFilterRules(ctx context.Context, userID uuid.UUID) ([]FilterRule, error) {
+	rows, err := q.db.QueryContext(ctx, listFilterRules, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FilterRule{}
+	for rows.Next() {
+		var i FilterRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Kind,
+			&i.Action,
+			&i.Pattern,
+			&i.FeedID,
+			&i.FolderID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateFilterRule = `-- name: UpdateFilterRule :one
+UPDATE filter_rules
+SET kind = $1,
+    action = $2,
+    pattern = $3,
+    feed_id = $4,
+    folder_id = $5,
+    updated_at = NOW()
+WHERE filter_rules.id = $6
+AND filter_rules.user_id = $7
+AND (
+    $4::uuid IS NULL
+    OR EXISTS (SELECT 1 FROM feed_follows WHERE feed_follows.feed_id = $4 AND feed_follows.user_id = $7)
+)
+AND (
+    $5::uuid IS NULL
+    OR EXISTS (SELECT 1 FROM folders WHERE folders.id = $5 AND folders.user_id = $7)
+)
+RETURNING id, user_id, kind, action, pattern, feed_id, folder_id, created_at, updated_at
+`
+
+type UpdateFilterRuleParams struct {
+	Kind     string        `json:"kind"`
+	Action   string        `json:"action"`
+	Pattern  string        `json:"pattern"`
+	FeedID   uuid.NullUUID `json:"feed_id"`
+	FolderID uuid.NullUUID `json:"folder_id"`
+	ID       uuid.UUID     `json:"id"`
+	UserID   uuid.UUID     `json:"user_id"`
+}
+
+// The feed must be followed by the user and the folder must belong to the user.
+func (q *Queries) UpdateFilterRule(ctx context.Context, arg UpdateFilterRuleParams) (FilterRule, error) {
+	row := q.db.QueryRowContext(ctx, updateFilterRule,
+		arg.Kind,
+		arg.Action,
+		arg.Pattern,
+		arg.FeedID,
+		arg.FolderID,
+		arg.ID,
+		arg.UserID,
+	)
+	var i FilterRule
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Kind,
+		&i.Action,
+		&i.Pattern,
+		&i.FeedID,
+		&i.FolderID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
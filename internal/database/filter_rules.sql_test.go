@@ -0,0 +1,223 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueries_CreateFilterRule(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+	params := CreateFilterRuleParams{
+		UserID:  user.ID,
+		Kind:    "keyword",
+		Action:  "exclude",
+		Pattern: "sponsored",
+	}
+	rule, err := testQueries.CreateFilterRule(ctx, params)
+	require.NoError(t, err)
+	require.NotEmpty(t, rule.ID)
+	assert.Equal(t, params.UserID, rule.UserID)
+	assert.Equal(t, params.Kind, rule.Kind)
+	assert.Equal(t, params.Action, rule.Action)
+	assert.Equal(t, params.Pattern, rule.Pattern)
+	assert.False(t, rule.FeedID.Valid)
+	assert.False(t, rule.FolderID.Valid)
+
+	rules, err := testQueries.ListFilterRules(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, rule.ID, rules[0].ID)
+}
+
+func TestQueries_CreateFilterRule_InvalidRegex(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+	_, err := testQueries.CreateFilterRule(ctx, CreateFilterRuleParams{
+		UserID:  user.ID,
+		Kind:    "regex",
+		Action:  "exclude",
+		Pattern: "(unclosed",
+	})
+	require.Error(t, err)
+	assert.True(t, IsInvalidRegularExpression(err))
+}
+
+func TestQueries_CreateFilterRule_Scope(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+	other := CreateRandomUser(t)
+	feed := CreateRandomFeed(t)
+	folder, err := testQueries.CreateFolder(ctx, CreateFolderParams{UserID: other.ID, Name: generator.RandomString(10)})
+	require.NoError(t, err)
+
+	// The feed is not followed by the user.
+	_, err = testQueries.CreateFilterRule(ctx, CreateFilterRuleParams{
+		UserID:  user.ID,
+		Kind:    "keyword",
+		Action:  "exclude",
+		Pattern: "sponsored",
+		FeedID:  uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// The folder belongs to another user.
+	_, err = testQueries.CreateFilterRule(ctx, CreateFilterRuleParams{
+		UserID:   user.ID,
+		Kind:     "keyword",
+		Action:   "exclude",
+		Pattern:  "sponsored",
+		FolderID: uuid.NullUUID{UUID: folder.ID, Valid: true},
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = testQueries.CreateFeedFollows(ctx, CreateFeedFollowsParams{
+		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	rule, err := testQueries.CreateFilterRule(ctx, CreateFilterRuleParams{
+		UserID:  user.ID,
+		Kind:    "keyword",
+		Action:  "exclude",
+		Pattern: "sponsored",
+		FeedID:  uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, feed.ID, rule.FeedID.UUID)
+
+	_, err = testQueries.UpdateFilterRule(ctx, UpdateFilterRuleParams{
+		ID:       rule.ID,
+		UserID:   user.ID,
+		Kind:     "keyword",
+		Action:   "exclude",
+		Pattern:  "sponsored",
+		FolderID: uuid.NullUUID{UUID: folder.ID, Valid: true},
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestQueries_UpdateFilterRule(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+	rule, err := testQueries.CreateFilterRule(ctx, CreateFilterRuleParams{
+		UserID:  user.ID,
+		Kind:    "keyword",
+		Action:  "exclude",
+		Pattern: "sponsored",
+	})
+	require.NoError(t, err)
+
+	updated, err := testQueries.UpdateFilterRule(ctx, UpdateFilterRuleParams{
+		ID:      rule.ID,
+		UserID:  user.ID,
+		Kind:    "author",
+		Action:  "include",
+		Pattern: "jane",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, rule.ID, updated.ID)
+	assert.Equal(t, "author", updated.Kind)
+	assert.Equal(t, "include", updated.Action)
+	assert.Equal(t, "jane", updated.Pattern)
+
+	err = testQueries.DeleteFilterRule(ctx, DeleteFilterRuleParams{ID: rule.ID, UserID: user.ID})
+	require.NoError(t, err)
+
+	rules, err := testQueries.ListFilterRules(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+}
+
+func TestQueries_GetPostsByUser_FilterRules(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	feed := CreateRandomFeed(t)
+	user := feed.UserID
+	_, err := testQueries.CreateFeedFollows(ctx, CreateFeedFollowsParams{
+		UserID: user,
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	createPost := func(title, author string, categories ...string) Post {
+		post, err := testQueries.CreatePost(ctx, CreatePostParams{
+			Title:       title,
+			Url:         generator.RandomURL(8),
+			Description: generator.RandomString(20),
+			PublishedAt: time.Now().UTC(),
+			FeedID:      uuid.NullUUID{UUID: feed.ID, Valid: true},
+			Author:      author,
+			Categories:  categories,
+		})
+		require.NoError(t, err)
+		return post
+	}
+	goPost := createPost("Go 1.22 is released", "Jane", "go")
+	sponsoredPost := createPost("SPONSORED: buy this", "Ads", "go")
+	rustPost := createPost("Rust news", "John", "rust")
+
+	timeline := func() []int32 {
+		posts, err := testQueries.GetPostsByUser(ctx, GetPostsByUserParams{UserID: user, Limit: 10})
+		require.NoError(t, err)
+		var ids []int32
+		for _, post := range posts {
+			ids = append(ids, post.ID)
+		}
+		return ids
+	}
+	assert.ElementsMatch(t, []int32{goPost.ID, sponsoredPost.ID, rustPost.ID}, timeline())
+
+	// An exclude rule hides the matching posts, case-insensitively.
+	_, err = testQueries.CreateFilterRule(ctx, CreateFilterRuleParams{
+		UserID:  user.UUID,
+		Kind:    "keyword",
+		Action:  "exclude",
+		Pattern: "sponsored",
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int32{goPost.ID, rustPost.ID}, timeline())
+
+	// An include rule keeps only the matching posts.
+	_, err = testQueries.CreateFilterRule(ctx, CreateFilterRuleParams{
+		UserID:  user.UUID,
+		Kind:    "category",
+		Action:  "include",
+		Pattern: "Go",
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int32{goPost.ID}, timeline())
+
+	// A rule scoped to another feed does not apply.
+	otherFeed := CreateRandomFeed(t)
+	_, err = testQueries.CreateFeedFollows(ctx, CreateFeedFollowsParams{
+		UserID: user,
+		FeedID: uuid.NullUUID{UUID: otherFeed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	_, err = testQueries.CreateFilterRule(ctx, CreateFilterRuleParams{
+		UserID:  user.UUID,
+		Kind:    "regex",
+		Action:  "exclude",
+		Pattern: "^go [0-9.]+",
+		FeedID:  uuid.NullUUID{UUID: otherFeed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int32{goPost.ID}, timeline())
+}
@@ -0,0 +1,166 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.25.0
+// source: folders.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createFolder = `-- name: CreateFolder :one
+INSERT INTO folders (user_id, name, position)
+VALUES ($1, $2, $3)
+RETURNING id, user_id, name, position, created_at, updated_at
+`
+
+type CreateFolderParams struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Name     string    `json:"name"`
+	Position int32     `json:"position"`
+}
+
+func (q *Queries) CreateFolder(ctx context.Context, arg CreateFolderParams) (Folder, error) {
+	row := q.db.QueryRowContext(ctx, createFolder, arg.UserID, arg.Name, arg.Position)
+	var i Folder
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Position,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteFolder = `-- name: DeleteFolder :exec
+DELETE FROM folders
+WHERE id = $1
+AND user_id = $2
+`
+
+type DeleteFolderParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) DeleteFolder(ctx context.Context, arg DeleteFolderParams) error {
+	_, err := q.db.ExecContext(ctx, deleteFolder, arg.ID, arg.UserID)
+	return err
+}
+
+const getNextFolderPosition = `-- name: GetNextFolderPosition :one
+SELECT COALESCE(MAX(position) + 1, 0)::integer AS position
+FROM folders
+WHERE user_id = $1
+`
+
+// Returns the position after the last folder of the user.
+func (q *Queries) GetNextFolderPosition(ctx context.Context, userID uuid.UUID) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getNextFolderPosition, userID)
+	var position int32
+	err := row.Scan(&position)
+	return position, err
+}
+
+const getOrCreateFolder = `-- name: GetOrCreateFolder :one
+INSERT INTO folders (user_id, name, position)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, name) DO UPDATE SET name = EXCLUDED.name
+RETURNING id, user_id, name, position, created_at, updated_at
+`
+
+type GetOrCreateFolderParams struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Name     string    `json:"name"`
+	Position int32     `json:"position"`
+}
+
+// An existing folder with the same name is returned unchanged.
+func (q *Queries) GetOrCreateFolder(ctx context.Context, arg GetOrCreateFolderParams) (Folder, error) {
+	row := q.db.QueryRowContext(ctx, getOrCreateFolder, arg.UserID, arg.Name, arg.Position)
+	var i Folder
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Position,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listFolders = `-- name: ListFolders :many
+SELECT id, user_id, name, position, created_at, updated_at FROM folders
+WHERE user_id = $1
+ORDER BY position ASC, name ASC
+`
+
+func (q *Queries) ListFolders(ctx context.Context, userID uuid.UUID) ([]Folder, error) {
+	rows, err := q.db.QueryContext(ctx, listFolders, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Folder{}
+	for rows.Next() {
+		var i Folder
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Position,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateFolder = `-- name: UpdateFolder :one
+UPDATE folders
+SET name = $3, position = $4, updated_at = NOW()
+WHERE id = $1
+AND user_id = $2
+RETURNING id, user_id, name, position, created_at, updated_at
+`
+
+type UpdateFolderParams struct {
+	ID       uuid.UUID `json:"id"`
+	UserID   uuid.UUID `json:"user_id"`
+	Name     string    `json:"name"`
+	Position int32     `json:"position"`
+}
+
+func (q *Queries) UpdateFolder(ctx context.Context, arg UpdateFolderParams) (Folder, error) {
+	row := q.db.QueryRowContext(ctx, updateFolder,
+		arg.ID,
+		arg.UserID,
+		arg.Name,
+		arg.Position,
+	)
+	var i Folder
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Position,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func CreateRandomFolder(t *testing.T, userID uuid.UUID) Folder {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	params := CreateFolderParams{
+		UserID:   userID,
+		Name:     generator.RandomString(8),
+		Position: 1,
+	}
+	folder, err := testQueries.CreateFolder(ctx, params)
+	require.NoError(t, err)
+	require.NotEmpty(t, folder.ID)
+	assert.Equal(t, params.UserID, folder.UserID)
+	assert.Equal(t, params.Name, folder.Name)
+	assert.Equal(t, params.Position, folder.Position)
+	assert.NotEmpty(t, folder.CreatedAt)
+	assert.NotEmpty(t, folder.UpdatedAt)
+
+	return folder
+}
+
+func TestQueries_CreateFolder(t *testing.T) {
+	user := CreateRandomUser(t)
+	folder := CreateRandomFolder(t, user.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The folder names are unique per user.
+	_, err := testQueries.CreateFolder(ctx, CreateFolderParams{
+		UserID: user.ID,
+		Name:   folder.Name,
+	})
+	require.Error(t, err)
+	assert.True(t, IsUniqueViolation(err))
+}
+
+func TestQueries_ListFolders(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+	second, err := testQueries.CreateFolder(ctx, CreateFolderParams{UserID: user.ID, Name: "b", Position: 2})
+	require.NoError(t, err)
+	first, err := testQueries.CreateFolder(ctx, CreateFolderParams{UserID: user.ID, Name: "a", Position: 1})
+	require.NoError(t, err)
+	CreateRandomFolder(t, CreateRandomUser(t).ID)
+
+	folders, err := testQueries.ListFolders(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, folders, 2)
+	assert.Equal(t, first.ID, folders[0].ID)
+	assert.Equal(t, second.ID, folders[1].ID)
+}
+
+func TestQueries_UpdateFolder(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+	folder := CreateRandomFolder(t, user.ID)
+
+	updated, err := testQueries.UpdateFolder(ctx, UpdateFolderParams{
+		ID:       folder.ID,
+		UserID:   user.ID,
+		Name:     "renamed",
+		Position: 5,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, folder.ID, updated.ID)
+	assert.Equal(t, "renamed", updated.Name)
+	assert.Equal(t, int32(5), updated.Position)
+
+	// Another user cannot update the folder.
+	_, err = testQueries.UpdateFolder(ctx, UpdateFolderParams{
+		ID:     folder.ID,
+		UserID: uuid.New(),
+		Name:   "stolen",
+	})
+	require.Error(t, err)
+}
+
+func TestQueries_DeleteFolder_KeepsFeedFollows(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	feed := CreateRandomFeed(t)
+	follow, err := testQueries.CreateFeedFollows(ctx, CreateFeedFollowsParams{
+		UserID: feed.UserID,
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	folder := CreateRandomFolder(t, feed.UserID.UUID)
+	follow, err = testQueries.UpdateFeedFollows(ctx, UpdateFeedFollowsParams{
+		ID:          follow.ID,
+		UserID:      feed.UserID,
+		SetFolderID: true,
+		FolderID:    uuid.NullUUID{UUID: folder.ID, Valid: true},
+		Position:    sql.NullInt32{Int32: 3, Valid: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, folder.ID, follow.FolderID.UUID)
+	assert.Equal(t, int32(3), follow.Position)
+
+	err = testQueries.DeleteFolder(ctx, DeleteFolderParams{ID: folder.ID, UserID: feed.UserID.UUID})
+	require.NoError(t, err)
+
+	follows, err := testQueries.ListFeedFollows(ctx, ListFeedFollowsParams{
+		UserID: feed.UserID,
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, follows, 1)
+	assert.Equal(t, follow.ID, follows[0].ID)
+	assert.False(t, follows[0].FolderID.Valid)
+}
+
+func TestQueries_GetOrCreateFolder(t *testing.T) {
+	user := CreateRandomUser(t)
+	folder := CreateRandomFolder(t, user.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The existing folder is returned unchanged.
+	actual, err := testQueries.GetOrCreateFolder(ctx, GetOrCreateFolderParams{
+		UserID:   user.ID,
+		Name:     folder.Name,
+		Position: 5,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, folder, actual)
+
+	created, err := testQueries.GetOrCreateFolder(ctx, GetOrCreateFolderParams{
+		UserID:   user.ID,
+		Name:     generator.RandomString(8),
+		Position: 5,
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, folder.ID, created.ID)
+	assert.Equal(t, int32(5), created.Position)
+}
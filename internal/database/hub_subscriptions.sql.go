@@ -0,0 +1,169 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.25.0
+// source: hub_subscriptions.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const deleteExpiredHubSubscriptions = `-- name: DeleteExpiredHubSubscriptions :execrows
+DELETE FROM hub_subscriptions
+WHERE lease_expires_at <= $1::timestamptz
+`
+
+func (q *Queries) DeleteExpiredHubSubscriptions(ctx context.Context, expiredAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredHubSubscriptions, expiredAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteHubSubscription = `-- name: DeleteHubSubscription :exec
+DELETE FROM hub_subscriptions
+WHERE topic_url = $1
+AND callback_url = $2
+`
+
+type DeleteHubSubscriptionParams struct {
+	TopicUrl    string `json:"topic_url"`
+	CallbackUrl string `json:"callback_url"`
+}
+
+func (q *Queries) DeleteHubSubscription(ctx context.Context, arg DeleteHubSubscriptionParams) error {
+	_, err := q.db.ExecContext(ctx, deleteHubSubscription, arg.TopicUrl, arg.CallbackUrl)
+	return err
+}
+
+const deleteUserHubSubscriptions = `-- name: DeleteUserHubSubscriptions :exec
+DELETE FROM hub_subscriptions WHERE user_id = $1
+`
+
+// The subscriptions to the timeline of a user are authenticated by its feed token.
+func (q *Queries) DeleteUserHubSubscriptions(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUserHubSubscriptions, userID)
+	return err
+}
+
+const listHubSubscriptionsForPost = `-- name: ListHubSubscriptionsForPost :many
+SELECT hs.id, hs.user_id, hs.topic_url, hs.format, hs.feed_id, hs.folder_id, hs.callback_url, hs.secret, hs.lease_expires_at, hs.created_at, hs.updated_at, u.id, u.name, u.created_at, u.updated_at, u.feed_token, u.email, u.password_hash, u.role, u.suspended_at
+FROM hub_subscriptions hs
+    JOIN users u ON u.id = hs.user_id
+    JOIN feed_follows ff ON ff.user_id = hs.user_id
+    JOIN posts p ON p.feed_id = ff.feed_id
+WHERE p.id = $1
+AND hs.lease_expires_at > NOW()
+AND (hs.feed_id IS NULL OR hs.feed_id = p.feed_id)
+AND (hs.folder_id IS NULL OR hs.folder_id = ff.folder_id)
+AND (NOT ff.muted OR hs.feed_id IS NOT NULL OR hs.folder_id IS NOT NULL)
+AND (ff.retention_days IS NULL OR p.published_at > NOW() - make_interval(days => ff.retention_days))
+AND post_passes_filters(ff.user_id, ff.folder_id, p)
+`
+
+type ListHubSubscriptionsForPostRow struct {
+	HubSubscription HubSubscription `json:"hub_subscription"`
+	User            User            `json:"user"`
+}
+
+// Returns the subscriptions whose timeline contains the post, with the owner of the timeline.
+// Like the timeline, the muted feeds are only part of the timelines restricted to a feed or a folder,
+// the posts older than the retention of the feed follow are skipped and the filter rules of the user are applied.
+func (q *Queries) ListHubSubscriptionsForPost(ctx context.Context, postID int32) ([]ListHubSubscriptionsForPostRow, error) {
+	rows, err := q.db.QueryContext(ctx, listHubSubscriptionsForPost, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListHubSubscriptionsForPostRow{}
+	for rows.Next() {
+		var i ListHubSubscriptionsForPostRow
+		if err := rows.Scan(
+			&i.HubSubscription.ID,
+			&i.HubSubscription.UserID,
+			&i.HubSubscription.TopicUrl,
+			&i.HubSubscription.Format,
+			&i.HubSubscription.FeedID,
+			&i.HubSubscription.FolderID,
+			&i.HubSubscription.CallbackUrl,
+			&i.HubSubscription.Secret,
+			&i.HubSubscription.LeaseExpiresAt,
+			&i.HubSubscription.CreatedAt,
+			&i.HubSubscription.UpdatedAt,
+			&i.User.ID,
+			&i.User.Name,
+			&i.User.CreatedAt,
+			&i.User.UpdatedAt,
+			&i.User.FeedToken,
+			&i.User.Email,
+			&i.User.PasswordHash,
+			&i.User.Role,
+			&i.User.SuspendedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertHubSubscription = `-- name: UpsertHubSubscription :one
+INSERT INTO hub_subscriptions (user_id, topic_url, format, feed_id, folder_id, callback_url, secret, lease_expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (topic_url, callback_url) DO UPDATE
+SET secret = EXCLUDED.secret,
+    lease_expires_at = EXCLUDED.lease_expires_at,
+    updated_at = NOW()
+RETURNING id, user_id, topic_url, format, feed_id, folder_id, callback_url, secret, lease_expires_at, created_at, updated_at
+`
+
+type UpsertHubSubscriptionParams struct {
+	UserID         uuid.UUID     `json:"user_id"`
+	TopicUrl       string        `json:"topic_url"`
+	Format         string        `json:"format"`
+	FeedID         uuid.NullUUID `json:"feed_id"`
+	FolderID       uuid.NullUUID `json:"folder_id"`
+	CallbackUrl    string        `json:"callback_url"`
+	Secret         string        `json:"-"`
+	LeaseExpiresAt time.Time     `json:"lease_expires_at"`
+}
+
+// A subscription is renewed when the callback subscribes again to the topic.
+func (q *Queries) UpsertHubSubscription(ctx context.Context, arg UpsertHubSubscriptionParams) (HubSubscription, error) {
+	row := q.db.QueryRowContext(ctx, upsertHubSubscription,
+		arg.UserID,
+		arg.TopicUrl,
+		arg.Format,
+		arg.FeedID,
+		arg.FolderID,
+		arg.CallbackUrl,
+		arg.Secret,
+		arg.LeaseExpiresAt,
+	)
+	var i HubSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TopicUrl,
+		&i.Format,
+		&i.FeedID,
+		&i.FolderID,
+		&i.CallbackUrl,
+		&i.Secret,
+		&i.LeaseExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
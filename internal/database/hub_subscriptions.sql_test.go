@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueries_HubSubscription(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	follow, post := createRandomFollowedPost(t)
+	topicURL := "https://rss.example.com/v1/users/" + follow.UserID.UUID.String() + "/feed.atom?token=" + uuid.NewString()
+	sub, err := testQueries.UpsertHubSubscription(ctx, UpsertHubSubscriptionParams{
+		UserID:         follow.UserID.UUID,
+		TopicUrl:       topicURL,
+		Format:         "atom",
+		CallbackUrl:    "https://reader.example.com/callback",
+		Secret:         "secret",
+		LeaseExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// Subscribing again renews the lease of the subscription.
+	renewed, err := testQueries.UpsertHubSubscription(ctx, UpsertHubSubscriptionParams{
+		UserID:         follow.UserID.UUID,
+		TopicUrl:       topicURL,
+		Format:         "atom",
+		CallbackUrl:    "https://reader.example.com/callback",
+		LeaseExpiresAt: time.Now().Add(2 * time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, sub.ID, renewed.ID)
+	assert.Empty(t, renewed.Secret)
+	assert.True(t, renewed.LeaseExpiresAt.After(sub.LeaseExpiresAt))
+
+	rows, err := testQueries.ListHubSubscriptionsForPost(ctx, post.ID)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, sub.ID, rows[0].HubSubscription.ID)
+	assert.Equal(t, follow.UserID.UUID, rows[0].User.ID)
+
+	// A timeline restricted to another feed does not contain the post.
+	other := CreateRandomFeed(t)
+	_, err = testQueries.UpsertHubSubscription(ctx, UpsertHubSubscriptionParams{
+		UserID:         follow.UserID.UUID,
+		TopicUrl:       topicURL + "&feed_id=" + other.ID.String(),
+		Format:         "atom",
+		FeedID:         uuid.NullUUID{UUID: other.ID, Valid: true},
+		CallbackUrl:    "https://reader.example.com/callback",
+		LeaseExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	rows, err = testQueries.ListHubSubscriptionsForPost(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Len(t, rows, 1)
+
+	// The expired subscriptions are not pushed the posts, then purged.
+	count, err := testQueries.DeleteExpiredHubSubscriptions(ctx, time.Now().Add(3*time.Hour))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, count, int64(2))
+	rows, err = testQueries.ListHubSubscriptionsForPost(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+
+	require.NoError(t, testQueries.DeleteHubSubscription(ctx, DeleteHubSubscriptionParams{
+		TopicUrl:    topicURL,
+		CallbackUrl: "https://reader.example.com/callback",
+	}))
+}
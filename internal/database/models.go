@@ -6,11 +6,46 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         uuid.UUID    `json:"id"`
+	UserID     uuid.UUID    `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	Hash       string       `json:"-"`
+	FeverKey   string       `json:"-"`
+	Scopes     []string     `json:"scopes"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
+type DigestItem struct {
+	UserID uuid.UUID `json:"user_id"`
+	PostID int32     `json:"post_id"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+type DigestSetting struct {
+	UserID     uuid.UUID    `json:"user_id"`
+	Email      string       `json:"email"`
+	Frequency  string       `json:"frequency"`
+	Hour       int32        `json:"hour"`
+	Weekday    int32        `json:"weekday"`
+	Timezone   string       `json:"timezone"`
+	Enabled    bool         `json:"enabled"`
+	NextSendAt time.Time    `json:"next_send_at"`
+	LastSentAt sql.NullTime `json:"last_sent_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
 type Feed struct {
 	ID            uuid.UUID     `json:"id"`
 	Name          string        `json:"name"`
@@ -19,16 +54,68 @@ type Feed struct {
 	CreatedAt     time.Time     `json:"created_at"`
 	UpdatedAt     time.Time     `json:"updated_at"`
 	LastFetchedAt sql.NullTime  `json:"last_fetched_at"`
+	SiteUrl       string        `json:"site_url"`
+	LastError     string        `json:"last_error"`
+	ErrorCount    int32         `json:"error_count"`
+	DisabledAt    sql.NullTime  `json:"disabled_at"`
+	Visibility    string        `json:"visibility"`
 }
 
 type FeedFollow struct {
+	ID            uuid.UUID      `json:"id"`
+	FeedID        uuid.NullUUID  `json:"feed_id"`
+	UserID        uuid.NullUUID  `json:"user_id"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	FolderID      uuid.NullUUID  `json:"folder_id"`
+	Position      int32          `json:"position"`
+	Title         sql.NullString `json:"title"`
+	Muted         bool           `json:"muted"`
+	Notify        bool           `json:"notify"`
+	RetentionDays sql.NullInt32  `json:"retention_days"`
+}
+
+type FeedShare struct {
+	FeedID    uuid.UUID `json:"feed_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FilterRule struct {
 	ID        uuid.UUID     `json:"id"`
+	UserID    uuid.UUID     `json:"user_id"`
+	Kind      string        `json:"kind"`
+	Action    string        `json:"action"`
+	Pattern   string        `json:"pattern"`
 	FeedID    uuid.NullUUID `json:"feed_id"`
-	UserID    uuid.NullUUID `json:"user_id"`
+	FolderID  uuid.NullUUID `json:"folder_id"`
 	CreatedAt time.Time     `json:"created_at"`
 	UpdatedAt time.Time     `json:"updated_at"`
 }
 
+type Folder struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+	Position  int32     `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type HubSubscription struct {
+	ID             uuid.UUID     `json:"id"`
+	UserID         uuid.UUID     `json:"user_id"`
+	TopicUrl       string        `json:"topic_url"`
+	Format         string        `json:"format"`
+	FeedID         uuid.NullUUID `json:"feed_id"`
+	FolderID       uuid.NullUUID `json:"folder_id"`
+	CallbackUrl    string        `json:"callback_url"`
+	Secret         string        `json:"-"`
+	LeaseExpiresAt time.Time     `json:"lease_expires_at"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
 type Post struct {
 	ID          int32         `json:"id"`
 	Title       string        `json:"title"`
@@ -38,12 +125,107 @@ type Post struct {
 	FeedID      uuid.NullUUID `json:"feed_id"`
 	CreatedAt   time.Time     `json:"created_at"`
 	UpdatedAt   time.Time     `json:"updated_at"`
+	Author      string        `json:"author"`
+	Categories  []string      `json:"categories"`
+}
+
+type PostRead struct {
+	UserID    uuid.UUID `json:"user_id"`
+	PostID    int32     `json:"post_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RefreshToken struct {
+	ID        uuid.UUID     `json:"id"`
+	UserID    uuid.UUID     `json:"user_id"`
+	ApiKeyID  uuid.NullUUID `json:"api_key_id"`
+	SessionID uuid.NullUUID `json:"session_id"`
+	TokenHash string        `json:"-"`
+	Scopes    []string      `json:"scopes"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type Session struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	TokenHash string    `json:"-"`
+	CsrfToken string    `json:"csrf_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type StarredPost struct {
+	ID          uuid.UUID     `json:"id"`
+	UserID      uuid.UUID     `json:"user_id"`
+	PostID      sql.NullInt32 `json:"post_id"`
+	FeedID      uuid.NullUUID `json:"feed_id"`
+	Title       string        `json:"title"`
+	Url         string        `json:"url"`
+	Description string        `json:"description"`
+	PublishedAt time.Time     `json:"published_at"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
 }
 
 type User struct {
+	ID           uuid.UUID    `json:"id"`
+	Name         string       `json:"name"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+	FeedToken    string       `json:"feed_token"`
+	Email        string       `json:"email"`
+	PasswordHash string       `json:"-"`
+	Role         string       `json:"role"`
+	SuspendedAt  sql.NullTime `json:"suspended_at"`
+}
+
+type UserIdentity struct {
 	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
+	UserID    uuid.UUID `json:"user_id"`
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-	ApiKey    string    `json:"api_key"`
+}
+
+type WebSubSubscription struct {
+	FeedID         uuid.UUID    `json:"feed_id"`
+	HubUrl         string       `json:"hub_url"`
+	TopicUrl       string       `json:"topic_url"`
+	Secret         string       `json:"-"`
+	State          string       `json:"state"`
+	LeaseExpiresAt sql.NullTime `json:"lease_expires_at"`
+	RequestedAt    time.Time    `json:"requested_at"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+type Webhook struct {
+	ID           uuid.UUID     `json:"id"`
+	UserID       uuid.UUID     `json:"user_id"`
+	Url          string        `json:"url"`
+	Secret       string        `json:"-"`
+	FeedID       uuid.NullUUID `json:"feed_id"`
+	FolderID     uuid.NullUUID `json:"folder_id"`
+	ApplyFilters bool          `json:"apply_filters"`
+	Active       bool          `json:"active"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"id"`
+	WebhookID      uuid.UUID       `json:"webhook_id"`
+	PostID         sql.NullInt32   `json:"post_id"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	ResponseStatus sql.NullInt32   `json:"response_status"`
+	LastError      sql.NullString  `json:"last_error"`
+	DeliveredAt    sql.NullTime    `json:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
 }
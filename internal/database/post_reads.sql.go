@@ -0,0 +1,353 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.25.0
+// source: post_reads.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const countPosts = `-- name: CountPosts :one
+SELECT COUNT(p.id)::integer
+FROM posts p
+    LEFT JOIN feed_follows ff ON ff.feed_id = p.feed_id AND ff.user_id = $1::uuid
+    LEFT JOIN starred_posts sp ON sp.post_id = p.id AND sp.user_id = $1::uuid
+WHERE (ff.id IS NOT NULL
+        AND (ff.retention_days IS NULL OR p.published_at > NOW() - make_interval(days => ff.retention_days))
+        AND post_passes_filters(ff.user_id, ff.folder_id, p))
+    OR sp.id IS NOT NULL
+`
+
+// Counts the posts listed by ListPostsWithStatus without restriction:
+// the visible posts of the followed feeds and the starred posts.
+func (q *Queries) CountPosts(ctx context.Context, userID uuid.UUID) (int32, error) {
+	row := q.db.QueryRowContext(ctx, countPosts, userID)
+	var column_1 int32
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const countUnreadPosts = `-- name: CountUnreadPosts :many
+SELECT ff.feed_id, ff.folder_id, COUNT(p.id)::integer AS unread
+FROM feed_follows ff
+    JOIN posts p ON p.feed_id = ff.feed_id
+    LEFT JOIN post_reads pr ON pr.post_id = p.id AND pr.user_id = ff.user_id
+WHERE ff.user_id = $1
+AND pr.post_id IS NULL
+AND (ff.retention_days IS NULL OR p.published_at > NOW() - make_interval(days => ff.retention_days))
+AND post_passes_filters(ff.user_id, ff.folder_id, p)
+GROUP BY ff.feed_id, ff.folder_id
+ORDER BY ff.feed_id
+`
+
+type CountUnreadPostsRow struct {
+	FeedID   uuid.NullUUID `json:"feed_id"`
+	FolderID uuid.NullUUID `json:"folder_id"`
+	Unread   int32         `json:"unread"`
+}
+
+// Counts the unread posts of each followed feed,
+// the posts hidden by the retention or the filter rules are not counted.
+func (q *Queries) CountUnreadPosts(ctx context.Context, userID uuid.NullUUID) ([]CountUnreadPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, countUnreadPosts, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountUnreadPostsRow{}
+	for rows.Next() {
+		var i CountUnreadPostsRow
+		if err := rows.Scan(&i.FeedID, &i.FolderID, &i.Unread); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsWithStatus = `-- name: ListPostsWithStatus :many
+SELECT p.id, p.title, p.url, p.description, p.published_at, p.feed_id, p.created_at, p.updated_at, p.author, p.categories,
+    (pr.post_id IS NOT NULL)::boolean AS is_read,
+    (sp.id IS NOT NULL)::boolean AS is_starred
+FROM posts p
+    LEFT JOIN feed_follows ff ON ff.feed_id = p.feed_id AND ff.user_id = $1::uuid
+    LEFT JOIN starred_posts sp ON sp.post_id = p.id AND sp.user_id = $1::uuid
+    LEFT JOIN post_reads pr ON pr.post_id = p.id AND pr.user_id = $1::uuid
+    CROSS JOIN LATERAL (
+        SELECT ff.id IS NOT NULL
+            AND (ff.retention_days IS NULL OR p.published_at > NOW() - make_interval(days => ff.retention_days))
+            AND post_passes_filters(ff.user_id, ff.folder_id, p) AS visible
+    ) v
+WHERE (v.visible OR sp.id IS NOT NULL)
+AND (NOT $2::boolean OR v.visible)
+AND ($3::integer IS NULL OR p.id > $3)
+AND ($4::integer IS NULL OR p.id < $4)
+AND ($5::integer[] IS NULL OR p.id = ANY($5::integer[]))
+AND ($6::uuid IS NULL OR p.feed_id = $6)
+AND ($7::uuid IS NULL OR ff.folder_id = $7)
+AND ($8::boolean IS NULL OR (pr.post_id IS NOT NULL) = $8)
+AND ($9::boolean IS NULL OR (sp.id IS NOT NULL) = $9)
+AND ($10::timestamptz IS NULL OR p.published_at > $10)
+ORDER BY
+    CASE WHEN $4::integer IS NOT NULL THEN -p.id ELSE p.id END ASC
+LIMIT $11
+`
+
+type ListPostsWithStatusParams struct {
+	UserID         uuid.UUID     `json:"user_id"`
+	FollowedOnly   bool          `json:"followed_only"`
+	AfterID        sql.NullInt32 `json:"after_id"`
+	BeforeID       sql.NullInt32 `json:"before_id"`
+	Ids            []int32       `json:"ids"`
+	FeedID         uuid.NullUUID `json:"feed_id"`
+	FolderID       uuid.NullUUID `json:"folder_id"`
+	IsRead         sql.NullBool  `json:"is_read"`
+	IsStarred      sql.NullBool  `json:"is_starred"`
+	PublishedAfter sql.NullTime  `json:"published_after"`
+	Limit          int32         `json:"limit"`
+}
+
+type ListPostsWithStatusRow struct {
+	Post      Post `json:"post"`
+	IsRead    bool `json:"is_read"`
+	IsStarred bool `json:"is_starred"`
+}
+
+// Returns the posts of the followed feeds and the starred posts with their read and starred status, by id:
+// the posts after an id in ascending order, the posts before an id in descending order, or the given posts.
+// They can be restricted to a feed, a folder, a read or starred status, or the posts published after a date.
+// The posts hidden by the retention or the filter rules are skipped, unless they are starred:
+// a starred post stays listed, even once its feed is unfollowed, but not with followed_only.
+func (q *Queries) ListPostsWithStatus(ctx context.Context, arg ListPostsWithStatusParams) ([]ListPostsWithStatusRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPostsWithStatus,
+		arg.UserID,
+		arg.FollowedOnly,
+		arg.AfterID,
+		arg.BeforeID,
+		pq.Array(arg.Ids),
+		arg.FeedID,
+		arg.FolderID,
+		arg.IsRead,
+		arg.IsStarred,
+		arg.PublishedAfter,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListPostsWithStatusRow{}
+	for rows.Next() {
+		var i ListPostsWithStatusRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.Title,
+			&i.Post.Url,
+			&i.Post.Description,
+			&i.Post.PublishedAt,
+			&i.Post.FeedID,
+			&i.Post.CreatedAt,
+			&i.Post.UpdatedAt,
+			&i.Post.Author,
+			pq.Array(&i.Post.Categories),
+			&i.IsRead,
+			&i.IsStarred,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReadPosts = `-- name: ListReadPosts :many
+SELECT p.id, p.url, f.url AS feed_url, pr.created_at AS read_at
+FROM post_reads pr
+    JOIN posts p ON p.id = pr.post_id
+    JOIN feeds f ON f.id = p.feed_id
+WHERE pr.user_id = $1
+ORDER BY pr.created_at ASC, p.id ASC
+`
+
+type ListReadPostsRow struct {
+	ID      int32     `json:"id"`
+	Url     string    `json:"url"`
+	FeedUrl string    `json:"feed_url"`
+	ReadAt  time.Time `json:"read_at"`
+}
+
+// Returns the posts read by the user with the url of their feed,
+// the urls identify the posts across instances unlike the ids.
+func (q *Queries) ListReadPosts(ctx context.Context, userID uuid.UUID) ([]ListReadPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReadPosts, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListReadPostsRow{}
+	for rows.Next() {
+		var i ListReadPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.FeedUrl,
+			&i.ReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnreadPostIDs = `-- name: ListUnreadPostIDs :many
+SELECT p.id
+FROM feed_follows ff
+    JOIN posts p ON p.feed_id = ff.feed_id
+    LEFT JOIN post_reads pr ON pr.post_id = p.id AND pr.user_id = ff.user_id
+WHERE ff.user_id = $1::uuid
+AND pr.post_id IS NULL
+AND (ff.retention_days IS NULL OR p.published_at > NOW() - make_interval(days => ff.retention_days))
+AND post_passes_filters(ff.user_id, ff.folder_id, p)
+ORDER BY p.id ASC
+`
+
+// Returns the ids of the unread posts of the followed feeds,
+// the posts hidden by the retention or the filter rules are skipped.
+func (q *Queries) ListUnreadPostIDs(ctx context.Context, userID uuid.UUID) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, listUnreadPostIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markPostsRead = `-- name: MarkPostsRead :many
+INSERT INTO post_reads (user_id, post_id)
+SELECT DISTINCT ff.user_id, p.id
+FROM posts p
+    JOIN feed_follows ff ON p.feed_id = ff.feed_id
+WHERE ff.user_id = $1::uuid
+AND p.id = ANY($2::integer[])
+ON CONFLICT (user_id, post_id) DO NOTHING
+RETURNING post_id
+`
+
+type MarkPostsReadParams struct {
+	UserID  uuid.UUID `json:"user_id"`
+	PostIds []int32   `json:"post_ids"`
+}
+
+// Only the posts of the followed feeds are marked as read, the ids of the marked posts are returned.
+func (q *Queries) MarkPostsRead(ctx context.Context, arg MarkPostsReadParams) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, markPostsRead, arg.UserID, pq.Array(arg.PostIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var post_id int32
+		if err := rows.Scan(&post_id); err != nil {
+			return nil, err
+		}
+		items = append(items, post_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markPostsReadBefore = `-- name: MarkPostsReadBefore :execrows
+INSERT INTO post_reads (user_id, post_id)
+SELECT ff.user_id, p.id
+FROM posts p
+    JOIN feed_follows ff ON p.feed_id = ff.feed_id
+WHERE ff.user_id = $1::uuid
+AND ($2::uuid IS NULL OR ff.feed_id = $2)
+AND ($3::uuid IS NULL OR ff.folder_id = $3)
+AND p.published_at <= $4::timestamptz
+ON CONFLICT (user_id, post_id) DO NOTHING
+`
+
+type MarkPostsReadBeforeParams struct {
+	UserID   uuid.UUID     `json:"user_id"`
+	FeedID   uuid.NullUUID `json:"feed_id"`
+	FolderID uuid.NullUUID `json:"folder_id"`
+	Before   time.Time     `json:"before"`
+}
+
+// Marks as read the posts of the followed feeds published before a date,
+// optionally restricted to a feed or a folder.
+func (q *Queries) MarkPostsReadBefore(ctx context.Context, arg MarkPostsReadBeforeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markPostsReadBefore,
+		arg.UserID,
+		arg.FeedID,
+		arg.FolderID,
+		arg.Before,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markPostsUnread = `-- name: MarkPostsUnread :exec
+DELETE FROM post_reads
+WHERE user_id = $1
+AND post_id = ANY($2::integer[])
+`
+
+type MarkPostsUnreadParams struct {
+	UserID  uuid.UUID `json:"user_id"`
+	PostIds []int32   `json:"post_ids"`
+}
+
+func (q *Queries) MarkPostsUnread(ctx context.Context, arg MarkPostsUnreadParams) error {
+	_, err := q.db.ExecContext(ctx, markPostsUnread, arg.UserID, pq.Array(arg.PostIds))
+	return err
+}
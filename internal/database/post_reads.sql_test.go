@@ -0,0 +1,219 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueries_MarkPostsRead(t *testing.T) {
+	follow, post := createRandomFollowedPost(t)
+	_, otherPost := createRandomFollowedPost(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	counts, err := testQueries.CountUnreadPosts(ctx, follow.UserID)
+	require.NoError(t, err)
+	require.Len(t, counts, 1)
+	assert.Equal(t, follow.FeedID, counts[0].FeedID)
+	assert.Equal(t, int32(1), counts[0].Unread)
+
+	// The posts of the feeds that are not followed are not marked.
+	ids, err := testQueries.MarkPostsRead(ctx, MarkPostsReadParams{
+		UserID:  follow.UserID.UUID,
+		PostIds: []int32{post.ID, otherPost.ID},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int32{post.ID}, ids)
+
+	counts, err = testQueries.CountUnreadPosts(ctx, follow.UserID)
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+
+	// The posts already read are not returned.
+	ids, err = testQueries.MarkPostsRead(ctx, MarkPostsReadParams{
+		UserID:  follow.UserID.UUID,
+		PostIds: []int32{post.ID},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestQueries_CountUnreadPosts_UnknownUser(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	counts, err := testQueries.CountUnreadPosts(ctx, uuid.NullUUID{UUID: uuid.New(), Valid: true})
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+}
+
+func TestQueries_ListPostsWithStatus(t *testing.T) {
+	follow, post := createRandomFollowedPost(t)
+	user := follow.UserID.UUID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	newPost, err := testQueries.CreatePost(ctx, CreatePostParams{
+		Title:       generator.RandomString(10),
+		Url:         generator.RandomURL(6),
+		Description: generator.RandomString(50),
+		PublishedAt: time.Now().UTC(),
+		FeedID:      follow.FeedID,
+	})
+	require.NoError(t, err)
+
+	ids, err := testQueries.ListUnreadPostIDs(ctx, user)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{post.ID, newPost.ID}, ids)
+	total, err := testQueries.CountPosts(ctx, user)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), total)
+
+	_, err = testQueries.MarkPostsRead(ctx, MarkPostsReadParams{UserID: user, PostIds: []int32{post.ID}})
+	require.NoError(t, err)
+	_, err = testQueries.StarPost(ctx, StarPostParams{UserID: user, PostID: newPost.ID})
+	require.NoError(t, err)
+
+	// The posts after an id are in ascending order.
+	rows, err := testQueries.ListPostsWithStatus(ctx, ListPostsWithStatusParams{
+		UserID:  user,
+		AfterID: sql.NullInt32{Int32: 0, Valid: true},
+		Limit:   10,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, post.ID, rows[0].Post.ID)
+	assert.True(t, rows[0].IsRead)
+	assert.False(t, rows[0].IsStarred)
+	assert.Equal(t, newPost.ID, rows[1].Post.ID)
+	assert.False(t, rows[1].IsRead)
+	assert.True(t, rows[1].IsStarred)
+
+	// The posts before an id are in descending order.
+	rows, err = testQueries.ListPostsWithStatus(ctx, ListPostsWithStatusParams{
+		UserID:   user,
+		BeforeID: sql.NullInt32{Int32: newPost.ID + 1, Valid: true},
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, newPost.ID, rows[0].Post.ID)
+
+	rows, err = testQueries.ListPostsWithStatus(ctx, ListPostsWithStatusParams{
+		UserID: user,
+		Ids:    []int32{post.ID},
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, post.ID, rows[0].Post.ID)
+
+	// The posts can be restricted to a feed and a read or starred status.
+	rows, err = testQueries.ListPostsWithStatus(ctx, ListPostsWithStatusParams{
+		UserID: user,
+		FeedID: follow.FeedID,
+		IsRead: sql.NullBool{Bool: false, Valid: true},
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, newPost.ID, rows[0].Post.ID)
+	rows, err = testQueries.ListPostsWithStatus(ctx, ListPostsWithStatusParams{
+		UserID:    user,
+		IsStarred: sql.NullBool{Bool: false, Valid: true},
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, post.ID, rows[0].Post.ID)
+
+	// The starred posts stay listed and counted once the feed is unfollowed.
+	require.NoError(t, testQueries.DeleteFeedFollows(ctx, DeleteFeedFollowsParams{ID: follow.ID, UserID: follow.UserID}))
+	rows, err = testQueries.ListPostsWithStatus(ctx, ListPostsWithStatusParams{
+		UserID:  user,
+		AfterID: sql.NullInt32{Int32: 0, Valid: true},
+		Limit:   10,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, newPost.ID, rows[0].Post.ID)
+	assert.True(t, rows[0].IsStarred)
+	total, err = testQueries.CountPosts(ctx, user)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), total)
+
+	starred, err := testQueries.ListStarredPostIDs(ctx, user)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{newPost.ID}, starred)
+	require.NoError(t, testQueries.UnstarPostByPostID(ctx, UnstarPostByPostIDParams{UserID: user, PostID: newPost.ID}))
+	starred, err = testQueries.ListStarredPostIDs(ctx, user)
+	require.NoError(t, err)
+	assert.Empty(t, starred)
+}
+
+func TestQueries_MarkPostsReadBefore(t *testing.T) {
+	follow, post := createRandomFollowedPost(t)
+	user := follow.UserID.UUID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The posts published after the date are kept unread.
+	count, err := testQueries.MarkPostsReadBefore(ctx, MarkPostsReadBeforeParams{
+		UserID: user,
+		FeedID: follow.FeedID,
+		Before: post.PublishedAt.Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	count, err = testQueries.MarkPostsReadBefore(ctx, MarkPostsReadBeforeParams{
+		UserID: user,
+		FeedID: follow.FeedID,
+		Before: post.PublishedAt,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	ids, err := testQueries.ListUnreadPostIDs(ctx, user)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	require.NoError(t, testQueries.MarkPostsUnread(ctx, MarkPostsUnreadParams{UserID: user, PostIds: []int32{post.ID}}))
+	ids, err = testQueries.ListUnreadPostIDs(ctx, user)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{post.ID}, ids)
+}
+
+func TestQueries_ListReadPosts(t *testing.T) {
+	follow, post := createRandomFollowedPost(t)
+	user := follow.UserID.UUID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	read, err := testQueries.ListReadPosts(ctx, user)
+	require.NoError(t, err)
+	assert.Empty(t, read)
+
+	_, err = testQueries.MarkPostsRead(ctx, MarkPostsReadParams{UserID: user, PostIds: []int32{post.ID}})
+	require.NoError(t, err)
+
+	feed, err := testQueries.GetFeed(ctx, follow.FeedID.UUID)
+	require.NoError(t, err)
+	read, err = testQueries.ListReadPosts(ctx, user)
+	require.NoError(t, err)
+	require.Len(t, read, 1)
+	assert.Equal(t, post.ID, read[0].ID)
+	assert.Equal(t, post.Url, read[0].Url)
+	assert.Equal(t, feed.Url, read[0].FeedUrl)
+	assert.NotZero(t, read[0].ReadAt)
+}
@@ -22,12 +22,9 @@ func NewPostRepository(db *sql.DB) PostRepository {
 	}
 }
 
-// GetPostsByUser returns the posts for the given user.
-func (u PostRepository) GetPostsByUser(ctx context.Context, userID uuid.NullUUID, limit int32) ([]Post, error) {
-	posts, err := u.queries.GetPostsByUser(ctx, GetPostsByUserParams{
-		UserID: userID,
-		Limit:  limit,
-	})
+// GetPostsByUser returns the posts of the feeds followed by the given user.
+func (u PostRepository) GetPostsByUser(ctx context.Context, arg GetPostsByUserParams) ([]Post, error) {
+	posts, err := u.queries.GetPostsByUser(ctx, arg)
 	if err != nil {
 		return nil, fmt.Errorf("error getting posts by user %w", err)
 	}
@@ -35,6 +32,16 @@ func (u PostRepository) GetPostsByUser(ctx context.Context, userID uuid.NullUUID
 	return posts, nil
 }
 
+// GetLastPostID returns the id of the last created post, 0 when there is no post.
+func (u PostRepository) GetLastPostID(ctx context.Context) (int32, error) {
+	id, err := u.queries.GetLastPostID(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error getting last post id: %w", err)
+	}
+
+	return id, nil
+}
+
 // CreatePost creates a new post.
 func (u PostRepository) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
 	post, err := u.queries.CreatePost(ctx, arg)
@@ -44,3 +51,154 @@ func (u PostRepository) CreatePost(ctx context.Context, arg CreatePostParams) (P
 
 	return post, nil
 }
+
+// StarPost stars a post for the given user.
+// The post is copied so the starred post is kept even if the post is purged.
+func (u PostRepository) StarPost(ctx context.Context, arg StarPostParams) (StarredPost, error) {
+	starred, err := u.queries.StarPost(ctx, arg)
+	if err != nil {
+		return StarredPost{}, fmt.Errorf("error starring post: %w", err)
+	}
+
+	return starred, nil
+}
+
+// ListStarredPosts returns the starred posts of a user.
+func (u PostRepository) ListStarredPosts(ctx context.Context, arg ListStarredPostsParams) ([]StarredPost, error) {
+	starred, err := u.queries.ListStarredPosts(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("error listing starred posts: %w", err)
+	}
+
+	return starred, nil
+}
+
+// UnstarPost removes a starred post.
+func (u PostRepository) UnstarPost(ctx context.Context, arg UnstarPostParams) error {
+	if err := u.queries.UnstarPost(ctx, arg); err != nil {
+		return fmt.Errorf("error unstarring post: %w", err)
+	}
+
+	return nil
+}
+
+// MarkPostsRead marks the posts of the followed feeds as read for a user.
+// It returns the ids of the posts that were not read yet.
+func (u PostRepository) MarkPostsRead(ctx context.Context, arg MarkPostsReadParams) ([]int32, error) {
+	ids, err := u.queries.MarkPostsRead(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("error marking posts read: %w", err)
+	}
+
+	return ids, nil
+}
+
+// CountUnreadPosts returns the number of unread posts of each feed followed by a user.
+func (u PostRepository) CountUnreadPosts(ctx context.Context, userID uuid.NullUUID) ([]CountUnreadPostsRow, error) {
+	counts, err := u.queries.CountUnreadPosts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error counting unread posts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// MarkPostsUnread marks the posts as unread for a user.
+func (u PostRepository) MarkPostsUnread(ctx context.Context, arg MarkPostsUnreadParams) error {
+	if err := u.queries.MarkPostsUnread(ctx, arg); err != nil {
+		return fmt.Errorf("error marking posts unread: %w", err)
+	}
+
+	return nil
+}
+
+// MarkPostsReadBefore marks as read the posts of the followed feeds published before a date.
+// It returns the number of posts that were not read yet.
+func (u PostRepository) MarkPostsReadBefore(ctx context.Context, arg MarkPostsReadBeforeParams) (int64, error) {
+	count, err := u.queries.MarkPostsReadBefore(ctx, arg)
+	if err != nil {
+		return 0, fmt.Errorf("error marking posts read before: %w", err)
+	}
+
+	return count, nil
+}
+
+// PurgePosts deletes the posts of a feed, or of all the feeds, published before a date.
+// It returns the number of deleted posts.
+func (u PostRepository) PurgePosts(ctx context.Context, arg PurgePostsParams) (int64, error) {
+	count, err := u.queries.PurgePosts(ctx, arg)
+	if err != nil {
+		return 0, fmt.Errorf("error purging posts: %w", err)
+	}
+
+	return count, nil
+}
+
+// ListUnreadPostIDs returns the ids of the unread posts of the feeds followed by a user.
+func (u PostRepository) ListUnreadPostIDs(ctx context.Context, userID uuid.UUID) ([]int32, error) {
+	ids, err := u.queries.ListUnreadPostIDs(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing unread post ids: %w", err)
+	}
+
+	return ids, nil
+}
+
+// ListPostsWithStatus returns the posts of the feeds followed by a user and the starred posts, with their read and starred status.
+func (u PostRepository) ListPostsWithStatus(ctx context.Context, arg ListPostsWithStatusParams) ([]ListPostsWithStatusRow, error) {
+	posts, err := u.queries.ListPostsWithStatus(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("error listing posts with status: %w", err)
+	}
+
+	return posts, nil
+}
+
+// CountPosts returns the number of posts listed by ListPostsWithStatus for a user.
+func (u PostRepository) CountPosts(ctx context.Context, userID uuid.UUID) (int32, error) {
+	count, err := u.queries.CountPosts(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("error counting posts: %w", err)
+	}
+
+	return count, nil
+}
+
+// ListStarredPostIDs returns the ids of the posts starred by a user.
+func (u PostRepository) ListStarredPostIDs(ctx context.Context, userID uuid.UUID) ([]int32, error) {
+	ids, err := u.queries.ListStarredPostIDs(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing starred post ids: %w", err)
+	}
+
+	return ids, nil
+}
+
+// UnstarPostByPostID unstars a post by the id of the post, rather than the id of the starred post.
+func (u PostRepository) UnstarPostByPostID(ctx context.Context, arg UnstarPostByPostIDParams) error {
+	if err := u.queries.UnstarPostByPostID(ctx, arg); err != nil {
+		return fmt.Errorf("error unstarring post by post id: %w", err)
+	}
+
+	return nil
+}
+
+// ListAllStarredPosts returns all the starred posts of the given user.
+func (u PostRepository) ListAllStarredPosts(ctx context.Context, userID uuid.UUID) ([]StarredPost, error) {
+	starred, err := u.queries.ListAllStarredPosts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing all starred posts: %w", err)
+	}
+
+	return starred, nil
+}
+
+// ListReadPosts returns the posts read by the given user.
+func (u PostRepository) ListReadPosts(ctx context.Context, userID uuid.UUID) ([]ListReadPostsRow, error) {
+	posts, err := u.queries.ListReadPosts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing read posts: %w", err)
+	}
+
+	return posts, nil
+}
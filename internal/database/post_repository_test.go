@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"sort"
 	"testing"
 	"time"
@@ -44,6 +45,12 @@ func TestPostRepository_GetPostsByUser(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	_, err := testQueries.CreateFeedFollows(ctx, CreateFeedFollowsParams{
+		UserID: feed.UserID,
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
 	var createdPosts []Post
 
 	for i := 0; i < 11; i++ {
@@ -68,7 +75,10 @@ func TestPostRepository_GetPostsByUser(t *testing.T) {
 		return createdPosts[i].ID > createdPosts[j].ID
 	})
 
-	posts, err := postRepository.GetPostsByUser(ctx, feed.UserID, 10)
+	posts, err := postRepository.GetPostsByUser(ctx, GetPostsByUserParams{
+		UserID: feed.UserID,
+		Limit:  10,
+	})
 	sort.Slice(posts, func(i, j int) bool {
 		return posts[i].ID > posts[j].ID
 	})
@@ -80,3 +90,188 @@ func TestPostRepository_GetPostsByUser(t *testing.T) {
 		assert.Equal(t, feed.ID, post.FeedID.UUID)
 	}
 }
+
+func TestPostRepository_GetPostsByUser_Folder(t *testing.T) {
+	postRepository := NewPostRepository(testDB)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	follow, post := createRandomFollowedPost(t)
+	user := follow.UserID
+
+	// Another followed feed, outside the folder.
+	otherFeed, err := testQueries.CreateFeed(ctx, CreateFeedParams{
+		Name:   generator.RandomString(12),
+		Url:    generator.RandomURL(8),
+		UserID: user,
+	})
+	require.NoError(t, err)
+	_, err = testQueries.CreateFeedFollows(ctx, CreateFeedFollowsParams{
+		UserID: user,
+		FeedID: uuid.NullUUID{UUID: otherFeed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	_, err = testQueries.CreatePost(ctx, CreatePostParams{
+		Title:       generator.RandomString(10),
+		Url:         generator.RandomURL(6),
+		Description: generator.RandomString(50),
+		PublishedAt: time.Now().UTC(),
+		FeedID:      uuid.NullUUID{UUID: otherFeed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	folder, err := testQueries.CreateFolder(ctx, CreateFolderParams{
+		UserID: user.UUID,
+		Name:   generator.RandomString(8),
+	})
+	require.NoError(t, err)
+	_, err = testQueries.UpdateFeedFollows(ctx, UpdateFeedFollowsParams{
+		ID:          follow.ID,
+		UserID:      user,
+		SetFolderID: true,
+		FolderID:    uuid.NullUUID{UUID: folder.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	posts, err := postRepository.GetPostsByUser(ctx, GetPostsByUserParams{
+		UserID: user,
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	assert.Len(t, posts, 2)
+
+	posts, err = postRepository.GetPostsByUser(ctx, GetPostsByUserParams{
+		UserID:   user,
+		FolderID: uuid.NullUUID{UUID: folder.ID, Valid: true},
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, post.ID, posts[0].ID)
+}
+
+func TestPostRepository_GetPostsByUser_FeedFollowsSettings(t *testing.T) {
+	postRepository := NewPostRepository(testDB)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	follow, post := createRandomFollowedPost(t)
+	user := follow.UserID
+
+	// An old post, out of the retention of the feed follow.
+	_, err := testQueries.CreatePost(ctx, CreatePostParams{
+		Title:       generator.RandomString(10),
+		Url:         generator.RandomURL(6),
+		Description: generator.RandomString(50),
+		PublishedAt: time.Now().UTC().Add(-72 * time.Hour),
+		FeedID:      follow.FeedID,
+	})
+	require.NoError(t, err)
+
+	follow, err = testQueries.UpdateFeedFollows(ctx, UpdateFeedFollowsParams{
+		ID:               follow.ID,
+		UserID:           user,
+		SetTitle:         true,
+		Title:            sql.NullString{String: "my title", Valid: true},
+		SetRetentionDays: true,
+		RetentionDays:    sql.NullInt32{Int32: 1, Valid: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "my title", follow.Title.String)
+
+	posts, err := postRepository.GetPostsByUser(ctx, GetPostsByUserParams{
+		UserID: user,
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, post.ID, posts[0].ID)
+
+	// A muted feed is hidden from the main timeline only.
+	follow, err = testQueries.UpdateFeedFollows(ctx, UpdateFeedFollowsParams{
+		ID:     follow.ID,
+		UserID: user,
+		Muted:  sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+
+	posts, err = postRepository.GetPostsByUser(ctx, GetPostsByUserParams{
+		UserID: user,
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, posts)
+
+	posts, err = postRepository.GetPostsByUser(ctx, GetPostsByUserParams{
+		UserID: user,
+		FeedID: follow.FeedID,
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, post.ID, posts[0].ID)
+}
+
+func TestPostRepository_GetPostsByUser_AfterID(t *testing.T) {
+	postRepository := NewPostRepository(testDB)
+	follow, post := createRandomFollowedPost(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	lastID, err := postRepository.GetLastPostID(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, lastID, post.ID)
+
+	newPost, err := postRepository.CreatePost(ctx, CreatePostParams{
+		Title:       generator.RandomString(10),
+		Url:         generator.RandomURL(5),
+		Description: generator.RandomString(50),
+		PublishedAt: time.Now(),
+		FeedID:      follow.FeedID,
+	})
+	require.NoError(t, err)
+
+	posts, err := postRepository.GetPostsByUser(ctx, GetPostsByUserParams{
+		UserID:  follow.UserID,
+		AfterID: sql.NullInt32{Int32: post.ID, Valid: true},
+		Limit:   10,
+	})
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, newPost.ID, posts[0].ID)
+}
+
+func TestPostRepository_PurgePosts(t *testing.T) {
+	postRepository := NewPostRepository(testDB)
+	feed := CreateRandomFeed(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	for _, age := range []time.Duration{time.Hour, 48 * time.Hour, 72 * time.Hour} {
+		_, err := postRepository.CreatePost(ctx, CreatePostParams{
+			Title:       generator.RandomString(10),
+			Url:         generator.RandomURL(5),
+			Description: generator.RandomString(50),
+			PublishedAt: now.Add(-age),
+			FeedID:      uuid.NullUUID{UUID: feed.ID, Valid: true},
+		})
+		require.NoError(t, err)
+	}
+
+	// Only the posts of the feed published before the date are deleted.
+	count, err := postRepository.PurgePosts(ctx, PurgePostsParams{
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+		Before: sql.NullTime{Time: now.Add(-24 * time.Hour), Valid: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	count, err = postRepository.PurgePosts(ctx, PurgePostsParams{
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
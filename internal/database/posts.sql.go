@@ -7,15 +7,25 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (title, url, description, published_at, feed_id)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, title, url, description, published_at, feed_id, created_at, updated_at
+INSERT INTO posts (title, url, description, published_at, feed_id, author, categories)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    COALESCE($7::text[], '{}')
+)
+RETURNING id, title, url, description, published_at, feed_id, created_at, updated_at, author, categories
 `
 
 type CreatePostParams struct {
@@ -24,6 +34,8 @@ type CreatePostParams struct {
 	Description string        `json:"description"`
 	PublishedAt time.Time     `json:"published_at"`
 	FeedID      uuid.NullUUID `json:"feed_id"`
+	Author      string        `json:"author"`
+	Categories  []string      `json:"categories"`
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
@@ -33,6 +45,8 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.Author,
+		pq.Array(arg.Categories),
 	)
 	var i Post
 	err := row.Scan(
@@ -44,26 +58,63 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.FeedID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Author,
+		pq.Array(&i.Categories),
 	)
 	return i, err
 }
 
+const getLastPostID = `-- name: GetLastPostID :one
+SELECT COALESCE(MAX(id), 0)::integer FROM posts
+`
+
+func (q *Queries) GetLastPostID(ctx context.Context) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getLastPostID)
+	var column_1 int32
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
 const getPostsByUser = `-- name: GetPostsByUser :many
-SELECT p.id, p.title, p.url, p.description, p.published_at, p.feed_id, p.created_at, p.updated_at
+SELECT p.id, p.title, p.url, p.description, p.published_at, p.feed_id, p.created_at, p.updated_at, p.author, p.categories
 FROM posts p
-    JOIN feeds f ON p.feed_id = f.id
-WHERE f.user_id = $1
-ORDER BY p.published_at DESC
-LIMIT $2
+    JOIN feed_follows ff ON p.feed_id = ff.feed_id
+WHERE ff.user_id = $1
+AND ($2::uuid IS NULL OR ff.folder_id = $2)
+AND ($3::uuid IS NULL OR ff.feed_id = $3)
+AND ($4::integer IS NULL OR p.id > $4)
+AND (NOT ff.muted OR $2::uuid IS NOT NULL OR $3::uuid IS NOT NULL)
+AND (ff.retention_days IS NULL OR p.published_at > NOW() - make_interval(days => ff.retention_days))
+AND post_passes_filters(ff.user_id, ff.folder_id, p)
+ORDER BY
+    CASE WHEN $4::integer IS NULL THEN p.published_at END DESC,
+    p.id ASC
+LIMIT $5
 `
 
 type GetPostsByUserParams struct {
-	UserID uuid.NullUUID `json:"user_id"`
-	Limit  int32         `json:"limit"`
+	UserID   uuid.NullUUID `json:"user_id"`
+	FolderID uuid.NullUUID `json:"folder_id"`
+	FeedID   uuid.NullUUID `json:"feed_id"`
+	AfterID  sql.NullInt32 `json:"after_id"`
+	Limit    int32         `json:"limit"`
 }
 
+// Returns the timeline of a user: the posts of the followed feeds,
+// optionally restricted to a folder or a feed, or to the posts created after a post.
+// The posts are the most recently published first, or in creation order after a post
+// for the pages to follow each other.
+// The muted feeds are only listed when the timeline is restricted,
+// the posts older than the retention of the feed follow are skipped
+// and the filter rules of the user are applied.
 func (q *Queries) GetPostsByUser(ctx context.Context, arg GetPostsByUserParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsByUser, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getPostsByUser,
+		arg.UserID,
+		arg.FolderID,
+		arg.FeedID,
+		arg.AfterID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -80,6 +131,8 @@ func (q *Queries) GetPostsByUser(ctx context.Context, arg GetPostsByUserParams)
 			&i.FeedID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Author,
+			pq.Array(&i.Categories),
 		); err != nil {
 			return nil, err
 		}
@@ -93,3 +146,24 @@ func (q *Queries) GetPostsByUser(ctx context.Context, arg GetPostsByUserParams)
 	}
 	return items, nil
 }
+
+const purgePosts = `-- name: PurgePosts :execrows
+DELETE FROM posts
+WHERE ($1::uuid IS NULL OR feed_id = $1)
+AND ($2::timestamptz IS NULL OR published_at < $2)
+`
+
+type PurgePostsParams struct {
+	FeedID uuid.NullUUID `json:"feed_id"`
+	Before sql.NullTime  `json:"before"`
+}
+
+// Deletes the posts of a feed, or of all the feeds, published before a date.
+// The starred posts keep their copy.
+func (q *Queries) PurgePosts(ctx context.Context, arg PurgePostsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgePosts, arg.FeedID, arg.Before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
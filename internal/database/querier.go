@@ -6,23 +6,224 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
+	ActivateWebSubSubscription(ctx context.Context, arg ActivateWebSubSubscriptionParams) (WebSubSubscription, error)
+	// Counts the followers of a feed other than its creator.
+	CountOtherFeedFollowers(ctx context.Context, feedID uuid.NullUUID) (int32, error)
+	// Counts the posts listed by ListPostsWithStatus without restriction:
+	// the visible posts of the followed feeds and the starred posts.
+	CountPosts(ctx context.Context, userID uuid.UUID) (int32, error)
+	// Counts the follows of the users who cannot follow the feed anymore.
+	CountUnfollowableFeedFollows(ctx context.Context, feedID uuid.UUID) (int32, error)
+	// Counts the unread posts of each followed feed,
+	// the posts hidden by the retention or the filter rules are not counted.
+	CountUnreadPosts(ctx context.Context, userID uuid.NullUUID) ([]CountUnreadPostsRow, error)
+	// The API key is only returned at the creation, its hash is stored with its prefix.
+	CreateApiKey(ctx context.Context, arg CreateApiKeyParams) (CreateApiKeyRow, error)
+	CreateDigestItems(ctx context.Context, arg CreateDigestItemsParams) error
+	// A feed is public unless another visibility is given.
 	CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error)
+	// Nothing is returned when the feed does not exist or the user cannot follow it.
 	CreateFeedFollows(ctx context.Context, arg CreateFeedFollowsParams) (FeedFollow, error)
+	// Nothing is returned when the user already follows the feed, or cannot follow it.
+	CreateFeedFollowsIfNotExists(ctx context.Context, arg CreateFeedFollowsIfNotExistsParams) (FeedFollow, error)
+	CreateFeedShare(ctx context.Context, arg CreateFeedShareParams) (FeedShare, error)
+	// The feed must be followed by the user and the folder must belong to the user.
+	CreateFilterRule(ctx context.Context, arg CreateFilterRuleParams) (FilterRule, error)
+	CreateFolder(ctx context.Context, arg CreateFolderParams) (Folder, error)
 	CreatePost(ctx context.Context, arg CreatePostParams) (Post, error)
-	CreateUser(ctx context.Context, name string) (User, error)
+	// The refresh token is only returned at the creation, its hash is stored.
+	// The expired refresh tokens of the user are deleted meanwhile.
+	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (CreateRefreshTokenRow, error)
+	// The session token is only returned at the creation, its hash is stored.
+	// The expired sessions of the user are deleted meanwhile.
+	CreateSession(ctx context.Context, arg CreateSessionParams) (CreateSessionRow, error)
+	// The user is created with a default API key granting all the scopes,
+	// it is only returned at the creation, its hash is stored with its prefix.
+	CreateUser(ctx context.Context, name string) (CreateUserRow, error)
+	CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error)
+	// The feed must be followed by the user and the folder must belong to the user.
+	CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error)
+	// Nothing is returned when the post has already been delivered to the webhook.
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error)
+	DeleteApiKey(ctx context.Context, arg DeleteApiKeyParams) error
+	DeleteDigestSettings(ctx context.Context, userID uuid.UUID) error
+	DeleteExpiredHubSubscriptions(ctx context.Context, expiredAt time.Time) (int64, error)
+	DeleteFeed(ctx context.Context, id uuid.UUID) error
 	DeleteFeedFollows(ctx context.Context, arg DeleteFeedFollowsParams) error
+	DeleteFeedShare(ctx context.Context, arg DeleteFeedShareParams) (int64, error)
+	DeleteFilterRule(ctx context.Context, arg DeleteFilterRuleParams) error
+	DeleteFolder(ctx context.Context, arg DeleteFolderParams) error
+	DeleteHubSubscription(ctx context.Context, arg DeleteHubSubscriptionParams) error
+	DeleteRefreshToken(ctx context.Context, token string) error
+	DeleteSession(ctx context.Context, token string) error
+	// Deletes the follows of the users who cannot follow the feed anymore.
+	DeleteUnfollowableFeedFollows(ctx context.Context, feedID uuid.UUID) (int64, error)
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+	// The subscriptions to the timeline of a user are authenticated by its feed token.
+	DeleteUserHubSubscriptions(ctx context.Context, userID uuid.UUID) error
+	DeleteUserRefreshTokens(ctx context.Context, userID uuid.UUID) error
+	DeleteUserSessions(ctx context.Context, userID uuid.UUID) error
+	DeleteWebSubSubscription(ctx context.Context, feedID uuid.UUID) error
+	DeleteWebhook(ctx context.Context, arg DeleteWebhookParams) error
+	DenyWebSubSubscription(ctx context.Context, arg DenyWebSubSubscriptionParams) error
+	GetDigestSettings(ctx context.Context, userID uuid.UUID) (DigestSetting, error)
+	GetFeed(ctx context.Context, id uuid.UUID) (Feed, error)
+	// Returns the feed of a url the user can follow, the public or unlisted one first.
+	// The private feeds of the other users are ignored, as if their url was unknown.
+	GetFeedByURL(ctx context.Context, arg GetFeedByURLParams) (Feed, error)
+	GetFeedFollows(ctx context.Context, arg GetFeedFollowsParams) (FeedFollow, error)
+	GetLastPostID(ctx context.Context) (int32, error)
+	// Returns the feed when the user manages it, as its creator or as an admin.
+	GetManagedFeed(ctx context.Context, arg GetManagedFeedParams) (Feed, error)
+	// Returns the position after the last feed follow of the folder, or of the follows without folder.
+	GetNextFeedFollowPosition(ctx context.Context, arg GetNextFeedFollowPositionParams) (int32, error)
+	// The disabled feeds are skipped.
 	GetNextFeedsToFetch(ctx context.Context, limit int32) ([]Feed, error)
+	// Returns the position after the last folder of the user.
+	GetNextFolderPosition(ctx context.Context, userID uuid.UUID) (int32, error)
+	// An existing folder with the same name is returned unchanged.
+	GetOrCreateFolder(ctx context.Context, arg GetOrCreateFolderParams) (Folder, error)
+	// Returns the timeline of a user: the posts of the followed feeds,
+	// optionally restricted to a folder or a feed, or to the posts created after a post.
+	// The posts are the most recently published first, or in creation order after a post
+	// for the pages to follow each other.
+	// The muted feeds are only listed when the timeline is restricted,
+	// the posts older than the retention of the feed follow are skipped
+	// and the filter rules of the user are applied.
 	GetPostsByUser(ctx context.Context, arg GetPostsByUserParams) ([]Post, error)
-	GetUserFromApiKey(ctx context.Context, apiKey string) (User, error)
+	// Returns the user of an API key which has not expired, with the key id and its scopes.
+	// The keys of the suspended users are refused.
+	// The last use of the key is updated at most once a minute.
+	GetUserFromApiKey(ctx context.Context, apiKey string) (GetUserFromApiKeyRow, error)
+	GetUserFromEmail(ctx context.Context, email string) (User, error)
+	GetUserFromFeedToken(ctx context.Context, arg GetUserFromFeedTokenParams) (User, error)
+	// Returns the user of the API key of a Fever key, with the scopes of the key.
+	// The keys replaced before the Fever keys were stored with the API keys have none.
+	// Only the hash of the Fever keys is stored.
+	GetUserFromFeverKey(ctx context.Context, feverKey string) (GetUserFromFeverKeyRow, error)
 	GetUserFromId(ctx context.Context, id uuid.UUID) (User, error)
+	// Returns the user of an identity, its email at the provider is updated meanwhile.
+	GetUserFromIdentity(ctx context.Context, arg GetUserFromIdentityParams) (User, error)
+	// Returns the user of a session which has not expired, with the session id and its CSRF token.
+	// The sessions of the suspended users are refused.
+	GetUserFromSession(ctx context.Context, token string) (GetUserFromSessionRow, error)
+	GetWebSubSubscription(ctx context.Context, feedID uuid.UUID) (GetWebSubSubscriptionRow, error)
+	GetWebhook(ctx context.Context, arg GetWebhookParams) (Webhook, error)
+	ListAllFeedFollows(ctx context.Context, userID uuid.NullUUID) ([]FeedFollow, error)
+	ListAllStarredPosts(ctx context.Context, userID uuid.UUID) ([]StarredPost, error)
+	ListApiKeys(ctx context.Context, userID uuid.UUID) ([]ApiKey, error)
+	// Returns the unread posts of the timeline of a user created since a date, and not sent in a previous digest.
+	// Like the timeline, the muted feeds are skipped, and the retention and the filter rules are applied.
+	// The feeds without notifications are skipped too.
+	ListDigestPosts(ctx context.Context, arg ListDigestPostsParams) ([]ListDigestPostsRow, error)
+	// Returns the subscriptions of the disabled feeds, they are unsubscribed from their hub.
+	ListDisabledWebSubSubscriptions(ctx context.Context) ([]WebSubSubscription, error)
+	ListDueDigests(ctx context.Context, arg ListDueDigestsParams) ([]ListDueDigestsRow, error)
+	ListDueWebhookDeliveries(ctx context.Context, limit int32) ([]ListDueWebhookDeliveriesRow, error)
 	ListFeedFollows(ctx context.Context, arg ListFeedFollowsParams) ([]FeedFollow, error)
+	ListFeedFollowsWithFeeds(ctx context.Context, userID uuid.NullUUID) ([]ListFeedFollowsWithFeedsRow, error)
+	ListFeedShares(ctx context.Context, feedID uuid.UUID) ([]FeedShare, error)
+	// Only the public feeds are listed.
 	ListFeeds(ctx context.Context, arg ListFeedsParams) ([]Feed, error)
-	MarkFeedFetched(ctx context.Context, id uuid.UUID) error
+	ListFeedsByCreator(ctx context.Context, userID uuid.NullUUID) ([]Feed, error)
+	// Returns all the feeds with their health status and their number of followers, the failing ones first.
+	// A feed is disabled, failing when its last fetch failed, pending when it was never fetched, ok otherwise.
+	ListFeedsWithHealth(ctx context.Context, arg ListFeedsWithHealthParams) ([]ListFeedsWithHealthRow, error)
+	ListFilterRules(ctx context.Context, userID uuid.UUID) ([]FilterRule, error)
+	ListFolders(ctx context.Context, userID uuid.UUID) ([]Folder, error)
+	// Returns the subscriptions whose timeline contains the post, with the owner of the timeline.
+	// Like the timeline, the muted feeds are only part of the timelines restricted to a feed or a folder,
+	// the posts older than the retention of the feed follow are skipped and the filter rules of the user are applied.
+	ListHubSubscriptionsForPost(ctx context.Context, postID int32) ([]ListHubSubscriptionsForPostRow, error)
+	// Returns the posts of the followed feeds and the starred posts with their read and starred status, by id:
+	// the posts after an id in ascending order, the posts before an id in descending order, or the given posts.
+	// They can be restricted to a feed, a folder, a read or starred status, or the posts published after a date.
+	// The posts hidden by the retention or the filter rules are skipped, unless they are starred:
+	// a starred post stays listed, even once its feed is unfollowed, but not with followed_only.
+	ListPostsWithStatus(ctx context.Context, arg ListPostsWithStatusParams) ([]ListPostsWithStatusRow, error)
+	// Returns the posts read by the user with the url of their feed,
+	// the urls identify the posts across instances unlike the ids.
+	ListReadPosts(ctx context.Context, userID uuid.UUID) ([]ListReadPostsRow, error)
+	// Returns the ids of the starred posts still stored.
+	ListStarredPostIDs(ctx context.Context, userID uuid.UUID) ([]int32, error)
+	ListStarredPosts(ctx context.Context, arg ListStarredPostsParams) ([]StarredPost, error)
+	// Returns the ids of the unread posts of the followed feeds,
+	// the posts hidden by the retention or the filter rules are skipped.
+	ListUnreadPostIDs(ctx context.Context, userID uuid.UUID) ([]int32, error)
+	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	// Returns the active subscriptions expiring before a date,
+	// and the pending subscriptions requested before a date, their verification has failed.
+	// The subscriptions of the disabled feeds are not renewed.
+	ListWebSubSubscriptionsToRenew(ctx context.Context, arg ListWebSubSubscriptionsToRenewParams) ([]WebSubSubscription, error)
+	// The deliveries of a webhook of the user, the most recent first.
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhooks(ctx context.Context, userID uuid.UUID) ([]Webhook, error)
+	// Returns the active webhooks whose scope matches the post:
+	// the post must be part of a feed followed by the owner of the webhook, with the notifications on.
+	ListWebhooksForPost(ctx context.Context, postID int32) ([]Webhook, error)
+	MarkDigestSent(ctx context.Context, arg MarkDigestSentParams) error
+	// The feed is fetched again at its turn, its failed fetches are counted until one succeeds.
+	MarkFeedFetchFailed(ctx context.Context, arg MarkFeedFetchFailedParams) error
+	// The site url is kept when the fetched feed does not provide one.
+	// A successful fetch clears the errors of the feed.
+	MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams) error
+	// Only the posts of the followed feeds are marked as read, the ids of the marked posts are returned.
+	MarkPostsRead(ctx context.Context, arg MarkPostsReadParams) ([]int32, error)
+	// Marks as read the posts of the followed feeds published before a date,
+	// optionally restricted to a feed or a folder.
+	MarkPostsReadBefore(ctx context.Context, arg MarkPostsReadBeforeParams) (int64, error)
+	MarkPostsUnread(ctx context.Context, arg MarkPostsUnreadParams) error
+	// The feeds created by a user which other users still follow are kept without creator,
+	// instead of being deleted with the user.
+	OrphanFollowedFeeds(ctx context.Context, userID uuid.NullUUID) (int64, error)
+	// Deletes the posts of a feed, or of all the feeds, published before a date.
+	// The starred posts keep their copy.
+	PurgePosts(ctx context.Context, arg PurgePostsParams) (int64, error)
+	// The users registered with an email and a password have no API key, they log in to open a session.
+	RegisterUser(ctx context.Context, arg RegisterUserParams) (User, error)
+	// Replaces an API key by a new one with the same name, scopes and expiration, and returns it.
+	// The replaced key still works until the given expiration, or is deleted at once without expiration.
+	RotateApiKey(ctx context.Context, arg RotateApiKeyParams) (RotateApiKeyRow, error)
+	// The feed token is replaced by a new one, generated like the default one.
+	RotateFeedToken(ctx context.Context, id uuid.UUID) (User, error)
+	// A disabled feed keeps the date it was first disabled at.
+	SetFeedDisabled(ctx context.Context, arg SetFeedDisabledParams) (Feed, error)
+	SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error)
+	StarPost(ctx context.Context, arg StarPostParams) (StarredPost, error)
+	// A suspended user keeps the date of its first suspension.
+	SuspendUser(ctx context.Context, id uuid.UUID) (User, error)
+	UnstarPost(ctx context.Context, arg UnstarPostParams) error
+	UnstarPostByPostID(ctx context.Context, arg UnstarPostByPostIDParams) error
+	UnsuspendUser(ctx context.Context, id uuid.UUID) (User, error)
+	// The name, the url, the visibility and the disabled state are only changed when given.
+	// A new url is fetched at once, without the errors of the previous one.
+	UpdateFeed(ctx context.Context, arg UpdateFeedParams) (Feed, error)
+	// The position, the muted and the notify settings are only changed when given.
+	// The folder, the title and the retention are only changed when set, they are cleared when set to null.
+	// The folder must belong to the user who follows the feed.
+	UpdateFeedFollows(ctx context.Context, arg UpdateFeedFollowsParams) (FeedFollow, error)
+	// The feed must be followed by the user and the folder must belong to the user.
+	UpdateFilterRule(ctx context.Context, arg UpdateFilterRuleParams) (FilterRule, error)
+	UpdateFolder(ctx context.Context, arg UpdateFolderParams) (Folder, error)
+	// The email is only updated when given, it is lowercased like at the registration.
+	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	// The feed must be followed by the user and the folder must belong to the user.
+	UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (Webhook, error)
+	UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) error
+	UpsertDigestSettings(ctx context.Context, arg UpsertDigestSettingsParams) (DigestSetting, error)
+	// A subscription is renewed when the callback subscribes again to the topic.
+	UpsertHubSubscription(ctx context.Context, arg UpsertHubSubscriptionParams) (HubSubscription, error)
+	// The secret of an existing subscription is kept, it is pending again when the hub or the topic changes.
+	UpsertWebSubSubscription(ctx context.Context, arg UpsertWebSubSubscriptionParams) (WebSubSubscription, error)
+	// A refresh token is used once, it is deleted and replaced by a new one.
+	// It is refused once the API key or the session it was issued for has expired, or once its user is suspended.
+	UseRefreshToken(ctx context.Context, token string) (UseRefreshTokenRow, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,135 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.25.0
+// source: refresh_tokens.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createRefreshToken = `-- name: CreateRefreshToken :one
+WITH token AS (
+    SELECT encode(sha256(convert_to(gen_random_uuid()::text || gen_random_uuid()::text, 'UTF8')), 'hex')::text AS token
+), expired AS (
+    DELETE FROM refresh_tokens
+    WHERE refresh_tokens.user_id = $1
+    AND refresh_tokens.expires_at <= NOW()
+), t AS (
+    INSERT INTO refresh_tokens (user_id, api_key_id, session_id, token_hash, scopes, expires_at)
+    SELECT $1, $2, $3,
+        encode(sha256(convert_to(token.token, 'UTF8')), 'hex'),
+        $4::text[],
+        $5::timestamptz
+    FROM token
+    RETURNING id, user_id, api_key_id, session_id, token_hash, scopes, expires_at, created_at
+)
+SELECT t.id, t.user_id, t.api_key_id, t.session_id, t.scopes::text[] AS scopes, t.expires_at, token.token
+FROM t, token
+`
+
+type CreateRefreshTokenParams struct {
+	UserID    uuid.UUID     `json:"user_id"`
+	ApiKeyID  uuid.NullUUID `json:"api_key_id"`
+	SessionID uuid.NullUUID `json:"session_id"`
+	Scopes    []string      `json:"scopes"`
+	ExpiresAt time.Time     `json:"expires_at"`
+}
+
+type CreateRefreshTokenRow struct {
+	ID        uuid.UUID     `json:"id"`
+	UserID    uuid.UUID     `json:"user_id"`
+	ApiKeyID  uuid.NullUUID `json:"api_key_id"`
+	SessionID uuid.NullUUID `json:"session_id"`
+	Scopes    []string      `json:"scopes"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	Token     string        `json:"token"`
+}
+
+// The refresh token is only returned at the creation, its hash is stored.
+// The expired refresh tokens of the user are deleted meanwhile.
+func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (CreateRefreshTokenRow, error) {
+	row := q.db.QueryRowContext(ctx, createRefreshToken,
+		arg.UserID,
+		arg.ApiKeyID,
+		arg.SessionID,
+		pq.Array(arg.Scopes),
+		arg.ExpiresAt,
+	)
+	var i CreateRefreshTokenRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ApiKeyID,
+		&i.SessionID,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+		&i.Token,
+	)
+	return i, err
+}
+
+const deleteRefreshToken = `-- name: DeleteRefreshToken :exec
+DELETE FROM refresh_tokens
+WHERE token_hash = encode(sha256(convert_to($1::text, 'UTF8')), 'hex')
+`
+
+func (q *Queries) DeleteRefreshToken(ctx context.Context, token string) error {
+	_, err := q.db.ExecContext(ctx, deleteRefreshToken, token)
+	return err
+}
+
+const deleteUserRefreshTokens = `-- name: DeleteUserRefreshTokens :exec
+DELETE FROM refresh_tokens WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUserRefreshTokens, userID)
+	return err
+}
+
+const useRefreshToken = `-- name: UseRefreshToken :one
+DELETE FROM refresh_tokens r
+WHERE r.token_hash = encode(sha256(convert_to($1::text, 'UTF8')), 'hex')
+AND r.expires_at > NOW()
+AND NOT EXISTS (
+    SELECT 1 FROM api_keys k WHERE k.id = r.api_key_id AND k.expires_at <= NOW()
+)
+AND NOT EXISTS (
+    SELECT 1 FROM sessions s WHERE s.id = r.session_id AND s.expires_at <= NOW()
+)
+AND NOT EXISTS (
+    SELECT 1 FROM users u WHERE u.id = r.user_id AND u.suspended_at IS NOT NULL
+)
+RETURNING r.id, r.user_id, r.api_key_id, r.session_id, r.scopes::text[] AS scopes, r.expires_at
+`
+
+type UseRefreshTokenRow struct {
+	ID        uuid.UUID     `json:"id"`
+	UserID    uuid.UUID     `json:"user_id"`
+	ApiKeyID  uuid.NullUUID `json:"api_key_id"`
+	SessionID uuid.NullUUID `json:"session_id"`
+	Scopes    []string      `json:"scopes"`
+	ExpiresAt time.Time     `json:"expires_at"`
+}
+
+// A refresh token is used once, it is deleted and replaced by a new one.
+// It is refused once the API key or the session it was issued for has expired, or once its user is suspended.
+func (q *Queries) UseRefreshToken(ctx context.Context, token string) (UseRefreshTokenRow, error) {
+	row := q.db.QueryRowContext(ctx, useRefreshToken, token)
+	var i UseRefreshTokenRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ApiKeyID,
+		&i.SessionID,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+	)
+	return i, err
+}
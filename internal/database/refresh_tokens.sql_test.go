@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueries_RefreshToken(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+	keys, err := testQueries.ListApiKeys(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+
+	token, err := testQueries.CreateRefreshToken(ctx, CreateRefreshTokenParams{
+		UserID:    user.ID,
+		ApiKeyID:  uuid.NullUUID{UUID: keys[0].ID, Valid: true},
+		Scopes:    []string{"feeds:read"},
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Len(t, token.Token, 64)
+
+	// A refresh token is used once, the new one keeps its user, scopes and API key.
+	userRepository := NewUserRepository(testDB)
+	next, err := userRepository.RotateRefreshToken(ctx, token.Token, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.NotEqual(t, token.Token, next.Token)
+	assert.Equal(t, user.ID, next.UserID)
+	assert.Equal(t, []string{"feeds:read"}, next.Scopes)
+	assert.Equal(t, keys[0].ID, next.ApiKeyID.UUID)
+	_, err = userRepository.RotateRefreshToken(ctx, token.Token, time.Now().Add(time.Hour))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// It is revoked with its API key.
+	require.NoError(t, testQueries.DeleteApiKey(ctx, DeleteApiKeyParams{ID: keys[0].ID, UserID: user.ID}))
+	_, err = testQueries.UseRefreshToken(ctx, next.Token)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestQueries_RefreshToken_Expired(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+
+	expired, err := testQueries.CreateRefreshToken(ctx, CreateRefreshTokenParams{
+		UserID:    user.ID,
+		Scopes:    []string{"feeds:read"},
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	_, err = testQueries.UseRefreshToken(ctx, expired.Token)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// The refresh token of an expired API key is refused.
+	key, err := testQueries.CreateApiKey(ctx, CreateApiKeyParams{
+		UserID:    user.ID,
+		Name:      "expired",
+		Scopes:    []string{"feeds:read"},
+		ExpiresAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+	require.NoError(t, err)
+	token, err := testQueries.CreateRefreshToken(ctx, CreateRefreshTokenParams{
+		UserID:    user.ID,
+		ApiKeyID:  uuid.NullUUID{UUID: key.ID, Valid: true},
+		Scopes:    []string{"feeds:read"},
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	_, err = testDB.ExecContext(ctx, "UPDATE api_keys SET expires_at = NOW() - INTERVAL '1 minute' WHERE id = $1", key.ID)
+	require.NoError(t, err)
+	_, err = testQueries.UseRefreshToken(ctx, token.Token)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// The revoked refresh tokens are not found.
+	token, err = testQueries.CreateRefreshToken(ctx, CreateRefreshTokenParams{
+		UserID:    user.ID,
+		Scopes:    []string{"feeds:read"},
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.NoError(t, testQueries.DeleteRefreshToken(ctx, token.Token))
+	_, err = testQueries.UseRefreshToken(ctx, token.Token)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestQueries_UseRefreshToken_Suspended(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+	token, err := testQueries.CreateRefreshToken(ctx, CreateRefreshTokenParams{
+		UserID:    user.ID,
+		Scopes:    []string{"feeds:read"},
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// The refresh token of a suspended user is refused, even before it is revoked.
+	_, err = testQueries.SuspendUser(ctx, user.ID)
+	require.NoError(t, err)
+	_, err = testQueries.UseRefreshToken(ctx, token.Token)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
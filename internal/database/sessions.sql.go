@@ -0,0 +1,115 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.25.0
+// source: sessions.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createSession = `-- name: CreateSession :one
+WITH token AS (
+    SELECT encode(sha256(convert_to(gen_random_uuid()::text || gen_random_uuid()::text, 'UTF8')), 'hex')::text AS token,
+        encode(sha256(convert_to(gen_random_uuid()::text || gen_random_uuid()::text, 'UTF8')), 'hex')::text AS csrf_token
+), expired AS (
+    DELETE FROM sessions
+    WHERE sessions.user_id = $1
+    AND sessions.expires_at <= NOW()
+), s AS (
+    INSERT INTO sessions (user_id, token_hash, csrf_token, expires_at)
+    SELECT $1, encode(sha256(convert_to(token.token, 'UTF8')), 'hex'), token.csrf_token, $2::timestamptz
+    FROM token
+    RETURNING id, user_id, token_hash, csrf_token, expires_at, created_at
+)
+SELECT s.id, s.user_id, s.csrf_token, s.expires_at, s.created_at, token.token
+FROM s, token
+`
+
+type CreateSessionParams struct {
+	UserID    uuid.UUID `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type CreateSessionRow struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	CsrfToken string    `json:"csrf_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	Token     string    `json:"token"`
+}
+
+// The session token is only returned at the creation, its hash is stored.
+// The expired sessions of the user are deleted meanwhile.
+func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (CreateSessionRow, error) {
+	row := q.db.QueryRowContext(ctx, createSession, arg.UserID, arg.ExpiresAt)
+	var i CreateSessionRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CsrfToken,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.Token,
+	)
+	return i, err
+}
+
+const deleteSession = `-- name: DeleteSession :exec
+DELETE FROM sessions
+WHERE token_hash = encode(sha256(convert_to($1::text, 'UTF8')), 'hex')
+`
+
+func (q *Queries) DeleteSession(ctx context.Context, token string) error {
+	_, err := q.db.ExecContext(ctx, deleteSession, token)
+	return err
+}
+
+const deleteUserSessions = `-- name: DeleteUserSessions :exec
+DELETE FROM sessions WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserSessions(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUserSessions, userID)
+	return err
+}
+
+const getUserFromSession = `-- name: GetUserFromSession :one
+SELECT users.id, users.name, users.created_at, users.updated_at, users.feed_token, users.email, users.password_hash, users.role, users.suspended_at, sessions.id AS session_id, sessions.csrf_token
+FROM sessions JOIN users ON users.id = sessions.user_id
+WHERE sessions.token_hash = encode(sha256(convert_to($1::text, 'UTF8')), 'hex')
+AND sessions.expires_at > NOW()
+AND users.suspended_at IS NULL
+`
+
+type GetUserFromSessionRow struct {
+	User      User      `json:"user"`
+	SessionID uuid.UUID `json:"session_id"`
+	CsrfToken string    `json:"csrf_token"`
+}
+
+// Returns the user of a session which has not expired, with the session id and its CSRF token.
+// The sessions of the suspended users are refused.
+func (q *Queries) GetUserFromSession(ctx context.Context, token string) (GetUserFromSessionRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserFromSession, token)
+	var i GetUserFromSessionRow
+	err := row.Scan(
+		&i.User.ID,
+		&i.User.Name,
+		&i.User.CreatedAt,
+		&i.User.UpdatedAt,
+		&i.User.FeedToken,
+		&i.User.Email,
+		&i.User.PasswordHash,
+		&i.User.Role,
+		&i.User.SuspendedAt,
+		&i.SessionID,
+		&i.CsrfToken,
+	)
+	return i, err
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueries_CreateSession(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+
+	session, err := testQueries.CreateSession(ctx, CreateSessionParams{
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Len(t, session.Token, 64)
+	require.Len(t, session.CsrfToken, 64)
+	assert.NotEqual(t, session.Token, session.CsrfToken)
+
+	found, err := testQueries.GetUserFromSession(ctx, session.Token)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.User.ID)
+	assert.Equal(t, session.ID, found.SessionID)
+	assert.Equal(t, session.CsrfToken, found.CsrfToken)
+
+	// The CSRF token does not authenticate the user.
+	_, err = testQueries.GetUserFromSession(ctx, session.CsrfToken)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, testQueries.DeleteSession(ctx, session.Token))
+	_, err = testQueries.GetUserFromSession(ctx, session.Token)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestQueries_CreateSession_Expired(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+
+	expired, err := testQueries.CreateSession(ctx, CreateSessionParams{
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	_, err = testQueries.GetUserFromSession(ctx, expired.Token)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// The expired sessions of the user are deleted when a session is created.
+	_, err = testQueries.CreateSession(ctx, CreateSessionParams{
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	var count int
+	require.NoError(t, testQueries.db.QueryRowContext(ctx, "SELECT count(*) FROM sessions WHERE user_id = $1", user.ID).Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestQueries_GetUserFromSession_Suspended(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+	session, err := testQueries.CreateSession(ctx, CreateSessionParams{
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// The session of a suspended user is refused, even before it is revoked.
+	_, err = testQueries.SuspendUser(ctx, user.ID)
+	require.NoError(t, err)
+	_, err = testQueries.GetUserFromSession(ctx, session.Token)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,199 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.25.0
+// source: starred_posts.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const listAllStarredPosts = `-- name: ListAllStarredPosts :many
+SELECT id, user_id, post_id, feed_id, title, url, description, published_at, created_at, updated_at FROM starred_posts
+WHERE user_id = $1
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListAllStarredPosts(ctx context.Context, userID uuid.UUID) ([]StarredPost, error) {
+	rows, err := q.db.QueryContext(ctx, listAllStarredPosts, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []StarredPost{}
+	for rows.Next() {
+		var i StarredPost
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.PostID,
+			&i.FeedID,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStarredPostIDs = `-- name: ListStarredPostIDs :many
+SELECT post_id::integer FROM starred_posts
+WHERE user_id = $1
+AND post_id IS NOT NULL
+ORDER BY post_id ASC
+`
+
+// Returns the ids of the starred posts still stored.
+func (q *Queries) ListStarredPostIDs(ctx context.Context, userID uuid.UUID) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, listStarredPostIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var post_id int32
+		if err := rows.Scan(&post_id); err != nil {
+			return nil, err
+		}
+		items = append(items, post_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStarredPosts = `-- name: ListStarredPosts :many
+SELECT id, user_id, post_id, feed_id, title, url, description, published_at, created_at, updated_at FROM starred_posts
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+OFFSET $3
+`
+
+type ListStarredPostsParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	Limit  int32     `json:"limit"`
+	Offset int32     `json:"offset"`
+}
+
+func (q *Queries) ListStarredPosts(ctx context.Context, arg ListStarredPostsParams) ([]StarredPost, error) {
+	rows, err := q.db.QueryContext(ctx, listStarredPosts, arg.UserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []StarredPost{}
+	for rows.Next() {
+		var i StarredPost
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.PostID,
+			&i.FeedID,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const starPost = `-- name: StarPost :one
+INSERT INTO starred_posts (user_id, post_id, feed_id, title, url, description, published_at)
+SELECT ff.user_id, p.id, p.feed_id, p.title, p.url, p.description, p.published_at
+FROM posts p
+    JOIN feed_follows ff ON ff.feed_id = p.feed_id
+WHERE ff.user_id = $1::uuid
+AND p.id = $2
+LIMIT 1
+ON CONFLICT (user_id, url) DO UPDATE
+SET post_id = EXCLUDED.post_id, updated_at = NOW()
+RETURNING id, user_id, post_id, feed_id, title, url, description, published_at, created_at, updated_at
+`
+
+type StarPostParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	PostID int32     `json:"post_id"`
+}
+
+func (q *Queries) StarPost(ctx context.Context, arg StarPostParams) (StarredPost, error) {
+	row := q.db.QueryRowContext(ctx, starPost, arg.UserID, arg.PostID)
+	var i StarredPost
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.PostID,
+		&i.FeedID,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const unstarPost = `-- name: UnstarPost :exec
+DELETE FROM starred_posts
+WHERE id = $1
+AND user_id = $2
+`
+
+type UnstarPostParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) UnstarPost(ctx context.Context, arg UnstarPostParams) error {
+	_, err := q.db.ExecContext(ctx, unstarPost, arg.ID, arg.UserID)
+	return err
+}
+
+const unstarPostByPostID = `-- name: UnstarPostByPostID :exec
+DELETE FROM starred_posts
+WHERE post_id = $1::integer
+AND user_id = $2
+`
+
+type UnstarPostByPostIDParams struct {
+	PostID int32     `json:"post_id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) UnstarPostByPostID(ctx context.Context, arg UnstarPostByPostIDParams) error {
+	_, err := q.db.ExecContext(ctx, unstarPostByPostID, arg.PostID, arg.UserID)
+	return err
+}
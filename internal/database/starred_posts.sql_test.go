@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomFollowedPost(t *testing.T) (FeedFollow, Post) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	feed := CreateRandomFeed(t)
+	follow, err := testQueries.CreateFeedFollows(ctx, CreateFeedFollowsParams{
+		UserID: feed.UserID,
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	post, err := testQueries.CreatePost(ctx, CreatePostParams{
+		Title:       generator.RandomString(10),
+		Url:         generator.RandomURL(6),
+		Description: generator.RandomString(50),
+		PublishedAt: time.Now().UTC().Round(time.Microsecond),
+		FeedID:      uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	return follow, post
+}
+
+func TestQueries_StarPost(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	follow, post := createRandomFollowedPost(t)
+
+	starred, err := testQueries.StarPost(ctx, StarPostParams{
+		UserID: follow.UserID.UUID,
+		PostID: post.ID,
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, starred.ID)
+	assert.Equal(t, follow.UserID.UUID, starred.UserID)
+	assert.Equal(t, sql.NullInt32{Int32: post.ID, Valid: true}, starred.PostID)
+	assert.Equal(t, post.FeedID, starred.FeedID)
+	assert.Equal(t, post.Title, starred.Title)
+	assert.Equal(t, post.Url, starred.Url)
+
+	// Starring twice the same post does not duplicate it.
+	starred2, err := testQueries.StarPost(ctx, StarPostParams{
+		UserID: follow.UserID.UUID,
+		PostID: post.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, starred.ID, starred2.ID)
+}
+
+func TestQueries_StarPost_NotFollowed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, post := createRandomFollowedPost(t)
+	user := CreateRandomUser(t)
+
+	starred, err := testQueries.StarPost(ctx, StarPostParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	assert.Empty(t, starred)
+}
+
+func TestQueries_ListStarredPosts_KeepsPurgedPosts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	follow, post := createRandomFollowedPost(t)
+	starred, err := testQueries.StarPost(ctx, StarPostParams{
+		UserID: follow.UserID.UUID,
+		PostID: post.ID,
+	})
+	require.NoError(t, err)
+
+	// Unfollow the feed and purge the post.
+	err = testQueries.DeleteFeedFollows(ctx, DeleteFeedFollowsParams{
+		ID:     follow.ID,
+		UserID: follow.UserID,
+	})
+	require.NoError(t, err)
+	_, err = testDB.ExecContext(ctx, "DELETE FROM posts WHERE id = $1", post.ID)
+	require.NoError(t, err)
+
+	list, err := testQueries.ListStarredPosts(ctx, ListStarredPostsParams{
+		UserID: follow.UserID.UUID,
+		Limit:  10,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, starred.ID, list[0].ID)
+	assert.False(t, list[0].PostID.Valid)
+	assert.Equal(t, post.Url, list[0].Url)
+}
+
+func TestQueries_UnstarPost(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	follow, post := createRandomFollowedPost(t)
+	starred, err := testQueries.StarPost(ctx, StarPostParams{
+		UserID: follow.UserID.UUID,
+		PostID: post.ID,
+	})
+	require.NoError(t, err)
+
+	err = testQueries.UnstarPost(ctx, UnstarPostParams{
+		ID:     starred.ID,
+		UserID: follow.UserID.UUID,
+	})
+	require.NoError(t, err)
+
+	list, err := testQueries.ListStarredPosts(ctx, ListStarredPostsParams{
+		UserID: follow.UserID.UUID,
+		Limit:  10,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
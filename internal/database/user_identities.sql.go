@@ -0,0 +1,78 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.25.0
+// source: user_identities.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createUserIdentity = `-- name: CreateUserIdentity :one
+INSERT INTO user_identities (user_id, issuer, subject, email)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, issuer, subject, email, created_at, updated_at
+`
+
+type CreateUserIdentityParams struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Issuer  string    `json:"issuer"`
+	Subject string    `json:"subject"`
+	Email   string    `json:"email"`
+}
+
+func (q *Queries) CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error) {
+	row := q.db.QueryRowContext(ctx, createUserIdentity,
+		arg.UserID,
+		arg.Issuer,
+		arg.Subject,
+		arg.Email,
+	)
+	var i UserIdentity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Issuer,
+		&i.Subject,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getUserFromIdentity = `-- name: GetUserFromIdentity :one
+WITH i AS (
+    UPDATE user_identities SET email = $1::text, updated_at = NOW()
+    WHERE issuer = $2::text AND subject = $3::text
+    RETURNING user_id
+)
+SELECT users.id, users.name, users.created_at, users.updated_at, users.feed_token, users.email, users.password_hash, users.role, users.suspended_at FROM users JOIN i ON users.id = i.user_id
+`
+
+type GetUserFromIdentityParams struct {
+	Email   string `json:"email"`
+	Issuer  string `json:"issuer"`
+	Subject string `json:"subject"`
+}
+
+// Returns the user of an identity, its email at the provider is updated meanwhile.
+func (q *Queries) GetUserFromIdentity(ctx context.Context, arg GetUserFromIdentityParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserFromIdentity, arg.Email, arg.Issuer, arg.Subject)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedToken,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
@@ -3,7 +3,9 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -22,21 +24,63 @@ func NewUserRepository(db *sql.DB) UserRepository {
 	}
 }
 
-// GetUserFromApiKey returns the user with the given api key.
-func (u UserRepository) GetUserFromApiKey(ctx context.Context, apiKey string) (User, error) {
+// GetUserFromApiKey returns the user with the given api key, with the id and the scopes of the key.
+func (u UserRepository) GetUserFromApiKey(ctx context.Context, apiKey string) (GetUserFromApiKeyRow, error) {
 	user, err := u.queries.GetUserFromApiKey(ctx, apiKey)
 	if err != nil {
-		return User{}, fmt.Errorf("error getting user from api key: %w", err)
+		return GetUserFromApiKeyRow{}, fmt.Errorf("error getting user from api key: %w", err)
 	}
 
 	return user, nil
 }
 
 // CreateUser creates a new user.
-func (u UserRepository) CreateUser(ctx context.Context, name string) (User, error) {
+// The API key of the user is only returned here.
+func (u UserRepository) CreateUser(ctx context.Context, name string) (CreateUserRow, error) {
 	user, err := u.queries.CreateUser(ctx, name)
 	if err != nil {
-		return User{}, fmt.Errorf("error creating user: %w", err)
+		return CreateUserRow{}, fmt.Errorf("error creating user: %w", err)
+	}
+
+	return user, nil
+}
+
+// CreateApiKey creates an API key of the user.
+// The key is only returned here.
+func (u UserRepository) CreateApiKey(ctx context.Context, arg CreateApiKeyParams) (CreateApiKeyRow, error) {
+	key, err := u.queries.CreateApiKey(ctx, arg)
+	if err != nil {
+		return CreateApiKeyRow{}, fmt.Errorf("error creating api key: %w", err)
+	}
+
+	return key, nil
+}
+
+// ListApiKeys lists the API keys of the user, without the keys themselves.
+func (u UserRepository) ListApiKeys(ctx context.Context, userID uuid.UUID) ([]ApiKey, error) {
+	keys, err := u.queries.ListApiKeys(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// DeleteApiKey revokes an API key of the user.
+func (u UserRepository) DeleteApiKey(ctx context.Context, arg DeleteApiKeyParams) error {
+	if err := u.queries.DeleteApiKey(ctx, arg); err != nil {
+		return fmt.Errorf("error deleting api key: %w", err)
+	}
+
+	return nil
+}
+
+// RotateApiKey replaces an API key of the user by a new one with the same name, scopes and expiration.
+// The previous key still works until the given expiration, or is revoked at once without expiration.
+func (u UserRepository) RotateApiKey(ctx context.Context, arg RotateApiKeyParams) (RotateApiKeyRow, error) {
+	user, err := u.queries.RotateApiKey(ctx, arg)
+	if err != nil {
+		return RotateApiKeyRow{}, fmt.Errorf("error rotating api key: %w", err)
 	}
 
 	return user, nil
@@ -51,3 +95,318 @@ func (u UserRepository) GetUserFromId(ctx context.Context, id uuid.UUID) (User,
 
 	return user, nil
 }
+
+// ListUsers returns a list of all the users.
+func (u UserRepository) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	users, err := u.queries.ListUsers(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("error listing users: %w", err)
+	}
+
+	return users, nil
+}
+
+// SetUserRole sets the role of the user.
+func (u UserRepository) SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error) {
+	user, err := u.queries.SetUserRole(ctx, arg)
+	if err != nil {
+		return User{}, fmt.Errorf("error setting user role: %w", err)
+	}
+
+	return user, nil
+}
+
+// SuspendUser suspends the user, its API keys are refused and its sessions and refresh tokens are revoked.
+// The access tokens already issued stay valid until they expire.
+func (u UserRepository) SuspendUser(ctx context.Context, id uuid.UUID) (User, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return User{}, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := u.queries.WithTx(tx)
+	user, err := qtx.SuspendUser(ctx, id)
+	if err != nil {
+		return User{}, fmt.Errorf("error suspending user: %w", err)
+	}
+	if err := qtx.DeleteUserSessions(ctx, id); err != nil {
+		return User{}, fmt.Errorf("error deleting user sessions: %w", err)
+	}
+	if err := qtx.DeleteUserRefreshTokens(ctx, id); err != nil {
+		return User{}, fmt.Errorf("error deleting user refresh tokens: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return User{}, fmt.Errorf("error committing suspension: %w", err)
+	}
+
+	return user, nil
+}
+
+// UnsuspendUser lifts the suspension of the user, its API keys are accepted again.
+func (u UserRepository) UnsuspendUser(ctx context.Context, id uuid.UUID) (User, error) {
+	user, err := u.queries.UnsuspendUser(ctx, id)
+	if err != nil {
+		return User{}, fmt.Errorf("error unsuspending user: %w", err)
+	}
+
+	return user, nil
+}
+
+// UpdateUser updates the name of the user, and its email when given.
+func (u UserRepository) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	user, err := u.queries.UpdateUser(ctx, arg)
+	if err != nil {
+		return User{}, fmt.Errorf("error updating user: %w", err)
+	}
+
+	return user, nil
+}
+
+// DeleteUser deletes the user with all its data.
+// The feeds it created which other users still follow are kept without creator, the others are deleted.
+// It returns sql.ErrNoRows when the user does not exist.
+func (u UserRepository) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := u.queries.WithTx(tx)
+	if _, err := qtx.GetUserFromId(ctx, id); err != nil {
+		return fmt.Errorf("error getting user from id: %w", err)
+	}
+	if _, err := qtx.OrphanFollowedFeeds(ctx, uuid.NullUUID{UUID: id, Valid: true}); err != nil {
+		return fmt.Errorf("error orphaning followed feeds: %w", err)
+	}
+	if err := qtx.DeleteUser(ctx, id); err != nil {
+		return fmt.Errorf("error deleting user: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing user deletion: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserFromFeedToken returns the user with the given id and feed token.
+func (u UserRepository) GetUserFromFeedToken(ctx context.Context, arg GetUserFromFeedTokenParams) (User, error) {
+	user, err := u.queries.GetUserFromFeedToken(ctx, arg)
+	if err != nil {
+		return User{}, fmt.Errorf("error getting user from feed token: %w", err)
+	}
+
+	return user, nil
+}
+
+// RotateFeedToken replaces the feed token of the user by a new one.
+// The subscriptions to its timeline feeds, authenticated by the previous token, are deleted.
+func (u UserRepository) RotateFeedToken(ctx context.Context, id uuid.UUID) (User, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return User{}, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := u.queries.WithTx(tx)
+	user, err := qtx.RotateFeedToken(ctx, id)
+	if err != nil {
+		return User{}, fmt.Errorf("error rotating feed token: %w", err)
+	}
+	if err := qtx.DeleteUserHubSubscriptions(ctx, id); err != nil {
+		return User{}, fmt.Errorf("error deleting user hub subscriptions: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return User{}, fmt.Errorf("error committing feed token: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetUserFromFeverKey returns the user with the given Fever key, with the scopes of its API key.
+func (u UserRepository) GetUserFromFeverKey(ctx context.Context, feverKey string) (GetUserFromFeverKeyRow, error) {
+	user, err := u.queries.GetUserFromFeverKey(ctx, feverKey)
+	if err != nil {
+		return GetUserFromFeverKeyRow{}, fmt.Errorf("error getting user from fever key: %w", err)
+	}
+
+	return user, nil
+}
+
+// RegisterUser creates a user with an email and the hash of a password, without API key.
+func (u UserRepository) RegisterUser(ctx context.Context, arg RegisterUserParams) (User, error) {
+	user, err := u.queries.RegisterUser(ctx, arg)
+	if err != nil {
+		return User{}, fmt.Errorf("error registering user: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetUserFromEmail returns the user with the given email, without regard to case.
+func (u UserRepository) GetUserFromEmail(ctx context.Context, email string) (User, error) {
+	user, err := u.queries.GetUserFromEmail(ctx, email)
+	if err != nil {
+		return User{}, fmt.Errorf("error getting user from email: %w", err)
+	}
+
+	return user, nil
+}
+
+// CreateSession opens a session of the user until the given expiration.
+// The session token is only returned here.
+func (u UserRepository) CreateSession(ctx context.Context, arg CreateSessionParams) (CreateSessionRow, error) {
+	session, err := u.queries.CreateSession(ctx, arg)
+	if err != nil {
+		return CreateSessionRow{}, fmt.Errorf("error creating session: %w", err)
+	}
+
+	return session, nil
+}
+
+// GetUserFromSession returns the user of the session token, with the id and the CSRF token of the session.
+func (u UserRepository) GetUserFromSession(ctx context.Context, token string) (GetUserFromSessionRow, error) {
+	user, err := u.queries.GetUserFromSession(ctx, token)
+	if err != nil {
+		return GetUserFromSessionRow{}, fmt.Errorf("error getting user from session: %w", err)
+	}
+
+	return user, nil
+}
+
+// DeleteSession closes the session of the token.
+func (u UserRepository) DeleteSession(ctx context.Context, token string) error {
+	if err := u.queries.DeleteSession(ctx, token); err != nil {
+		return fmt.Errorf("error deleting session: %w", err)
+	}
+
+	return nil
+}
+
+// Identity is the identity of a user at an OpenID Connect provider.
+type Identity struct {
+	Issuer  string
+	Subject string
+	Email   string
+	// EmailVerified tells whether the provider verified the email, only a verified email links an existing user.
+	EmailVerified bool
+	// Name is the name of the user created at the first login.
+	Name string
+}
+
+// GetOrCreateUserFromIdentity returns the user of an identity at an OpenID Connect provider.
+// At the first login with the identity, it is linked to the user with the same email when the provider verified it,
+// otherwise a user is created, without API key nor password.
+func (u UserRepository) GetOrCreateUserFromIdentity(ctx context.Context, identity Identity) (User, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return User{}, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := u.queries.WithTx(tx)
+	user, err := qtx.GetUserFromIdentity(ctx, GetUserFromIdentityParams{
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+		Email:   identity.Email,
+	})
+	if err == nil {
+		if err := tx.Commit(); err != nil {
+			return User{}, fmt.Errorf("error committing identity: %w", err)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return User{}, fmt.Errorf("error getting user from identity: %w", err)
+	}
+
+	// An unverified email could be the one of another user, it is not kept.
+	email := ""
+	if identity.EmailVerified {
+		email = identity.Email
+	}
+
+	user, err = qtx.GetUserFromEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		user, err = qtx.RegisterUser(ctx, RegisterUserParams{
+			Name:  identity.Name,
+			Email: email,
+		})
+		if err != nil {
+			return User{}, fmt.Errorf("error creating user from identity: %w", err)
+		}
+	} else if err != nil {
+		return User{}, fmt.Errorf("error getting user from email: %w", err)
+	}
+
+	if _, err := qtx.CreateUserIdentity(ctx, CreateUserIdentityParams{
+		UserID:  user.ID,
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+		Email:   identity.Email,
+	}); err != nil {
+		return User{}, fmt.Errorf("error creating user identity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return User{}, fmt.Errorf("error committing identity: %w", err)
+	}
+
+	return user, nil
+}
+
+// CreateRefreshToken creates a refresh token of the user, for the API key or the session authenticating it.
+// The refresh token is only returned here.
+func (u UserRepository) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (CreateRefreshTokenRow, error) {
+	token, err := u.queries.CreateRefreshToken(ctx, arg)
+	if err != nil {
+		return CreateRefreshTokenRow{}, fmt.Errorf("error creating refresh token: %w", err)
+	}
+
+	return token, nil
+}
+
+// RotateRefreshToken replaces a refresh token by a new one until the given expiration,
+// with the same user, scopes, API key and session. A refresh token is only used once.
+func (u UserRepository) RotateRefreshToken(ctx context.Context, token string, expiresAt time.Time) (CreateRefreshTokenRow, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return CreateRefreshTokenRow{}, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := u.queries.WithTx(tx)
+	used, err := qtx.UseRefreshToken(ctx, token)
+	if err != nil {
+		return CreateRefreshTokenRow{}, fmt.Errorf("error using refresh token: %w", err)
+	}
+	next, err := qtx.CreateRefreshToken(ctx, CreateRefreshTokenParams{
+		UserID:    used.UserID,
+		ApiKeyID:  used.ApiKeyID,
+		SessionID: used.SessionID,
+		Scopes:    used.Scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return CreateRefreshTokenRow{}, fmt.Errorf("error creating refresh token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return CreateRefreshTokenRow{}, fmt.Errorf("error committing refresh token: %w", err)
+	}
+
+	return next, nil
+}
+
+// DeleteRefreshToken revokes a refresh token.
+func (u UserRepository) DeleteRefreshToken(ctx context.Context, token string) error {
+	if err := u.queries.DeleteRefreshToken(ctx, token); err != nil {
+		return fmt.Errorf("error deleting refresh token: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRepository_GetOrCreateUserFromIdentity(t *testing.T) {
+	userRepository := NewUserRepository(testDB)
+	ctx := context.Background()
+
+	// A user is created at the first login.
+	identity := Identity{
+		Issuer:        "https://sso.example.com",
+		Subject:       generator.RandomString(12),
+		Email:         generator.RandomString(12) + "@example.com",
+		EmailVerified: true,
+		Name:          "Jane Doe",
+	}
+	user, err := userRepository.GetOrCreateUserFromIdentity(ctx, identity)
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe", user.Name)
+	assert.Equal(t, identity.Email, user.Email)
+	assert.Empty(t, user.PasswordHash)
+
+	// Then it is found by its identity, whatever its email at the provider.
+	identity.Email = generator.RandomString(12) + "@example.com"
+	found, err := userRepository.GetOrCreateUserFromIdentity(ctx, identity)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+
+	// The subjects are unique per issuer.
+	identity.Issuer = "https://other.example.com"
+	other, err := userRepository.GetOrCreateUserFromIdentity(ctx, identity)
+	require.NoError(t, err)
+	assert.NotEqual(t, user.ID, other.ID)
+
+	// An identity with the verified email of a user is linked to it.
+	registered, err := testQueries.RegisterUser(ctx, RegisterUserParams{
+		Name:         generator.RandomString(12),
+		Email:        generator.RandomString(12) + "@example.com",
+		PasswordHash: "hash",
+	})
+	require.NoError(t, err)
+	linked, err := userRepository.GetOrCreateUserFromIdentity(ctx, Identity{
+		Issuer:        "https://sso.example.com",
+		Subject:       generator.RandomString(12),
+		Email:         registered.Email,
+		EmailVerified: true,
+		Name:          "Jane Doe",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, registered.ID, linked.ID)
+
+	// An unverified email is not.
+	unverified, err := userRepository.GetOrCreateUserFromIdentity(ctx, Identity{
+		Issuer:  "https://sso.example.com",
+		Subject: generator.RandomString(12),
+		Email:   registered.Email,
+		Name:    "Jane Doe",
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, registered.ID, unverified.ID)
+	assert.Empty(t, unverified.Email)
+}
+
+func TestUserRepository_SuspendUser(t *testing.T) {
+	userRepository := NewUserRepository(testDB)
+	ctx := context.Background()
+
+	user, err := testQueries.RegisterUser(ctx, RegisterUserParams{
+		Name:         generator.RandomString(12),
+		Email:        generator.RandomString(12) + "@example.com",
+		PasswordHash: "hash",
+	})
+	require.NoError(t, err)
+	session, err := testQueries.CreateSession(ctx, CreateSessionParams{UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	refreshToken, err := testQueries.CreateRefreshToken(ctx, CreateRefreshTokenParams{
+		UserID:    user.ID,
+		SessionID: uuid.NullUUID{UUID: session.ID, Valid: true},
+		Scopes:    []string{"feeds:read"},
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// The sessions and the refresh tokens of a suspended user are revoked.
+	suspended, err := userRepository.SuspendUser(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, suspended.SuspendedAt.Valid)
+	_, err = testQueries.GetUserFromSession(ctx, session.Token)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = testQueries.UseRefreshToken(ctx, refreshToken.Token)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, userRepository.DeleteUser(ctx, user.ID))
+	require.ErrorIs(t, userRepository.DeleteUser(ctx, user.ID), sql.ErrNoRows)
+}
+
+func TestUserRepository_DeleteUser(t *testing.T) {
+	userRepository := NewUserRepository(testDB)
+	ctx := context.Background()
+
+	followed := CreateRandomFeed(t)
+	unfollowed, err := testQueries.CreateFeed(ctx, CreateFeedParams{
+		Name:   generator.RandomString(12),
+		Url:    generator.RandomURL(10),
+		UserID: followed.UserID,
+	})
+	require.NoError(t, err)
+	follower := CreateRandomUser(t)
+	_, err = testQueries.CreateFeedFollows(ctx, CreateFeedFollowsParams{
+		UserID: uuid.NullUUID{UUID: follower.ID, Valid: true},
+		FeedID: uuid.NullUUID{UUID: followed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// The feeds other users follow are kept without creator, the others are deleted with the user.
+	require.NoError(t, userRepository.DeleteUser(ctx, followed.UserID.UUID))
+	kept, err := testQueries.GetFeed(ctx, followed.ID)
+	require.NoError(t, err)
+	assert.False(t, kept.UserID.Valid)
+	_, err = testQueries.GetFeed(ctx, unfollowed.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
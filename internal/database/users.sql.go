@@ -7,48 +7,194 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (name)
-VALUES ($1)
-RETURNING id, name, created_at, updated_at, api_key
+WITH key AS (
+    SELECT encode(sha256(convert_to(gen_random_uuid()::text || gen_random_uuid()::text, 'UTF8')), 'hex')::text AS api_key
+), u AS (
+    INSERT INTO users (name)
+    VALUES ($1::text)
+    RETURNING id, name, created_at, updated_at, feed_token, email, password_hash, role, suspended_at
+), k AS (
+    INSERT INTO api_keys (user_id, name, prefix, hash, fever_key)
+    SELECT u.id, 'default',
+        left(key.api_key, 8),
+        encode(sha256(convert_to(key.api_key, 'UTF8')), 'hex'),
+        encode(sha256(convert_to(md5(u.id::text || ':' || key.api_key), 'UTF8')), 'hex')
+    FROM u, key
+)
+SELECT u.id, u.name, u.created_at, u.updated_at, u.feed_token, key.api_key FROM u, key
 `
 
-func (q *Queries) CreateUser(ctx context.Context, name string) (User, error) {
+type CreateUserRow struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	FeedToken string    `json:"feed_token"`
+	ApiKey    string    `json:"api_key"`
+}
+
+// The user is created with a default API key granting all the scopes,
+// it is only returned at the creation, its hash is stored with its prefix.
+func (q *Queries) CreateUser(ctx context.Context, name string) (CreateUserRow, error) {
 	row := q.db.QueryRowContext(ctx, createUser, name)
-	var i User
+	var i CreateUserRow
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.FeedToken,
 		&i.ApiKey,
 	)
 	return i, err
 }
 
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
 const getUserFromApiKey = `-- name: GetUserFromApiKey :one
-SELECT id, name, created_at, updated_at, api_key FROM users WHERE api_key = $1
+WITH k AS (
+    SELECT id, user_id, name, prefix, hash, fever_key, scopes, expires_at, last_used_at, created_at, updated_at FROM api_keys
+    WHERE api_keys.prefix = left($1::text, 8)
+    AND api_keys.hash = encode(sha256(convert_to($1::text, 'UTF8')), 'hex')
+    AND (api_keys.expires_at IS NULL OR api_keys.expires_at > NOW())
+), used AS (
+    UPDATE api_keys SET last_used_at = NOW()
+    FROM k
+    WHERE api_keys.id = k.id
+    AND (k.last_used_at IS NULL OR k.last_used_at < NOW() - INTERVAL '1 minute')
+)
+SELECT users.id, users.name, users.created_at, users.updated_at, users.feed_token, users.email, users.password_hash, users.role, users.suspended_at, k.id AS api_key_id, k.scopes::text[] AS scopes
+FROM k JOIN users ON users.id = k.user_id
+WHERE users.suspended_at IS NULL
 `
 
-func (q *Queries) GetUserFromApiKey(ctx context.Context, apiKey string) (User, error) {
+type GetUserFromApiKeyRow struct {
+	User     User      `json:"user"`
+	ApiKeyID uuid.UUID `json:"api_key_id"`
+	Scopes   []string  `json:"scopes"`
+}
+
+// Returns the user of an API key which has not expired, with the key id and its scopes.
+// The keys of the suspended users are refused.
+// The last use of the key is updated at most once a minute.
+func (q *Queries) GetUserFromApiKey(ctx context.Context, apiKey string) (GetUserFromApiKeyRow, error) {
 	row := q.db.QueryRowContext(ctx, getUserFromApiKey, apiKey)
+	var i GetUserFromApiKeyRow
+	err := row.Scan(
+		&i.User.ID,
+		&i.User.Name,
+		&i.User.CreatedAt,
+		&i.User.UpdatedAt,
+		&i.User.FeedToken,
+		&i.User.Email,
+		&i.User.PasswordHash,
+		&i.User.Role,
+		&i.User.SuspendedAt,
+		&i.ApiKeyID,
+		pq.Array(&i.Scopes),
+	)
+	return i, err
+}
+
+const getUserFromEmail = `-- name: GetUserFromEmail :one
+SELECT id, name, created_at, updated_at, feed_token, email, password_hash, role, suspended_at FROM users WHERE email <> '' AND lower(email) = lower($1::text)
+`
+
+func (q *Queries) GetUserFromEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserFromEmail, email)
 	var i User
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.ApiKey,
+		&i.FeedToken,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const getUserFromFeedToken = `-- name: GetUserFromFeedToken :one
+SELECT id, name, created_at, updated_at, feed_token, email, password_hash, role, suspended_at FROM users WHERE id = $1 AND feed_token = $2 AND suspended_at IS NULL
+`
+
+type GetUserFromFeedTokenParams struct {
+	ID        uuid.UUID `json:"id"`
+	FeedToken string    `json:"feed_token"`
+}
+
+func (q *Queries) GetUserFromFeedToken(ctx context.Context, arg GetUserFromFeedTokenParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserFromFeedToken, arg.ID, arg.FeedToken)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedToken,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const getUserFromFeverKey = `-- name: GetUserFromFeverKey :one
+SELECT users.id, users.name, users.created_at, users.updated_at, users.feed_token, users.email, users.password_hash, users.role, users.suspended_at, k.scopes::text[] AS scopes
+FROM api_keys k JOIN users ON users.id = k.user_id
+WHERE k.fever_key = encode(sha256(convert_to($1::text, 'UTF8')), 'hex')
+AND k.fever_key <> ''
+AND (k.expires_at IS NULL OR k.expires_at > NOW())
+AND users.suspended_at IS NULL
+`
+
+type GetUserFromFeverKeyRow struct {
+	User   User     `json:"user"`
+	Scopes []string `json:"scopes"`
+}
+
+// Returns the user of the API key of a Fever key, with the scopes of the key.
+// The keys replaced before the Fever keys were stored with the API keys have none.
+// Only the hash of the Fever keys is stored.
+func (q *Queries) GetUserFromFeverKey(ctx context.Context, feverKey string) (GetUserFromFeverKeyRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserFromFeverKey, feverKey)
+	var i GetUserFromFeverKeyRow
+	err := row.Scan(
+		&i.User.ID,
+		&i.User.Name,
+		&i.User.CreatedAt,
+		&i.User.UpdatedAt,
+		&i.User.FeedToken,
+		&i.User.Email,
+		&i.User.PasswordHash,
+		&i.User.Role,
+		&i.User.SuspendedAt,
+		pq.Array(&i.Scopes),
 	)
 	return i, err
 }
 
 const getUserFromId = `-- name: GetUserFromId :one
-SELECT id, name, created_at, updated_at, api_key FROM users WHERE id = $1
+SELECT id, name, created_at, updated_at, feed_token, email, password_hash, role, suspended_at FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUserFromId(ctx context.Context, id uuid.UUID) (User, error) {
@@ -59,7 +205,220 @@ func (q *Queries) GetUserFromId(ctx context.Context, id uuid.UUID) (User, error)
 		&i.Name,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.ApiKey,
+		&i.FeedToken,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, name, created_at, updated_at, feed_token, email, password_hash, role, suspended_at FROM users
+ORDER BY created_at ASC
+LIMIT $1
+OFFSET $2
+`
+
+type ListUsersParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.FeedToken,
+			&i.Email,
+			&i.PasswordHash,
+			&i.Role,
+			&i.SuspendedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const registerUser = `-- name: RegisterUser :one
+INSERT INTO users (name, email, password_hash)
+VALUES ($1::text, lower($2::text), $3::text)
+RETURNING id, name, created_at, updated_at, feed_token, email, password_hash, role, suspended_at
+`
+
+type RegisterUserParams struct {
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	PasswordHash string `json:"password_hash"`
+}
+
+// The users registered with an email and a password have no API key, they log in to open a session.
+func (q *Queries) RegisterUser(ctx context.Context, arg RegisterUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, registerUser, arg.Name, arg.Email, arg.PasswordHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedToken,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const rotateFeedToken = `-- name: RotateFeedToken :one
+UPDATE users
+SET feed_token = encode(sha256(convert_to(gen_random_uuid()::text || gen_random_uuid()::text, 'UTF8')), 'hex'),
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, name, created_at, updated_at, feed_token, email, password_hash, role, suspended_at
+`
+
+// The feed token is replaced by a new one, generated like the default one.
+func (q *Queries) RotateFeedToken(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, rotateFeedToken, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedToken,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const setUserRole = `-- name: SetUserRole :one
+UPDATE users SET role = $1::text, updated_at = NOW()
+WHERE id = $2
+RETURNING id, name, created_at, updated_at, feed_token, email, password_hash, role, suspended_at
+`
+
+type SetUserRoleParams struct {
+	Role string    `json:"role"`
+	ID   uuid.UUID `json:"id"`
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserRole, arg.Role, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedToken,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const suspendUser = `-- name: SuspendUser :one
+UPDATE users SET suspended_at = COALESCE(suspended_at, NOW()), updated_at = NOW()
+WHERE id = $1
+RETURNING id, name, created_at, updated_at, feed_token, email, password_hash, role, suspended_at
+`
+
+// A suspended user keeps the date of its first suspension.
+func (q *Queries) SuspendUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, suspendUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedToken,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const unsuspendUser = `-- name: UnsuspendUser :one
+UPDATE users SET suspended_at = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING id, name, created_at, updated_at, feed_token, email, password_hash, role, suspended_at
+`
+
+func (q *Queries) UnsuspendUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, unsuspendUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedToken,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const updateUser = `-- name: UpdateUser :one
+UPDATE users
+SET name = $1::text,
+    email = COALESCE(lower($2::text), email),
+    updated_at = NOW()
+WHERE id = $3
+RETURNING id, name, created_at, updated_at, feed_token, email, password_hash, role, suspended_at
+`
+
+type UpdateUserParams struct {
+	Name  string         `json:"name"`
+	Email sql.NullString `json:"email"`
+	ID    uuid.UUID      `json:"id"`
+}
+
+// The email is only updated when given, it is lowercased like at the registration.
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUser, arg.Name, arg.Email, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedToken,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Role,
+		&i.SuspendedAt,
 	)
 	return i, err
 }
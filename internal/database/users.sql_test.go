@@ -2,8 +2,13 @@ package database
 
 import (
 	"context"
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"strings"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/jbdoumenjou/go-rssaggregator/internal/generator"
 
 	"github.com/stretchr/testify/assert"
@@ -11,7 +16,8 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func CreateRandomUser(t *testing.T) User {
+// createRandomUserWithApiKey creates a user and returns it with its API key, only known at the creation.
+func createRandomUserWithApiKey(t *testing.T) CreateUserRow {
 	t.Helper()
 
 	name := generator.RandomString(12)
@@ -23,7 +29,17 @@ func CreateRandomUser(t *testing.T) User {
 	require.Equal(t, name, user.Name)
 	require.NotEmpty(t, user.CreatedAt)
 	require.NotEmpty(t, user.UpdatedAt)
-	require.NotEmpty(t, user.ApiKey)
+	require.Len(t, user.ApiKey, 64)
+
+	return user
+}
+
+func CreateRandomUser(t *testing.T) User {
+	t.Helper()
+
+	created := createRandomUserWithApiKey(t)
+	user, err := testQueries.GetUserFromId(context.Background(), created.ID)
+	require.NoError(t, err)
 
 	return user
 }
@@ -35,11 +51,17 @@ func TestQueries_CreateUser(t *testing.T) {
 }
 
 func TestQueries_GetUserFromApiKey(t *testing.T) {
-	user := CreateRandomUser(t)
+	user := createRandomUserWithApiKey(t)
 
-	user2, err := testQueries.GetUserFromApiKey(context.Background(), user.ApiKey)
+	// The default key grants all the scopes.
+	key, err := testQueries.GetUserFromApiKey(context.Background(), user.ApiKey)
 	require.NoError(t, err)
-	assert.Equal(t, user, user2)
+	assert.Equal(t, user.ID, key.User.ID)
+	assert.NotEqual(t, uuid.Nil, key.ApiKeyID)
+	assert.ElementsMatch(t, []string{"feeds:read", "feeds:write", "posts:read", "follows:write"}, key.Scopes)
+
+	_, err = testQueries.GetUserFromApiKey(context.Background(), user.ApiKey[:8])
+	require.Error(t, err)
 
 	testQueries.db.QueryContext(context.Background(), "Delete from users where id = $1", user.ID)
 
@@ -77,3 +99,108 @@ func TestQueries_GetUserFromId(t *testing.T) {
 	require.Error(t, err)
 	assert.Empty(t, user3)
 }
+
+func TestQueries_GetUserFromFeedToken(t *testing.T) {
+	user := CreateRandomUser(t)
+	require.NotEmpty(t, user.FeedToken)
+
+	user2, err := testQueries.GetUserFromFeedToken(context.Background(), GetUserFromFeedTokenParams{
+		ID:        user.ID,
+		FeedToken: user.FeedToken,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, user, user2)
+
+	user3, err := testQueries.GetUserFromFeedToken(context.Background(), GetUserFromFeedTokenParams{
+		ID:        user.ID,
+		FeedToken: "unknown",
+	})
+	require.Error(t, err)
+	assert.Empty(t, user3)
+
+	testQueries.db.QueryContext(context.Background(), "Delete from users where id = $1", user.ID)
+}
+
+func TestQueries_GetUserFromFeverKey(t *testing.T) {
+	user := createRandomUserWithApiKey(t)
+
+	sum := md5.Sum([]byte(user.ID.String() + ":" + user.ApiKey))
+	key, err := testQueries.GetUserFromFeverKey(context.Background(), hex.EncodeToString(sum[:]))
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, key.User.ID)
+	assert.Len(t, key.Scopes, 4)
+
+	_, err = testQueries.GetUserFromFeverKey(context.Background(), "")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestQueries_RegisterUser(t *testing.T) {
+	ctx := context.Background()
+
+	email := generator.RandomString(12) + "@Example.com"
+	user, err := testQueries.RegisterUser(ctx, RegisterUserParams{
+		Name:         generator.RandomString(12),
+		Email:        email,
+		PasswordHash: "hash",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, strings.ToLower(email), user.Email)
+	assert.Equal(t, "hash", user.PasswordHash)
+
+	// The email is found and unique without regard to case.
+	found, err := testQueries.GetUserFromEmail(ctx, strings.ToUpper(email))
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+
+	_, err = testQueries.RegisterUser(ctx, RegisterUserParams{
+		Name:         generator.RandomString(12),
+		Email:        strings.ToUpper(email),
+		PasswordHash: "hash",
+	})
+	require.Error(t, err)
+	assert.True(t, IsUniqueViolation(err))
+
+	// The users created with an API key have no email.
+	CreateRandomUser(t)
+	_, err = testQueries.GetUserFromEmail(ctx, "")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestQueries_SuspendUser(t *testing.T) {
+	ctx := context.Background()
+	user := createRandomUserWithApiKey(t)
+
+	suspended, err := testQueries.SuspendUser(ctx, user.ID)
+	require.NoError(t, err)
+	require.True(t, suspended.SuspendedAt.Valid)
+
+	// The credentials of a suspended user are refused.
+	_, err = testQueries.GetUserFromApiKey(ctx, user.ApiKey)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = testQueries.GetUserFromFeedToken(ctx, GetUserFromFeedTokenParams{ID: user.ID, FeedToken: user.FeedToken})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// The date of the first suspension is kept.
+	again, err := testQueries.SuspendUser(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, suspended.SuspendedAt, again.SuspendedAt)
+
+	unsuspended, err := testQueries.UnsuspendUser(ctx, user.ID)
+	require.NoError(t, err)
+	assert.False(t, unsuspended.SuspendedAt.Valid)
+	_, err = testQueries.GetUserFromApiKey(ctx, user.ApiKey)
+	require.NoError(t, err)
+}
+
+func TestQueries_SetUserRole(t *testing.T) {
+	ctx := context.Background()
+	user := CreateRandomUser(t)
+	assert.Equal(t, "user", user.Role)
+
+	admin, err := testQueries.SetUserRole(ctx, SetUserRoleParams{ID: user.ID, Role: "admin"})
+	require.NoError(t, err)
+	assert.Equal(t, "admin", admin.Role)
+
+	_, err = testQueries.SetUserRole(ctx, SetUserRoleParams{ID: user.ID, Role: "owner"})
+	require.Error(t, err)
+}
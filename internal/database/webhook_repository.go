@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// WebhookRepository is responsible for managing the webhooks and their deliveries in the database.
+type WebhookRepository struct {
+	db      *sql.DB
+	queries *Queries
+}
+
+// NewWebhookRepository creates a new WebhookRepository.
+func NewWebhookRepository(db *sql.DB) WebhookRepository {
+	return WebhookRepository{
+		db:      db,
+		queries: New(db),
+	}
+}
+
+// CreateWebhook creates a new webhook.
+func (w WebhookRepository) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	webhook, err := w.queries.CreateWebhook(ctx, arg)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("error creating webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// ListWebhooks returns the webhooks of a user.
+func (w WebhookRepository) ListWebhooks(ctx context.Context, userID uuid.UUID) ([]Webhook, error) {
+	webhooks, err := w.queries.ListWebhooks(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhook returns a webhook of a user.
+func (w WebhookRepository) GetWebhook(ctx context.Context, arg GetWebhookParams) (Webhook, error) {
+	webhook, err := w.queries.GetWebhook(ctx, arg)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("error getting webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// UpdateWebhook updates a webhook.
+func (w WebhookRepository) UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (Webhook, error) {
+	webhook, err := w.queries.UpdateWebhook(ctx, arg)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("error updating webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// DeleteWebhook deletes a webhook and its deliveries.
+func (w WebhookRepository) DeleteWebhook(ctx context.Context, arg DeleteWebhookParams) error {
+	if err := w.queries.DeleteWebhook(ctx, arg); err != nil {
+		return fmt.Errorf("error deleting webhook: %w", err)
+	}
+
+	return nil
+}
+
+// ListWebhooksForPost returns the active webhooks whose scope matches a post.
+func (w WebhookRepository) ListWebhooksForPost(ctx context.Context, postID int32) ([]Webhook, error) {
+	webhooks, err := w.queries.ListWebhooksForPost(ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing webhooks for post: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// CreateWebhookDelivery creates a pending delivery of a post to a webhook.
+// It returns sql.ErrNoRows when the post already has a delivery for this webhook.
+func (w WebhookRepository) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error) {
+	delivery, err := w.queries.CreateWebhookDelivery(ctx, arg)
+	if err != nil {
+		return WebhookDelivery{}, fmt.Errorf("error creating webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// ListDueWebhookDeliveries returns the pending deliveries to attempt now, with the url and the secret of their webhook.
+func (w WebhookRepository) ListDueWebhookDeliveries(ctx context.Context, limit int32) ([]ListDueWebhookDeliveriesRow, error) {
+	deliveries, err := w.queries.ListDueWebhookDeliveries(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing due webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// UpdateWebhookDelivery records the result of a delivery attempt.
+func (w WebhookRepository) UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) error {
+	if err := w.queries.UpdateWebhookDelivery(ctx, arg); err != nil {
+		return fmt.Errorf("error updating webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// ListWebhookDeliveries returns the deliveries of a webhook of a user, the most recent first.
+func (w WebhookRepository) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	deliveries, err := w.queries.ListWebhookDeliveries(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("error listing webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
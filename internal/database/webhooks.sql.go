@@ -0,0 +1,440 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.25.0
+// source: webhooks.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createWebhook = `-- name: CreateWebhook :one
+INSERT INTO webhooks (user_id, url, secret, feed_id, folder_id, apply_filters)
+SELECT $1::uuid, $2::varchar, $3::varchar,
+    $4::uuid, $5::uuid, $6::boolean
+WHERE (
+    $4::uuid IS NULL
+    OR EXISTS (SELECT 1 FROM feed_follows WHERE feed_follows.feed_id = $4 AND feed_follows.user_id = $1)
+)
+AND (
+    $5::uuid IS NULL
+    OR EXISTS (SELECT 1 FROM folders WHERE folders.id = $5 AND folders.user_id = $1)
+)
+RETURNING id, user_id, url, secret, feed_id, folder_id, apply_filters, active, created_at, updated_at
+`
+
+type CreateWebhookParams struct {
+	UserID       uuid.UUID     `json:"user_id"`
+	Url          string        `json:"url"`
+	Secret       string        `json:"secret"`
+	FeedID       uuid.NullUUID `json:"feed_id"`
+	FolderID     uuid.NullUUID `json:"folder_id"`
+	ApplyFilters bool          `json:"apply_filters"`
+}
+
+// The feed must be followed by the user and the folder must belong to the user.
+func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, createWebhook,
+		arg.UserID,
+		arg.Url,
+		arg.Secret,
+		arg.FeedID,
+		arg.FolderID,
+		arg.ApplyFilters,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Url,
+		&i.Secret,
+		&i.FeedID,
+		&i.FolderID,
+		&i.ApplyFilters,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createWebhookDelivery = `-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (webhook_id, post_id, payload)
+VALUES ($1, $2, $3)
+ON CONFLICT (webhook_id, post_id) DO NOTHING
+RETURNING id, webhook_id, post_id, payload, status, attempts, next_attempt_at, response_status, last_error, delivered_at, created_at, updated_at
+`
+
+type CreateWebhookDeliveryParams struct {
+	WebhookID uuid.UUID       `json:"webhook_id"`
+	PostID    sql.NullInt32   `json:"post_id"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// Nothing is returned when the post has already been delivered to the webhook.
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, createWebhookDelivery, arg.WebhookID, arg.PostID, arg.Payload)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookID,
+		&i.PostID,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.ResponseStatus,
+		&i.LastError,
+		&i.DeliveredAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteWebhook = `-- name: DeleteWebhook :exec
+DELETE FROM webhooks
+WHERE id = $1
+AND user_id = $2
+`
+
+type DeleteWebhookParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) DeleteWebhook(ctx context.Context, arg DeleteWebhookParams) error {
+	_, err := q.db.ExecContext(ctx, deleteWebhook, arg.ID, arg.UserID)
+	return err
+}
+
+const getWebhook = `-- name: GetWebhook :one
+SELECT id, user_id, url, secret, feed_id, folder_id, apply_filters, active, created_at, updated_at FROM webhooks
+WHERE id = $1
+AND user_id = $2
+`
+
+type GetWebhookParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) GetWebhook(ctx context.Context, arg GetWebhookParams) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, getWebhook, arg.ID, arg.UserID)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Url,
+		&i.Secret,
+		&i.FeedID,
+		&i.FolderID,
+		&i.ApplyFilters,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listDueWebhookDeliveries = `-- name: ListDueWebhookDeliveries :many
+SELECT webhook_deliveries.id, webhook_deliveries.webhook_id, webhook_deliveries.post_id, webhook_deliveries.payload, webhook_deliveries.status, webhook_deliveries.attempts, webhook_deliveries.next_attempt_at, webhook_deliveries.response_status, webhook_deliveries.last_error, webhook_deliveries.delivered_at, webhook_deliveries.created_at, webhook_deliveries.updated_at, webhooks.url, webhooks.secret
+FROM webhook_deliveries
+    JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+WHERE webhook_deliveries.status = 'pending'
+AND webhook_deliveries.next_attempt_at <= NOW()
+ORDER BY webhook_deliveries.next_attempt_at ASC
+LIMIT $1
+`
+
+type ListDueWebhookDeliveriesRow struct {
+	WebhookDelivery WebhookDelivery `json:"webhook_delivery"`
+	Url             string          `json:"url"`
+	Secret          string          `json:"-"`
+}
+
+func (q *Queries) ListDueWebhookDeliveries(ctx context.Context, limit int32) ([]ListDueWebhookDeliveriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDueWebhookDeliveries, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListDueWebhookDeliveriesRow{}
+	for rows.Next() {
+		var i ListDueWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.WebhookDelivery.ID,
+			&i.WebhookDelivery.WebhookID,
+			&i.WebhookDelivery.PostID,
+			&i.WebhookDelivery.Payload,
+			&i.WebhookDelivery.Status,
+			&i.WebhookDelivery.Attempts,
+			&i.WebhookDelivery.NextAttemptAt,
+			&i.WebhookDelivery.ResponseStatus,
+			&i.WebhookDelivery.LastError,
+			&i.WebhookDelivery.DeliveredAt,
+			&i.WebhookDelivery.CreatedAt,
+			&i.WebhookDelivery.UpdatedAt,
+			&i.Url,
+			&i.Secret,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT webhook_deliveries.id, webhook_deliveries.webhook_id, webhook_deliveries.post_id, webhook_deliveries.payload, webhook_deliveries.status, webhook_deliveries.attempts, webhook_deliveries.next_attempt_at, webhook_deliveries.response_status, webhook_deliveries.last_error, webhook_deliveries.delivered_at, webhook_deliveries.created_at, webhook_deliveries.updated_at
+FROM webhook_deliveries
+    JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+WHERE webhook_deliveries.webhook_id = $1
+AND webhooks.user_id = $2
+ORDER BY webhook_deliveries.created_at DESC
+LIMIT $4
+OFFSET $3
+`
+
+type ListWebhookDeliveriesParams struct {
+	WebhookID uuid.UUID `json:"webhook_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Offset    int32     `json:"offset"`
+	Limit     int32     `json:"limit"`
+}
+
+// The deliveries of a webhook of the user, the most recent first.
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhookDeliveries,
+		arg.WebhookID,
+		arg.UserID,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDelivery{}
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.PostID,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.ResponseStatus,
+			&i.LastError,
+			&i.DeliveredAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhooks = `-- name: ListWebhooks :many
+SELECT id, user_id, url, secret, feed_id, folder_id, apply_filters, active, created_at, updated_at FROM webhooks
+WHERE user_id = $1
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListWebhooks(ctx context.Context, userID uuid.UUID) ([]Webhook, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhooks, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Webhook{}
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Url,
+			&i.Secret,
+			&i.FeedID,
+			&i.FolderID,
+			&i.ApplyFilters,
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhooksForPost = `-- name: ListWebhooksForPost :many
+SELECT w.id, w.user_id, w.url, w.secret, w.feed_id, w.folder_id, w.apply_filters, w.active, w.created_at, w.updated_at
+FROM webhooks w
+    JOIN feed_follows ff ON ff.user_id = w.user_id
+    JOIN posts p ON p.feed_id = ff.feed_id
+WHERE p.id = $1
+AND w.active
+AND ff.notify
+AND (w.feed_id IS NULL OR w.feed_id = p.feed_id)
+AND (w.folder_id IS NULL OR w.folder_id = ff.folder_id)
+AND (NOT w.apply_filters OR post_passes_filters(w.user_id, ff.folder_id, p))
+`
+
+// Returns the active webhooks whose scope matches the post:
+// the post must be part of a feed followed by the owner of the webhook, with the notifications on.
+func (q *Queries) ListWebhooksForPost(ctx context.Context, postID int32) ([]Webhook, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhooksForPost, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Webhook{}
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Url,
+			&i.Secret,
+			&i.FeedID,
+			&i.FolderID,
+			&i.ApplyFilters,
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateWebhook = `-- name: UpdateWebhook :one
+UPDATE webhooks
+SET url = $1,
+    feed_id = $2,
+    folder_id = $3,
+    apply_filters = $4,
+    active = $5,
+    updated_at = NOW()
+WHERE webhooks.id = $6
+AND webhooks.user_id = $7
+AND (
+    $2::uuid IS NULL
+    OR EXISTS (SELECT 1 FROM feed_follows WHERE feed_follows.feed_id = $2 AND feed_follows.user_id = $7)
+)
+AND (
+    $3::uuid IS NULL
+    OR EXISTS (SELECT 1 FROM folders WHERE folders.id = $3 AND folders.user_id = $7)
+)
+RETURNING id, user_id, url, secret, feed_id, folder_id, apply_filters, active, created_at, updated_at
+`
+
+type UpdateWebhookParams struct {
+	Url          string        `json:"url"`
+	FeedID       uuid.NullUUID `json:"feed_id"`
+	FolderID     uuid.NullUUID `json:"folder_id"`
+	ApplyFilters bool          `json:"apply_filters"`
+	Active       bool          `json:"active"`
+	ID           uuid.UUID     `json:"id"`
+	UserID       uuid.UUID     `json:"user_id"`
+}
+
+// The feed must be followed by the user and the folder must belong to the user.
+func (q *Queries) UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, updateWebhook,
+		arg.Url,
+		arg.FeedID,
+		arg.FolderID,
+		arg.ApplyFilters,
+		arg.Active,
+		arg.ID,
+		arg.UserID,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Url,
+		&i.Secret,
+		&i.FeedID,
+		&i.FolderID,
+		&i.ApplyFilters,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateWebhookDelivery = `-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET status = $2,
+    attempts = $3,
+    next_attempt_at = $4,
+    response_status = $5,
+    last_error = $6,
+    delivered_at = $7,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateWebhookDeliveryParams struct {
+	ID             uuid.UUID      `json:"id"`
+	Status         string         `json:"status"`
+	Attempts       int32          `json:"attempts"`
+	NextAttemptAt  time.Time      `json:"next_attempt_at"`
+	ResponseStatus sql.NullInt32  `json:"response_status"`
+	LastError      sql.NullString `json:"last_error"`
+	DeliveredAt    sql.NullTime   `json:"delivered_at"`
+}
+
+func (q *Queries) UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) error {
+	_, err := q.db.ExecContext(ctx, updateWebhookDelivery,
+		arg.ID,
+		arg.Status,
+		arg.Attempts,
+		arg.NextAttemptAt,
+		arg.ResponseStatus,
+		arg.LastError,
+		arg.DeliveredAt,
+	)
+	return err
+}
@@ -0,0 +1,194 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueries_CreateWebhook_Scope(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := CreateRandomUser(t)
+	feed := CreateRandomFeed(t)
+	folder, err := testQueries.CreateFolder(ctx, CreateFolderParams{UserID: CreateRandomUser(t).ID, Name: generator.RandomString(10)})
+	require.NoError(t, err)
+
+	// The feed is not followed by the user.
+	_, err = testQueries.CreateWebhook(ctx, CreateWebhookParams{
+		UserID: user.ID,
+		Url:    "https://example.com/hook",
+		Secret: "secret",
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// The folder belongs to another user.
+	webhook, err := testQueries.CreateWebhook(ctx, CreateWebhookParams{
+		UserID: user.ID,
+		Url:    "https://example.com/hook",
+		Secret: "secret",
+	})
+	require.NoError(t, err)
+	_, err = testQueries.UpdateWebhook(ctx, UpdateWebhookParams{
+		ID:       webhook.ID,
+		UserID:   user.ID,
+		Url:      "https://example.com/hook",
+		FolderID: uuid.NullUUID{UUID: folder.ID, Valid: true},
+		Active:   true,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestQueries_ListWebhooksForPost(t *testing.T) {
+	follow, post := createRandomFollowedPost(t)
+	user := follow.UserID.UUID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	all, err := testQueries.CreateWebhook(ctx, CreateWebhookParams{
+		UserID:       user,
+		Url:          "https://example.com/all",
+		Secret:       "secret",
+		ApplyFilters: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "secret", all.Secret)
+	assert.True(t, all.Active)
+
+	// A webhook restricted to another feed does not match the post.
+	otherFeed := CreateRandomFeed(t)
+	_, err = testQueries.CreateFeedFollows(ctx, CreateFeedFollowsParams{
+		UserID: follow.UserID,
+		FeedID: uuid.NullUUID{UUID: otherFeed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	_, err = testQueries.CreateWebhook(ctx, CreateWebhookParams{
+		UserID: user,
+		Url:    "https://example.com/other",
+		Secret: "secret",
+		FeedID: uuid.NullUUID{UUID: otherFeed.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// The webhooks of the users not following the feed do not match the post.
+	_, err = testQueries.CreateWebhook(ctx, CreateWebhookParams{
+		UserID: CreateRandomUser(t).ID,
+		Url:    "https://example.com/stranger",
+		Secret: "secret",
+	})
+	require.NoError(t, err)
+
+	webhooks, err := testQueries.ListWebhooksForPost(ctx, post.ID)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, all.ID, webhooks[0].ID)
+
+	// The feeds without notifications do not trigger the webhooks.
+	_, err = testQueries.UpdateFeedFollows(ctx, UpdateFeedFollowsParams{ID: follow.ID, UserID: follow.UserID, Notify: sql.NullBool{Bool: false, Valid: true}})
+	require.NoError(t, err)
+	webhooks, err = testQueries.ListWebhooksForPost(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Empty(t, webhooks)
+	_, err = testQueries.UpdateFeedFollows(ctx, UpdateFeedFollowsParams{ID: follow.ID, UserID: follow.UserID, Notify: sql.NullBool{Bool: true, Valid: true}})
+	require.NoError(t, err)
+
+	// The filter rules of the user apply to the webhooks.
+	_, err = testQueries.CreateFilterRule(ctx, CreateFilterRuleParams{
+		UserID:  user,
+		Kind:    "keyword",
+		Action:  "exclude",
+		Pattern: post.Title,
+	})
+	require.NoError(t, err)
+
+	webhooks, err = testQueries.ListWebhooksForPost(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Empty(t, webhooks)
+}
+
+func TestQueries_WebhookDeliveries(t *testing.T) {
+	follow, post := createRandomFollowedPost(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	webhook, err := testQueries.CreateWebhook(ctx, CreateWebhookParams{
+		UserID: follow.UserID.UUID,
+		Url:    "https://example.com/hook",
+		Secret: "secret",
+	})
+	require.NoError(t, err)
+
+	params := CreateWebhookDeliveryParams{
+		WebhookID: webhook.ID,
+		PostID:    sql.NullInt32{Int32: post.ID, Valid: true},
+		Payload:   []byte(`{"event":"post.created"}`),
+	}
+	delivery, err := testQueries.CreateWebhookDelivery(ctx, params)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", delivery.Status)
+	assert.Equal(t, int32(0), delivery.Attempts)
+
+	// A post is delivered once to a webhook.
+	_, err = testQueries.CreateWebhookDelivery(ctx, params)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	due, err := testQueries.ListDueWebhookDeliveries(ctx, 1000)
+	require.NoError(t, err)
+	var found bool
+	for _, row := range due {
+		if row.WebhookDelivery.ID == delivery.ID {
+			found = true
+			assert.Equal(t, webhook.Url, row.Url)
+			assert.Equal(t, webhook.Secret, row.Secret)
+		}
+	}
+	assert.True(t, found)
+
+	// A delivery retried later is not due.
+	err = testQueries.UpdateWebhookDelivery(ctx, UpdateWebhookDeliveryParams{
+		ID:             delivery.ID,
+		Status:         "pending",
+		Attempts:       1,
+		NextAttemptAt:  time.Now().Add(time.Hour),
+		ResponseStatus: sql.NullInt32{Int32: 500, Valid: true},
+		LastError:      sql.NullString{String: "unexpected status 500", Valid: true},
+	})
+	require.NoError(t, err)
+
+	due, err = testQueries.ListDueWebhookDeliveries(ctx, 1000)
+	require.NoError(t, err)
+	for _, row := range due {
+		assert.NotEqual(t, delivery.ID, row.WebhookDelivery.ID)
+	}
+
+	deliveries, err := testQueries.ListWebhookDeliveries(ctx, ListWebhookDeliveriesParams{
+		WebhookID: webhook.ID,
+		UserID:    follow.UserID.UUID,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, int32(1), deliveries[0].Attempts)
+	assert.Equal(t, int32(500), deliveries[0].ResponseStatus.Int32)
+	assert.JSONEq(t, `{"event":"post.created"}`, string(deliveries[0].Payload))
+
+	// The deliveries are only listed for the owner of the webhook.
+	deliveries, err = testQueries.ListWebhookDeliveries(ctx, ListWebhookDeliveriesParams{
+		WebhookID: webhook.ID,
+		UserID:    uuid.New(),
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+}
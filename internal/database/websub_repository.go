@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebSubRepository is responsible for managing the WebSub subscriptions in the database:
+// the subscriptions of the feeds to their hubs, and the subscriptions to the timeline feeds of the server.
+type WebSubRepository struct {
+	db      *sql.DB
+	queries *Queries
+}
+
+// NewWebSubRepository creates a new WebSubRepository.
+func NewWebSubRepository(db *sql.DB) WebSubRepository {
+	return WebSubRepository{
+		db:      db,
+		queries: New(db),
+	}
+}
+
+// UpsertWebSubSubscription creates or updates the subscription of a feed to its hub.
+func (w WebSubRepository) UpsertWebSubSubscription(ctx context.Context, arg UpsertWebSubSubscriptionParams) (WebSubSubscription, error) {
+	subscription, err := w.queries.UpsertWebSubSubscription(ctx, arg)
+	if err != nil {
+		return WebSubSubscription{}, fmt.Errorf("error upserting websub subscription: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// GetWebSubSubscription returns the subscription of a feed, with the feed.
+func (w WebSubRepository) GetWebSubSubscription(ctx context.Context, feedID uuid.UUID) (GetWebSubSubscriptionRow, error) {
+	subscription, err := w.queries.GetWebSubSubscription(ctx, feedID)
+	if err != nil {
+		return GetWebSubSubscriptionRow{}, fmt.Errorf("error getting websub subscription: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// ActivateWebSubSubscription activates the subscription of a feed to a topic, once verified by the hub.
+func (w WebSubRepository) ActivateWebSubSubscription(ctx context.Context, arg ActivateWebSubSubscriptionParams) (WebSubSubscription, error) {
+	subscription, err := w.queries.ActivateWebSubSubscription(ctx, arg)
+	if err != nil {
+		return WebSubSubscription{}, fmt.Errorf("error activating websub subscription: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// DenyWebSubSubscription records the subscription of a feed to a topic has been denied by the hub.
+func (w WebSubRepository) DenyWebSubSubscription(ctx context.Context, arg DenyWebSubSubscriptionParams) error {
+	if err := w.queries.DenyWebSubSubscription(ctx, arg); err != nil {
+		return fmt.Errorf("error denying websub subscription: %w", err)
+	}
+
+	return nil
+}
+
+// ListWebSubSubscriptionsToRenew returns the subscriptions to request again to their hub.
+func (w WebSubRepository) ListWebSubSubscriptionsToRenew(ctx context.Context, arg ListWebSubSubscriptionsToRenewParams) ([]WebSubSubscription, error) {
+	subscriptions, err := w.queries.ListWebSubSubscriptionsToRenew(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("error listing websub subscriptions to renew: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// ListDisabledWebSubSubscriptions returns the subscriptions of the disabled feeds.
+func (w WebSubRepository) ListDisabledWebSubSubscriptions(ctx context.Context) ([]WebSubSubscription, error) {
+	subscriptions, err := w.queries.ListDisabledWebSubSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing disabled websub subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// DeleteWebSubSubscription deletes the subscription of a feed.
+func (w WebSubRepository) DeleteWebSubSubscription(ctx context.Context, feedID uuid.UUID) error {
+	if err := w.queries.DeleteWebSubSubscription(ctx, feedID); err != nil {
+		return fmt.Errorf("error deleting websub subscription: %w", err)
+	}
+
+	return nil
+}
+
+// UpsertHubSubscription creates or renews the subscription of a callback to a timeline feed.
+func (w WebSubRepository) UpsertHubSubscription(ctx context.Context, arg UpsertHubSubscriptionParams) (HubSubscription, error) {
+	subscription, err := w.queries.UpsertHubSubscription(ctx, arg)
+	if err != nil {
+		return HubSubscription{}, fmt.Errorf("error upserting hub subscription: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// DeleteHubSubscription deletes the subscription of a callback to a timeline feed.
+func (w WebSubRepository) DeleteHubSubscription(ctx context.Context, arg DeleteHubSubscriptionParams) error {
+	if err := w.queries.DeleteHubSubscription(ctx, arg); err != nil {
+		return fmt.Errorf("error deleting hub subscription: %w", err)
+	}
+
+	return nil
+}
+
+// ListHubSubscriptionsForPost returns the subscriptions to the timeline feeds containing a post, with their owner.
+func (w WebSubRepository) ListHubSubscriptionsForPost(ctx context.Context, postID int32) ([]ListHubSubscriptionsForPostRow, error) {
+	subscriptions, err := w.queries.ListHubSubscriptionsForPost(ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing hub subscriptions for post: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// DeleteExpiredHubSubscriptions deletes the subscriptions whose lease has expired at a date, it returns their number.
+func (w WebSubRepository) DeleteExpiredHubSubscriptions(ctx context.Context, expiredAt time.Time) (int64, error) {
+	count, err := w.queries.DeleteExpiredHubSubscriptions(ctx, expiredAt)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting expired hub subscriptions: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,248 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.25.0
+// source: websub_subscriptions.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const activateWebSubSubscription = `-- name: ActivateWebSubSubscription :one
+UPDATE websub_subscriptions
+SET state = 'active', lease_expires_at = $1, updated_at = NOW()
+WHERE feed_id = $2
+AND topic_url = $3
+RETURNING feed_id, hub_url, topic_url, secret, state, lease_expires_at, requested_at, created_at, updated_at
+`
+
+type ActivateWebSubSubscriptionParams struct {
+	LeaseExpiresAt sql.NullTime `json:"lease_expires_at"`
+	FeedID         uuid.UUID    `json:"feed_id"`
+	TopicUrl       string       `json:"topic_url"`
+}
+
+func (q *Queries) ActivateWebSubSubscription(ctx context.Context, arg ActivateWebSubSubscriptionParams) (WebSubSubscription, error) {
+	row := q.db.QueryRowContext(ctx, activateWebSubSubscription, arg.LeaseExpiresAt, arg.FeedID, arg.TopicUrl)
+	var i WebSubSubscription
+	err := row.Scan(
+		&i.FeedID,
+		&i.HubUrl,
+		&i.TopicUrl,
+		&i.Secret,
+		&i.State,
+		&i.LeaseExpiresAt,
+		&i.RequestedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteWebSubSubscription = `-- name: DeleteWebSubSubscription :exec
+DELETE FROM websub_subscriptions
+WHERE feed_id = $1
+`
+
+func (q *Queries) DeleteWebSubSubscription(ctx context.Context, feedID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteWebSubSubscription, feedID)
+	return err
+}
+
+const denyWebSubSubscription = `-- name: DenyWebSubSubscription :exec
+UPDATE websub_subscriptions
+SET state = 'denied', lease_expires_at = NULL, updated_at = NOW()
+WHERE feed_id = $1
+AND topic_url = $2
+`
+
+type DenyWebSubSubscriptionParams struct {
+	FeedID   uuid.UUID `json:"feed_id"`
+	TopicUrl string    `json:"topic_url"`
+}
+
+func (q *Queries) DenyWebSubSubscription(ctx context.Context, arg DenyWebSubSubscriptionParams) error {
+	_, err := q.db.ExecContext(ctx, denyWebSubSubscription, arg.FeedID, arg.TopicUrl)
+	return err
+}
+
+const getWebSubSubscription = `-- name: GetWebSubSubscription :one
+SELECT websub_subscriptions.feed_id, websub_subscriptions.hub_url, websub_subscriptions.topic_url, websub_subscriptions.secret, websub_subscriptions.state, websub_subscriptions.lease_expires_at, websub_subscriptions.requested_at, websub_subscriptions.created_at, websub_subscriptions.updated_at, feeds.id, feeds.name, feeds.url, feeds.user_id, feeds.created_at, feeds.updated_at, feeds.last_fetched_at, feeds.site_url, feeds.last_error, feeds.error_count, feeds.disabled_at, feeds.visibility
+FROM websub_subscriptions
+    JOIN feeds ON feeds.id = websub_subscriptions.feed_id
+WHERE websub_subscriptions.feed_id = $1
+`
+
+type GetWebSubSubscriptionRow struct {
+	WebSubSubscription WebSubSubscription `json:"web_sub_subscription"`
+	Feed               Feed               `json:"feed"`
+}
+
+func (q *Queries) GetWebSubSubscription(ctx context.Context, feedID uuid.UUID) (GetWebSubSubscriptionRow, error) {
+	row := q.db.QueryRowContext(ctx, getWebSubSubscription, feedID)
+	var i GetWebSubSubscriptionRow
+	err := row.Scan(
+		&i.WebSubSubscription.FeedID,
+		&i.WebSubSubscription.HubUrl,
+		&i.WebSubSubscription.TopicUrl,
+		&i.WebSubSubscription.Secret,
+		&i.WebSubSubscription.State,
+		&i.WebSubSubscription.LeaseExpiresAt,
+		&i.WebSubSubscription.RequestedAt,
+		&i.WebSubSubscription.CreatedAt,
+		&i.WebSubSubscription.UpdatedAt,
+		&i.Feed.ID,
+		&i.Feed.Name,
+		&i.Feed.Url,
+		&i.Feed.UserID,
+		&i.Feed.CreatedAt,
+		&i.Feed.UpdatedAt,
+		&i.Feed.LastFetchedAt,
+		&i.Feed.SiteUrl,
+		&i.Feed.LastError,
+		&i.Feed.ErrorCount,
+		&i.Feed.DisabledAt,
+		&i.Feed.Visibility,
+	)
+	return i, err
+}
+
+const listDisabledWebSubSubscriptions = `-- name: ListDisabledWebSubSubscriptions :many
+SELECT feed_id, hub_url, topic_url, secret, state, lease_expires_at, requested_at, created_at, updated_at FROM websub_subscriptions
+WHERE feed_id IN (SELECT id FROM feeds WHERE disabled_at IS NOT NULL)
+ORDER BY requested_at ASC
+`
+
+// Returns the subscriptions of the disabled feeds, they are unsubscribed from their hub.
+func (q *Queries) ListDisabledWebSubSubscriptions(ctx context.Context) ([]WebSubSubscription, error) {
+	rows, err := q.db.QueryContext(ctx, listDisabledWebSubSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebSubSubscription{}
+	for rows.Next() {
+		var i WebSubSubscription
+		if err := rows.Scan(
+			&i.FeedID,
+			&i.HubUrl,
+			&i.TopicUrl,
+			&i.Secret,
+			&i.State,
+			&i.LeaseExpiresAt,
+			&i.RequestedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebSubSubscriptionsToRenew = `-- name: ListWebSubSubscriptionsToRenew :many
+SELECT feed_id, hub_url, topic_url, secret, state, lease_expires_at, requested_at, created_at, updated_at FROM websub_subscriptions
+WHERE ((state = 'active' AND lease_expires_at < $1::timestamptz)
+    OR (state = 'pending' AND requested_at < $2))
+AND feed_id IN (SELECT id FROM feeds WHERE disabled_at IS NULL)
+ORDER BY requested_at ASC
+`
+
+type ListWebSubSubscriptionsToRenewParams struct {
+	ExpiresBefore   time.Time `json:"expires_before"`
+	RequestedBefore time.Time `json:"requested_before"`
+}
+
+// Returns the active subscriptions expiring before a date,
+// and the pending subscriptions requested before a date, their verification has failed.
+// The subscriptions of the disabled feeds are not renewed.
+func (q *Queries) ListWebSubSubscriptionsToRenew(ctx context.Context, arg ListWebSubSubscriptionsToRenewParams) ([]WebSubSubscription, error) {
+	rows, err := q.db.QueryContext(ctx, listWebSubSubscriptionsToRenew, arg.ExpiresBefore, arg.RequestedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebSubSubscription{}
+	for rows.Next() {
+		var i WebSubSubscription
+		if err := rows.Scan(
+			&i.FeedID,
+			&i.HubUrl,
+			&i.TopicUrl,
+			&i.Secret,
+			&i.State,
+			&i.LeaseExpiresAt,
+			&i.RequestedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertWebSubSubscription = `-- name: UpsertWebSubSubscription :one
+INSERT INTO websub_subscriptions (feed_id, hub_url, topic_url, secret)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (feed_id) DO UPDATE
+SET state = CASE
+        WHEN websub_subscriptions.hub_url = EXCLUDED.hub_url AND websub_subscriptions.topic_url = EXCLUDED.topic_url
+        THEN websub_subscriptions.state
+        ELSE 'pending'
+    END,
+    hub_url = EXCLUDED.hub_url,
+    topic_url = EXCLUDED.topic_url,
+    requested_at = NOW(),
+    updated_at = NOW()
+RETURNING feed_id, hub_url, topic_url, secret, state, lease_expires_at, requested_at, created_at, updated_at
+`
+
+type UpsertWebSubSubscriptionParams struct {
+	FeedID   uuid.UUID `json:"feed_id"`
+	HubUrl   string    `json:"hub_url"`
+	TopicUrl string    `json:"topic_url"`
+	Secret   string    `json:"-"`
+}
+
+// The secret of an existing subscription is kept, it is pending again when the hub or the topic changes.
+func (q *Queries) UpsertWebSubSubscription(ctx context.Context, arg UpsertWebSubSubscriptionParams) (WebSubSubscription, error) {
+	row := q.db.QueryRowContext(ctx, upsertWebSubSubscription,
+		arg.FeedID,
+		arg.HubUrl,
+		arg.TopicUrl,
+		arg.Secret,
+	)
+	var i WebSubSubscription
+	err := row.Scan(
+		&i.FeedID,
+		&i.HubUrl,
+		&i.TopicUrl,
+		&i.Secret,
+		&i.State,
+		&i.LeaseExpiresAt,
+		&i.RequestedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueries_WebSubSubscription(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	feed := CreateRandomFeed(t)
+	sub, err := testQueries.UpsertWebSubSubscription(ctx, UpsertWebSubSubscriptionParams{
+		FeedID:   feed.ID,
+		HubUrl:   "https://hub.example.com/",
+		TopicUrl: feed.Url,
+		Secret:   "secret",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "pending", sub.State)
+	assert.False(t, sub.LeaseExpiresAt.Valid)
+
+	// The subscription is only activated for its topic.
+	_, err = testQueries.ActivateWebSubSubscription(ctx, ActivateWebSubSubscriptionParams{
+		FeedID:         feed.ID,
+		TopicUrl:       "https://other.example.com/feed.xml",
+		LeaseExpiresAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	sub, err = testQueries.ActivateWebSubSubscription(ctx, ActivateWebSubSubscriptionParams{
+		FeedID:         feed.ID,
+		TopicUrl:       feed.Url,
+		LeaseExpiresAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "active", sub.State)
+
+	row, err := testQueries.GetWebSubSubscription(ctx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, feed.ID, row.Feed.ID)
+	assert.Equal(t, "active", row.WebSubSubscription.State)
+
+	// The subscription is renewed before its expiration.
+	subs, err := testQueries.ListWebSubSubscriptionsToRenew(ctx, ListWebSubSubscriptionsToRenewParams{
+		ExpiresBefore:   time.Now().Add(24 * time.Hour),
+		RequestedBefore: time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Contains(t, webSubFeedIDs(subs), feed.ID.String())
+
+	// Requesting the same subscription again keeps its state and its secret.
+	sub, err = testQueries.UpsertWebSubSubscription(ctx, UpsertWebSubSubscriptionParams{
+		FeedID:   feed.ID,
+		HubUrl:   "https://hub.example.com/",
+		TopicUrl: feed.Url,
+		Secret:   "other",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "active", sub.State)
+	assert.Equal(t, "secret", sub.Secret)
+
+	// A new hub makes the subscription pending.
+	sub, err = testQueries.UpsertWebSubSubscription(ctx, UpsertWebSubSubscriptionParams{
+		FeedID:   feed.ID,
+		HubUrl:   "https://other-hub.example.com/",
+		TopicUrl: feed.Url,
+		Secret:   "other",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "pending", sub.State)
+
+	require.NoError(t, testQueries.DenyWebSubSubscription(ctx, DenyWebSubSubscriptionParams{
+		FeedID:   feed.ID,
+		TopicUrl: feed.Url,
+	}))
+	row, err = testQueries.GetWebSubSubscription(ctx, feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "denied", row.WebSubSubscription.State)
+}
+
+func TestQueries_ListWebSubSubscriptions_Disabled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	feed := CreateRandomFeed(t)
+	_, err := testQueries.UpsertWebSubSubscription(ctx, UpsertWebSubSubscriptionParams{
+		FeedID:   feed.ID,
+		HubUrl:   "https://hub.example.com/",
+		TopicUrl: feed.Url,
+		Secret:   "secret",
+	})
+	require.NoError(t, err)
+	_, err = testQueries.SetFeedDisabled(ctx, SetFeedDisabledParams{ID: feed.ID, Disabled: true})
+	require.NoError(t, err)
+
+	// The subscription of a disabled feed is not renewed, but unsubscribed.
+	subs, err := testQueries.ListWebSubSubscriptionsToRenew(ctx, ListWebSubSubscriptionsToRenewParams{
+		ExpiresBefore:   time.Now().Add(24 * time.Hour),
+		RequestedBefore: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, webSubFeedIDs(subs), feed.ID.String())
+	subs, err = testQueries.ListDisabledWebSubSubscriptions(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, webSubFeedIDs(subs), feed.ID.String())
+
+	require.NoError(t, testQueries.DeleteWebSubSubscription(ctx, feed.ID))
+	_, err = testQueries.GetWebSubSubscription(ctx, feed.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func webSubFeedIDs(subs []WebSubSubscription) []string {
+	ids := make([]string, 0, len(subs))
+	for _, sub := range subs {
+		ids = append(ids, sub.FeedID.String())
+	}
+
+	return ids
+}
@@ -0,0 +1,222 @@
+// Package digest sends the unread posts of the users by email, on the schedule of each user.
+package digest
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"text/template"
+	"time"
+
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+)
+
+const (
+	// maxPosts is the maximum number of posts of a digest, the others are sent in the next digest.
+	maxPosts = 100
+	// batchSize is the maximum number of digests sent at once.
+	batchSize = 50
+)
+
+//go:embed templates
+var templates embed.FS
+
+var (
+	htmlTemplate = htmltemplate.Must(htmltemplate.ParseFS(templates, "templates/digest.html"))
+	textTemplate = template.Must(template.ParseFS(templates, "templates/digest.txt"))
+)
+
+// Store represents a store of the digests.
+type Store interface {
+	ListDueDigests(ctx context.Context, arg database.ListDueDigestsParams) ([]database.ListDueDigestsRow, error)
+	ListDigestPosts(ctx context.Context, arg database.ListDigestPostsParams) ([]database.ListDigestPostsRow, error)
+	MarkDigestSent(ctx context.Context, arg database.MarkDigestSentParams, postIDs []int32) error
+}
+
+// Digest is the content of a digest email.
+type Digest struct {
+	Subject   string
+	Name      string
+	Frequency string
+	Count     int
+	Feeds     []Feed
+}
+
+// Feed groups the posts of a feed in a digest.
+type Feed struct {
+	Title string
+	Posts []Post
+}
+
+// Post is a post of a digest.
+type Post struct {
+	Title       string
+	URL         string
+	Author      string
+	PublishedAt time.Time
+}
+
+// Sender sends the due digests periodically.
+type Sender struct {
+	store    Store
+	mailer   Mailer
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewSender returns a new digest sender.
+func NewSender(store Store, mailer Mailer) *Sender {
+	return &Sender{
+		store:    store,
+		mailer:   mailer,
+		interval: time.Minute,
+		now:      time.Now,
+	}
+}
+
+// Start sends the due digests every minute until the context is done.
+func (s *Sender) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.SendDue(ctx); err != nil {
+			slog.Log(ctx, slog.LevelError, "send digests", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue sends the digests due now and schedules the next ones.
+// A digest failing to be sent is logged and retried on the next call.
+func (s *Sender) SendDue(ctx context.Context) error {
+	listCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	digests, err := s.store.ListDueDigests(listCtx, database.ListDueDigestsParams{
+		Now:   s.now(),
+		Limit: batchSize,
+	})
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	for _, row := range digests {
+		if err := s.send(ctx, row); err != nil {
+			slog.Log(ctx, slog.LevelError, "send digest", "user_id", row.DigestSetting.UserID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// send sends the digest of a user, nothing is sent when there is no new post.
+func (s *Sender) send(ctx context.Context, row database.ListDueDigestsRow) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	settings := row.DigestSetting
+	schedule := ScheduleOf(settings)
+
+	// The first digest covers the period before its creation, the next ones the posts not sent yet.
+	posts, err := s.store.ListDigestPosts(ctx, database.ListDigestPostsParams{
+		UserID: settings.UserID,
+		Since:  settings.CreatedAt.Add(-schedule.Period()),
+		Limit:  maxPosts,
+	})
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	lastSentAt := settings.LastSentAt
+	ids := make([]int32, 0, len(posts))
+	if len(posts) > 0 {
+		digest := NewDigest(row.Name, settings.Frequency, schedule.Location, posts)
+		msg, err := Render(digest)
+		if err != nil {
+			return err
+		}
+		msg.To = settings.Email
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			return err
+		}
+		for _, post := range posts {
+			ids = append(ids, post.ID)
+		}
+		lastSentAt = sql.NullTime{Time: now, Valid: true}
+	}
+
+	return s.store.MarkDigestSent(ctx, database.MarkDigestSentParams{
+		UserID:     settings.UserID,
+		LastSentAt: lastSentAt,
+		NextSendAt: schedule.Next(now),
+	}, ids)
+}
+
+// ScheduleOf returns the schedule of the digest settings.
+// An unknown time zone falls back to UTC.
+func ScheduleOf(settings database.DigestSetting) Schedule {
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		slog.Log(context.Background(), slog.LevelWarn, "load digest time zone", "timezone", settings.Timezone, "error", err)
+		loc = time.UTC
+	}
+
+	return Schedule{
+		Frequency: settings.Frequency,
+		Hour:      int(settings.Hour),
+		Weekday:   time.Weekday(settings.Weekday),
+		Location:  loc,
+	}
+}
+
+// NewDigest returns the digest of the posts, grouped by feed in their order.
+// The dates of the posts are displayed in the given time zone.
+func NewDigest(name, frequency string, loc *time.Location, posts []database.ListDigestPostsRow) Digest {
+	digest := Digest{
+		Subject:   fmt.Sprintf("Your %s digest: %d new posts", frequency, len(posts)),
+		Name:      name,
+		Frequency: frequency,
+		Count:     len(posts),
+	}
+	for _, post := range posts {
+		if len(digest.Feeds) == 0 || digest.Feeds[len(digest.Feeds)-1].Title != post.FeedTitle {
+			digest.Feeds = append(digest.Feeds, Feed{Title: post.FeedTitle})
+		}
+		feed := &digest.Feeds[len(digest.Feeds)-1]
+		feed.Posts = append(feed.Posts, Post{
+			Title:       post.Title,
+			URL:         post.Url,
+			Author:      post.Author,
+			PublishedAt: post.PublishedAt.In(loc),
+		})
+	}
+
+	return digest
+}
+
+// Render renders the digest as an email message, without recipient.
+func Render(digest Digest) (Message, error) {
+	var html, text bytes.Buffer
+	if err := htmlTemplate.Execute(&html, digest); err != nil {
+		return Message{}, fmt.Errorf("render html digest: %w", err)
+	}
+	if err := textTemplate.Execute(&text, digest); err != nil {
+		return Message{}, fmt.Errorf("render text digest: %w", err)
+	}
+
+	return Message{
+		Subject: digest.Subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
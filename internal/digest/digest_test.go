@@ -0,0 +1,228 @@
+package digest
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpSink is a local SMTP server keeping the received messages.
+type smtpSink struct {
+	listener net.Listener
+	messages chan []byte
+}
+
+// newSMTPSink starts a new SMTP sink on a random local port.
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	sink := &smtpSink{listener: listener, messages: make(chan []byte, 10)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go sink.serve(conn)
+		}
+	}()
+
+	return sink
+}
+
+func (s *smtpSink) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// serve handles the minimal set of commands used by net/smtp.
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+
+	_ = tp.PrintfLine("220 localhost sink")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		switch cmd := strings.ToUpper(strings.Fields(line + " ")[0]); cmd {
+		case "EHLO", "HELO":
+			_ = tp.PrintfLine("250 localhost")
+		case "DATA":
+			_ = tp.PrintfLine("354 end with .")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.messages <- data
+			_ = tp.PrintfLine("250 queued")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("250 ok")
+		}
+	}
+}
+
+// fakeStore is a store with a single due digest.
+type fakeStore struct {
+	digests []database.ListDueDigestsRow
+	posts   []database.ListDigestPostsRow
+	sent    []database.MarkDigestSentParams
+	sentIDs [][]int32
+}
+
+func (s *fakeStore) ListDueDigests(_ context.Context, _ database.ListDueDigestsParams) ([]database.ListDueDigestsRow, error) {
+	return s.digests, nil
+}
+
+func (s *fakeStore) ListDigestPosts(_ context.Context, _ database.ListDigestPostsParams) ([]database.ListDigestPostsRow, error) {
+	return s.posts, nil
+}
+
+func (s *fakeStore) MarkDigestSent(_ context.Context, arg database.MarkDigestSentParams, postIDs []int32) error {
+	s.sent = append(s.sent, arg)
+	s.sentIDs = append(s.sentIDs, postIDs)
+	return nil
+}
+
+// parts returns the bodies of the multipart message by content type.
+func parts(t *testing.T, msg *mail.Message) map[string]string {
+	t.Helper()
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	bodies := make(map[string]string)
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return bodies
+		}
+		require.NoError(t, err)
+		// The quoted-printable encoding is decoded by the reader.
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		bodies[contentType] = string(body)
+	}
+}
+
+func TestSender_SendDue(t *testing.T) {
+	sink := newSMTPSink(t)
+	mailer := NewSMTPMailer("127.0.0.1", sink.port(), "", "", "digest@example.com")
+
+	userID := uuid.New()
+	store := &fakeStore{
+		digests: []database.ListDueDigestsRow{{
+			DigestSetting: database.DigestSetting{
+				UserID:    userID,
+				Email:     "jane@example.com",
+				Frequency: Daily,
+				Hour:      8,
+				Timezone:  "UTC",
+				Enabled:   true,
+			},
+			Name: "Jane",
+		}},
+		posts: []database.ListDigestPostsRow{
+			{ID: 1, Title: "Go 1.22 <released>", Url: "https://go.dev/blog/go1.22", FeedTitle: "Go blog", Author: "gopher"},
+			{ID: 2, Title: "Range functions", Url: "https://go.dev/blog/range-functions", FeedTitle: "Go blog"},
+			{ID: 3, Title: "Release notes", Url: "https://example.com/notes", FeedTitle: "Other"},
+		},
+	}
+
+	sender := NewSender(store, mailer)
+	now := time.Date(2024, 3, 1, 8, 0, 30, 0, time.UTC)
+	sender.now = func() time.Time { return now }
+
+	require.NoError(t, sender.SendDue(context.Background()))
+
+	var data []byte
+	select {
+	case data = <-sink.messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(data)))
+	require.NoError(t, err)
+	assert.Equal(t, "digest@example.com", msg.Header.Get("From"))
+	assert.Equal(t, "jane@example.com", msg.Header.Get("To"))
+	assert.Equal(t, "Your daily digest: 3 new posts", msg.Header.Get("Subject"))
+
+	bodies := parts(t, msg)
+	assert.Contains(t, bodies["text/plain"], "Hello Jane,")
+	assert.Contains(t, bodies["text/plain"], "- Go 1.22 <released> by gopher")
+	assert.Contains(t, bodies["text/plain"], "https://go.dev/blog/range-functions")
+	assert.Contains(t, bodies["text/html"], `<a href="https://go.dev/blog/go1.22">Go 1.22 &lt;released&gt;</a>`)
+	assert.Contains(t, bodies["text/html"], "Other</h2>")
+
+	require.Len(t, store.sent, 1)
+	assert.Equal(t, userID, store.sent[0].UserID)
+	assert.Equal(t, sql.NullTime{Time: now, Valid: true}, store.sent[0].LastSentAt)
+	assert.Equal(t, time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC), store.sent[0].NextSendAt)
+	assert.Equal(t, []int32{1, 2, 3}, store.sentIDs[0])
+}
+
+func TestSender_SendDue_NoPosts(t *testing.T) {
+	sink := newSMTPSink(t)
+	mailer := NewSMTPMailer("127.0.0.1", sink.port(), "", "", "digest@example.com")
+
+	store := &fakeStore{
+		digests: []database.ListDueDigestsRow{{
+			DigestSetting: database.DigestSetting{
+				UserID:    uuid.New(),
+				Email:     "jane@example.com",
+				Frequency: Weekly,
+				Hour:      8,
+				Weekday:   int32(time.Monday),
+				Timezone:  "UTC",
+			},
+		}},
+	}
+
+	sender := NewSender(store, mailer)
+	// A Friday.
+	sender.now = func() time.Time { return time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC) }
+
+	require.NoError(t, sender.SendDue(context.Background()))
+
+	// The next digest is scheduled without sending an email.
+	require.Len(t, store.sent, 1)
+	assert.False(t, store.sent[0].LastSentAt.Valid)
+	assert.Equal(t, time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC), store.sent[0].NextSendAt)
+	assert.Empty(t, store.sentIDs[0])
+	assert.Empty(t, sink.messages)
+}
+
+func TestSMTPMailer_Send_Unreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	mailer := NewSMTPMailer("127.0.0.1", port, "", "", "digest@example.com")
+	err = mailer.Send(context.Background(), Message{To: "jane@example.com", Subject: "subject"})
+	assert.Error(t, err)
+}
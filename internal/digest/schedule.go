@@ -0,0 +1,65 @@
+package digest
+
+import (
+	"fmt"
+	"time"
+)
+
+// Frequencies of the digests.
+const (
+	Daily  = "daily"
+	Weekly = "weekly"
+)
+
+// Schedule is the time a digest is sent, in the time zone of its user.
+type Schedule struct {
+	Frequency string
+	// Hour of the day, from 0 to 23.
+	Hour int
+	// Weekday is the day of a weekly digest.
+	Weekday time.Weekday
+	// Location is the time zone of the hour and the day of the week.
+	Location *time.Location
+}
+
+// Validate checks the values of the schedule.
+func (s Schedule) Validate() error {
+	if s.Frequency != Daily && s.Frequency != Weekly {
+		return fmt.Errorf("invalid frequency: %q, expected %q or %q", s.Frequency, Daily, Weekly)
+	}
+	if s.Hour < 0 || s.Hour > 23 {
+		return fmt.Errorf("invalid hour: %d, expected a value between 0 and 23", s.Hour)
+	}
+	if s.Weekday < time.Sunday || s.Weekday > time.Saturday {
+		return fmt.Errorf("invalid weekday: %d, expected a value between 0 (Sunday) and 6", s.Weekday)
+	}
+	if s.Location == nil {
+		return fmt.Errorf("missing time zone")
+	}
+
+	return nil
+}
+
+// Period returns the duration between two digests.
+func (s Schedule) Period() time.Duration {
+	if s.Frequency == Weekly {
+		return 7 * 24 * time.Hour
+	}
+
+	return 24 * time.Hour
+}
+
+// Next returns the first time of the schedule strictly after the given time.
+// The days are computed in the time zone of the schedule, so the hour is kept across the daylight saving changes.
+func (s Schedule) Next(after time.Time) time.Time {
+	local := after.In(s.Location)
+	year, month, day := local.Date()
+	next := time.Date(year, month, day, s.Hour, 0, 0, 0, s.Location)
+
+	for !next.After(after) || (s.Frequency == Weekly && next.Weekday() != s.Weekday) {
+		day++
+		next = time.Date(year, month, day, s.Hour, 0, 0, 0, s.Location)
+	}
+
+	return next.UTC()
+}
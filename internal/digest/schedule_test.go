@@ -0,0 +1,73 @@
+package digest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		after    time.Time
+		expected time.Time
+	}{
+		{
+			name:     "daily later today",
+			schedule: Schedule{Frequency: Daily, Hour: 8, Location: time.UTC},
+			after:    time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "daily tomorrow",
+			schedule: Schedule{Frequency: Daily, Hour: 8, Location: time.UTC},
+			after:    time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "daily in the time zone of the user",
+			schedule: Schedule{Frequency: Daily, Hour: 8, Location: paris},
+			after:    time.Date(2024, 1, 15, 7, 30, 0, 0, time.UTC),
+			expected: time.Date(2024, 1, 16, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "daily across the daylight saving change",
+			schedule: Schedule{Frequency: Daily, Hour: 8, Location: paris},
+			after:    time.Date(2024, 3, 30, 7, 30, 0, 0, time.UTC),
+			expected: time.Date(2024, 3, 31, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekly",
+			schedule: Schedule{Frequency: Weekly, Hour: 18, Weekday: time.Friday, Location: time.UTC},
+			// A Monday.
+			after:    time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 3, 8, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekly next week",
+			schedule: Schedule{Frequency: Weekly, Hour: 18, Weekday: time.Friday, Location: time.UTC},
+			after:    time.Date(2024, 3, 8, 18, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 3, 15, 18, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, test := range tests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.schedule.Next(tc.after))
+		})
+	}
+}
+
+func TestSchedule_Validate(t *testing.T) {
+	assert.NoError(t, Schedule{Frequency: Daily, Hour: 0, Location: time.UTC}.Validate())
+	assert.Error(t, Schedule{Frequency: "monthly", Location: time.UTC}.Validate())
+	assert.Error(t, Schedule{Frequency: Daily, Hour: 24, Location: time.UTC}.Validate())
+	assert.Error(t, Schedule{Frequency: Weekly, Weekday: 7, Location: time.UTC}.Validate())
+	assert.Error(t, Schedule{Frequency: Daily}.Validate())
+}
@@ -0,0 +1,259 @@
+// Package jwt signs and verifies the JSON Web Tokens, with HS256, RS256, ES256 or EdDSA.
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Signing algorithms of the tokens.
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+)
+
+var (
+	// ErrMalformed is returned when a token is not a JWT in the compact serialization.
+	ErrMalformed = errors.New("malformed token")
+	// ErrInvalidSignature is returned when the signature of a token does not match its key.
+	ErrInvalidSignature = errors.New("invalid token signature")
+)
+
+// Header is the header of a token.
+type Header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// Token is a parsed token, its signature is not verified yet.
+type Token struct {
+	Header Header
+
+	signingInput string
+	payload      []byte
+	signature    []byte
+}
+
+// Parse parses a token in the compact serialization, header.payload.signature.
+func Parse(raw string) (*Token, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	t := &Token{
+		signingInput: parts[0] + "." + parts[1],
+		payload:      payload,
+		signature:    signature,
+	}
+	if err := json.Unmarshal(header, &t.Header); err != nil {
+		return nil, ErrMalformed
+	}
+
+	return t, nil
+}
+
+// Verify verifies the signature of the token with the key of its algorithm:
+// a []byte secret for HS256, an *rsa.PublicKey for RS256, an *ecdsa.PublicKey for ES256
+// and an ed25519.PublicKey for EdDSA. A key of another type is refused, whatever the header tells.
+func (t *Token) Verify(key crypto.PublicKey) error {
+	digest := sha256.Sum256([]byte(t.signingInput))
+
+	switch t.Header.Alg {
+	case HS256:
+		secret, ok := key.([]byte)
+		if !ok || len(secret) == 0 {
+			return fmt.Errorf("invalid key for %s", t.Header.Alg)
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(t.signingInput))
+		if !hmac.Equal(mac.Sum(nil), t.signature) {
+			return ErrInvalidSignature
+		}
+	case RS256:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("invalid key for %s", t.Header.Alg)
+		}
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], t.signature); err != nil {
+			return ErrInvalidSignature
+		}
+	case ES256:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("invalid key for %s", t.Header.Alg)
+		}
+		// The signature is the concatenation of r and s, 32 bytes each.
+		if len(t.signature) != 64 {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(t.signature[:32])
+		s := new(big.Int).SetBytes(t.signature[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return ErrInvalidSignature
+		}
+	case EdDSA:
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("invalid key for %s", t.Header.Alg)
+		}
+		if !ed25519.Verify(pub, []byte(t.signingInput), t.signature) {
+			return ErrInvalidSignature
+		}
+	default:
+		return fmt.Errorf("unsupported algorithm: %q", t.Header.Alg)
+	}
+
+	return nil
+}
+
+// Claims decodes the payload of the token into v.
+func (t *Token) Claims(v any) error {
+	if err := json.Unmarshal(t.payload, v); err != nil {
+		return fmt.Errorf("error decoding claims: %w", err)
+	}
+
+	return nil
+}
+
+// Audience is the audience of a token, a single string or an array in the payload.
+type Audience []string
+
+// UnmarshalJSON decodes a single string or an array of strings.
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("invalid audience: %w", err)
+	}
+	*a = multiple
+
+	return nil
+}
+
+// Claims are the registered claims of a token, the times are in seconds since the epoch.
+type Claims struct {
+	Issuer    string   `json:"iss,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	ID        string   `json:"jti,omitempty"`
+}
+
+// Validate checks the issuer, the audience and the validity period of the claims at the given time,
+// with a leeway for the clock skew. The expiration is required.
+func (c Claims) Validate(issuer, audience string, now time.Time, leeway time.Duration) error {
+	if c.Issuer != issuer {
+		return fmt.Errorf("invalid issuer: %q", c.Issuer)
+	}
+	if !slices.Contains(c.Audience, audience) {
+		return fmt.Errorf("invalid audience: %q", c.Audience)
+	}
+	if c.ExpiresAt == 0 {
+		return errors.New("missing expiration")
+	}
+	if now.Add(-leeway).Unix() >= c.ExpiresAt {
+		return errors.New("token expired")
+	}
+	if c.NotBefore != 0 && now.Add(leeway).Unix() < c.NotBefore {
+		return errors.New("token not valid yet")
+	}
+
+	return nil
+}
+
+// Sign returns the token of the claims signed with the key of the algorithm:
+// a []byte secret for HS256, an *rsa.PrivateKey for RS256, an *ecdsa.PrivateKey on P-256 for ES256
+// and an ed25519.PrivateKey for EdDSA. The kid identifies the key in the JWKS of the issuer, it is optional.
+func Sign(alg, kid string, claims any, key crypto.PrivateKey) (string, error) {
+	header, err := json.Marshal(Header{Alg: alg, Kid: kid, Typ: "JWT"})
+	if err != nil {
+		return "", fmt.Errorf("error encoding header: %w", err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("error encoding claims: %w", err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch alg {
+	case HS256:
+		secret, ok := key.([]byte)
+		if !ok || len(secret) == 0 {
+			return "", fmt.Errorf("invalid key for %s", alg)
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case RS256:
+		priv, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return "", fmt.Errorf("invalid key for %s", alg)
+		}
+		signature, err = rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, digest[:])
+		if err != nil {
+			return "", fmt.Errorf("error signing token: %w", err)
+		}
+	case ES256:
+		priv, ok := key.(*ecdsa.PrivateKey)
+		if !ok || priv.Curve != elliptic.P256() {
+			return "", fmt.Errorf("invalid key for %s", alg)
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, priv, digest[:])
+		if err != nil {
+			return "", fmt.Errorf("error signing token: %w", err)
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	case EdDSA:
+		priv, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return "", fmt.Errorf("invalid key for %s", alg)
+		}
+		signature = ed25519.Sign(priv, []byte(signingInput))
+	default:
+		return "", fmt.Errorf("unsupported algorithm: %q", alg)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,139 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		alg     string
+		private crypto.PrivateKey
+		public  crypto.PublicKey
+	}{
+		{alg: HS256, private: []byte("secret"), public: []byte("secret")},
+		{alg: RS256, private: rsaKey, public: &rsaKey.PublicKey},
+		{alg: ES256, private: ecKey, public: &ecKey.PublicKey},
+		{alg: EdDSA, private: edKey, public: edPub},
+	}
+
+	claims := Claims{Issuer: "https://issuer.example.com", Subject: "user", Audience: Audience{"client"}}
+	for _, test := range tests {
+		tc := test
+		t.Run(tc.alg, func(t *testing.T) {
+			raw, err := Sign(tc.alg, "key-1", claims, tc.private)
+			require.NoError(t, err)
+
+			token, err := Parse(raw)
+			require.NoError(t, err)
+			assert.Equal(t, Header{Alg: tc.alg, Kid: "key-1", Typ: "JWT"}, token.Header)
+			require.NoError(t, token.Verify(tc.public))
+
+			var actual Claims
+			require.NoError(t, token.Claims(&actual))
+			assert.Equal(t, claims, actual)
+
+			// The signature covers the payload.
+			tampered, err := Parse(raw[:len(raw)-4] + "AAAA")
+			require.NoError(t, err)
+			assert.Error(t, tampered.Verify(tc.public))
+		})
+	}
+
+	// The key must be of the type of the algorithm, an HMAC token cannot be verified with a public key as secret.
+	raw, err := Sign(HS256, "", claims, []byte("secret"))
+	require.NoError(t, err)
+	token, err := Parse(raw)
+	require.NoError(t, err)
+	assert.Error(t, token.Verify(&rsaKey.PublicKey))
+
+	// The unsigned tokens are refused.
+	token, err = Parse("eyJhbGciOiJub25lIn0.e30.")
+	require.NoError(t, err)
+	assert.Error(t, token.Verify([]byte("secret")))
+
+	for _, malformed := range []string{"", "a.b", "a.b.c.d", "!.e30.", "e30.!.", "e30.e30.!"} {
+		_, err := Parse(malformed)
+		assert.ErrorIs(t, err, ErrMalformed, malformed)
+	}
+}
+
+func TestJWK(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for _, key := range []crypto.PublicKey{&rsaKey.PublicKey, &ecKey.PublicKey, edPub} {
+		jwk, err := NewJWK("kid", "", key)
+		require.NoError(t, err)
+
+		actual, err := jwk.PublicKey()
+		require.NoError(t, err)
+		assert.True(t, key.(interface{ Equal(crypto.PublicKey) bool }).Equal(actual))
+	}
+
+	set := JWKS{Keys: []JWK{{Kid: "a"}, {Kid: "b"}}}
+	key, ok := set.Key("b")
+	assert.True(t, ok)
+	assert.Equal(t, "b", key.Kid)
+	_, ok = set.Key("c")
+	assert.False(t, ok)
+
+	// A point not on the curve is refused.
+	jwk, err := NewJWK("kid", "", &ecKey.PublicKey)
+	require.NoError(t, err)
+	jwk.Y = jwk.X
+	_, err = jwk.PublicKey()
+	assert.Error(t, err)
+}
+
+func TestClaims_Validate(t *testing.T) {
+	now := time.Now()
+	claims := Claims{
+		Issuer:    "https://issuer.example.com",
+		Audience:  Audience{"other", "client"},
+		ExpiresAt: now.Add(time.Minute).Unix(),
+	}
+	require.NoError(t, claims.Validate("https://issuer.example.com", "client", now, 0))
+
+	assert.Error(t, claims.Validate("https://other.example.com", "client", now, 0))
+	assert.Error(t, claims.Validate("https://issuer.example.com", "unknown", now, 0))
+	assert.Error(t, claims.Validate("https://issuer.example.com", "client", now.Add(2*time.Minute), 0))
+	require.NoError(t, claims.Validate("https://issuer.example.com", "client", now.Add(2*time.Minute), 2*time.Minute))
+
+	notYet := claims
+	notYet.NotBefore = now.Add(time.Minute).Unix()
+	assert.Error(t, notYet.Validate("https://issuer.example.com", "client", now, 0))
+
+	withoutExpiration := claims
+	withoutExpiration.ExpiresAt = 0
+	assert.Error(t, withoutExpiration.Validate("https://issuer.example.com", "client", now, 0))
+
+	// The audience is a string or an array.
+	var aud struct {
+		Audience Audience `json:"aud"`
+	}
+	token, err := Parse("eyJhbGciOiJIUzI1NiJ9.eyJhdWQiOiJjbGllbnQifQ.")
+	require.NoError(t, err)
+	require.NoError(t, token.Claims(&aud))
+	assert.Equal(t, Audience{"client"}, aud.Audience)
+}
@@ -5,12 +5,14 @@
 //
 //	mockgen -package mockdb -destination internal/mock/db.go github.com/jbdoumenjou/go-rssaggregator/internal/database Querier
 //
+
 // Package mockdb is a generated GoMock package.
 package mockdb
 
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	uuid "github.com/google/uuid"
 	database "github.com/jbdoumenjou/go-rssaggregator/internal/database"
@@ -40,6 +42,110 @@ func (m *MockQuerier) EXPECT() *MockQuerierMockRecorder {
 	return m.recorder
 }
 
+// ActivateWebSubSubscription mocks base method.
+func (m *MockQuerier) ActivateWebSubSubscription(arg0 context.Context, arg1 database.ActivateWebSubSubscriptionParams) (database.WebSubSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActivateWebSubSubscription", arg0, arg1)
+	ret0, _ := ret[0].(database.WebSubSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ActivateWebSubSubscription indicates an expected call of ActivateWebSubSubscription.
+func (mr *MockQuerierMockRecorder) ActivateWebSubSubscription(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActivateWebSubSubscription", reflect.TypeOf((*MockQuerier)(nil).ActivateWebSubSubscription), arg0, arg1)
+}
+
+// CountOtherFeedFollowers mocks base method.
+func (m *MockQuerier) CountOtherFeedFollowers(arg0 context.Context, arg1 uuid.NullUUID) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountOtherFeedFollowers", arg0, arg1)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountOtherFeedFollowers indicates an expected call of CountOtherFeedFollowers.
+func (mr *MockQuerierMockRecorder) CountOtherFeedFollowers(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountOtherFeedFollowers", reflect.TypeOf((*MockQuerier)(nil).CountOtherFeedFollowers), arg0, arg1)
+}
+
+// CountPosts mocks base method.
+func (m *MockQuerier) CountPosts(arg0 context.Context, arg1 uuid.UUID) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPosts", arg0, arg1)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPosts indicates an expected call of CountPosts.
+func (mr *MockQuerierMockRecorder) CountPosts(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPosts", reflect.TypeOf((*MockQuerier)(nil).CountPosts), arg0, arg1)
+}
+
+// CountUnfollowableFeedFollows mocks base method.
+func (m *MockQuerier) CountUnfollowableFeedFollows(arg0 context.Context, arg1 uuid.UUID) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnfollowableFeedFollows", arg0, arg1)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnfollowableFeedFollows indicates an expected call of CountUnfollowableFeedFollows.
+func (mr *MockQuerierMockRecorder) CountUnfollowableFeedFollows(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnfollowableFeedFollows", reflect.TypeOf((*MockQuerier)(nil).CountUnfollowableFeedFollows), arg0, arg1)
+}
+
+// CountUnreadPosts mocks base method.
+func (m *MockQuerier) CountUnreadPosts(arg0 context.Context, arg1 uuid.NullUUID) ([]database.CountUnreadPostsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnreadPosts", arg0, arg1)
+	ret0, _ := ret[0].([]database.CountUnreadPostsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnreadPosts indicates an expected call of CountUnreadPosts.
+func (mr *MockQuerierMockRecorder) CountUnreadPosts(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnreadPosts", reflect.TypeOf((*MockQuerier)(nil).CountUnreadPosts), arg0, arg1)
+}
+
+// CreateApiKey mocks base method.
+func (m *MockQuerier) CreateApiKey(arg0 context.Context, arg1 database.CreateApiKeyParams) (database.CreateApiKeyRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateApiKey", arg0, arg1)
+	ret0, _ := ret[0].(database.CreateApiKeyRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateApiKey indicates an expected call of CreateApiKey.
+func (mr *MockQuerierMockRecorder) CreateApiKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApiKey", reflect.TypeOf((*MockQuerier)(nil).CreateApiKey), arg0, arg1)
+}
+
+// CreateDigestItems mocks base method.
+func (m *MockQuerier) CreateDigestItems(arg0 context.Context, arg1 database.CreateDigestItemsParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDigestItems", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateDigestItems indicates an expected call of CreateDigestItems.
+func (mr *MockQuerierMockRecorder) CreateDigestItems(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDigestItems", reflect.TypeOf((*MockQuerier)(nil).CreateDigestItems), arg0, arg1)
+}
+
 // CreateFeed mocks base method.
 func (m *MockQuerier) CreateFeed(arg0 context.Context, arg1 database.CreateFeedParams) (database.Feed, error) {
 	m.ctrl.T.Helper()
@@ -70,11 +176,116 @@ func (mr *MockQuerierMockRecorder) CreateFeedFollows(arg0, arg1 any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFeedFollows", reflect.TypeOf((*MockQuerier)(nil).CreateFeedFollows), arg0, arg1)
 }
 
+// CreateFeedFollowsIfNotExists mocks base method.
+func (m *MockQuerier) CreateFeedFollowsIfNotExists(arg0 context.Context, arg1 database.CreateFeedFollowsIfNotExistsParams) (database.FeedFollow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFeedFollowsIfNotExists", arg0, arg1)
+	ret0, _ := ret[0].(database.FeedFollow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFeedFollowsIfNotExists indicates an expected call of CreateFeedFollowsIfNotExists.
+func (mr *MockQuerierMockRecorder) CreateFeedFollowsIfNotExists(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFeedFollowsIfNotExists", reflect.TypeOf((*MockQuerier)(nil).CreateFeedFollowsIfNotExists), arg0, arg1)
+}
+
+// CreateFeedShare mocks base method.
+func (m *MockQuerier) CreateFeedShare(arg0 context.Context, arg1 database.CreateFeedShareParams) (database.FeedShare, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFeedShare", arg0, arg1)
+	ret0, _ := ret[0].(database.FeedShare)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFeedShare indicates an expected call of CreateFeedShare.
+func (mr *MockQuerierMockRecorder) CreateFeedShare(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFeedShare", reflect.TypeOf((*MockQuerier)(nil).CreateFeedShare), arg0, arg1)
+}
+
+// CreateFilterRule mocks base method.
+func (m *MockQuerier) CreateFilterRule(arg0 context.Context, arg1 database.CreateFilterRuleParams) (database.FilterRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFilterRule", arg0, arg1)
+	ret0, _ := ret[0].(database.FilterRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFilterRule indicates an expected call of CreateFilterRule.
+func (mr *MockQuerierMockRecorder) CreateFilterRule(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFilterRule", reflect.TypeOf((*MockQuerier)(nil).CreateFilterRule), arg0, arg1)
+}
+
+// CreateFolder mocks base method.
+func (m *MockQuerier) CreateFolder(arg0 context.Context, arg1 database.CreateFolderParams) (database.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFolder", arg0, arg1)
+	ret0, _ := ret[0].(database.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFolder indicates an expected call of CreateFolder.
+func (mr *MockQuerierMockRecorder) CreateFolder(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFolder", reflect.TypeOf((*MockQuerier)(nil).CreateFolder), arg0, arg1)
+}
+
+// CreatePost mocks base method.
+func (m *MockQuerier) CreatePost(arg0 context.Context, arg1 database.CreatePostParams) (database.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePost", arg0, arg1)
+	ret0, _ := ret[0].(database.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePost indicates an expected call of CreatePost.
+func (mr *MockQuerierMockRecorder) CreatePost(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePost", reflect.TypeOf((*MockQuerier)(nil).CreatePost), arg0, arg1)
+}
+
+// CreateRefreshToken mocks base method.
+func (m *MockQuerier) CreateRefreshToken(arg0 context.Context, arg1 database.CreateRefreshTokenParams) (database.CreateRefreshTokenRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(database.CreateRefreshTokenRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRefreshToken indicates an expected call of CreateRefreshToken.
+func (mr *MockQuerierMockRecorder) CreateRefreshToken(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefreshToken", reflect.TypeOf((*MockQuerier)(nil).CreateRefreshToken), arg0, arg1)
+}
+
+// CreateSession mocks base method.
+func (m *MockQuerier) CreateSession(arg0 context.Context, arg1 database.CreateSessionParams) (database.CreateSessionRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
+	ret0, _ := ret[0].(database.CreateSessionRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockQuerierMockRecorder) CreateSession(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockQuerier)(nil).CreateSession), arg0, arg1)
+}
+
 // CreateUser mocks base method.
-func (m *MockQuerier) CreateUser(arg0 context.Context, arg1 string) (database.User, error) {
+func (m *MockQuerier) CreateUser(arg0 context.Context, arg1 string) (database.CreateUserRow, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
-	ret0, _ := ret[0].(database.User)
+	ret0, _ := ret[0].(database.CreateUserRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -85,8 +296,110 @@ func (mr *MockQuerierMockRecorder) CreateUser(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockQuerier)(nil).CreateUser), arg0, arg1)
 }
 
+// CreateUserIdentity mocks base method.
+func (m *MockQuerier) CreateUserIdentity(arg0 context.Context, arg1 database.CreateUserIdentityParams) (database.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserIdentity", arg0, arg1)
+	ret0, _ := ret[0].(database.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUserIdentity indicates an expected call of CreateUserIdentity.
+func (mr *MockQuerierMockRecorder) CreateUserIdentity(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserIdentity", reflect.TypeOf((*MockQuerier)(nil).CreateUserIdentity), arg0, arg1)
+}
+
+// CreateWebhook mocks base method.
+func (m *MockQuerier) CreateWebhook(arg0 context.Context, arg1 database.CreateWebhookParams) (database.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", arg0, arg1)
+	ret0, _ := ret[0].(database.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockQuerierMockRecorder) CreateWebhook(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockQuerier)(nil).CreateWebhook), arg0, arg1)
+}
+
+// CreateWebhookDelivery mocks base method.
+func (m *MockQuerier) CreateWebhookDelivery(arg0 context.Context, arg1 database.CreateWebhookDeliveryParams) (database.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(database.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookDelivery indicates an expected call of CreateWebhookDelivery.
+func (mr *MockQuerierMockRecorder) CreateWebhookDelivery(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookDelivery", reflect.TypeOf((*MockQuerier)(nil).CreateWebhookDelivery), arg0, arg1)
+}
+
+// DeleteApiKey mocks base method.
+func (m *MockQuerier) DeleteApiKey(arg0 context.Context, arg1 database.DeleteApiKeyParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteApiKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteApiKey indicates an expected call of DeleteApiKey.
+func (mr *MockQuerierMockRecorder) DeleteApiKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteApiKey", reflect.TypeOf((*MockQuerier)(nil).DeleteApiKey), arg0, arg1)
+}
+
+// DeleteDigestSettings mocks base method.
+func (m *MockQuerier) DeleteDigestSettings(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDigestSettings", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDigestSettings indicates an expected call of DeleteDigestSettings.
+func (mr *MockQuerierMockRecorder) DeleteDigestSettings(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDigestSettings", reflect.TypeOf((*MockQuerier)(nil).DeleteDigestSettings), arg0, arg1)
+}
+
+// DeleteExpiredHubSubscriptions mocks base method.
+func (m *MockQuerier) DeleteExpiredHubSubscriptions(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredHubSubscriptions", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredHubSubscriptions indicates an expected call of DeleteExpiredHubSubscriptions.
+func (mr *MockQuerierMockRecorder) DeleteExpiredHubSubscriptions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredHubSubscriptions", reflect.TypeOf((*MockQuerier)(nil).DeleteExpiredHubSubscriptions), arg0, arg1)
+}
+
+// DeleteFeed mocks base method.
+func (m *MockQuerier) DeleteFeed(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFeed", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFeed indicates an expected call of DeleteFeed.
+func (mr *MockQuerierMockRecorder) DeleteFeed(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFeed", reflect.TypeOf((*MockQuerier)(nil).DeleteFeed), arg0, arg1)
+}
+
 // DeleteFeedFollows mocks base method.
-func (m *MockQuerier) DeleteFeedFollows(arg0 context.Context, arg1 uuid.UUID) error {
+func (m *MockQuerier) DeleteFeedFollows(arg0 context.Context, arg1 database.DeleteFeedFollowsParams) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "DeleteFeedFollows", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -99,62 +412,1318 @@ func (mr *MockQuerierMockRecorder) DeleteFeedFollows(arg0, arg1 any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFeedFollows", reflect.TypeOf((*MockQuerier)(nil).DeleteFeedFollows), arg0, arg1)
 }
 
-// GetUserFromApiKey mocks base method.
-func (m *MockQuerier) GetUserFromApiKey(arg0 context.Context, arg1 string) (database.User, error) {
+// DeleteFeedShare mocks base method.
+func (m *MockQuerier) DeleteFeedShare(arg0 context.Context, arg1 database.DeleteFeedShareParams) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserFromApiKey", arg0, arg1)
-	ret0, _ := ret[0].(database.User)
+	ret := m.ctrl.Call(m, "DeleteFeedShare", arg0, arg1)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserFromApiKey indicates an expected call of GetUserFromApiKey.
-func (mr *MockQuerierMockRecorder) GetUserFromApiKey(arg0, arg1 any) *gomock.Call {
+// DeleteFeedShare indicates an expected call of DeleteFeedShare.
+func (mr *MockQuerierMockRecorder) DeleteFeedShare(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserFromApiKey", reflect.TypeOf((*MockQuerier)(nil).GetUserFromApiKey), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFeedShare", reflect.TypeOf((*MockQuerier)(nil).DeleteFeedShare), arg0, arg1)
 }
 
-// GetUserFromId mocks base method.
-func (m *MockQuerier) GetUserFromId(arg0 context.Context, arg1 uuid.UUID) (database.User, error) {
+// DeleteFilterRule mocks base method.
+func (m *MockQuerier) DeleteFilterRule(arg0 context.Context, arg1 database.DeleteFilterRuleParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserFromId", arg0, arg1)
-	ret0, _ := ret[0].(database.User)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "DeleteFilterRule", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetUserFromId indicates an expected call of GetUserFromId.
-func (mr *MockQuerierMockRecorder) GetUserFromId(arg0, arg1 any) *gomock.Call {
+// DeleteFilterRule indicates an expected call of DeleteFilterRule.
+func (mr *MockQuerierMockRecorder) DeleteFilterRule(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserFromId", reflect.TypeOf((*MockQuerier)(nil).GetUserFromId), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFilterRule", reflect.TypeOf((*MockQuerier)(nil).DeleteFilterRule), arg0, arg1)
 }
 
-// ListFeedFollows mocks base method.
-func (m *MockQuerier) ListFeedFollows(arg0 context.Context, arg1 database.ListFeedFollowsParams) ([]database.FeedFollow, error) {
+// DeleteFolder mocks base method.
+func (m *MockQuerier) DeleteFolder(arg0 context.Context, arg1 database.DeleteFolderParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListFeedFollows", arg0, arg1)
-	ret0, _ := ret[0].([]database.FeedFollow)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "DeleteFolder", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// ListFeedFollows indicates an expected call of ListFeedFollows.
-func (mr *MockQuerierMockRecorder) ListFeedFollows(arg0, arg1 any) *gomock.Call {
+// DeleteFolder indicates an expected call of DeleteFolder.
+func (mr *MockQuerierMockRecorder) DeleteFolder(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFeedFollows", reflect.TypeOf((*MockQuerier)(nil).ListFeedFollows), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFolder", reflect.TypeOf((*MockQuerier)(nil).DeleteFolder), arg0, arg1)
 }
 
-// ListFeeds mocks base method.
-func (m *MockQuerier) ListFeeds(arg0 context.Context, arg1 database.ListFeedsParams) ([]database.Feed, error) {
+// DeleteHubSubscription mocks base method.
+func (m *MockQuerier) DeleteHubSubscription(arg0 context.Context, arg1 database.DeleteHubSubscriptionParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListFeeds", arg0, arg1)
-	ret0, _ := ret[0].([]database.Feed)
+	ret := m.ctrl.Call(m, "DeleteHubSubscription", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteHubSubscription indicates an expected call of DeleteHubSubscription.
+func (mr *MockQuerierMockRecorder) DeleteHubSubscription(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteHubSubscription", reflect.TypeOf((*MockQuerier)(nil).DeleteHubSubscription), arg0, arg1)
+}
+
+// DeleteRefreshToken mocks base method.
+func (m *MockQuerier) DeleteRefreshToken(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRefreshToken indicates an expected call of DeleteRefreshToken.
+func (mr *MockQuerierMockRecorder) DeleteRefreshToken(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRefreshToken", reflect.TypeOf((*MockQuerier)(nil).DeleteRefreshToken), arg0, arg1)
+}
+
+// DeleteSession mocks base method.
+func (m *MockQuerier) DeleteSession(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSession", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSession indicates an expected call of DeleteSession.
+func (mr *MockQuerierMockRecorder) DeleteSession(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockQuerier)(nil).DeleteSession), arg0, arg1)
+}
+
+// DeleteUnfollowableFeedFollows mocks base method.
+func (m *MockQuerier) DeleteUnfollowableFeedFollows(arg0 context.Context, arg1 uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUnfollowableFeedFollows", arg0, arg1)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListFeeds indicates an expected call of ListFeeds.
-func (mr *MockQuerierMockRecorder) ListFeeds(arg0, arg1 any) *gomock.Call {
+// DeleteUnfollowableFeedFollows indicates an expected call of DeleteUnfollowableFeedFollows.
+func (mr *MockQuerierMockRecorder) DeleteUnfollowableFeedFollows(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFeeds", reflect.TypeOf((*MockQuerier)(nil).ListFeeds), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUnfollowableFeedFollows", reflect.TypeOf((*MockQuerier)(nil).DeleteUnfollowableFeedFollows), arg0, arg1)
+}
+
+// DeleteUser mocks base method.
+func (m *MockQuerier) DeleteUser(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockQuerierMockRecorder) DeleteUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockQuerier)(nil).DeleteUser), arg0, arg1)
+}
+
+// DeleteUserHubSubscriptions mocks base method.
+func (m *MockQuerier) DeleteUserHubSubscriptions(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserHubSubscriptions", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserHubSubscriptions indicates an expected call of DeleteUserHubSubscriptions.
+func (mr *MockQuerierMockRecorder) DeleteUserHubSubscriptions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserHubSubscriptions", reflect.TypeOf((*MockQuerier)(nil).DeleteUserHubSubscriptions), arg0, arg1)
+}
+
+// DeleteUserRefreshTokens mocks base method.
+func (m *MockQuerier) DeleteUserRefreshTokens(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserRefreshTokens", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserRefreshTokens indicates an expected call of DeleteUserRefreshTokens.
+func (mr *MockQuerierMockRecorder) DeleteUserRefreshTokens(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserRefreshTokens", reflect.TypeOf((*MockQuerier)(nil).DeleteUserRefreshTokens), arg0, arg1)
+}
+
+// DeleteUserSessions mocks base method.
+func (m *MockQuerier) DeleteUserSessions(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserSessions", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserSessions indicates an expected call of DeleteUserSessions.
+func (mr *MockQuerierMockRecorder) DeleteUserSessions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserSessions", reflect.TypeOf((*MockQuerier)(nil).DeleteUserSessions), arg0, arg1)
+}
+
+// DeleteWebSubSubscription mocks base method.
+func (m *MockQuerier) DeleteWebSubSubscription(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebSubSubscription", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebSubSubscription indicates an expected call of DeleteWebSubSubscription.
+func (mr *MockQuerierMockRecorder) DeleteWebSubSubscription(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebSubSubscription", reflect.TypeOf((*MockQuerier)(nil).DeleteWebSubSubscription), arg0, arg1)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockQuerier) DeleteWebhook(arg0 context.Context, arg1 database.DeleteWebhookParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockQuerierMockRecorder) DeleteWebhook(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockQuerier)(nil).DeleteWebhook), arg0, arg1)
+}
+
+// DenyWebSubSubscription mocks base method.
+func (m *MockQuerier) DenyWebSubSubscription(arg0 context.Context, arg1 database.DenyWebSubSubscriptionParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DenyWebSubSubscription", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DenyWebSubSubscription indicates an expected call of DenyWebSubSubscription.
+func (mr *MockQuerierMockRecorder) DenyWebSubSubscription(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DenyWebSubSubscription", reflect.TypeOf((*MockQuerier)(nil).DenyWebSubSubscription), arg0, arg1)
+}
+
+// GetDigestSettings mocks base method.
+func (m *MockQuerier) GetDigestSettings(arg0 context.Context, arg1 uuid.UUID) (database.DigestSetting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDigestSettings", arg0, arg1)
+	ret0, _ := ret[0].(database.DigestSetting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDigestSettings indicates an expected call of GetDigestSettings.
+func (mr *MockQuerierMockRecorder) GetDigestSettings(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDigestSettings", reflect.TypeOf((*MockQuerier)(nil).GetDigestSettings), arg0, arg1)
+}
+
+// GetFeed mocks base method.
+func (m *MockQuerier) GetFeed(arg0 context.Context, arg1 uuid.UUID) (database.Feed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeed", arg0, arg1)
+	ret0, _ := ret[0].(database.Feed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeed indicates an expected call of GetFeed.
+func (mr *MockQuerierMockRecorder) GetFeed(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeed", reflect.TypeOf((*MockQuerier)(nil).GetFeed), arg0, arg1)
+}
+
+// GetFeedByURL mocks base method.
+func (m *MockQuerier) GetFeedByURL(arg0 context.Context, arg1 database.GetFeedByURLParams) (database.Feed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeedByURL", arg0, arg1)
+	ret0, _ := ret[0].(database.Feed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeedByURL indicates an expected call of GetFeedByURL.
+func (mr *MockQuerierMockRecorder) GetFeedByURL(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeedByURL", reflect.TypeOf((*MockQuerier)(nil).GetFeedByURL), arg0, arg1)
+}
+
+// GetFeedFollows mocks base method.
+func (m *MockQuerier) GetFeedFollows(arg0 context.Context, arg1 database.GetFeedFollowsParams) (database.FeedFollow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeedFollows", arg0, arg1)
+	ret0, _ := ret[0].(database.FeedFollow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeedFollows indicates an expected call of GetFeedFollows.
+func (mr *MockQuerierMockRecorder) GetFeedFollows(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeedFollows", reflect.TypeOf((*MockQuerier)(nil).GetFeedFollows), arg0, arg1)
+}
+
+// GetLastPostID mocks base method.
+func (m *MockQuerier) GetLastPostID(arg0 context.Context) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastPostID", arg0)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastPostID indicates an expected call of GetLastPostID.
+func (mr *MockQuerierMockRecorder) GetLastPostID(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastPostID", reflect.TypeOf((*MockQuerier)(nil).GetLastPostID), arg0)
+}
+
+// GetManagedFeed mocks base method.
+func (m *MockQuerier) GetManagedFeed(arg0 context.Context, arg1 database.GetManagedFeedParams) (database.Feed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetManagedFeed", arg0, arg1)
+	ret0, _ := ret[0].(database.Feed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetManagedFeed indicates an expected call of GetManagedFeed.
+func (mr *MockQuerierMockRecorder) GetManagedFeed(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetManagedFeed", reflect.TypeOf((*MockQuerier)(nil).GetManagedFeed), arg0, arg1)
+}
+
+// GetNextFeedFollowPosition mocks base method.
+func (m *MockQuerier) GetNextFeedFollowPosition(arg0 context.Context, arg1 database.GetNextFeedFollowPositionParams) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNextFeedFollowPosition", arg0, arg1)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNextFeedFollowPosition indicates an expected call of GetNextFeedFollowPosition.
+func (mr *MockQuerierMockRecorder) GetNextFeedFollowPosition(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNextFeedFollowPosition", reflect.TypeOf((*MockQuerier)(nil).GetNextFeedFollowPosition), arg0, arg1)
+}
+
+// GetNextFeedsToFetch mocks base method.
+func (m *MockQuerier) GetNextFeedsToFetch(arg0 context.Context, arg1 int32) ([]database.Feed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNextFeedsToFetch", arg0, arg1)
+	ret0, _ := ret[0].([]database.Feed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNextFeedsToFetch indicates an expected call of GetNextFeedsToFetch.
+func (mr *MockQuerierMockRecorder) GetNextFeedsToFetch(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNextFeedsToFetch", reflect.TypeOf((*MockQuerier)(nil).GetNextFeedsToFetch), arg0, arg1)
+}
+
+// GetNextFolderPosition mocks base method.
+func (m *MockQuerier) GetNextFolderPosition(arg0 context.Context, arg1 uuid.UUID) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNextFolderPosition", arg0, arg1)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNextFolderPosition indicates an expected call of GetNextFolderPosition.
+func (mr *MockQuerierMockRecorder) GetNextFolderPosition(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNextFolderPosition", reflect.TypeOf((*MockQuerier)(nil).GetNextFolderPosition), arg0, arg1)
+}
+
+// GetOrCreateFolder mocks base method.
+func (m *MockQuerier) GetOrCreateFolder(arg0 context.Context, arg1 database.GetOrCreateFolderParams) (database.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrCreateFolder", arg0, arg1)
+	ret0, _ := ret[0].(database.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrCreateFolder indicates an expected call of GetOrCreateFolder.
+func (mr *MockQuerierMockRecorder) GetOrCreateFolder(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrCreateFolder", reflect.TypeOf((*MockQuerier)(nil).GetOrCreateFolder), arg0, arg1)
+}
+
+// GetPostsByUser mocks base method.
+func (m *MockQuerier) GetPostsByUser(arg0 context.Context, arg1 database.GetPostsByUserParams) ([]database.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostsByUser", arg0, arg1)
+	ret0, _ := ret[0].([]database.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostsByUser indicates an expected call of GetPostsByUser.
+func (mr *MockQuerierMockRecorder) GetPostsByUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsByUser", reflect.TypeOf((*MockQuerier)(nil).GetPostsByUser), arg0, arg1)
+}
+
+// GetUserFromApiKey mocks base method.
+func (m *MockQuerier) GetUserFromApiKey(arg0 context.Context, arg1 string) (database.GetUserFromApiKeyRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserFromApiKey", arg0, arg1)
+	ret0, _ := ret[0].(database.GetUserFromApiKeyRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserFromApiKey indicates an expected call of GetUserFromApiKey.
+func (mr *MockQuerierMockRecorder) GetUserFromApiKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserFromApiKey", reflect.TypeOf((*MockQuerier)(nil).GetUserFromApiKey), arg0, arg1)
+}
+
+// GetUserFromEmail mocks base method.
+func (m *MockQuerier) GetUserFromEmail(arg0 context.Context, arg1 string) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserFromEmail", arg0, arg1)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserFromEmail indicates an expected call of GetUserFromEmail.
+func (mr *MockQuerierMockRecorder) GetUserFromEmail(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserFromEmail", reflect.TypeOf((*MockQuerier)(nil).GetUserFromEmail), arg0, arg1)
+}
+
+// GetUserFromFeedToken mocks base method.
+func (m *MockQuerier) GetUserFromFeedToken(arg0 context.Context, arg1 database.GetUserFromFeedTokenParams) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserFromFeedToken", arg0, arg1)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserFromFeedToken indicates an expected call of GetUserFromFeedToken.
+func (mr *MockQuerierMockRecorder) GetUserFromFeedToken(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserFromFeedToken", reflect.TypeOf((*MockQuerier)(nil).GetUserFromFeedToken), arg0, arg1)
+}
+
+// GetUserFromFeverKey mocks base method.
+func (m *MockQuerier) GetUserFromFeverKey(arg0 context.Context, arg1 string) (database.GetUserFromFeverKeyRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserFromFeverKey", arg0, arg1)
+	ret0, _ := ret[0].(database.GetUserFromFeverKeyRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserFromFeverKey indicates an expected call of GetUserFromFeverKey.
+func (mr *MockQuerierMockRecorder) GetUserFromFeverKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserFromFeverKey", reflect.TypeOf((*MockQuerier)(nil).GetUserFromFeverKey), arg0, arg1)
+}
+
+// GetUserFromId mocks base method.
+func (m *MockQuerier) GetUserFromId(arg0 context.Context, arg1 uuid.UUID) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserFromId", arg0, arg1)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserFromId indicates an expected call of GetUserFromId.
+func (mr *MockQuerierMockRecorder) GetUserFromId(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserFromId", reflect.TypeOf((*MockQuerier)(nil).GetUserFromId), arg0, arg1)
+}
+
+// GetUserFromIdentity mocks base method.
+func (m *MockQuerier) GetUserFromIdentity(arg0 context.Context, arg1 database.GetUserFromIdentityParams) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserFromIdentity", arg0, arg1)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserFromIdentity indicates an expected call of GetUserFromIdentity.
+func (mr *MockQuerierMockRecorder) GetUserFromIdentity(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserFromIdentity", reflect.TypeOf((*MockQuerier)(nil).GetUserFromIdentity), arg0, arg1)
+}
+
+// GetUserFromSession mocks base method.
+func (m *MockQuerier) GetUserFromSession(arg0 context.Context, arg1 string) (database.GetUserFromSessionRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserFromSession", arg0, arg1)
+	ret0, _ := ret[0].(database.GetUserFromSessionRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserFromSession indicates an expected call of GetUserFromSession.
+func (mr *MockQuerierMockRecorder) GetUserFromSession(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserFromSession", reflect.TypeOf((*MockQuerier)(nil).GetUserFromSession), arg0, arg1)
+}
+
+// GetWebSubSubscription mocks base method.
+func (m *MockQuerier) GetWebSubSubscription(arg0 context.Context, arg1 uuid.UUID) (database.GetWebSubSubscriptionRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebSubSubscription", arg0, arg1)
+	ret0, _ := ret[0].(database.GetWebSubSubscriptionRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebSubSubscription indicates an expected call of GetWebSubSubscription.
+func (mr *MockQuerierMockRecorder) GetWebSubSubscription(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebSubSubscription", reflect.TypeOf((*MockQuerier)(nil).GetWebSubSubscription), arg0, arg1)
+}
+
+// GetWebhook mocks base method.
+func (m *MockQuerier) GetWebhook(arg0 context.Context, arg1 database.GetWebhookParams) (database.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhook", arg0, arg1)
+	ret0, _ := ret[0].(database.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhook indicates an expected call of GetWebhook.
+func (mr *MockQuerierMockRecorder) GetWebhook(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhook", reflect.TypeOf((*MockQuerier)(nil).GetWebhook), arg0, arg1)
+}
+
+// ListAllFeedFollows mocks base method.
+func (m *MockQuerier) ListAllFeedFollows(arg0 context.Context, arg1 uuid.NullUUID) ([]database.FeedFollow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAllFeedFollows", arg0, arg1)
+	ret0, _ := ret[0].([]database.FeedFollow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAllFeedFollows indicates an expected call of ListAllFeedFollows.
+func (mr *MockQuerierMockRecorder) ListAllFeedFollows(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllFeedFollows", reflect.TypeOf((*MockQuerier)(nil).ListAllFeedFollows), arg0, arg1)
+}
+
+// ListAllStarredPosts mocks base method.
+func (m *MockQuerier) ListAllStarredPosts(arg0 context.Context, arg1 uuid.UUID) ([]database.StarredPost, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAllStarredPosts", arg0, arg1)
+	ret0, _ := ret[0].([]database.StarredPost)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAllStarredPosts indicates an expected call of ListAllStarredPosts.
+func (mr *MockQuerierMockRecorder) ListAllStarredPosts(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllStarredPosts", reflect.TypeOf((*MockQuerier)(nil).ListAllStarredPosts), arg0, arg1)
+}
+
+// ListApiKeys mocks base method.
+func (m *MockQuerier) ListApiKeys(arg0 context.Context, arg1 uuid.UUID) ([]database.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListApiKeys", arg0, arg1)
+	ret0, _ := ret[0].([]database.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListApiKeys indicates an expected call of ListApiKeys.
+func (mr *MockQuerierMockRecorder) ListApiKeys(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListApiKeys", reflect.TypeOf((*MockQuerier)(nil).ListApiKeys), arg0, arg1)
+}
+
+// ListDigestPosts mocks base method.
+func (m *MockQuerier) ListDigestPosts(arg0 context.Context, arg1 database.ListDigestPostsParams) ([]database.ListDigestPostsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDigestPosts", arg0, arg1)
+	ret0, _ := ret[0].([]database.ListDigestPostsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDigestPosts indicates an expected call of ListDigestPosts.
+func (mr *MockQuerierMockRecorder) ListDigestPosts(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDigestPosts", reflect.TypeOf((*MockQuerier)(nil).ListDigestPosts), arg0, arg1)
+}
+
+// ListDisabledWebSubSubscriptions mocks base method.
+func (m *MockQuerier) ListDisabledWebSubSubscriptions(arg0 context.Context) ([]database.WebSubSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDisabledWebSubSubscriptions", arg0)
+	ret0, _ := ret[0].([]database.WebSubSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDisabledWebSubSubscriptions indicates an expected call of ListDisabledWebSubSubscriptions.
+func (mr *MockQuerierMockRecorder) ListDisabledWebSubSubscriptions(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDisabledWebSubSubscriptions", reflect.TypeOf((*MockQuerier)(nil).ListDisabledWebSubSubscriptions), arg0)
+}
+
+// ListDueDigests mocks base method.
+func (m *MockQuerier) ListDueDigests(arg0 context.Context, arg1 database.ListDueDigestsParams) ([]database.ListDueDigestsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDueDigests", arg0, arg1)
+	ret0, _ := ret[0].([]database.ListDueDigestsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDueDigests indicates an expected call of ListDueDigests.
+func (mr *MockQuerierMockRecorder) ListDueDigests(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDueDigests", reflect.TypeOf((*MockQuerier)(nil).ListDueDigests), arg0, arg1)
+}
+
+// ListDueWebhookDeliveries mocks base method.
+func (m *MockQuerier) ListDueWebhookDeliveries(arg0 context.Context, arg1 int32) ([]database.ListDueWebhookDeliveriesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDueWebhookDeliveries", arg0, arg1)
+	ret0, _ := ret[0].([]database.ListDueWebhookDeliveriesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDueWebhookDeliveries indicates an expected call of ListDueWebhookDeliveries.
+func (mr *MockQuerierMockRecorder) ListDueWebhookDeliveries(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDueWebhookDeliveries", reflect.TypeOf((*MockQuerier)(nil).ListDueWebhookDeliveries), arg0, arg1)
+}
+
+// ListFeedFollows mocks base method.
+func (m *MockQuerier) ListFeedFollows(arg0 context.Context, arg1 database.ListFeedFollowsParams) ([]database.FeedFollow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFeedFollows", arg0, arg1)
+	ret0, _ := ret[0].([]database.FeedFollow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFeedFollows indicates an expected call of ListFeedFollows.
+func (mr *MockQuerierMockRecorder) ListFeedFollows(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFeedFollows", reflect.TypeOf((*MockQuerier)(nil).ListFeedFollows), arg0, arg1)
+}
+
+// ListFeedFollowsWithFeeds mocks base method.
+func (m *MockQuerier) ListFeedFollowsWithFeeds(arg0 context.Context, arg1 uuid.NullUUID) ([]database.ListFeedFollowsWithFeedsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFeedFollowsWithFeeds", arg0, arg1)
+	ret0, _ := ret[0].([]database.ListFeedFollowsWithFeedsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFeedFollowsWithFeeds indicates an expected call of ListFeedFollowsWithFeeds.
+func (mr *MockQuerierMockRecorder) ListFeedFollowsWithFeeds(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFeedFollowsWithFeeds", reflect.TypeOf((*MockQuerier)(nil).ListFeedFollowsWithFeeds), arg0, arg1)
+}
+
+// ListFeedShares mocks base method.
+func (m *MockQuerier) ListFeedShares(arg0 context.Context, arg1 uuid.UUID) ([]database.FeedShare, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFeedShares", arg0, arg1)
+	ret0, _ := ret[0].([]database.FeedShare)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFeedShares indicates an expected call of ListFeedShares.
+func (mr *MockQuerierMockRecorder) ListFeedShares(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFeedShares", reflect.TypeOf((*MockQuerier)(nil).ListFeedShares), arg0, arg1)
+}
+
+// ListFeeds mocks base method.
+func (m *MockQuerier) ListFeeds(arg0 context.Context, arg1 database.ListFeedsParams) ([]database.Feed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFeeds", arg0, arg1)
+	ret0, _ := ret[0].([]database.Feed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFeeds indicates an expected call of ListFeeds.
+func (mr *MockQuerierMockRecorder) ListFeeds(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFeeds", reflect.TypeOf((*MockQuerier)(nil).ListFeeds), arg0, arg1)
+}
+
+// ListFeedsByCreator mocks base method.
+func (m *MockQuerier) ListFeedsByCreator(arg0 context.Context, arg1 uuid.NullUUID) ([]database.Feed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFeedsByCreator", arg0, arg1)
+	ret0, _ := ret[0].([]database.Feed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFeedsByCreator indicates an expected call of ListFeedsByCreator.
+func (mr *MockQuerierMockRecorder) ListFeedsByCreator(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFeedsByCreator", reflect.TypeOf((*MockQuerier)(nil).ListFeedsByCreator), arg0, arg1)
+}
+
+// ListFeedsWithHealth mocks base method.
+func (m *MockQuerier) ListFeedsWithHealth(arg0 context.Context, arg1 database.ListFeedsWithHealthParams) ([]database.ListFeedsWithHealthRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFeedsWithHealth", arg0, arg1)
+	ret0, _ := ret[0].([]database.ListFeedsWithHealthRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFeedsWithHealth indicates an expected call of ListFeedsWithHealth.
+func (mr *MockQuerierMockRecorder) ListFeedsWithHealth(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFeedsWithHealth", reflect.TypeOf((*MockQuerier)(nil).ListFeedsWithHealth), arg0, arg1)
+}
+
+// ListFilterRules mocks base method.
+func (m *MockQuerier) ListFilterRules(arg0 context.Context, arg1 uuid.UUID) ([]database.FilterRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFilterRules", arg0, arg1)
+	ret0, _ := ret[0].([]database.FilterRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFilterRules indicates an expected call of ListFilterRules.
+func (mr *MockQuerierMockRecorder) ListFilterRules(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFilterRules", reflect.TypeOf((*MockQuerier)(nil).ListFilterRules), arg0, arg1)
+}
+
+// ListFolders mocks base method.
+func (m *MockQuerier) ListFolders(arg0 context.Context, arg1 uuid.UUID) ([]database.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFolders", arg0, arg1)
+	ret0, _ := ret[0].([]database.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFolders indicates an expected call of ListFolders.
+func (mr *MockQuerierMockRecorder) ListFolders(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFolders", reflect.TypeOf((*MockQuerier)(nil).ListFolders), arg0, arg1)
+}
+
+// ListHubSubscriptionsForPost mocks base method.
+func (m *MockQuerier) ListHubSubscriptionsForPost(arg0 context.Context, arg1 int32) ([]database.ListHubSubscriptionsForPostRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListHubSubscriptionsForPost", arg0, arg1)
+	ret0, _ := ret[0].([]database.ListHubSubscriptionsForPostRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListHubSubscriptionsForPost indicates an expected call of ListHubSubscriptionsForPost.
+func (mr *MockQuerierMockRecorder) ListHubSubscriptionsForPost(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListHubSubscriptionsForPost", reflect.TypeOf((*MockQuerier)(nil).ListHubSubscriptionsForPost), arg0, arg1)
+}
+
+// ListPostsWithStatus mocks base method.
+func (m *MockQuerier) ListPostsWithStatus(arg0 context.Context, arg1 database.ListPostsWithStatusParams) ([]database.ListPostsWithStatusRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPostsWithStatus", arg0, arg1)
+	ret0, _ := ret[0].([]database.ListPostsWithStatusRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPostsWithStatus indicates an expected call of ListPostsWithStatus.
+func (mr *MockQuerierMockRecorder) ListPostsWithStatus(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPostsWithStatus", reflect.TypeOf((*MockQuerier)(nil).ListPostsWithStatus), arg0, arg1)
+}
+
+// ListReadPosts mocks base method.
+func (m *MockQuerier) ListReadPosts(arg0 context.Context, arg1 uuid.UUID) ([]database.ListReadPostsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReadPosts", arg0, arg1)
+	ret0, _ := ret[0].([]database.ListReadPostsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReadPosts indicates an expected call of ListReadPosts.
+func (mr *MockQuerierMockRecorder) ListReadPosts(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReadPosts", reflect.TypeOf((*MockQuerier)(nil).ListReadPosts), arg0, arg1)
+}
+
+// ListStarredPostIDs mocks base method.
+func (m *MockQuerier) ListStarredPostIDs(arg0 context.Context, arg1 uuid.UUID) ([]int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStarredPostIDs", arg0, arg1)
+	ret0, _ := ret[0].([]int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStarredPostIDs indicates an expected call of ListStarredPostIDs.
+func (mr *MockQuerierMockRecorder) ListStarredPostIDs(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStarredPostIDs", reflect.TypeOf((*MockQuerier)(nil).ListStarredPostIDs), arg0, arg1)
+}
+
+// ListStarredPosts mocks base method.
+func (m *MockQuerier) ListStarredPosts(arg0 context.Context, arg1 database.ListStarredPostsParams) ([]database.StarredPost, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStarredPosts", arg0, arg1)
+	ret0, _ := ret[0].([]database.StarredPost)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStarredPosts indicates an expected call of ListStarredPosts.
+func (mr *MockQuerierMockRecorder) ListStarredPosts(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStarredPosts", reflect.TypeOf((*MockQuerier)(nil).ListStarredPosts), arg0, arg1)
+}
+
+// ListUnreadPostIDs mocks base method.
+func (m *MockQuerier) ListUnreadPostIDs(arg0 context.Context, arg1 uuid.UUID) ([]int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUnreadPostIDs", arg0, arg1)
+	ret0, _ := ret[0].([]int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUnreadPostIDs indicates an expected call of ListUnreadPostIDs.
+func (mr *MockQuerierMockRecorder) ListUnreadPostIDs(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUnreadPostIDs", reflect.TypeOf((*MockQuerier)(nil).ListUnreadPostIDs), arg0, arg1)
+}
+
+// ListUsers mocks base method.
+func (m *MockQuerier) ListUsers(arg0 context.Context, arg1 database.ListUsersParams) ([]database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", arg0, arg1)
+	ret0, _ := ret[0].([]database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockQuerierMockRecorder) ListUsers(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockQuerier)(nil).ListUsers), arg0, arg1)
+}
+
+// ListWebSubSubscriptionsToRenew mocks base method.
+func (m *MockQuerier) ListWebSubSubscriptionsToRenew(arg0 context.Context, arg1 database.ListWebSubSubscriptionsToRenewParams) ([]database.WebSubSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebSubSubscriptionsToRenew", arg0, arg1)
+	ret0, _ := ret[0].([]database.WebSubSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebSubSubscriptionsToRenew indicates an expected call of ListWebSubSubscriptionsToRenew.
+func (mr *MockQuerierMockRecorder) ListWebSubSubscriptionsToRenew(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebSubSubscriptionsToRenew", reflect.TypeOf((*MockQuerier)(nil).ListWebSubSubscriptionsToRenew), arg0, arg1)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockQuerier) ListWebhookDeliveries(arg0 context.Context, arg1 database.ListWebhookDeliveriesParams) ([]database.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", arg0, arg1)
+	ret0, _ := ret[0].([]database.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockQuerierMockRecorder) ListWebhookDeliveries(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockQuerier)(nil).ListWebhookDeliveries), arg0, arg1)
+}
+
+// ListWebhooks mocks base method.
+func (m *MockQuerier) ListWebhooks(arg0 context.Context, arg1 uuid.UUID) ([]database.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooks", arg0, arg1)
+	ret0, _ := ret[0].([]database.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooks indicates an expected call of ListWebhooks.
+func (mr *MockQuerierMockRecorder) ListWebhooks(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooks", reflect.TypeOf((*MockQuerier)(nil).ListWebhooks), arg0, arg1)
+}
+
+// ListWebhooksForPost mocks base method.
+func (m *MockQuerier) ListWebhooksForPost(arg0 context.Context, arg1 int32) ([]database.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooksForPost", arg0, arg1)
+	ret0, _ := ret[0].([]database.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooksForPost indicates an expected call of ListWebhooksForPost.
+func (mr *MockQuerierMockRecorder) ListWebhooksForPost(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooksForPost", reflect.TypeOf((*MockQuerier)(nil).ListWebhooksForPost), arg0, arg1)
+}
+
+// MarkDigestSent mocks base method.
+func (m *MockQuerier) MarkDigestSent(arg0 context.Context, arg1 database.MarkDigestSentParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDigestSent", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkDigestSent indicates an expected call of MarkDigestSent.
+func (mr *MockQuerierMockRecorder) MarkDigestSent(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDigestSent", reflect.TypeOf((*MockQuerier)(nil).MarkDigestSent), arg0, arg1)
+}
+
+// MarkFeedFetchFailed mocks base method.
+func (m *MockQuerier) MarkFeedFetchFailed(arg0 context.Context, arg1 database.MarkFeedFetchFailedParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkFeedFetchFailed", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkFeedFetchFailed indicates an expected call of MarkFeedFetchFailed.
+func (mr *MockQuerierMockRecorder) MarkFeedFetchFailed(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFeedFetchFailed", reflect.TypeOf((*MockQuerier)(nil).MarkFeedFetchFailed), arg0, arg1)
+}
+
+// MarkFeedFetched mocks base method.
+func (m *MockQuerier) MarkFeedFetched(arg0 context.Context, arg1 database.MarkFeedFetchedParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkFeedFetched", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkFeedFetched indicates an expected call of MarkFeedFetched.
+func (mr *MockQuerierMockRecorder) MarkFeedFetched(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFeedFetched", reflect.TypeOf((*MockQuerier)(nil).MarkFeedFetched), arg0, arg1)
+}
+
+// MarkPostsRead mocks base method.
+func (m *MockQuerier) MarkPostsRead(arg0 context.Context, arg1 database.MarkPostsReadParams) ([]int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkPostsRead", arg0, arg1)
+	ret0, _ := ret[0].([]int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkPostsRead indicates an expected call of MarkPostsRead.
+func (mr *MockQuerierMockRecorder) MarkPostsRead(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPostsRead", reflect.TypeOf((*MockQuerier)(nil).MarkPostsRead), arg0, arg1)
+}
+
+// MarkPostsReadBefore mocks base method.
+func (m *MockQuerier) MarkPostsReadBefore(arg0 context.Context, arg1 database.MarkPostsReadBeforeParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkPostsReadBefore", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkPostsReadBefore indicates an expected call of MarkPostsReadBefore.
+func (mr *MockQuerierMockRecorder) MarkPostsReadBefore(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPostsReadBefore", reflect.TypeOf((*MockQuerier)(nil).MarkPostsReadBefore), arg0, arg1)
+}
+
+// MarkPostsUnread mocks base method.
+func (m *MockQuerier) MarkPostsUnread(arg0 context.Context, arg1 database.MarkPostsUnreadParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkPostsUnread", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkPostsUnread indicates an expected call of MarkPostsUnread.
+func (mr *MockQuerierMockRecorder) MarkPostsUnread(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPostsUnread", reflect.TypeOf((*MockQuerier)(nil).MarkPostsUnread), arg0, arg1)
+}
+
+// OrphanFollowedFeeds mocks base method.
+func (m *MockQuerier) OrphanFollowedFeeds(arg0 context.Context, arg1 uuid.NullUUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OrphanFollowedFeeds", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OrphanFollowedFeeds indicates an expected call of OrphanFollowedFeeds.
+func (mr *MockQuerierMockRecorder) OrphanFollowedFeeds(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OrphanFollowedFeeds", reflect.TypeOf((*MockQuerier)(nil).OrphanFollowedFeeds), arg0, arg1)
+}
+
+// PurgePosts mocks base method.
+func (m *MockQuerier) PurgePosts(arg0 context.Context, arg1 database.PurgePostsParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgePosts", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgePosts indicates an expected call of PurgePosts.
+func (mr *MockQuerierMockRecorder) PurgePosts(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgePosts", reflect.TypeOf((*MockQuerier)(nil).PurgePosts), arg0, arg1)
+}
+
+// RegisterUser mocks base method.
+func (m *MockQuerier) RegisterUser(arg0 context.Context, arg1 database.RegisterUserParams) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterUser", arg0, arg1)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterUser indicates an expected call of RegisterUser.
+func (mr *MockQuerierMockRecorder) RegisterUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterUser", reflect.TypeOf((*MockQuerier)(nil).RegisterUser), arg0, arg1)
+}
+
+// RotateApiKey mocks base method.
+func (m *MockQuerier) RotateApiKey(arg0 context.Context, arg1 database.RotateApiKeyParams) (database.RotateApiKeyRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateApiKey", arg0, arg1)
+	ret0, _ := ret[0].(database.RotateApiKeyRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateApiKey indicates an expected call of RotateApiKey.
+func (mr *MockQuerierMockRecorder) RotateApiKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateApiKey", reflect.TypeOf((*MockQuerier)(nil).RotateApiKey), arg0, arg1)
+}
+
+// RotateFeedToken mocks base method.
+func (m *MockQuerier) RotateFeedToken(arg0 context.Context, arg1 uuid.UUID) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateFeedToken", arg0, arg1)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateFeedToken indicates an expected call of RotateFeedToken.
+func (mr *MockQuerierMockRecorder) RotateFeedToken(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateFeedToken", reflect.TypeOf((*MockQuerier)(nil).RotateFeedToken), arg0, arg1)
+}
+
+// SetFeedDisabled mocks base method.
+func (m *MockQuerier) SetFeedDisabled(arg0 context.Context, arg1 database.SetFeedDisabledParams) (database.Feed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFeedDisabled", arg0, arg1)
+	ret0, _ := ret[0].(database.Feed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetFeedDisabled indicates an expected call of SetFeedDisabled.
+func (mr *MockQuerierMockRecorder) SetFeedDisabled(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFeedDisabled", reflect.TypeOf((*MockQuerier)(nil).SetFeedDisabled), arg0, arg1)
+}
+
+// SetUserRole mocks base method.
+func (m *MockQuerier) SetUserRole(arg0 context.Context, arg1 database.SetUserRoleParams) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRole", arg0, arg1)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserRole indicates an expected call of SetUserRole.
+func (mr *MockQuerierMockRecorder) SetUserRole(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRole", reflect.TypeOf((*MockQuerier)(nil).SetUserRole), arg0, arg1)
+}
+
+// StarPost mocks base method.
+func (m *MockQuerier) StarPost(arg0 context.Context, arg1 database.StarPostParams) (database.StarredPost, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StarPost", arg0, arg1)
+	ret0, _ := ret[0].(database.StarredPost)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StarPost indicates an expected call of StarPost.
+func (mr *MockQuerierMockRecorder) StarPost(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StarPost", reflect.TypeOf((*MockQuerier)(nil).StarPost), arg0, arg1)
+}
+
+// SuspendUser mocks base method.
+func (m *MockQuerier) SuspendUser(arg0 context.Context, arg1 uuid.UUID) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuspendUser", arg0, arg1)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SuspendUser indicates an expected call of SuspendUser.
+func (mr *MockQuerierMockRecorder) SuspendUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuspendUser", reflect.TypeOf((*MockQuerier)(nil).SuspendUser), arg0, arg1)
+}
+
+// UnstarPost mocks base method.
+func (m *MockQuerier) UnstarPost(arg0 context.Context, arg1 database.UnstarPostParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnstarPost", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnstarPost indicates an expected call of UnstarPost.
+func (mr *MockQuerierMockRecorder) UnstarPost(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnstarPost", reflect.TypeOf((*MockQuerier)(nil).UnstarPost), arg0, arg1)
+}
+
+// UnstarPostByPostID mocks base method.
+func (m *MockQuerier) UnstarPostByPostID(arg0 context.Context, arg1 database.UnstarPostByPostIDParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnstarPostByPostID", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnstarPostByPostID indicates an expected call of UnstarPostByPostID.
+func (mr *MockQuerierMockRecorder) UnstarPostByPostID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnstarPostByPostID", reflect.TypeOf((*MockQuerier)(nil).UnstarPostByPostID), arg0, arg1)
+}
+
+// UnsuspendUser mocks base method.
+func (m *MockQuerier) UnsuspendUser(arg0 context.Context, arg1 uuid.UUID) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnsuspendUser", arg0, arg1)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnsuspendUser indicates an expected call of UnsuspendUser.
+func (mr *MockQuerierMockRecorder) UnsuspendUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnsuspendUser", reflect.TypeOf((*MockQuerier)(nil).UnsuspendUser), arg0, arg1)
+}
+
+// UpdateFeed mocks base method.
+func (m *MockQuerier) UpdateFeed(arg0 context.Context, arg1 database.UpdateFeedParams) (database.Feed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFeed", arg0, arg1)
+	ret0, _ := ret[0].(database.Feed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateFeed indicates an expected call of UpdateFeed.
+func (mr *MockQuerierMockRecorder) UpdateFeed(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFeed", reflect.TypeOf((*MockQuerier)(nil).UpdateFeed), arg0, arg1)
+}
+
+// UpdateFeedFollows mocks base method.
+func (m *MockQuerier) UpdateFeedFollows(arg0 context.Context, arg1 database.UpdateFeedFollowsParams) (database.FeedFollow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFeedFollows", arg0, arg1)
+	ret0, _ := ret[0].(database.FeedFollow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateFeedFollows indicates an expected call of UpdateFeedFollows.
+func (mr *MockQuerierMockRecorder) UpdateFeedFollows(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFeedFollows", reflect.TypeOf((*MockQuerier)(nil).UpdateFeedFollows), arg0, arg1)
+}
+
+// UpdateFilterRule mocks base method.
+func (m *MockQuerier) UpdateFilterRule(arg0 context.Context, arg1 database.UpdateFilterRuleParams) (database.FilterRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFilterRule", arg0, arg1)
+	ret0, _ := ret[0].(database.FilterRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateFilterRule indicates an expected call of UpdateFilterRule.
+func (mr *MockQuerierMockRecorder) UpdateFilterRule(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFilterRule", reflect.TypeOf((*MockQuerier)(nil).UpdateFilterRule), arg0, arg1)
+}
+
+// UpdateFolder mocks base method.
+func (m *MockQuerier) UpdateFolder(arg0 context.Context, arg1 database.UpdateFolderParams) (database.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFolder", arg0, arg1)
+	ret0, _ := ret[0].(database.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateFolder indicates an expected call of UpdateFolder.
+func (mr *MockQuerierMockRecorder) UpdateFolder(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFolder", reflect.TypeOf((*MockQuerier)(nil).UpdateFolder), arg0, arg1)
+}
+
+// UpdateUser mocks base method.
+func (m *MockQuerier) UpdateUser(arg0 context.Context, arg1 database.UpdateUserParams) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", arg0, arg1)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUser indicates an expected call of UpdateUser.
+func (mr *MockQuerierMockRecorder) UpdateUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockQuerier)(nil).UpdateUser), arg0, arg1)
+}
+
+// UpdateWebhook mocks base method.
+func (m *MockQuerier) UpdateWebhook(arg0 context.Context, arg1 database.UpdateWebhookParams) (database.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhook", arg0, arg1)
+	ret0, _ := ret[0].(database.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebhook indicates an expected call of UpdateWebhook.
+func (mr *MockQuerierMockRecorder) UpdateWebhook(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhook", reflect.TypeOf((*MockQuerier)(nil).UpdateWebhook), arg0, arg1)
+}
+
+// UpdateWebhookDelivery mocks base method.
+func (m *MockQuerier) UpdateWebhookDelivery(arg0 context.Context, arg1 database.UpdateWebhookDeliveryParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhookDelivery indicates an expected call of UpdateWebhookDelivery.
+func (mr *MockQuerierMockRecorder) UpdateWebhookDelivery(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookDelivery", reflect.TypeOf((*MockQuerier)(nil).UpdateWebhookDelivery), arg0, arg1)
+}
+
+// UpsertDigestSettings mocks base method.
+func (m *MockQuerier) UpsertDigestSettings(arg0 context.Context, arg1 database.UpsertDigestSettingsParams) (database.DigestSetting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertDigestSettings", arg0, arg1)
+	ret0, _ := ret[0].(database.DigestSetting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertDigestSettings indicates an expected call of UpsertDigestSettings.
+func (mr *MockQuerierMockRecorder) UpsertDigestSettings(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertDigestSettings", reflect.TypeOf((*MockQuerier)(nil).UpsertDigestSettings), arg0, arg1)
+}
+
+// UpsertHubSubscription mocks base method.
+func (m *MockQuerier) UpsertHubSubscription(arg0 context.Context, arg1 database.UpsertHubSubscriptionParams) (database.HubSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertHubSubscription", arg0, arg1)
+	ret0, _ := ret[0].(database.HubSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertHubSubscription indicates an expected call of UpsertHubSubscription.
+func (mr *MockQuerierMockRecorder) UpsertHubSubscription(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertHubSubscription", reflect.TypeOf((*MockQuerier)(nil).UpsertHubSubscription), arg0, arg1)
+}
+
+// UpsertWebSubSubscription mocks base method.
+func (m *MockQuerier) UpsertWebSubSubscription(arg0 context.Context, arg1 database.UpsertWebSubSubscriptionParams) (database.WebSubSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertWebSubSubscription", arg0, arg1)
+	ret0, _ := ret[0].(database.WebSubSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertWebSubSubscription indicates an expected call of UpsertWebSubSubscription.
+func (mr *MockQuerierMockRecorder) UpsertWebSubSubscription(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertWebSubSubscription", reflect.TypeOf((*MockQuerier)(nil).UpsertWebSubSubscription), arg0, arg1)
+}
+
+// UseRefreshToken mocks base method.
+func (m *MockQuerier) UseRefreshToken(arg0 context.Context, arg1 string) (database.UseRefreshTokenRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(database.UseRefreshTokenRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRefreshToken indicates an expected call of UseRefreshToken.
+func (mr *MockQuerierMockRecorder) UseRefreshToken(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRefreshToken", reflect.TypeOf((*MockQuerier)(nil).UseRefreshToken), arg0, arg1)
 }
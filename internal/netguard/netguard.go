@@ -0,0 +1,107 @@
+// Package netguard keeps the requests sent to the urls given by the users, like the webhooks
+// and the WebSub callbacks, away from the internal network of the server.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned for an address which is not a public unicast address.
+var ErrForbiddenAddress = errors.New("forbidden address")
+
+// reserved are the non-public ranges not reported by the netip.Addr methods.
+var reserved = []netip.Prefix{
+	// The "this network" addresses.
+	netip.MustParsePrefix("0.0.0.0/8"),
+	// The shared address space of the carrier-grade NATs.
+	netip.MustParsePrefix("100.64.0.0/10"),
+	// The IETF protocol assignments.
+	netip.MustParsePrefix("192.0.0.0/24"),
+	// The benchmarking networks.
+	netip.MustParsePrefix("198.18.0.0/15"),
+	// The reserved addresses and the broadcast address.
+	netip.MustParsePrefix("240.0.0.0/4"),
+	// The NAT64 addresses, they embed an IPv4 address.
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// IsPublic reports whether the address is a public unicast address.
+// The loopback, private, link-local, multicast and reserved addresses are not.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() ||
+		addr.IsUnspecified() ||
+		addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range reserved {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CheckURL resolves the host of the url and returns an error wrapping ErrForbiddenAddress
+// when one of its addresses is not public.
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %q", rawURL)
+	}
+
+	host := u.Hostname()
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("resolve host %q: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !IsPublic(addr) {
+			return fmt.Errorf("%w: %q resolves to %s", ErrForbiddenAddress, host, addr)
+		}
+	}
+
+	return nil
+}
+
+// Control refuses the connections to the addresses which are not public, it is the Control of a net.Dialer.
+// It is called with the resolved address right before connecting, so a host resolving to an internal address
+// after CheckURL, for example by DNS rebinding, is refused too.
+func Control(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	if !IsPublic(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+
+	return nil
+}
+
+// NewClient returns an HTTP client refusing to connect to the addresses which are not public,
+// including after a redirect. It does not use the proxies, they would connect in its stead.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   Control,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
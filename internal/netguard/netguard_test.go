@@ -0,0 +1,65 @@
+package netguard
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{addr: "93.184.215.14", public: true},
+		{addr: "2606:2800:21f:cb07:6820:80da:af6b:8b2c", public: true},
+		{addr: "127.0.0.1", public: false},
+		{addr: "::1", public: false},
+		{addr: "0.0.0.0", public: false},
+		{addr: "::", public: false},
+		{addr: "10.1.2.3", public: false},
+		{addr: "172.16.0.1", public: false},
+		{addr: "192.168.1.1", public: false},
+		{addr: "fd00::1", public: false},
+		{addr: "169.254.169.254", public: false},
+		{addr: "fe80::1", public: false},
+		{addr: "100.64.0.1", public: false},
+		{addr: "224.0.0.1", public: false},
+		{addr: "255.255.255.255", public: false},
+		{addr: "::ffff:127.0.0.1", public: false},
+		{addr: "64:ff9b::a00:1", public: false},
+	}
+	for _, test := range tests {
+		tc := test
+		t.Run(tc.addr, func(t *testing.T) {
+			assert.Equal(t, tc.public, IsPublic(netip.MustParseAddr(tc.addr)))
+		})
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, CheckURL(ctx, "https://93.184.215.14/hook"))
+	assert.ErrorIs(t, CheckURL(ctx, "http://127.0.0.1:8080/hook"), ErrForbiddenAddress)
+	assert.ErrorIs(t, CheckURL(ctx, "http://[::1]/hook"), ErrForbiddenAddress)
+	assert.ErrorIs(t, CheckURL(ctx, "http://169.254.169.254/latest/meta-data"), ErrForbiddenAddress)
+	assert.ErrorIs(t, CheckURL(ctx, "http://localhost/hook"), ErrForbiddenAddress)
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// The server listens on the loopback address.
+	_, err := NewClient(time.Second).Get(server.URL)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrForbiddenAddress)
+}
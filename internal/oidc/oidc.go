@@ -0,0 +1,268 @@
+// Package oidc signs in the users with an OpenID Connect provider,
+// with the authorization code flow and PKCE.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jbdoumenjou/go-rssaggregator/internal/jwt"
+)
+
+const (
+	// clockSkew is the leeway of the validity period of the ID tokens.
+	clockSkew = time.Minute
+	// minKeysRefresh is the shortest time between two fetches of the keys of the provider,
+	// when an ID token is signed by an unknown key.
+	minKeysRefresh = time.Minute
+	// maxResponseSize is the maximum size of the responses of the provider.
+	maxResponseSize = 1 << 20
+)
+
+// Config is the configuration of the client registered at the provider.
+type Config struct {
+	// Issuer is the URL of the provider, its configuration is discovered from it.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback receiving the authorization code.
+	RedirectURL string
+	// Scopes are requested in addition to openid.
+	Scopes []string
+}
+
+// Claims are the claims of an ID token identifying the user.
+type Claims struct {
+	jwt.Claims
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// metadata is the configuration of the provider, published at /.well-known/openid-configuration.
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is an OpenID Connect provider.
+type Provider struct {
+	config   Config
+	client   *http.Client
+	metadata metadata
+
+	mu        sync.Mutex
+	keys      jwt.JWKS
+	fetchedAt time.Time
+}
+
+// Discover returns the provider of the issuer of the configuration, from its published configuration.
+func Discover(ctx context.Context, client *http.Client, config Config) (*Provider, error) {
+	wellKnown := strings.TrimSuffix(config.Issuer, "/") + "/.well-known/openid-configuration"
+
+	var m metadata
+	if err := getJSON(ctx, client, wellKnown, &m); err != nil {
+		return nil, fmt.Errorf("error discovering provider: %w", err)
+	}
+	// The issuer must be the one configured, it is checked in the ID tokens.
+	if m.Issuer != config.Issuer {
+		return nil, fmt.Errorf("issuer mismatch: %q, expected %q", m.Issuer, config.Issuer)
+	}
+	if m.AuthorizationEndpoint == "" || m.TokenEndpoint == "" || m.JWKSURI == "" {
+		return nil, errors.New("incomplete provider configuration")
+	}
+
+	return &Provider{
+		config:   config,
+		client:   client,
+		metadata: m,
+	}, nil
+}
+
+// Issuer returns the issuer of the provider, the subjects of the users are unique per issuer.
+func (p *Provider) Issuer() string {
+	return p.config.Issuer
+}
+
+// AuthCodeURL returns the URL of the provider the user is redirected to, to sign in.
+// The state and the nonce bind the callback and the ID token to the login, the verifier is the PKCE secret.
+func (p *Provider) AuthCodeURL(state, nonce, verifier string) string {
+	challenge := sha256.Sum256([]byte(verifier))
+
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", p.config.ClientID)
+	v.Set("redirect_uri", p.config.RedirectURL)
+	v.Set("scope", strings.Join(append([]string{"openid"}, p.config.Scopes...), " "))
+	v.Set("state", state)
+	v.Set("nonce", nonce)
+	v.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	v.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(p.metadata.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return p.metadata.AuthorizationEndpoint + sep + v.Encode()
+}
+
+// Login exchanges the authorization code of the callback for an ID token,
+// and returns its claims once verified.
+func (p *Provider) Login(ctx context.Context, code, verifier, nonce string) (Claims, error) {
+	rawIDToken, err := p.exchange(ctx, code, verifier)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	return p.verify(ctx, rawIDToken, nonce)
+}
+
+// exchange exchanges the authorization code for the ID token at the token endpoint.
+func (p *Provider) exchange(ctx context.Context, code, verifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("error creating token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error requesting token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&body); err != nil {
+		return "", fmt.Errorf("error decoding token response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request failed with status %d: %s %s", resp.StatusCode, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", errors.New("missing id_token in token response")
+	}
+
+	return body.IDToken, nil
+}
+
+// verify verifies the signature and the claims of the ID token.
+func (p *Provider) verify(ctx context.Context, rawIDToken, nonce string) (Claims, error) {
+	token, err := jwt.Parse(rawIDToken)
+	if err != nil {
+		return Claims{}, fmt.Errorf("error parsing id token: %w", err)
+	}
+	// The ID tokens are signed with the keys of the provider, never with the client secret.
+	if token.Header.Alg == jwt.HS256 {
+		return Claims{}, fmt.Errorf("unsupported id token algorithm: %q", token.Header.Alg)
+	}
+
+	key, err := p.key(ctx, token.Header.Kid)
+	if err != nil {
+		return Claims{}, err
+	}
+	if err := token.Verify(key); err != nil {
+		return Claims{}, fmt.Errorf("error verifying id token: %w", err)
+	}
+
+	var claims Claims
+	if err := token.Claims(&claims); err != nil {
+		return Claims{}, err
+	}
+	if err := claims.Validate(p.config.Issuer, p.config.ClientID, time.Now(), clockSkew); err != nil {
+		return Claims{}, fmt.Errorf("invalid id token: %w", err)
+	}
+	if claims.Nonce != nonce {
+		return Claims{}, errors.New("invalid id token: nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return Claims{}, errors.New("invalid id token: missing subject")
+	}
+
+	return claims, nil
+}
+
+// key returns the public key of the provider with the given id.
+// The keys are fetched again when the key is unknown, the provider may have rotated them.
+func (p *Provider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	jwk, ok := p.keys.Key(kid)
+	if !ok && time.Since(p.fetchedAt) >= minKeysRefresh {
+		var keys jwt.JWKS
+		if err := getJSON(ctx, p.client, p.metadata.JWKSURI, &keys); err != nil {
+			return nil, fmt.Errorf("error fetching provider keys: %w", err)
+		}
+		p.keys = keys
+		p.fetchedAt = time.Now()
+		jwk, ok = p.keys.Key(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown id token key: %q", kid)
+	}
+
+	return jwk.PublicKey()
+}
+
+// getJSON decodes the JSON document of the URL into v.
+func getJSON(ctx context.Context, client *http.Client, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error requesting %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, u)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v); err != nil {
+		return fmt.Errorf("error decoding %s: %w", u, err)
+	}
+
+	return nil
+}
+
+// RandomString returns a random URL-safe string, for the states, the nonces and the PKCE verifiers.
+func RandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating random string: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
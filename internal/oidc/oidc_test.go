@@ -0,0 +1,90 @@
+package oidc_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jbdoumenjou/go-rssaggregator/internal/oidc"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// authorize signs in at the provider and returns the query of the callback.
+func authorize(t *testing.T, provider *oidc.Provider, state, nonce, verifier string) url.Values {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(provider.AuthCodeURL(state, nonce, verifier))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := resp.Location()
+	require.NoError(t, err)
+	assert.Equal(t, "/callback", location.Path)
+
+	return location.Query()
+}
+
+func TestProvider_Login(t *testing.T) {
+	mock := oidctest.NewProvider("client", "secret")
+	defer mock.Close()
+	mock.SetIdentity(oidctest.Identity{Subject: "42", Email: "jane@example.com", EmailVerified: true, Name: "Jane Doe"})
+
+	ctx := context.Background()
+	provider, err := oidc.Discover(ctx, http.DefaultClient, mock.Config("https://rss.example.com/callback"))
+	require.NoError(t, err)
+	assert.Equal(t, mock.URL, provider.Issuer())
+
+	verifier, err := oidc.RandomString()
+	require.NoError(t, err)
+
+	callback := authorize(t, provider, "state", "nonce", verifier)
+	assert.Equal(t, "state", callback.Get("state"))
+
+	claims, err := provider.Login(ctx, callback.Get("code"), verifier, "nonce")
+	require.NoError(t, err)
+	assert.Equal(t, "42", claims.Subject)
+	assert.Equal(t, "jane@example.com", claims.Email)
+	assert.True(t, claims.EmailVerified)
+	assert.Equal(t, "Jane Doe", claims.Name)
+
+	// The code is only exchanged once.
+	_, err = provider.Login(ctx, callback.Get("code"), verifier, "nonce")
+	assert.Error(t, err)
+
+	// The code is bound to the PKCE verifier.
+	callback = authorize(t, provider, "state", "nonce", verifier)
+	_, err = provider.Login(ctx, callback.Get("code"), "other verifier", "nonce")
+	assert.Error(t, err)
+
+	// The ID token is bound to the nonce.
+	callback = authorize(t, provider, "state", "nonce", verifier)
+	_, err = provider.Login(ctx, callback.Get("code"), verifier, "other nonce")
+	assert.Error(t, err)
+}
+
+func TestDiscover(t *testing.T) {
+	mock := oidctest.NewProvider("client", "secret")
+	defer mock.Close()
+
+	// The issuer must be the one published by the provider.
+	config := mock.Config("https://rss.example.com/callback")
+	config.Issuer = mock.URL + "/"
+	_, err := oidc.Discover(context.Background(), http.DefaultClient, config)
+	assert.Error(t, err)
+
+	// The client is authenticated at the token endpoint.
+	config = mock.Config("https://rss.example.com/callback")
+	config.ClientSecret = "wrong"
+	provider, err := oidc.Discover(context.Background(), http.DefaultClient, config)
+	require.NoError(t, err)
+	callback := authorize(t, provider, "state", "nonce", "verifier")
+	_, err = provider.Login(context.Background(), callback.Get("code"), "verifier", "nonce")
+	assert.ErrorContains(t, err, "invalid_client")
+}
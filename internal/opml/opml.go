@@ -0,0 +1,177 @@
+// Package opml reads and writes OPML 2.0 subscription lists.
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Document represents an OPML document.
+type Document struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+// Head represents the head of an OPML document.
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+// Body represents the body of an OPML document.
+type Body struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+// Outline represents an outline element.
+// An outline with a xmlUrl is a subscription, the others group their children.
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Name returns the title of the outline, or its text when the title is missing.
+func (o Outline) Name() string {
+	if title := strings.TrimSpace(o.Title); title != "" {
+		return title
+	}
+
+	return strings.TrimSpace(o.Text)
+}
+
+// Subscription is a feed subscription of an OPML document.
+type Subscription struct {
+	Title   string
+	XMLURL  string
+	HTMLURL string
+	// Folder is the path of the parent outlines, joined by FolderSeparator.
+	// It is empty for the top-level subscriptions.
+	Folder string
+}
+
+// FolderSeparator separates the names of the nested outlines in the folder of a subscription.
+const FolderSeparator = " / "
+
+// Parse reads an OPML document.
+func Parse(r io.Reader) (Document, error) {
+	var doc Document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return Document{}, fmt.Errorf("error decoding opml: %w", err)
+	}
+
+	return doc, nil
+}
+
+// Subscriptions returns the subscriptions of the document in order, with the folder they belong to.
+func (d Document) Subscriptions() []Subscription {
+	var subs []Subscription
+	for _, outline := range d.Body.Outlines {
+		subs = appendSubscriptions(subs, nil, outline)
+	}
+
+	return subs
+}
+
+func appendSubscriptions(subs []Subscription, path []string, outline Outline) []Subscription {
+	if outline.XMLURL != "" {
+		subs = append(subs, Subscription{
+			Title:   outline.Name(),
+			XMLURL:  strings.TrimSpace(outline.XMLURL),
+			HTMLURL: strings.TrimSpace(outline.HTMLURL),
+			Folder:  strings.Join(path, FolderSeparator),
+		})
+	}
+
+	if len(outline.Outlines) == 0 {
+		return subs
+	}
+
+	// A subscription with children is unusual, its children stay in the current folder.
+	if outline.XMLURL == "" {
+		if name := outline.Name(); name != "" {
+			path = append(path[:len(path):len(path)], name)
+		}
+	}
+	for _, child := range outline.Outlines {
+		subs = appendSubscriptions(subs, path, child)
+	}
+
+	return subs
+}
+
+// NewDocument builds an OPML 2.0 document from the subscriptions.
+// The folders become nested outlines, in the given order, even when they are empty.
+// The folders of the subscriptions missing from the list are added after them.
+func NewDocument(title string, folders []string, subs []Subscription) Document {
+	doc := Document{
+		Version: "2.0",
+		Head: Head{
+			Title:       title,
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		},
+		Body: Body{Outlines: []Outline{}},
+	}
+
+	for _, folder := range folders {
+		folderOutlines(&doc.Body.Outlines, folder)
+	}
+	for _, sub := range subs {
+		outlines := folderOutlines(&doc.Body.Outlines, sub.Folder)
+		*outlines = append(*outlines, Outline{
+			Text:    sub.Title,
+			Title:   sub.Title,
+			Type:    "rss",
+			XMLURL:  sub.XMLURL,
+			HTMLURL: sub.HTMLURL,
+		})
+	}
+
+	return doc
+}
+
+// folderOutlines returns the children of the outline of the folder, the missing outlines of its path are created.
+// The returned pointer is only valid until the outlines are modified.
+func folderOutlines(root *[]Outline, folder string) *[]Outline {
+	outlines := root
+	if folder == "" {
+		return outlines
+	}
+
+	for _, name := range strings.Split(folder, FolderSeparator) {
+		i := slices.IndexFunc(*outlines, func(o Outline) bool {
+			return o.XMLURL == "" && o.Text == name
+		})
+		if i < 0 {
+			*outlines = append(*outlines, Outline{Text: name, Title: name})
+			i = len(*outlines) - 1
+		}
+		outlines = &(*outlines)[i].Outlines
+	}
+
+	return outlines
+}
+
+// Write writes the document as indented XML.
+func Write(w io.Writer, doc Document) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("error writing opml: %w", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("error encoding opml: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package opml
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	f, err := os.Open("testdata/subscriptions.opml")
+	require.NoError(t, err)
+	defer f.Close()
+
+	doc, err := Parse(f)
+	require.NoError(t, err)
+	assert.Equal(t, "2.0", doc.Version)
+	assert.Equal(t, "Subscriptions", doc.Head.Title)
+
+	expected := []Subscription{
+		{Title: "The Go Blog", XMLURL: "https://go.dev/blog/feed.atom", HTMLURL: "https://go.dev/blog"},
+		{Title: "Hacker News", XMLURL: "https://news.ycombinator.com/rss", Folder: "Tech"},
+		{Title: "PostgreSQL", XMLURL: "https://www.postgresql.org/news.rss", Folder: "Tech / Databases"},
+	}
+	assert.Equal(t, expected, doc.Subscriptions())
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := Parse(strings.NewReader("not an opml document"))
+	require.Error(t, err)
+}
+
+func TestWrite(t *testing.T) {
+	subs := []Subscription{
+		{Title: "The Go Blog", XMLURL: "https://go.dev/blog/feed.atom", HTMLURL: "https://go.dev/blog"},
+		{Title: "Hacker News", XMLURL: "https://news.ycombinator.com/rss", Folder: "Tech"},
+		{Title: "PostgreSQL", XMLURL: "https://www.postgresql.org/news.rss", Folder: "Tech / Databases"},
+	}
+	doc := NewDocument("Subscriptions", []string{"Empty", "Tech"}, subs)
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, doc))
+	assert.True(t, strings.HasPrefix(buf.String(), "<?xml"))
+	assert.Contains(t, buf.String(), `<outline text="Empty" title="Empty"></outline>`)
+	assert.Contains(t, buf.String(), `htmlUrl="https://go.dev/blog"`)
+
+	// The written document is read back with the same subscriptions.
+	actual, err := Parse(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, "2.0", actual.Version)
+	assert.Equal(t, "Subscriptions", actual.Head.Title)
+	// The folders are listed before the subscriptions without folder.
+	assert.ElementsMatch(t, subs, actual.Subscriptions())
+	require.Len(t, actual.Body.Outlines, 3)
+	assert.Equal(t, "Empty", actual.Body.Outlines[0].Text)
+	assert.Equal(t, "Tech", actual.Body.Outlines[1].Text)
+}
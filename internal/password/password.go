@@ -0,0 +1,75 @@
+// Package password hashes the passwords of the users with argon2id.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Parameters of the hashes, the recommended ones of the argon2 package for argon2id.
+const (
+	memory     = 64 * 1024
+	iterations = 1
+	threads    = 4
+	keyLen     = 32
+	saltLen    = 16
+)
+
+// ErrInvalidHash is returned when a hash is not in the encoded form of Hash.
+var ErrInvalidHash = errors.New("invalid password hash")
+
+// Hash returns the argon2id hash of the password with a random salt.
+// The hash is encoded with its parameters: $argon2id$v=19$m=65536,t=1,p=4$<salt>$<key>
+func Hash(password string) (string, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("error generating salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, iterations, memory, threads, keyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, memory, iterations, threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify reports whether the password matches the hash.
+// The parameters of the hash are used, so the hashes stay valid when the parameters change.
+func Verify(password, hash string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return false, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, ErrInvalidHash
+	}
+
+	var m, t uint32
+	var p uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &m, &t, &p); err != nil || m == 0 || t == 0 || p == 0 {
+		return false, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return false, ErrInvalidHash
+	}
+
+	other := argon2.IDKey([]byte(password), salt, t, m, p, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
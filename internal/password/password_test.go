@@ -0,0 +1,51 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHash(t *testing.T) {
+	hash, err := Hash("correct horse battery staple")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=1,p=4$"))
+
+	// The salt is random.
+	other, err := Hash("correct horse battery staple")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+}
+
+func TestVerify(t *testing.T) {
+	hash, err := Hash("correct horse battery staple")
+	require.NoError(t, err)
+
+	ok, err := Verify("correct horse battery staple", hash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = Verify("Tr0ub4dor&3", hash)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// The parameters of the hash are used.
+	ok, err = Verify("password", "$argon2id$v=19$m=16,t=2,p=1$c29tZXNhbHQ$p0Dm1q+iLZmXi9eJ0Nx6qg")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	for _, invalid := range []string{
+		"",
+		"password",
+		"$argon2i$v=19$m=65536,t=1,p=4$c29tZXNhbHQ$a2V5",
+		"$argon2id$v=16$m=65536,t=1,p=4$c29tZXNhbHQ$a2V5",
+		"$argon2id$v=19$m=0,t=1,p=4$c29tZXNhbHQ$a2V5",
+		"$argon2id$v=19$m=65536,t=1,p=4$!!!$a2V5",
+		"$argon2id$v=19$m=65536,t=1,p=4$c29tZXNhbHQ$",
+	} {
+		_, err := Verify("password", invalid)
+		assert.ErrorIs(t, err, ErrInvalidHash, invalid)
+	}
+}
@@ -0,0 +1,75 @@
+// Package pubsub broadcasts the posts inserted by the feed fetcher to the API.
+package pubsub
+
+import (
+	"sync"
+
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+)
+
+// Broker broadcasts the published posts to its subscriptions.
+type Broker struct {
+	mu     sync.RWMutex
+	subs   map[*Subscription]struct{}
+	buffer int
+}
+
+// NewBroker creates a new broker.
+// Each subscription buffers up to the given number of posts,
+// the posts published to a full subscription are dropped for this subscription.
+func NewBroker(buffer int) *Broker {
+	return &Broker{
+		subs:   make(map[*Subscription]struct{}),
+		buffer: buffer,
+	}
+}
+
+// Subscription receives the posts published after its creation.
+type Subscription struct {
+	// C receives the published posts, it is closed when the subscription is closed.
+	C <-chan database.Post
+
+	c      chan database.Post
+	broker *Broker
+	once   sync.Once
+}
+
+// Subscribe creates a new subscription, it must be closed once done.
+func (b *Broker) Subscribe() *Subscription {
+	c := make(chan database.Post, b.buffer)
+	sub := &Subscription{
+		C:      c,
+		c:      c,
+		broker: b,
+	}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub
+}
+
+// Publish sends the post to all the subscriptions without blocking.
+func (b *Broker) Publish(post database.Post) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs {
+		select {
+		case sub.c <- post:
+		default:
+			// The subscriber is too slow, it misses the post.
+		}
+	}
+}
+
+// Close removes the subscription from the broker and closes its channel.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.broker.mu.Lock()
+		delete(s.broker.subs, s)
+		s.broker.mu.Unlock()
+		close(s.c)
+	})
+}
@@ -0,0 +1,41 @@
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker_Publish(t *testing.T) {
+	broker := NewBroker(1)
+
+	sub1 := broker.Subscribe()
+	defer sub1.Close()
+	sub2 := broker.Subscribe()
+	defer sub2.Close()
+
+	broker.Publish(database.Post{ID: 1})
+	assert.Equal(t, int32(1), (<-sub1.C).ID)
+
+	// The second post is dropped for the subscription whose buffer is full.
+	broker.Publish(database.Post{ID: 2})
+	assert.Equal(t, int32(2), (<-sub1.C).ID)
+	assert.Equal(t, int32(1), (<-sub2.C).ID)
+	assert.Empty(t, sub2.C)
+}
+
+func TestSubscription_Close(t *testing.T) {
+	broker := NewBroker(1)
+
+	sub := broker.Subscribe()
+	sub.Close()
+	sub.Close()
+
+	_, ok := <-sub.C
+	require.False(t, ok)
+
+	// Publishing after a subscription is closed does not panic.
+	broker.Publish(database.Post{ID: 1})
+}
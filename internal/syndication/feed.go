@@ -0,0 +1,333 @@
+// Package syndication renders feed documents: RSS 2.0, Atom 1.0 and JSON Feed 1.1.
+package syndication
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Content types of the feed documents.
+const (
+	ContentTypeRSS      = "application/rss+xml; charset=utf-8"
+	ContentTypeAtom     = "application/atom+xml; charset=utf-8"
+	ContentTypeJSONFeed = "application/feed+json; charset=utf-8"
+)
+
+// Feed is the format-agnostic representation of a feed document.
+type Feed struct {
+	Title       string
+	Description string
+	// Link is the web page of the feed.
+	Link string
+	// FeedURL is the URL of the feed document itself.
+	FeedURL string
+	// Hub is the url of the WebSub hub pushing the updates of the feed, if any.
+	Hub     string
+	Author  string
+	Updated time.Time
+	Items   []Item
+}
+
+// Item is an entry of a feed document.
+type Item struct {
+	// ID uniquely and permanently identifies the item.
+	ID          string
+	Title       string
+	Link        string
+	Description string
+	Author      string
+	Categories  []string
+	Published   time.Time
+	Updated     time.Time
+}
+
+// Render writes the feed document in the given format: "rss", "atom" or "json".
+// It returns the content type of the document.
+func Render(w io.Writer, format string, feed Feed) (string, error) {
+	switch format {
+	case "rss":
+		return ContentTypeRSS, WriteRSS(w, feed)
+	case "atom":
+		return ContentTypeAtom, WriteAtom(w, feed)
+	case "json":
+		return ContentTypeJSONFeed, WriteJSONFeed(w, feed)
+	default:
+		return "", fmt.Errorf("unknown feed format: %q", format)
+	}
+}
+
+// IsFormat reports whether the format can be rendered.
+func IsFormat(format string) bool {
+	return format == "rss" || format == "atom" || format == "json"
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	AtomLinks     []rssLink `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	Categories  []string `xml:"category"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// WriteRSS writes the feed as a RSS 2.0 document.
+func WriteRSS(w io.Writer, feed Feed) error {
+	doc := rss{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:       feed.Title,
+			Link:        feed.Link,
+			Description: feed.Description,
+			AtomLinks:   []rssLink{{Href: feed.FeedURL, Rel: "self", Type: "application/rss+xml"}},
+		},
+	}
+	if feed.Hub != "" {
+		doc.Channel.AtomLinks = append(doc.Channel.AtomLinks, rssLink{Href: feed.Hub, Rel: "hub"})
+	}
+	if !feed.Updated.IsZero() {
+		doc.Channel.LastBuildDate = feed.Updated.Format(time.RFC1123Z)
+	}
+
+	for _, item := range feed.Items {
+		rssItem := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Description,
+			GUID:        rssGUID{IsPermaLink: item.ID == item.Link, Value: item.ID},
+			Creator:     item.Author,
+			Categories:  item.Categories,
+		}
+		if !item.Published.IsZero() {
+			rssItem.PubDate = item.Published.Format(time.RFC1123Z)
+		}
+		doc.Channel.Items = append(doc.Channel.Items, rssItem)
+	}
+
+	return writeXML(w, doc)
+}
+
+type atomFeed struct {
+	XMLName  xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string       `xml:"id"`
+	Title    string       `xml:"title"`
+	Subtitle *atomContent `xml:"subtitle,omitempty"`
+	Updated  string       `xml:"updated"`
+	Links    []atomLink   `xml:"link"`
+	Author   *atomPerson  `xml:"author,omitempty"`
+	Entries  []atomEntry  `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Links      []atomLink     `xml:"link"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Summary    atomContent    `xml:"summary"`
+}
+
+// WriteAtom writes the feed as an Atom 1.0 document.
+func WriteAtom(w io.Writer, feed Feed) error {
+	doc := atomFeed{
+		ID:      feed.FeedURL,
+		Title:   feed.Title,
+		Updated: atomTime(feed.Updated),
+		Links: []atomLink{
+			{Href: feed.FeedURL, Rel: "self", Type: "application/atom+xml"},
+		},
+	}
+	if feed.Link != "" && feed.Link != feed.FeedURL {
+		doc.Links = append(doc.Links, atomLink{Href: feed.Link, Rel: "alternate"})
+	}
+	if feed.Hub != "" {
+		doc.Links = append(doc.Links, atomLink{Href: feed.Hub, Rel: "hub"})
+	}
+	if feed.Author != "" {
+		doc.Author = &atomPerson{Name: feed.Author}
+	}
+	if feed.Description != "" {
+		doc.Subtitle = &atomContent{Type: "text", Value: feed.Description}
+	}
+
+	for _, item := range feed.Items {
+		updated := item.Updated
+		if updated.IsZero() {
+			updated = item.Published
+		}
+		entry := atomEntry{
+			ID:      item.ID,
+			Title:   item.Title,
+			Links:   []atomLink{{Href: item.Link, Rel: "alternate"}},
+			Updated: atomTime(updated),
+			Summary: atomContent{Type: "html", Value: item.Description},
+		}
+		if !item.Published.IsZero() {
+			entry.Published = atomTime(item.Published)
+		}
+		if item.Author != "" {
+			entry.Author = &atomPerson{Name: item.Author}
+		}
+		for _, category := range item.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return writeXML(w, doc)
+}
+
+// atomTime formats a time as required by Atom, the zero time is replaced by the current time.
+func atomTime(t time.Time) string {
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Authors     []jsonAuthor   `json:"authors,omitempty"`
+	Hubs        []jsonHub      `json:"hubs,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonHub struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedItem struct {
+	ID            string       `json:"id"`
+	URL           string       `json:"url,omitempty"`
+	Title         string       `json:"title,omitempty"`
+	ContentHTML   string       `json:"content_html"`
+	DatePublished string       `json:"date_published,omitempty"`
+	DateModified  string       `json:"date_modified,omitempty"`
+	Authors       []jsonAuthor `json:"authors,omitempty"`
+	Tags          []string     `json:"tags,omitempty"`
+}
+
+// WriteJSONFeed writes the feed as a JSON Feed 1.1 document.
+func WriteJSONFeed(w io.Writer, feed Feed) error {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feed.Title,
+		HomePageURL: feed.Link,
+		FeedURL:     feed.FeedURL,
+		Description: feed.Description,
+		Items:       []jsonFeedItem{},
+	}
+	if feed.Author != "" {
+		doc.Authors = []jsonAuthor{{Name: feed.Author}}
+	}
+	if feed.Hub != "" {
+		doc.Hubs = []jsonHub{{Type: "WebSub", URL: feed.Hub}}
+	}
+
+	for _, item := range feed.Items {
+		jsonItem := jsonFeedItem{
+			ID:          item.ID,
+			URL:         item.Link,
+			Title:       item.Title,
+			ContentHTML: item.Description,
+			Tags:        item.Categories,
+		}
+		if !item.Published.IsZero() {
+			jsonItem.DatePublished = item.Published.UTC().Format(time.RFC3339)
+		}
+		if !item.Updated.IsZero() {
+			jsonItem.DateModified = item.Updated.UTC().Format(time.RFC3339)
+		}
+		if item.Author != "" {
+			jsonItem.Authors = []jsonAuthor{{Name: item.Author}}
+		}
+		doc.Items = append(doc.Items, jsonItem)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("error encoding json feed: %w", err)
+	}
+
+	return nil
+}
+
+// writeXML writes an indented XML document with its header.
+func writeXML(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("error writing xml header: %w", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("error encoding xml: %w", err)
+	}
+
+	return nil
+}
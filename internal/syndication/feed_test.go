@@ -0,0 +1,157 @@
+package syndication
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFeed() Feed {
+	published := time.Date(2024, 2, 28, 10, 0, 0, 0, time.UTC)
+
+	return Feed{
+		Title:       "John's timeline",
+		Description: "Posts <followed> by John",
+		Link:        "https://example.com/v1/users/1/feed.rss",
+		FeedURL:     "https://example.com/v1/users/1/feed.rss",
+		Author:      "John",
+		Updated:     published,
+		Items: []Item{
+			{
+				ID:          "https://blog.example.com/post-1",
+				Title:       "Post 1 & more",
+				Link:        "https://blog.example.com/post-1",
+				Description: "<p>Hello</p>",
+				Author:      "Jane",
+				Categories:  []string{"go", "news"},
+				Published:   published,
+			},
+		},
+	}
+}
+
+func TestWriteRSS(t *testing.T) {
+	var buf bytes.Buffer
+	contentType, err := Render(&buf, "rss", testFeed())
+	require.NoError(t, err)
+	assert.Equal(t, ContentTypeRSS, contentType)
+
+	var doc struct {
+		Channel struct {
+			Title string `xml:"title"`
+			// The atom link must be declared first to not be taken as the channel link.
+			Self struct {
+				Href string `xml:"href,attr"`
+			} `xml:"http://www.w3.org/2005/Atom link"`
+			Link  string `xml:"link"`
+			Items []struct {
+				Title      string   `xml:"title"`
+				Link       string   `xml:"link"`
+				GUID       string   `xml:"guid"`
+				PubDate    string   `xml:"pubDate"`
+				Creator    string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+				Categories []string `xml:"category"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "John's timeline", doc.Channel.Title)
+	assert.Equal(t, "https://example.com/v1/users/1/feed.rss", doc.Channel.Link)
+	assert.Equal(t, "https://example.com/v1/users/1/feed.rss", doc.Channel.Self.Href)
+	require.Len(t, doc.Channel.Items, 1)
+	item := doc.Channel.Items[0]
+	assert.Equal(t, "Post 1 & more", item.Title)
+	assert.Equal(t, "https://blog.example.com/post-1", item.GUID)
+	assert.Equal(t, "Wed, 28 Feb 2024 10:00:00 +0000", item.PubDate)
+	assert.Equal(t, "Jane", item.Creator)
+	assert.Equal(t, []string{"go", "news"}, item.Categories)
+}
+
+func TestWriteAtom(t *testing.T) {
+	var buf bytes.Buffer
+	contentType, err := Render(&buf, "atom", testFeed())
+	require.NoError(t, err)
+	assert.Equal(t, ContentTypeAtom, contentType)
+
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string   `xml:"id"`
+		Title   string   `xml:"title"`
+		Updated string   `xml:"updated"`
+		Entries []struct {
+			ID        string `xml:"id"`
+			Title     string `xml:"title"`
+			Published string `xml:"published"`
+			Link      struct {
+				Href string `xml:"href,attr"`
+			} `xml:"link"`
+			Summary string `xml:"summary"`
+		} `xml:"entry"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "https://example.com/v1/users/1/feed.rss", doc.ID)
+	assert.Equal(t, "2024-02-28T10:00:00Z", doc.Updated)
+	require.Len(t, doc.Entries, 1)
+	entry := doc.Entries[0]
+	assert.Equal(t, "https://blog.example.com/post-1", entry.ID)
+	assert.Equal(t, "https://blog.example.com/post-1", entry.Link.Href)
+	assert.Equal(t, "2024-02-28T10:00:00Z", entry.Published)
+	assert.Equal(t, "<p>Hello</p>", entry.Summary)
+}
+
+func TestWriteJSONFeed(t *testing.T) {
+	var buf bytes.Buffer
+	contentType, err := Render(&buf, "json", testFeed())
+	require.NoError(t, err)
+	assert.Equal(t, ContentTypeJSONFeed, contentType)
+
+	var doc struct {
+		Version string `json:"version"`
+		Title   string `json:"title"`
+		FeedURL string `json:"feed_url"`
+		Items   []struct {
+			ID            string   `json:"id"`
+			URL           string   `json:"url"`
+			ContentHTML   string   `json:"content_html"`
+			DatePublished string   `json:"date_published"`
+			Tags          []string `json:"tags"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "https://jsonfeed.org/version/1.1", doc.Version)
+	assert.Equal(t, "John's timeline", doc.Title)
+	require.Len(t, doc.Items, 1)
+	assert.Equal(t, "https://blog.example.com/post-1", doc.Items[0].ID)
+	assert.Equal(t, "<p>Hello</p>", doc.Items[0].ContentHTML)
+	assert.Equal(t, "2024-02-28T10:00:00Z", doc.Items[0].DatePublished)
+	assert.Equal(t, []string{"go", "news"}, doc.Items[0].Tags)
+}
+
+func TestRender_UnknownFormat(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := Render(&buf, "csv", testFeed())
+	require.Error(t, err)
+	assert.False(t, IsFormat("csv"))
+}
+
+func TestRender_Hub(t *testing.T) {
+	feed := testFeed()
+	feed.Hub = "https://example.com/v1/websub/hub"
+
+	for _, format := range []string{"rss", "atom", "json"} {
+		var buf bytes.Buffer
+		_, err := Render(&buf, format, feed)
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), feed.Hub, format)
+	}
+
+	var buf bytes.Buffer
+	_, err := Render(&buf, "atom", feed)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), `<link href="https://example.com/v1/websub/hub" rel="hub"></link>`)
+}
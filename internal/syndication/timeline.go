@@ -0,0 +1,38 @@
+package syndication
+
+import (
+	"fmt"
+
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+)
+
+// Timeline returns the feed document of the timeline of a user, made of the given posts.
+// The feed url is the url of the document, it is also its web page.
+func Timeline(user database.User, feedURL string, posts []database.Post) Feed {
+	feed := Feed{
+		Title:       fmt.Sprintf("%s's timeline", user.Name),
+		Description: fmt.Sprintf("Posts of the feeds followed by %s.", user.Name),
+		Link:        feedURL,
+		FeedURL:     feedURL,
+		Author:      user.Name,
+		Updated:     user.UpdatedAt,
+		Items:       make([]Item, 0, len(posts)),
+	}
+	for _, post := range posts {
+		if post.UpdatedAt.After(feed.Updated) {
+			feed.Updated = post.UpdatedAt
+		}
+		feed.Items = append(feed.Items, Item{
+			ID:          post.Url,
+			Title:       post.Title,
+			Link:        post.Url,
+			Description: post.Description,
+			Author:      post.Author,
+			Categories:  post.Categories,
+			Published:   post.PublishedAt,
+			Updated:     post.UpdatedAt,
+		})
+	}
+
+	return feed
+}
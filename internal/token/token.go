@@ -0,0 +1,194 @@
+// Package token issues the access tokens of the users, JWTs short-lived enough to be verified
+// without looking up the user, by the server and by the services trusting its keys.
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/jwt"
+)
+
+const (
+	// AccessTokenDuration is the time an access token is valid, its scopes cannot be revoked meanwhile.
+	AccessTokenDuration = 15 * time.Minute
+	// clockSkew is the leeway of the validity period of the access tokens.
+	clockSkew = 30 * time.Second
+)
+
+// Claims are the claims of an access token, the subject is the id of the user.
+type Claims struct {
+	jwt.Claims
+	// Scope is the space-separated list of the scopes granted by the token.
+	Scope string `json:"scope"`
+}
+
+// Issuer signs and verifies the access tokens with its key.
+// The tokens signed with an asymmetric key can be verified by other services with the published JWKS.
+type Issuer struct {
+	issuer string
+	alg    string
+	kid    string
+	key    crypto.PrivateKey
+	public crypto.PublicKey
+}
+
+// NewIssuer returns an issuer signing the tokens with the key of the algorithm:
+// a []byte secret for HS256, an *rsa.PrivateKey for RS256, an *ecdsa.PrivateKey for ES256
+// and an ed25519.PrivateKey for EdDSA. The issuer is also the audience of the tokens.
+func NewIssuer(issuer, alg string, key crypto.PrivateKey) (*Issuer, error) {
+	i := &Issuer{issuer: issuer, alg: alg, key: key}
+
+	switch alg {
+	case jwt.HS256:
+		secret, ok := key.([]byte)
+		// The secret must be as long as the hash, see RFC 7518.
+		if !ok || len(secret) < sha256.Size {
+			return nil, fmt.Errorf("the %s secret must be at least %d bytes", alg, sha256.Size)
+		}
+		i.public = secret
+		return i, nil
+	case jwt.RS256, jwt.ES256, jwt.EdDSA:
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("invalid key for %s", alg)
+		}
+		i.public = signer.Public()
+	default:
+		return nil, fmt.Errorf("unsupported algorithm: %q", alg)
+	}
+
+	// The key id is derived from the public key, so it changes with the key.
+	jwk, err := jwt.NewJWK("", alg, i.public)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(jwk)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding key: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	i.kid = base64.RawURLEncoding.EncodeToString(sum[:12])
+
+	// The key must match the algorithm.
+	if _, err := jwt.Sign(alg, i.kid, jwt.Claims{}, key); err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+// IssueAccessToken returns an access token of the user granting the scopes, with its expiration.
+func (i *Issuer) IssueAccessToken(userID uuid.UUID, scopes []string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(AccessTokenDuration)
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", time.Time{}, fmt.Errorf("error generating token id: %w", err)
+	}
+
+	raw, err := jwt.Sign(i.alg, i.kid, Claims{
+		Claims: jwt.Claims{
+			Issuer:    i.issuer,
+			Subject:   userID.String(),
+			Audience:  jwt.Audience{i.issuer},
+			ExpiresAt: expiresAt.Unix(),
+			IssuedAt:  now.Unix(),
+			ID:        base64.RawURLEncoding.EncodeToString(id),
+		},
+		Scope: strings.Join(scopes, " "),
+	}, i.key)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error signing access token: %w", err)
+	}
+
+	return raw, expiresAt, nil
+}
+
+// VerifyAccessToken verifies an access token, then returns the id of its user and its scopes.
+func (i *Issuer) VerifyAccessToken(raw string) (uuid.UUID, []string, error) {
+	token, err := jwt.Parse(raw)
+	if err != nil {
+		return uuid.UUID{}, nil, err
+	}
+	// Only the algorithm of the issuer is accepted, whatever the header tells.
+	if token.Header.Alg != i.alg || token.Header.Kid != i.kid {
+		return uuid.UUID{}, nil, errors.New("unknown access token key")
+	}
+	if err := token.Verify(i.public); err != nil {
+		return uuid.UUID{}, nil, err
+	}
+
+	var claims Claims
+	if err := token.Claims(&claims); err != nil {
+		return uuid.UUID{}, nil, err
+	}
+	if err := claims.Validate(i.issuer, i.issuer, time.Now(), clockSkew); err != nil {
+		return uuid.UUID{}, nil, fmt.Errorf("invalid access token: %w", err)
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.UUID{}, nil, fmt.Errorf("invalid access token subject: %w", err)
+	}
+
+	return userID, strings.Fields(claims.Scope), nil
+}
+
+// JWKS returns the public key of the issuer, to verify the access tokens.
+// It is empty with a secret, which cannot be published.
+func (i *Issuer) JWKS() jwt.JWKS {
+	keys := jwt.JWKS{Keys: []jwt.JWK{}}
+	if i.alg == jwt.HS256 {
+		return keys
+	}
+
+	// The public key was encoded at the creation.
+	jwk, _ := jwt.NewJWK(i.kid, i.alg, i.public)
+	keys.Keys = append(keys.Keys, jwk)
+
+	return keys
+}
+
+// ParsePrivateKey parses a private key in a PKCS #8 PEM block,
+// an RSA key for RS256, an EC P-256 key for ES256 or an Ed25519 key for EdDSA.
+func ParsePrivateKey(data []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, errors.New("a PKCS #8 PEM block is expected")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private key: %w", err)
+	}
+
+	return key, nil
+}
+
+// Algorithm returns the signing algorithm of a private key.
+func Algorithm(key crypto.PrivateKey) (string, error) {
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		return jwt.RS256, nil
+	case *ecdsa.PrivateKey:
+		return jwt.ES256, nil
+	case ed25519.PrivateKey:
+		return jwt.EdDSA, nil
+	default:
+		return "", fmt.Errorf("unsupported key type: %T", key)
+	}
+}
@@ -0,0 +1,121 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssuer(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		alg string
+		key crypto.PrivateKey
+	}{
+		{alg: jwt.HS256, key: []byte("0123456789abcdef0123456789abcdef")},
+		{alg: jwt.RS256, key: rsaKey},
+		{alg: jwt.ES256, key: ecKey},
+		{alg: jwt.EdDSA, key: edKey},
+	}
+
+	userID := uuid.New()
+	for _, test := range tests {
+		tc := test
+		t.Run(tc.alg, func(t *testing.T) {
+			issuer, err := NewIssuer("https://rss.example.com", tc.alg, tc.key)
+			require.NoError(t, err)
+
+			raw, expiresAt, err := issuer.IssueAccessToken(userID, []string{"feeds:read", "posts:read"})
+			require.NoError(t, err)
+			assert.WithinDuration(t, time.Now().Add(AccessTokenDuration), expiresAt, time.Second)
+
+			actualUserID, scopes, err := issuer.VerifyAccessToken(raw)
+			require.NoError(t, err)
+			assert.Equal(t, userID, actualUserID)
+			assert.Equal(t, []string{"feeds:read", "posts:read"}, scopes)
+
+			// The tokens of another issuer are refused.
+			other, err := NewIssuer("https://other.example.com", tc.alg, tc.key)
+			require.NoError(t, err)
+			raw, _, err = other.IssueAccessToken(userID, nil)
+			require.NoError(t, err)
+			_, _, err = issuer.VerifyAccessToken(raw)
+			assert.Error(t, err)
+
+			// The public key is published with the id of the tokens, the secrets are not.
+			keys := issuer.JWKS()
+			if tc.alg == jwt.HS256 {
+				assert.Empty(t, keys.Keys)
+				return
+			}
+			require.Len(t, keys.Keys, 1)
+			token, err := jwt.Parse(raw)
+			require.NoError(t, err)
+			_, ok := other.JWKS().Key(token.Header.Kid)
+			assert.True(t, ok)
+		})
+	}
+
+	// The tokens signed with another key or algorithm are refused.
+	issuer, err := NewIssuer("https://rss.example.com", jwt.RS256, rsaKey)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	other, err := NewIssuer("https://rss.example.com", jwt.RS256, otherKey)
+	require.NoError(t, err)
+	raw, _, err := other.IssueAccessToken(userID, nil)
+	require.NoError(t, err)
+	_, _, err = issuer.VerifyAccessToken(raw)
+	assert.Error(t, err)
+
+	forged, err := jwt.Sign(jwt.HS256, issuer.kid, Claims{Claims: jwt.Claims{
+		Issuer:    "https://rss.example.com",
+		Subject:   userID.String(),
+		Audience:  jwt.Audience{"https://rss.example.com"},
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}}, []byte("secret"))
+	require.NoError(t, err)
+	_, _, err = issuer.VerifyAccessToken(forged)
+	assert.Error(t, err)
+
+	// The keys must match the algorithms.
+	_, err = NewIssuer("https://rss.example.com", jwt.HS256, []byte("short"))
+	assert.Error(t, err)
+	_, err = NewIssuer("https://rss.example.com", jwt.ES256, rsaKey)
+	assert.Error(t, err)
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+
+	key, err := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+	assert.Equal(t, edKey, key)
+	alg, err := Algorithm(key)
+	require.NoError(t, err)
+	assert.Equal(t, jwt.EdDSA, alg)
+
+	_, err = ParsePrivateKey([]byte("not a key"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,274 @@
+// Package webhook delivers the posts inserted by the feed fetcher to the webhooks of the users.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+)
+
+// Status of a delivery.
+const (
+	// StatusPending means the delivery is waiting for its next attempt.
+	StatusPending = "pending"
+	// StatusDelivered means the endpoint accepted the payload.
+	StatusDelivered = "delivered"
+	// StatusFailed means the delivery is given up after too many attempts.
+	StatusFailed = "failed"
+)
+
+// EventPostCreated is the event of the payload sent for a new post.
+const EventPostCreated = "post.created"
+
+// Headers of the delivery requests.
+const (
+	// SignatureHeader holds the HMAC-SHA256 of the body, keyed by the secret of the webhook.
+	SignatureHeader = "X-Webhook-Signature"
+	// DeliveryHeader holds the id of the delivery, it is the same for all the attempts.
+	DeliveryHeader = "X-Webhook-Delivery"
+	// EventHeader holds the event of the payload.
+	EventHeader = "X-Webhook-Event"
+)
+
+const (
+	// maxAttempts is the number of attempts before a delivery fails.
+	maxAttempts = 8
+	// minBackoff is the delay before the second attempt, it doubles with each attempt.
+	minBackoff = 30 * time.Second
+	// maxBackoff is the maximum delay between two attempts.
+	maxBackoff = 6 * time.Hour
+	// batchSize is the maximum number of deliveries attempted at once.
+	batchSize = 50
+)
+
+// Store represents a store of the webhooks and their deliveries.
+type Store interface {
+	ListWebhooksForPost(ctx context.Context, postID int32) ([]database.Webhook, error)
+	CreateWebhookDelivery(ctx context.Context, arg database.CreateWebhookDeliveryParams) (database.WebhookDelivery, error)
+	ListDueWebhookDeliveries(ctx context.Context, limit int32) ([]database.ListDueWebhookDeliveriesRow, error)
+	UpdateWebhookDelivery(ctx context.Context, arg database.UpdateWebhookDeliveryParams) error
+}
+
+// Payload is the JSON body sent to a webhook.
+type Payload struct {
+	Event     string        `json:"event"`
+	WebhookID uuid.UUID     `json:"webhook_id"`
+	Post      database.Post `json:"post"`
+}
+
+// Dispatcher persists a delivery per matching webhook for each new post, then sends them.
+// The failed deliveries are retried with an exponential backoff.
+type Dispatcher struct {
+	store    Store
+	client   *http.Client
+	interval time.Duration
+	now      func() time.Time
+	// wake triggers the delivery of the enqueued posts.
+	wake chan struct{}
+}
+
+// NewDispatcher returns a new dispatcher sending the deliveries with the client.
+// The client should refuse to connect to the internal network, see netguard.NewClient.
+func NewDispatcher(store Store, client *http.Client) *Dispatcher {
+	return &Dispatcher{
+		store:    store,
+		client:   client,
+		interval: 30 * time.Second,
+		now:      time.Now,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Publish enqueues the deliveries of a new post, they are sent by the running dispatcher.
+// The deliveries are persisted before returning, so a post is not lost when the endpoints are slow or down.
+func (d *Dispatcher) Publish(post database.Post) {
+	ctx := context.Background()
+	if err := d.Enqueue(ctx, post); err != nil {
+		slog.Log(ctx, slog.LevelError, "enqueue webhook deliveries", "post_id", post.ID, "error", err)
+		return
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+		// A delivery is already planned.
+	}
+}
+
+// Start sends the enqueued deliveries when posts are published,
+// and periodically retries the pending ones, until the context is done.
+func (d *Dispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	// Send the deliveries left pending by a previous run.
+	d.deliver(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+			d.deliver(ctx)
+		case <-ticker.C:
+			d.deliver(ctx)
+		}
+	}
+}
+
+// deliver sends the due deliveries and logs the failure.
+func (d *Dispatcher) deliver(ctx context.Context) {
+	if err := d.Deliver(ctx); err != nil {
+		slog.Log(ctx, slog.LevelError, "deliver webhooks", "error", err)
+	}
+}
+
+// Enqueue creates a pending delivery of the post for each webhook matching it.
+func (d *Dispatcher) Enqueue(ctx context.Context, post database.Post) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	webhooks, err := d.store.ListWebhooksForPost(ctx, post.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		payload, err := json.Marshal(Payload{
+			Event:     EventPostCreated,
+			WebhookID: webhook.ID,
+			Post:      post,
+		})
+		if err != nil {
+			return fmt.Errorf("encode payload: %w", err)
+		}
+
+		_, err = d.store.CreateWebhookDelivery(ctx, database.CreateWebhookDeliveryParams{
+			WebhookID: webhook.ID,
+			PostID:    sql.NullInt32{Int32: post.ID, Valid: true},
+			Payload:   payload,
+		})
+		// The post has already been enqueued for this webhook.
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Deliver attempts the due deliveries and records their results.
+func (d *Dispatcher) Deliver(ctx context.Context) error {
+	listCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	deliveries, err := d.store.ListDueWebhookDeliveries(listCtx, batchSize)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	for _, row := range deliveries {
+		params := d.attempt(ctx, row)
+
+		updateCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err := d.store.UpdateWebhookDelivery(updateCtx, params)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attempt sends a delivery and returns its updated state.
+func (d *Dispatcher) attempt(ctx context.Context, row database.ListDueWebhookDeliveriesRow) database.UpdateWebhookDeliveryParams {
+	delivery := row.WebhookDelivery
+	params := database.UpdateWebhookDeliveryParams{
+		ID:            delivery.ID,
+		Status:        StatusPending,
+		Attempts:      delivery.Attempts + 1,
+		NextAttemptAt: delivery.NextAttemptAt,
+	}
+
+	status, err := d.send(ctx, row.Url, row.Secret, delivery)
+	if status != 0 {
+		params.ResponseStatus = sql.NullInt32{Int32: int32(status), Valid: true}
+	}
+	if err == nil {
+		params.Status = StatusDelivered
+		params.DeliveredAt = sql.NullTime{Time: d.now(), Valid: true}
+		return params
+	}
+
+	params.LastError = sql.NullString{String: err.Error(), Valid: true}
+	if params.Attempts >= maxAttempts {
+		params.Status = StatusFailed
+	} else {
+		params.NextAttemptAt = d.now().Add(Backoff(int(params.Attempts)))
+	}
+
+	return params
+}
+
+// send posts the payload of the delivery to the url.
+// It returns the status of the response, if any, and an error unless the status is 2xx.
+func (d *Dispatcher) send(ctx context.Context, url, secret string, delivery database.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-rssaggregator-webhook")
+	req.Header.Set(EventHeader, EventPostCreated)
+	req.Header.Set(DeliveryHeader, delivery.ID.String())
+	req.Header.Set(SignatureHeader, Sign(secret, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// The body of the response is not kept, it could expose the content of the endpoint.
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature of the body sent in the SignatureHeader, as 'sha256=<hex encoded HMAC-SHA256>'.
+// A receiver computes the signature of the body with the secret of the webhook and compares them.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns the delay before the next attempt of a delivery attempted the given number of times.
+func Backoff(attempts int) time.Duration {
+	delay := minBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+
+	return delay
+}
@@ -0,0 +1,216 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore keeps the deliveries in memory, every delivery is due.
+type fakeStore struct {
+	mu         sync.Mutex
+	webhooks   []database.Webhook
+	deliveries map[uuid.UUID]database.WebhookDelivery
+	updates    chan database.UpdateWebhookDeliveryParams
+}
+
+func newFakeStore(webhooks ...database.Webhook) *fakeStore {
+	return &fakeStore{
+		webhooks:   webhooks,
+		deliveries: make(map[uuid.UUID]database.WebhookDelivery),
+		updates:    make(chan database.UpdateWebhookDeliveryParams, 10),
+	}
+}
+
+func (s *fakeStore) ListWebhooksForPost(_ context.Context, _ int32) ([]database.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.webhooks, nil
+}
+
+func (s *fakeStore) CreateWebhookDelivery(_ context.Context, arg database.CreateWebhookDeliveryParams) (database.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, delivery := range s.deliveries {
+		if delivery.WebhookID == arg.WebhookID && delivery.PostID == arg.PostID {
+			return database.WebhookDelivery{}, sql.ErrNoRows
+		}
+	}
+
+	delivery := database.WebhookDelivery{
+		ID:        uuid.New(),
+		WebhookID: arg.WebhookID,
+		PostID:    arg.PostID,
+		Payload:   arg.Payload,
+		Status:    StatusPending,
+	}
+	s.deliveries[delivery.ID] = delivery
+
+	return delivery, nil
+}
+
+func (s *fakeStore) ListDueWebhookDeliveries(_ context.Context, _ int32) ([]database.ListDueWebhookDeliveriesRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rows []database.ListDueWebhookDeliveriesRow
+	for _, delivery := range s.deliveries {
+		if delivery.Status != StatusPending {
+			continue
+		}
+		for _, webhook := range s.webhooks {
+			if webhook.ID == delivery.WebhookID {
+				rows = append(rows, database.ListDueWebhookDeliveriesRow{
+					WebhookDelivery: delivery,
+					Url:             webhook.Url,
+					Secret:          webhook.Secret,
+				})
+			}
+		}
+	}
+
+	return rows, nil
+}
+
+func (s *fakeStore) UpdateWebhookDelivery(_ context.Context, arg database.UpdateWebhookDeliveryParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery := s.deliveries[arg.ID]
+	delivery.Status = arg.Status
+	delivery.Attempts = arg.Attempts
+	delivery.NextAttemptAt = arg.NextAttemptAt
+	delivery.ResponseStatus = arg.ResponseStatus
+	delivery.LastError = arg.LastError
+	delivery.DeliveredAt = arg.DeliveredAt
+	s.deliveries[arg.ID] = delivery
+	s.updates <- arg
+
+	return nil
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	type request struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{header: r.Header, body: body}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	webhook := database.Webhook{ID: uuid.New(), Url: server.URL, Secret: "secret"}
+	store := newFakeStore(webhook)
+	dispatcher := NewDispatcher(store, server.Client())
+	post := database.Post{ID: 42, Title: "title", Url: "https://example.com/post"}
+
+	ctx := context.Background()
+	require.NoError(t, dispatcher.Enqueue(ctx, post))
+	// A post is only enqueued once per webhook.
+	require.NoError(t, dispatcher.Enqueue(ctx, post))
+	require.Len(t, store.deliveries, 1)
+
+	require.NoError(t, dispatcher.Deliver(ctx))
+
+	req := <-requests
+	assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+	assert.Equal(t, EventPostCreated, req.header.Get(EventHeader))
+	assert.NotEmpty(t, req.header.Get(DeliveryHeader))
+	assert.True(t, hmac.Equal([]byte(Sign("secret", req.body)), []byte(req.header.Get(SignatureHeader))))
+
+	var payload Payload
+	require.NoError(t, json.Unmarshal(req.body, &payload))
+	assert.Equal(t, EventPostCreated, payload.Event)
+	assert.Equal(t, webhook.ID, payload.WebhookID)
+	assert.Equal(t, post.ID, payload.Post.ID)
+	assert.Equal(t, post.Title, payload.Post.Title)
+
+	update := <-store.updates
+	assert.Equal(t, StatusDelivered, update.Status)
+	assert.Equal(t, int32(1), update.Attempts)
+	assert.Equal(t, int32(http.StatusNoContent), update.ResponseStatus.Int32)
+	assert.True(t, update.DeliveredAt.Valid)
+	assert.False(t, update.LastError.Valid)
+}
+
+func TestDispatcher_Deliver_Retry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	webhook := database.Webhook{ID: uuid.New(), Url: server.URL, Secret: "secret"}
+	store := newFakeStore(webhook)
+	dispatcher := NewDispatcher(store, server.Client())
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	dispatcher.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	require.NoError(t, dispatcher.Enqueue(ctx, database.Post{ID: 1}))
+
+	for attempt := 1; attempt < maxAttempts; attempt++ {
+		require.NoError(t, dispatcher.Deliver(ctx))
+		update := <-store.updates
+		assert.Equal(t, StatusPending, update.Status)
+		assert.Equal(t, int32(attempt), update.Attempts)
+		assert.Equal(t, now.Add(Backoff(attempt)), update.NextAttemptAt)
+		assert.Equal(t, int32(http.StatusServiceUnavailable), update.ResponseStatus.Int32)
+		assert.Equal(t, "unexpected status 503", update.LastError.String)
+	}
+
+	require.NoError(t, dispatcher.Deliver(ctx))
+	update := <-store.updates
+	assert.Equal(t, StatusFailed, update.Status)
+	assert.Equal(t, int32(maxAttempts), update.Attempts)
+
+	// A failed delivery is not attempted anymore.
+	require.NoError(t, dispatcher.Deliver(ctx))
+	assert.Empty(t, store.updates)
+}
+
+func TestDispatcher_Start(t *testing.T) {
+	received := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+	}))
+	defer server.Close()
+
+	store := newFakeStore(database.Webhook{ID: uuid.New(), Url: server.URL, Secret: "secret"})
+	dispatcher := NewDispatcher(store, server.Client())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dispatcher.Start(ctx)
+
+	dispatcher.Publish(database.Post{ID: 1})
+
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the webhook has not been delivered")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, minBackoff, Backoff(1))
+	assert.Equal(t, 2*minBackoff, Backoff(2))
+	assert.Equal(t, 4*minBackoff, Backoff(3))
+	assert.Equal(t, maxBackoff, Backoff(100))
+}
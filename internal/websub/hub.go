@@ -0,0 +1,462 @@
+package websub
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/netguard"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/syndication"
+)
+
+// HubPath is the path of the hub endpoint, relative to the base url of the server.
+const HubPath = "/v1/websub/hub"
+
+const (
+	// defaultHubLease is the lease granted when the subscriber does not ask for one.
+	defaultHubLease = 10 * 24 * time.Hour
+	// minHubLease and maxHubLease bound the lease asked by the subscribers.
+	minHubLease = time.Hour
+	maxHubLease = 30 * 24 * time.Hour
+	// maxSecretSize is the maximum size of the secret of a subscriber, as defined by the protocol.
+	maxSecretSize = 200
+	// hubQueueSize is the maximum number of published posts waiting to be distributed.
+	hubQueueSize = 1000
+	// hubVerificationQueueSize is the maximum number of requests waiting for the verification of their intent.
+	hubVerificationQueueSize = 100
+	// hubVerifiers is the number of intents verified at once.
+	hubVerifiers = 4
+)
+
+var (
+	// ErrInvalidRequest is returned when a subscription request is rejected by the hub.
+	ErrInvalidRequest = errors.New("invalid hub request")
+	// ErrHubBusy is returned when too many requests are waiting for their verification.
+	ErrHubBusy = errors.New("websub hub busy")
+)
+
+// HubStore represents a store of the subscriptions to the timeline feeds.
+type HubStore interface {
+	UpsertHubSubscription(ctx context.Context, arg database.UpsertHubSubscriptionParams) (database.HubSubscription, error)
+	DeleteHubSubscription(ctx context.Context, arg database.DeleteHubSubscriptionParams) error
+	ListHubSubscriptionsForPost(ctx context.Context, postID int32) ([]database.ListHubSubscriptionsForPostRow, error)
+	DeleteExpiredHubSubscriptions(ctx context.Context, expiredAt time.Time) (int64, error)
+}
+
+// FeedTokenStore represents a store to authenticate the owner of a timeline feed by its feed token.
+type FeedTokenStore interface {
+	GetUserFromFeedToken(ctx context.Context, arg database.GetUserFromFeedTokenParams) (database.User, error)
+}
+
+// HubRequest is a subscription request sent to the hub.
+type HubRequest struct {
+	Mode     string
+	Topic    string
+	Callback string
+	Secret   string
+	// Lease is the lease asked by the subscriber, zero for the default one.
+	Lease time.Duration
+}
+
+// verification is a request waiting for the verification of the intent of its subscriber.
+type verification struct {
+	req   HubRequest
+	topic topic
+}
+
+// topic is a timeline feed of the server.
+type topic struct {
+	user     database.User
+	format   string
+	feedID   uuid.NullUUID
+	folderID uuid.NullUUID
+}
+
+// Hub is the WebSub hub of the timeline feeds published by the server.
+// It verifies the subscriptions, then pushes the new posts of the timelines to the callbacks of the subscribers.
+type Hub struct {
+	store    HubStore
+	users    FeedTokenStore
+	client   *http.Client
+	baseURL  string
+	interval time.Duration
+	now      func() time.Time
+	// checkCallback rejects the callbacks of the internal network.
+	checkCallback func(ctx context.Context, rawURL string) error
+	// posts holds the published posts waiting to be distributed.
+	posts chan database.Post
+	// verifications holds the requests waiting for the verification of their intent.
+	verifications chan verification
+}
+
+// NewHub returns a new hub.
+// The base url is the public url of the server, the topics are the timeline feeds under it.
+// The client should refuse to connect to the internal network, see netguard.NewClient.
+func NewHub(store HubStore, users FeedTokenStore, client *http.Client, baseURL string) *Hub {
+	return &Hub{
+		store:         store,
+		users:         users,
+		client:        client,
+		baseURL:       strings.TrimSuffix(baseURL, "/"),
+		interval:      time.Hour,
+		now:           time.Now,
+		checkCallback: netguard.CheckURL,
+		posts:         make(chan database.Post, hubQueueSize),
+		verifications: make(chan verification, hubVerificationQueueSize),
+	}
+}
+
+// URL returns the url of the hub.
+func (h *Hub) URL() string {
+	return h.baseURL + HubPath
+}
+
+// Request validates a subscription request, then queues the verification of the intent of the subscriber,
+// done in the background by the running hub. ErrHubBusy is returned when the queue is full.
+// The subscription is only recorded, or removed, once the subscriber has confirmed it.
+func (h *Hub) Request(ctx context.Context, req HubRequest) error {
+	if req.Mode != ModeSubscribe && req.Mode != ModeUnsubscribe {
+		return fmt.Errorf("%w: invalid mode: %q", ErrInvalidRequest, req.Mode)
+	}
+	callback, err := url.Parse(req.Callback)
+	if err != nil || (callback.Scheme != "http" && callback.Scheme != "https") || callback.Host == "" {
+		return fmt.Errorf("%w: invalid callback: %q, an absolute http(s) url is expected", ErrInvalidRequest, req.Callback)
+	}
+	if len(req.Secret) >= maxSecretSize {
+		return fmt.Errorf("%w: the secret must be less than %d bytes", ErrInvalidRequest, maxSecretSize)
+	}
+
+	t, err := h.parseTopic(ctx, req.Topic)
+	if err != nil {
+		return err
+	}
+	// The callback is resolved last, once the request is known to be valid.
+	if err := h.checkCallback(ctx, req.Callback); err != nil {
+		return fmt.Errorf("%w: invalid callback: %w", ErrInvalidRequest, err)
+	}
+
+	select {
+	case h.verifications <- verification{req: req, topic: t}:
+		return nil
+	default:
+		return ErrHubBusy
+	}
+}
+
+// parseTopic returns the timeline feed of a topic url, authenticated by its feed token.
+func (h *Hub) parseTopic(ctx context.Context, topicURL string) (topic, error) {
+	invalid := fmt.Errorf("%w: unknown topic: %q", ErrInvalidRequest, topicURL)
+
+	u, err := url.Parse(topicURL)
+	if err != nil {
+		return topic{}, invalid
+	}
+	base, err := url.Parse(h.baseURL)
+	if err != nil {
+		return topic{}, fmt.Errorf("parse base url: %w", err)
+	}
+	if u.Scheme != base.Scheme || u.Host != base.Host {
+		return topic{}, invalid
+	}
+
+	// The path is '/v1/users/{id}/feed.{format}'.
+	path, ok := strings.CutPrefix(u.Path, base.Path+"/v1/users/")
+	if !ok {
+		return topic{}, invalid
+	}
+	id, file, ok := strings.Cut(path, "/")
+	if !ok {
+		return topic{}, invalid
+	}
+	format, ok := strings.CutPrefix(file, "feed.")
+	if !ok || !syndication.IsFormat(format) {
+		return topic{}, invalid
+	}
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return topic{}, invalid
+	}
+
+	t := topic{format: format}
+	query := u.Query()
+	for name, value := range map[string]*uuid.NullUUID{"feed_id": &t.feedID, "folder_id": &t.folderID} {
+		if query.Get(name) == "" {
+			continue
+		}
+		parsed, err := uuid.Parse(query.Get(name))
+		if err != nil {
+			return topic{}, invalid
+		}
+		*value = uuid.NullUUID{UUID: parsed, Valid: true}
+	}
+
+	t.user, err = h.users.GetUserFromFeedToken(ctx, database.GetUserFromFeedTokenParams{
+		ID:        userID,
+		FeedToken: query.Get("token"),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return topic{}, invalid
+		}
+		return topic{}, err
+	}
+
+	return t, nil
+}
+
+// leaseOf returns the lease granted for a request.
+func leaseOf(req HubRequest) time.Duration {
+	if req.Lease <= 0 {
+		return defaultHubLease
+	}
+
+	return min(max(req.Lease, minHubLease), maxHubLease)
+}
+
+// verify asks the subscriber to confirm the request by echoing a challenge,
+// then records or removes the subscription.
+func (h *Hub) verify(ctx context.Context, req HubRequest, t topic) error {
+	challenge, err := generateSecret()
+	if err != nil {
+		return err
+	}
+	lease := leaseOf(req)
+
+	callback, err := url.Parse(req.Callback)
+	if err != nil {
+		return fmt.Errorf("parse callback: %w", err)
+	}
+	// The parameters are added to the ones of the callback.
+	query := callback.Query()
+	query.Set("hub.mode", req.Mode)
+	query.Set("hub.topic", req.Topic)
+	query.Set("hub.challenge", challenge)
+	if req.Mode == ModeSubscribe {
+		query.Set("hub.lease_seconds", strconv.Itoa(int(lease.Seconds())))
+	}
+	callback.RawQuery = query.Encode()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, callback.String(), nil)
+	if err != nil {
+		return fmt.Errorf("create verification request: %w", err)
+	}
+	resp, err := h.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("send verification request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	if err != nil {
+		return fmt.Errorf("read verification response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || strings.TrimSpace(string(body)) != challenge {
+		return fmt.Errorf("%s of %s not confirmed by the callback, status %d", req.Mode, req.Topic, resp.StatusCode)
+	}
+
+	if req.Mode == ModeUnsubscribe {
+		return h.store.DeleteHubSubscription(ctx, database.DeleteHubSubscriptionParams{
+			TopicUrl:    req.Topic,
+			CallbackUrl: req.Callback,
+		})
+	}
+
+	_, err = h.store.UpsertHubSubscription(ctx, database.UpsertHubSubscriptionParams{
+		UserID:         t.user.ID,
+		TopicUrl:       req.Topic,
+		Format:         t.format,
+		FeedID:         t.feedID,
+		FolderID:       t.folderID,
+		CallbackUrl:    req.Callback,
+		Secret:         req.Secret,
+		LeaseExpiresAt: h.now().Add(lease),
+	})
+
+	return err
+}
+
+// Publish queues a new post, it is pushed to the subscribers of the timelines containing it by the running hub.
+// The post is dropped when the queue is full, the subscribers still get it when they fetch the topic.
+func (h *Hub) Publish(post database.Post) {
+	select {
+	case h.posts <- post:
+	default:
+		slog.Log(context.Background(), slog.LevelWarn, "websub hub queue full, post dropped", "post_id", post.ID)
+	}
+}
+
+// Start verifies the intent of the subscribers, distributes the published posts,
+// and periodically removes the expired subscriptions, until the context is done.
+func (h *Hub) Start(ctx context.Context) {
+	for range hubVerifiers {
+		go h.startVerifier(ctx)
+	}
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case post := <-h.posts:
+			// The posts inserted together are pushed in the same document.
+			posts := []database.Post{post}
+		drain:
+			for {
+				select {
+				case post := <-h.posts:
+					posts = append(posts, post)
+				default:
+					break drain
+				}
+			}
+			if err := h.Distribute(ctx, posts); err != nil {
+				slog.Log(ctx, slog.LevelError, "distribute websub content", "error", err)
+			}
+		case <-ticker.C:
+			if err := h.Purge(ctx); err != nil {
+				slog.Log(ctx, slog.LevelError, "purge websub subscriptions", "error", err)
+			}
+		}
+	}
+}
+
+// startVerifier verifies the queued requests one at a time, until the context is done.
+func (h *Hub) startVerifier(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case v := <-h.verifications:
+			verifyCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			if err := h.verify(verifyCtx, v.req, v.topic); err != nil {
+				slog.Log(ctx, slog.LevelInfo, "verify websub intent", "callback", v.req.Callback, "error", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// distribution is the content pushed to a subscriber.
+type distribution struct {
+	subscription database.HubSubscription
+	user         database.User
+	posts        []database.Post
+}
+
+// Distribute pushes the posts to the subscribers of the timelines containing them.
+// Each subscriber receives a single document with its posts.
+func (h *Hub) Distribute(ctx context.Context, posts []database.Post) error {
+	var distributions []*distribution
+	bySubscription := make(map[uuid.UUID]*distribution)
+	for _, post := range posts {
+		rows, err := h.store.ListHubSubscriptionsForPost(ctx, post.ID)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			d, ok := bySubscription[row.HubSubscription.ID]
+			if !ok {
+				d = &distribution{subscription: row.HubSubscription, user: row.User}
+				bySubscription[row.HubSubscription.ID] = d
+				distributions = append(distributions, d)
+			}
+			d.posts = append(d.posts, post)
+		}
+	}
+
+	for _, d := range distributions {
+		pushCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err := h.push(pushCtx, d)
+		cancel()
+		if err != nil {
+			slog.Log(ctx, slog.LevelInfo, "push websub content", "callback", d.subscription.CallbackUrl, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// push sends the posts to the callback of a subscriber, as a document in the format of the topic.
+// The subscription is removed when the callback answers it is gone.
+func (h *Hub) push(ctx context.Context, d *distribution) error {
+	// The timeline lists the most recent posts first.
+	slices.SortFunc(d.posts, func(a, b database.Post) int {
+		return b.PublishedAt.Compare(a.PublishedAt)
+	})
+	feed := syndication.Timeline(d.user, d.subscription.TopicUrl, d.posts)
+	feed.Hub = h.URL()
+
+	var buf bytes.Buffer
+	contentType, err := syndication.Render(&buf, d.subscription.Format, feed)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.subscription.CallbackUrl, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return fmt.Errorf("create content request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Add("Link", fmt.Sprintf("<%s>; rel=\"hub\"", feed.Hub))
+	req.Header.Add("Link", fmt.Sprintf("<%s>; rel=\"self\"", feed.FeedURL))
+	if d.subscription.Secret != "" {
+		req.Header.Set("X-Hub-Signature", Sign(d.subscription.Secret, buf.Bytes()))
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send content: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<10))
+
+	if resp.StatusCode == http.StatusGone {
+		return h.store.DeleteHubSubscription(ctx, database.DeleteHubSubscriptionParams{
+			TopicUrl:    d.subscription.TopicUrl,
+			CallbackUrl: d.subscription.CallbackUrl,
+		})
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("content rejected by %s with status %d", d.subscription.CallbackUrl, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Purge removes the subscriptions whose lease has expired.
+func (h *Hub) Purge(ctx context.Context) error {
+	count, err := h.store.DeleteExpiredHubSubscriptions(ctx, h.now())
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		slog.Log(ctx, slog.LevelInfo, "expired websub subscriptions removed", "count", count)
+	}
+
+	return nil
+}
+
+// Sign returns the X-Hub-Signature header of the body, the HMAC-SHA256 keyed by the secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,388 @@
+package websub
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/netguard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHubStore keeps the subscriptions to the timeline feeds in memory.
+// All the subscriptions match all the posts.
+type fakeHubStore struct {
+	mu   sync.Mutex
+	subs map[string]database.HubSubscription
+	user database.User
+}
+
+func newFakeHubStore(user database.User) *fakeHubStore {
+	return &fakeHubStore{subs: make(map[string]database.HubSubscription), user: user}
+}
+
+func (s *fakeHubStore) UpsertHubSubscription(_ context.Context, arg database.UpsertHubSubscriptionParams) (database.HubSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subs[arg.TopicUrl+arg.CallbackUrl]
+	if !ok {
+		sub = database.HubSubscription{
+			ID:          uuid.New(),
+			UserID:      arg.UserID,
+			TopicUrl:    arg.TopicUrl,
+			Format:      arg.Format,
+			FeedID:      arg.FeedID,
+			FolderID:    arg.FolderID,
+			CallbackUrl: arg.CallbackUrl,
+		}
+	}
+	sub.Secret = arg.Secret
+	sub.LeaseExpiresAt = arg.LeaseExpiresAt
+	s.subs[arg.TopicUrl+arg.CallbackUrl] = sub
+
+	return sub, nil
+}
+
+func (s *fakeHubStore) DeleteHubSubscription(_ context.Context, arg database.DeleteHubSubscriptionParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subs, arg.TopicUrl+arg.CallbackUrl)
+
+	return nil
+}
+
+func (s *fakeHubStore) ListHubSubscriptionsForPost(_ context.Context, _ int32) ([]database.ListHubSubscriptionsForPostRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rows []database.ListHubSubscriptionsForPostRow
+	for _, sub := range s.subs {
+		rows = append(rows, database.ListHubSubscriptionsForPostRow{HubSubscription: sub, User: s.user})
+	}
+
+	return rows, nil
+}
+
+func (s *fakeHubStore) DeleteExpiredHubSubscriptions(_ context.Context, expiredAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for key, sub := range s.subs {
+		if !sub.LeaseExpiresAt.After(expiredAt) {
+			delete(s.subs, key)
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (s *fakeHubStore) get(topic, callback string) (database.HubSubscription, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subs[topic+callback]
+	return sub, ok
+}
+
+// fakeFeedTokenStore authenticates a single user.
+type fakeFeedTokenStore struct {
+	user database.User
+}
+
+func (s fakeFeedTokenStore) GetUserFromFeedToken(_ context.Context, arg database.GetUserFromFeedTokenParams) (database.User, error) {
+	if arg.ID != s.user.ID || arg.FeedToken != s.user.FeedToken {
+		return database.User{}, sql.ErrNoRows
+	}
+
+	return s.user, nil
+}
+
+// newSubscriberServer starts a subscriber confirming the verifications when confirm is set.
+// It records the requests, and the bodies of the pushed content.
+func newSubscriberServer(t *testing.T, confirm bool) (*httptest.Server, chan *http.Request, chan []byte) {
+	t.Helper()
+
+	requests := make(chan *http.Request, 10)
+	contents := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requests <- r
+			if !confirm {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = io.WriteString(w, r.URL.Query().Get("hub.challenge"))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests <- r
+		contents <- body
+	}))
+	t.Cleanup(server.Close)
+
+	return server, requests, contents
+}
+
+func TestHub_Request(t *testing.T) {
+	user := database.User{ID: uuid.New(), Name: "john", FeedToken: "token"}
+	topicURL := "https://rss.example.com/v1/users/" + user.ID.String() + "/feed.atom?token=token"
+
+	tests := []struct {
+		name    string
+		req     HubRequest
+		wantErr string
+	}{
+		{
+			name:    "invalid mode",
+			req:     HubRequest{Mode: "publish", Topic: topicURL, Callback: "https://reader.example.com/cb"},
+			wantErr: "invalid mode",
+		},
+		{
+			name:    "invalid callback",
+			req:     HubRequest{Mode: ModeSubscribe, Topic: topicURL, Callback: "/cb"},
+			wantErr: "invalid callback",
+		},
+		{
+			name:    "other server",
+			req:     HubRequest{Mode: ModeSubscribe, Topic: "https://other.example.com/v1/users/" + user.ID.String() + "/feed.atom?token=token", Callback: "https://reader.example.com/cb"},
+			wantErr: "unknown topic",
+		},
+		{
+			name:    "not a timeline",
+			req:     HubRequest{Mode: ModeSubscribe, Topic: "https://rss.example.com/v1/feeds", Callback: "https://reader.example.com/cb"},
+			wantErr: "unknown topic",
+		},
+		{
+			name:    "unknown format",
+			req:     HubRequest{Mode: ModeSubscribe, Topic: "https://rss.example.com/v1/users/" + user.ID.String() + "/feed.csv?token=token", Callback: "https://reader.example.com/cb"},
+			wantErr: "unknown topic",
+		},
+		{
+			name:    "invalid token",
+			req:     HubRequest{Mode: ModeSubscribe, Topic: "https://rss.example.com/v1/users/" + user.ID.String() + "/feed.atom?token=other", Callback: "https://reader.example.com/cb"},
+			wantErr: "unknown topic",
+		},
+	}
+
+	hub := NewHub(newFakeHubStore(user), fakeFeedTokenStore{user: user}, http.DefaultClient, "https://rss.example.com/")
+	for _, test := range tests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			err := hub.Request(context.Background(), tc.req)
+			require.ErrorIs(t, err, ErrInvalidRequest)
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestHub_Subscribe(t *testing.T) {
+	user := database.User{ID: uuid.New(), Name: "john", FeedToken: "token"}
+	folderID := uuid.New()
+	topicURL := "https://rss.example.com/v1/users/" + user.ID.String() + "/feed.rss?token=token&folder_id=" + folderID.String()
+	store := newFakeHubStore(user)
+	hub := NewHub(store, fakeFeedTokenStore{user: user}, http.DefaultClient, "https://rss.example.com")
+	// The subscriber listens on the loopback address.
+	hub.checkCallback = func(context.Context, string) error { return nil }
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Start(ctx)
+
+	subscriber, verifications, _ := newSubscriberServer(t, true)
+	callback := subscriber.URL + "/cb?id=1"
+	require.NoError(t, hub.Request(context.Background(), HubRequest{
+		Mode:     ModeSubscribe,
+		Topic:    topicURL,
+		Callback: callback,
+		Secret:   "secret",
+		Lease:    time.Minute,
+	}))
+
+	r := <-verifications
+	query := r.URL.Query()
+	assert.Equal(t, "1", query.Get("id"))
+	assert.Equal(t, ModeSubscribe, query.Get("hub.mode"))
+	assert.Equal(t, topicURL, query.Get("hub.topic"))
+	assert.NotEmpty(t, query.Get("hub.challenge"))
+	// The lease is at least an hour.
+	assert.Equal(t, "3600", query.Get("hub.lease_seconds"))
+
+	require.Eventually(t, func() bool {
+		_, ok := store.get(topicURL, callback)
+		return ok
+	}, time.Second, 10*time.Millisecond)
+	sub, _ := store.get(topicURL, callback)
+	assert.Equal(t, user.ID, sub.UserID)
+	assert.Equal(t, "rss", sub.Format)
+	assert.Equal(t, uuid.NullUUID{UUID: folderID, Valid: true}, sub.FolderID)
+	assert.False(t, sub.FeedID.Valid)
+	assert.Equal(t, "secret", sub.Secret)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), sub.LeaseExpiresAt, time.Minute)
+
+	// The unsubscription is verified as well.
+	require.NoError(t, hub.Request(context.Background(), HubRequest{Mode: ModeUnsubscribe, Topic: topicURL, Callback: callback}))
+	r = <-verifications
+	assert.Equal(t, ModeUnsubscribe, r.URL.Query().Get("hub.mode"))
+	require.Eventually(t, func() bool {
+		_, ok := store.get(topicURL, callback)
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestHub_Request_InternalCallback(t *testing.T) {
+	user := database.User{ID: uuid.New(), Name: "john", FeedToken: "token"}
+	topicURL := "https://rss.example.com/v1/users/" + user.ID.String() + "/feed.atom?token=token"
+	hub := NewHub(newFakeHubStore(user), fakeFeedTokenStore{user: user}, http.DefaultClient, "https://rss.example.com")
+
+	for _, callback := range []string{"http://127.0.0.1:8080/cb", "http://localhost/cb", "http://169.254.169.254/latest/meta-data"} {
+		err := hub.Request(context.Background(), HubRequest{Mode: ModeSubscribe, Topic: topicURL, Callback: callback})
+		require.ErrorIs(t, err, ErrInvalidRequest, callback)
+		assert.ErrorIs(t, err, netguard.ErrForbiddenAddress, callback)
+	}
+}
+
+func TestHub_Request_Busy(t *testing.T) {
+	user := database.User{ID: uuid.New(), Name: "john", FeedToken: "token"}
+	topicURL := "https://rss.example.com/v1/users/" + user.ID.String() + "/feed.atom?token=token"
+	hub := NewHub(newFakeHubStore(user), fakeFeedTokenStore{user: user}, http.DefaultClient, "https://rss.example.com")
+	hub.checkCallback = func(context.Context, string) error { return nil }
+
+	// The hub is not started, the verifications are queued until the queue is full.
+	req := HubRequest{Mode: ModeSubscribe, Topic: topicURL, Callback: "https://reader.example.com/cb"}
+	for range hubVerificationQueueSize {
+		require.NoError(t, hub.Request(context.Background(), req))
+	}
+	assert.ErrorIs(t, hub.Request(context.Background(), req), ErrHubBusy)
+}
+
+func TestHub_verify_NotConfirmed(t *testing.T) {
+	user := database.User{ID: uuid.New(), Name: "john", FeedToken: "token"}
+	topicURL := "https://rss.example.com/v1/users/" + user.ID.String() + "/feed.json?token=token"
+	store := newFakeHubStore(user)
+	hub := NewHub(store, fakeFeedTokenStore{user: user}, http.DefaultClient, "https://rss.example.com")
+
+	subscriber, _, _ := newSubscriberServer(t, false)
+	req := HubRequest{Mode: ModeSubscribe, Topic: topicURL, Callback: subscriber.URL}
+	err := hub.verify(context.Background(), req, topic{user: user, format: "json"})
+	assert.ErrorContains(t, err, "not confirmed")
+	_, ok := store.get(topicURL, subscriber.URL)
+	assert.False(t, ok)
+}
+
+func TestHub_Distribute(t *testing.T) {
+	user := database.User{ID: uuid.New(), Name: "john", FeedToken: "token"}
+	topicURL := "https://rss.example.com/v1/users/" + user.ID.String() + "/feed.atom?token=token"
+	store := newFakeHubStore(user)
+	hub := NewHub(store, fakeFeedTokenStore{user: user}, http.DefaultClient, "https://rss.example.com")
+
+	subscriber, requests, contents := newSubscriberServer(t, true)
+	_, err := store.UpsertHubSubscription(context.Background(), database.UpsertHubSubscriptionParams{
+		UserID:         user.ID,
+		TopicUrl:       topicURL,
+		Format:         "atom",
+		CallbackUrl:    subscriber.URL,
+		Secret:         "secret",
+		LeaseExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	posts := []database.Post{
+		{ID: 1, Title: "Old", Url: "https://blog.example.com/old", PublishedAt: now.Add(-time.Hour)},
+		{ID: 2, Title: "New", Url: "https://blog.example.com/new", PublishedAt: now},
+	}
+	require.NoError(t, hub.Distribute(context.Background(), posts))
+
+	// The posts are pushed in a single document, signed with the secret of the subscriber.
+	r := <-requests
+	body := <-contents
+	assert.Empty(t, contents)
+	assert.Equal(t, "application/atom+xml; charset=utf-8", r.Header.Get("Content-Type"))
+	assert.True(t, VerifySignature("secret", r.Header.Get("X-Hub-Signature"), body))
+	assert.Equal(t, []string{
+		`<https://rss.example.com/v1/websub/hub>; rel="hub"`,
+		`<` + topicURL + `>; rel="self"`,
+	}, r.Header.Values("Link"))
+
+	var doc struct {
+		ID      string `xml:"id"`
+		Entries []struct {
+			Title string `xml:"title"`
+		} `xml:"entry"`
+	}
+	require.NoError(t, xml.Unmarshal(body, &doc))
+	assert.Equal(t, topicURL, doc.ID)
+	require.Len(t, doc.Entries, 2)
+	assert.Equal(t, "New", doc.Entries[0].Title)
+	assert.Equal(t, "Old", doc.Entries[1].Title)
+}
+
+func TestHub_Distribute_Gone(t *testing.T) {
+	user := database.User{ID: uuid.New(), Name: "john"}
+	store := newFakeHubStore(user)
+	hub := NewHub(store, fakeFeedTokenStore{user: user}, http.DefaultClient, "https://rss.example.com")
+
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer subscriber.Close()
+
+	topicURL := "https://rss.example.com/v1/users/" + user.ID.String() + "/feed.rss?token=token"
+	_, err := store.UpsertHubSubscription(context.Background(), database.UpsertHubSubscriptionParams{
+		UserID:         user.ID,
+		TopicUrl:       topicURL,
+		Format:         "rss",
+		CallbackUrl:    subscriber.URL,
+		LeaseExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, hub.Distribute(context.Background(), []database.Post{{ID: 1, Url: "https://blog.example.com/post"}}))
+	_, ok := store.get(topicURL, subscriber.URL)
+	assert.False(t, ok)
+}
+
+func TestHub_Purge(t *testing.T) {
+	user := database.User{ID: uuid.New(), Name: "john"}
+	store := newFakeHubStore(user)
+	hub := NewHub(store, fakeFeedTokenStore{user: user}, http.DefaultClient, "https://rss.example.com")
+
+	ctx := context.Background()
+	for callback, expiresAt := range map[string]time.Time{
+		"https://reader.example.com/expired": time.Now().Add(-time.Minute),
+		"https://reader.example.com/valid":   time.Now().Add(time.Hour),
+	} {
+		_, err := store.UpsertHubSubscription(ctx, database.UpsertHubSubscriptionParams{
+			TopicUrl:       "https://rss.example.com/v1/users/1/feed.rss",
+			CallbackUrl:    callback,
+			LeaseExpiresAt: expiresAt,
+		})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, hub.Purge(ctx))
+	_, ok := store.get("https://rss.example.com/v1/users/1/feed.rss", "https://reader.example.com/expired")
+	assert.False(t, ok)
+	_, ok = store.get("https://rss.example.com/v1/users/1/feed.rss", "https://reader.example.com/valid")
+	assert.True(t, ok)
+}
+
+func TestSign(t *testing.T) {
+	body := []byte("<feed></feed>")
+	assert.True(t, VerifySignature("secret", Sign("secret", body), body))
+	assert.False(t, VerifySignature("other", Sign("secret", body), body))
+}
@@ -0,0 +1,299 @@
+// Package websub implements the WebSub protocol (https://www.w3.org/TR/websub/)
+// to receive the new posts of the feeds from their hubs,
+// and to push the new posts of the timeline feeds of the users as their hub.
+package websub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+)
+
+// Modes of the hub requests.
+const (
+	ModeSubscribe   = "subscribe"
+	ModeUnsubscribe = "unsubscribe"
+	ModeDenied      = "denied"
+)
+
+// States of a subscription.
+const (
+	StatePending = "pending"
+	StateActive  = "active"
+	StateDenied  = "denied"
+)
+
+const (
+	// leaseSeconds is the lease requested to the hubs, they can grant another one.
+	leaseSeconds = 7 * 24 * 60 * 60
+	// renewMargin is the time before the expiration of a lease when the subscription is renewed.
+	renewMargin = 24 * time.Hour
+	// retryDelay is the time after which a subscription not verified by its hub is requested again.
+	retryDelay = time.Hour
+)
+
+// SubscriberStore represents a store of the WebSub subscriptions.
+type SubscriberStore interface {
+	GetWebSubSubscription(ctx context.Context, feedID uuid.UUID) (database.GetWebSubSubscriptionRow, error)
+	UpsertWebSubSubscription(ctx context.Context, arg database.UpsertWebSubSubscriptionParams) (database.WebSubSubscription, error)
+	ListWebSubSubscriptionsToRenew(ctx context.Context, arg database.ListWebSubSubscriptionsToRenewParams) ([]database.WebSubSubscription, error)
+	ListDisabledWebSubSubscriptions(ctx context.Context) ([]database.WebSubSubscription, error)
+	DeleteWebSubSubscription(ctx context.Context, feedID uuid.UUID) error
+}
+
+// Subscriber subscribes the feeds to their hubs and renews the leases.
+// The hubs verify the subscriptions and push the content to the callback of each feed.
+type Subscriber struct {
+	store    SubscriberStore
+	client   *http.Client
+	baseURL  string
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewSubscriber returns a new subscriber.
+// The base url is the public url of the server, the callbacks are relative to it.
+func NewSubscriber(store SubscriberStore, client *http.Client, baseURL string) *Subscriber {
+	return &Subscriber{
+		store:    store,
+		client:   client,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		interval: time.Hour,
+		now:      time.Now,
+	}
+}
+
+// CallbackURL returns the callback url of a feed.
+func (s *Subscriber) CallbackURL(feedID uuid.UUID) string {
+	return s.baseURL + "/v1/websub/" + feedID.String()
+}
+
+// Discover subscribes a feed to the hub it advertises.
+// Nothing is done when the feed is disabled,
+// or when it is already subscribed to the hub and its lease is not about to expire.
+func (s *Subscriber) Discover(ctx context.Context, feed database.Feed, hubURL, topicURL string) error {
+	if feed.DisabledAt.Valid {
+		return nil
+	}
+
+	current, err := s.store.GetWebSubSubscription(ctx, feed.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err == nil {
+		sub := current.WebSubSubscription
+		if sub.HubUrl == hubURL && sub.TopicUrl == topicURL && !s.needsRenewal(sub) {
+			return nil
+		}
+	}
+
+	return s.Subscribe(ctx, feed.ID, hubURL, topicURL)
+}
+
+// needsRenewal reports whether a subscription must be requested again.
+func (s *Subscriber) needsRenewal(sub database.WebSubSubscription) bool {
+	switch sub.State {
+	case StateActive:
+		return !sub.LeaseExpiresAt.Valid || sub.LeaseExpiresAt.Time.Before(s.now().Add(renewMargin))
+	case StatePending:
+		return sub.RequestedAt.Before(s.now().Add(-retryDelay))
+	default:
+		// The hub has denied the subscription, it is not requested again for the same topic.
+		return false
+	}
+}
+
+// Subscribe records the subscription of a feed and requests it to the hub.
+// The subscription is active once the hub has verified it on the callback.
+func (s *Subscriber) Subscribe(ctx context.Context, feedID uuid.UUID, hubURL, topicURL string) error {
+	secret, err := generateSecret()
+	if err != nil {
+		return err
+	}
+
+	// The secret of an existing subscription is kept.
+	sub, err := s.store.UpsertWebSubSubscription(ctx, database.UpsertWebSubSubscriptionParams{
+		FeedID:   feedID,
+		HubUrl:   hubURL,
+		TopicUrl: topicURL,
+		Secret:   secret,
+	})
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{
+		"hub.mode":          {ModeSubscribe},
+		"hub.topic":         {sub.TopicUrl},
+		"hub.callback":      {s.CallbackURL(feedID)},
+		"hub.secret":        {sub.Secret},
+		"hub.lease_seconds": {strconv.Itoa(leaseSeconds)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.HubUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("create subscription request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send subscription request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<10))
+
+	// The hubs accept the request with 202, and verify it asynchronously.
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("subscription to %s rejected with status %d", sub.HubUrl, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Unsubscribe deletes the subscription of a feed and requests its hub to stop pushing the content.
+// The subscription is deleted first, so that the callback confirms the unsubscription to the hub.
+func (s *Subscriber) Unsubscribe(ctx context.Context, sub database.WebSubSubscription) error {
+	if err := s.store.DeleteWebSubSubscription(ctx, sub.FeedID); err != nil {
+		return err
+	}
+
+	form := url.Values{
+		"hub.mode":     {ModeUnsubscribe},
+		"hub.topic":    {sub.TopicUrl},
+		"hub.callback": {s.CallbackURL(sub.FeedID)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.HubUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("create unsubscription request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send unsubscription request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unsubscription from %s rejected with status %d", sub.HubUrl, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Start renews the subscriptions about to expire, and requests again the ones not verified, until the context is done.
+func (s *Subscriber) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Renew(ctx); err != nil {
+			slog.Log(ctx, slog.LevelError, "renew websub subscriptions", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Renew requests again the subscriptions about to expire, and the ones not verified by their hub.
+// The feeds disabled since their subscription are unsubscribed from their hub.
+func (s *Subscriber) Renew(ctx context.Context) error {
+	now := s.now()
+	subs, err := s.store.ListWebSubSubscriptionsToRenew(ctx, database.ListWebSubSubscriptionsToRenewParams{
+		ExpiresBefore:   now.Add(renewMargin),
+		RequestedBefore: now.Add(-retryDelay),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		subCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err := s.Subscribe(subCtx, sub.FeedID, sub.HubUrl, sub.TopicUrl)
+		cancel()
+		if err != nil {
+			slog.Log(ctx, slog.LevelError, "renew websub subscription", "feed_id", sub.FeedID, "error", err)
+		}
+	}
+
+	disabled, err := s.store.ListDisabledWebSubSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range disabled {
+		subCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err := s.Unsubscribe(subCtx, sub)
+		cancel()
+		if err != nil {
+			slog.Log(ctx, slog.LevelError, "unsubscribe websub subscription", "feed_id", sub.FeedID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// VerifySignature reports whether the X-Hub-Signature header, 'method=signature', is the HMAC of the body.
+// The sha1, sha256, sha384 and sha512 methods are supported.
+func VerifySignature(secret, header string, body []byte) bool {
+	method, signature, ok := strings.Cut(header, "=")
+	if !ok {
+		return false
+	}
+
+	var h func() hash.Hash
+	switch method {
+	case "sha1":
+		h = sha1.New
+	case "sha256":
+		h = sha256.New
+	case "sha384":
+		h = sha512.New384
+	case "sha512":
+		h = sha512.New
+	default:
+		return false
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+
+	return hmac.Equal(expected, mac.Sum(nil))
+}
+
+// generateSecret returns a random hex encoded secret.
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate secret: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,248 @@
+package websub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSubscriberStore keeps the subscriptions, and the disabled feeds, in memory.
+type fakeSubscriberStore struct {
+	mu       sync.Mutex
+	subs     map[uuid.UUID]database.WebSubSubscription
+	disabled map[uuid.UUID]bool
+}
+
+func newFakeSubscriberStore() *fakeSubscriberStore {
+	return &fakeSubscriberStore{
+		subs:     make(map[uuid.UUID]database.WebSubSubscription),
+		disabled: make(map[uuid.UUID]bool),
+	}
+}
+
+func (s *fakeSubscriberStore) GetWebSubSubscription(_ context.Context, feedID uuid.UUID) (database.GetWebSubSubscriptionRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subs[feedID]
+	if !ok {
+		return database.GetWebSubSubscriptionRow{}, sql.ErrNoRows
+	}
+
+	return database.GetWebSubSubscriptionRow{WebSubSubscription: sub, Feed: database.Feed{ID: feedID}}, nil
+}
+
+func (s *fakeSubscriberStore) UpsertWebSubSubscription(_ context.Context, arg database.UpsertWebSubSubscriptionParams) (database.WebSubSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subs[arg.FeedID]
+	if !ok {
+		sub = database.WebSubSubscription{FeedID: arg.FeedID, Secret: arg.Secret, State: StatePending}
+	}
+	if sub.HubUrl != arg.HubUrl || sub.TopicUrl != arg.TopicUrl {
+		sub.State = StatePending
+	}
+	sub.HubUrl = arg.HubUrl
+	sub.TopicUrl = arg.TopicUrl
+	sub.RequestedAt = time.Now()
+	s.subs[arg.FeedID] = sub
+
+	return sub, nil
+}
+
+func (s *fakeSubscriberStore) ListWebSubSubscriptionsToRenew(_ context.Context, arg database.ListWebSubSubscriptionsToRenewParams) ([]database.WebSubSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var subs []database.WebSubSubscription
+	for _, sub := range s.subs {
+		if s.disabled[sub.FeedID] {
+			continue
+		}
+		if (sub.State == StateActive && sub.LeaseExpiresAt.Time.Before(arg.ExpiresBefore)) ||
+			(sub.State == StatePending && sub.RequestedAt.Before(arg.RequestedBefore)) {
+			subs = append(subs, sub)
+		}
+	}
+
+	return subs, nil
+}
+
+func (s *fakeSubscriberStore) ListDisabledWebSubSubscriptions(_ context.Context) ([]database.WebSubSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var subs []database.WebSubSubscription
+	for _, sub := range s.subs {
+		if s.disabled[sub.FeedID] {
+			subs = append(subs, sub)
+		}
+	}
+
+	return subs, nil
+}
+
+func (s *fakeSubscriberStore) DeleteWebSubSubscription(_ context.Context, feedID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subs, feedID)
+
+	return nil
+}
+
+// newHub starts a hub recording the subscription requests.
+func newHub(t *testing.T) (*httptest.Server, chan url.Values) {
+	t.Helper()
+
+	requests := make(chan url.Values, 10)
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		requests <- r.PostForm
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(hub.Close)
+
+	return hub, requests
+}
+
+func TestSubscriber_Discover(t *testing.T) {
+	hub, requests := newHub(t)
+	store := newFakeSubscriberStore()
+	subscriber := NewSubscriber(store, hub.Client(), "https://rss.example.com/")
+
+	feed := database.Feed{ID: uuid.New(), Url: "https://blog.example.com/feed.xml"}
+	ctx := context.Background()
+	require.NoError(t, subscriber.Discover(ctx, feed, hub.URL, feed.Url))
+
+	form := <-requests
+	assert.Equal(t, ModeSubscribe, form.Get("hub.mode"))
+	assert.Equal(t, feed.Url, form.Get("hub.topic"))
+	assert.Equal(t, "https://rss.example.com/v1/websub/"+feed.ID.String(), form.Get("hub.callback"))
+	assert.Equal(t, store.subs[feed.ID].Secret, form.Get("hub.secret"))
+	assert.Equal(t, "604800", form.Get("hub.lease_seconds"))
+
+	// A pending subscription is not requested again until its retry delay.
+	require.NoError(t, subscriber.Discover(ctx, feed, hub.URL, feed.Url))
+	assert.Empty(t, requests)
+
+	// An active subscription is not requested again until it is about to expire.
+	sub := store.subs[feed.ID]
+	sub.State = StateActive
+	sub.LeaseExpiresAt = sql.NullTime{Time: time.Now().Add(3 * 24 * time.Hour), Valid: true}
+	store.subs[feed.ID] = sub
+	require.NoError(t, subscriber.Discover(ctx, feed, hub.URL, feed.Url))
+	assert.Empty(t, requests)
+
+	// A new hub is subscribed, with the same secret.
+	other, otherRequests := newHub(t)
+	require.NoError(t, subscriber.Discover(ctx, feed, other.URL, feed.Url))
+	form = <-otherRequests
+	assert.Equal(t, sub.Secret, form.Get("hub.secret"))
+	assert.Equal(t, StatePending, store.subs[feed.ID].State)
+
+	// A disabled feed is not subscribed.
+	disabled := database.Feed{
+		ID:         uuid.New(),
+		Url:        "https://blog.example.com/disabled.xml",
+		DisabledAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}
+	require.NoError(t, subscriber.Discover(ctx, disabled, hub.URL, disabled.Url))
+	assert.Empty(t, requests)
+	assert.NotContains(t, store.subs, disabled.ID)
+}
+
+func TestSubscriber_Subscribe_Rejected(t *testing.T) {
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unknown topic", http.StatusBadRequest)
+	}))
+	defer hub.Close()
+
+	subscriber := NewSubscriber(newFakeSubscriberStore(), hub.Client(), "https://rss.example.com")
+	err := subscriber.Subscribe(context.Background(), uuid.New(), hub.URL, "https://blog.example.com/feed.xml")
+	assert.ErrorContains(t, err, "status 400")
+}
+
+func TestSubscriber_Renew(t *testing.T) {
+	hub, requests := newHub(t)
+	store := newFakeSubscriberStore()
+	subscriber := NewSubscriber(store, hub.Client(), "https://rss.example.com")
+
+	now := time.Now()
+	expiring := database.WebSubSubscription{
+		FeedID:         uuid.New(),
+		HubUrl:         hub.URL,
+		TopicUrl:       "https://blog.example.com/expiring.xml",
+		State:          StateActive,
+		LeaseExpiresAt: sql.NullTime{Time: now.Add(time.Hour), Valid: true},
+	}
+	valid := database.WebSubSubscription{
+		FeedID:         uuid.New(),
+		HubUrl:         hub.URL,
+		TopicUrl:       "https://blog.example.com/valid.xml",
+		State:          StateActive,
+		LeaseExpiresAt: sql.NullTime{Time: now.Add(5 * 24 * time.Hour), Valid: true},
+	}
+	store.subs[expiring.FeedID] = expiring
+	store.subs[valid.FeedID] = valid
+
+	require.NoError(t, subscriber.Renew(context.Background()))
+
+	form := <-requests
+	assert.Equal(t, expiring.TopicUrl, form.Get("hub.topic"))
+	assert.Empty(t, requests)
+}
+
+func TestSubscriber_Renew_Disabled(t *testing.T) {
+	hub, requests := newHub(t)
+	store := newFakeSubscriberStore()
+	subscriber := NewSubscriber(store, hub.Client(), "https://rss.example.com")
+
+	// The subscription of a disabled feed is not renewed, the feed is unsubscribed from its hub.
+	sub := database.WebSubSubscription{
+		FeedID:         uuid.New(),
+		HubUrl:         hub.URL,
+		TopicUrl:       "https://blog.example.com/disabled.xml",
+		State:          StateActive,
+		LeaseExpiresAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	}
+	store.subs[sub.FeedID] = sub
+	store.disabled[sub.FeedID] = true
+
+	require.NoError(t, subscriber.Renew(context.Background()))
+
+	form := <-requests
+	assert.Equal(t, ModeUnsubscribe, form.Get("hub.mode"))
+	assert.Equal(t, sub.TopicUrl, form.Get("hub.topic"))
+	assert.Equal(t, "https://rss.example.com/v1/websub/"+sub.FeedID.String(), form.Get("hub.callback"))
+	assert.Empty(t, requests)
+	assert.NotContains(t, store.subs, sub.FeedID)
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte("<rss></rss>")
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	assert.True(t, VerifySignature("secret", "sha256="+signature, body))
+	assert.False(t, VerifySignature("other", "sha256="+signature, body))
+	assert.False(t, VerifySignature("secret", "sha256="+signature, []byte("<rss/>")))
+	assert.False(t, VerifySignature("secret", "md5="+signature, body))
+	assert.False(t, VerifySignature("secret", "", body))
+	assert.False(t, VerifySignature("secret", "sha256=zz", body))
+}
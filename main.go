@@ -1,17 +1,33 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"crypto"
 	"database/sql"
 	"log"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
+	// The time zones of the digests are available even when the system has no tz database.
+	_ "time/tzdata"
 
+	"github.com/google/uuid"
 	"github.com/jbdoumenjou/go-rssaggregator/internal/api"
 	"github.com/jbdoumenjou/go-rssaggregator/internal/api/handler"
 	"github.com/jbdoumenjou/go-rssaggregator/internal/api/middleware"
 	"github.com/jbdoumenjou/go-rssaggregator/internal/api/scrapper"
 	"github.com/jbdoumenjou/go-rssaggregator/internal/database"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/digest"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/jwt"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/netguard"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/oidc"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/pubsub"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/token"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/webhook"
+	"github.com/jbdoumenjou/go-rssaggregator/internal/websub"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
@@ -44,20 +60,181 @@ func main() {
 	userRepository := database.NewUserRepository(db)
 	feedRepository := database.NewFeedRepository(db)
 	postRepository := database.NewPostRepository(db)
+	filterRuleRepository := database.NewFilterRuleRepository(db)
+	webhookRepository := database.NewWebhookRepository(db)
+	webSubRepository := database.NewWebSubRepository(db)
 
-	authHandler := middleware.NewAuthMiddleware(userRepository)
+	// The posts created by the fetcher are broadcast to the streams of the API.
+	broker := pubsub.NewBroker(100)
+
+	// The first admins are promoted at the start, then they can promote the other users.
+	for _, value := range strings.Split(os.Getenv("ADMIN_USER_IDS"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		id, err := uuid.Parse(value)
+		if err != nil {
+			log.Fatalf("invalid ADMIN_USER_IDS: %q", value)
+		}
+		if _, err := userRepository.SetUserRole(context.Background(), database.SetUserRoleParams{
+			ID:   id,
+			Role: middleware.RoleAdmin,
+		}); err != nil {
+			log.Printf("cannot promote the admin %s: %v\n", id, err)
+		}
+	}
+
+	// The access tokens are only issued when a signing key is configured,
+	// a secret for HS256 or a private key file for RS256, ES256 or EdDSA.
+	var authOpts []middleware.AuthOption
+	var tokenIssuer *token.Issuer
+	if secret, keyFile := os.Getenv("JWT_SECRET"), os.Getenv("JWT_PRIVATE_KEY_FILE"); secret != "" || keyFile != "" {
+		issuerURL := cmp.Or(os.Getenv("JWT_ISSUER"), os.Getenv("BASE_URL"))
+		if issuerURL == "" {
+			log.Fatal("JWT_ISSUER or BASE_URL env variable not set")
+		}
+
+		alg, key := jwt.HS256, crypto.PrivateKey([]byte(secret))
+		if keyFile != "" {
+			data, err := os.ReadFile(keyFile)
+			if err != nil {
+				log.Fatal("cannot read JWT_PRIVATE_KEY_FILE:", err)
+			}
+			if key, err = token.ParsePrivateKey(data); err != nil {
+				log.Fatal("invalid JWT_PRIVATE_KEY_FILE:", err)
+			}
+			if alg, err = token.Algorithm(key); err != nil {
+				log.Fatal("invalid JWT_PRIVATE_KEY_FILE:", err)
+			}
+		}
+
+		tokenIssuer, err = token.NewIssuer(issuerURL, alg, key)
+		if err != nil {
+			log.Fatal("cannot create the access token issuer:", err)
+		}
+		authOpts = append(authOpts, middleware.WithAccessTokens(tokenIssuer))
+	} else {
+		log.Printf("JWT_SECRET and JWT_PRIVATE_KEY_FILE env variables not set, the access tokens are disabled\n")
+	}
+
+	authHandler := middleware.NewAuthMiddleware(userRepository, authOpts...)
 	userHandler := handler.NewUserHandler(userRepository)
 	feedHandler := handler.NewFeedHandler(feedRepository)
 	feedFollowsHandler := handler.NewFeedFollowsHandler(feedRepository)
 	postHandler := handler.NewPostHandler(postRepository)
+	filterRuleHandler := handler.NewFilterRuleHandler(filterRuleRepository)
+	streamHandler := handler.NewStreamHandler(postRepository, broker)
+	webSocketHandler := handler.NewWebSocketHandler(postRepository, broker)
+	webhookHandler := handler.NewWebhookHandler(webhookRepository)
+
+	// The deliveries of the webhooks are enqueued by the fetcher, and sent in the background.
+	// The urls are given by the users, the client does not connect to the internal network.
+	dispatcher := webhook.NewDispatcher(webhookRepository, netguard.NewClient(10*time.Second))
+	go dispatcher.Start(context.Background())
+
+	// The hubs push the content of the feeds to the server, so it must be reachable from them at its base url.
+	// The server is also the hub of the timeline feeds, pushing them the new posts.
+	baseURL := os.Getenv("BASE_URL")
+	publishers := scrapper.Publishers{broker, dispatcher}
+	var fetcherOpts []scrapper.Option
+	var hub *websub.Hub
+	hubURL := ""
+	if baseURL != "" {
+		// The hubs are advertised by the feed documents, the client does not connect to the internal network.
+		subscriber := websub.NewSubscriber(webSubRepository, netguard.NewClient(10*time.Second), baseURL)
+		go subscriber.Start(context.Background())
+		fetcherOpts = append(fetcherOpts, scrapper.WithHubSubscriber(subscriber))
+
+		hub = websub.NewHub(webSubRepository, userRepository, netguard.NewClient(10*time.Second), baseURL)
+		go hub.Start(context.Background())
+		publishers = append(publishers, hub)
+		hubURL = hub.URL()
+	} else {
+		log.Printf("BASE_URL env variable not set, the WebSub subscriptions and hub are disabled\n")
+	}
+	syndicationHandler := handler.NewSyndicationHandler(userRepository, postRepository, hubURL)
 
-	fetcher := scrapper.NewFeedFetcher(feedRepository, postRepository, 50, time.Hour*24)
+	fetcher := scrapper.NewFeedFetcher(feedRepository, postRepository, publishers, 50, time.Hour*24, fetcherOpts...)
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 	go fetcher.Start(ctx)
 
+	opts := []api.Option{
+		api.WithFilterRuleHandler(filterRuleHandler),
+		api.WithSyndicationHandler(syndicationHandler),
+		api.WithStreamHandler(streamHandler),
+		api.WithWebSocketHandler(webSocketHandler),
+		api.WithWebhookHandler(webhookHandler),
+		api.WithFeverHandler(handler.NewFeverHandler(userRepository, feedRepository, postRepository)),
+		api.WithGReaderHandler(handler.NewGReaderHandler(userRepository, feedRepository, postRepository)),
+		api.WithAdminHandler(handler.NewAdminHandler(userRepository, feedRepository, postRepository, fetcher)),
+		api.WithExportHandler(handler.NewExportHandler(userRepository, feedRepository, postRepository, filterRuleRepository)),
+	}
+	if baseURL != "" {
+		opts = append(opts,
+			api.WithWebSubHandler(handler.NewWebSubHandler(webSubRepository, fetcher)),
+			api.WithWebSubHubHandler(handler.NewWebSubHubHandler(hub)),
+		)
+	}
+
+	if tokenIssuer != nil {
+		opts = append(opts, api.WithTokenHandler(handler.NewTokenHandler(userRepository, tokenIssuer)))
+	}
+
+	// The single sign-on is only available when an OpenID Connect provider is configured.
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+		config := oidc.Config{
+			Issuer:       issuer,
+			ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+			Scopes:       []string{"email", "profile"},
+		}
+		if config.ClientID == "" {
+			log.Fatal("OIDC_CLIENT_ID env variable not set")
+		}
+		if config.RedirectURL == "" {
+			if baseURL == "" {
+				log.Fatal("OIDC_REDIRECT_URL or BASE_URL env variable not set")
+			}
+			config.RedirectURL = strings.TrimSuffix(baseURL, "/") + "/v1/oidc/callback"
+		}
+
+		discoverCtx, cancelDiscover := context.WithTimeout(context.Background(), 10*time.Second)
+		provider, err := oidc.Discover(discoverCtx, &http.Client{Timeout: 10 * time.Second}, config)
+		cancelDiscover()
+		if err != nil {
+			log.Fatal("cannot discover the OpenID Connect provider:", err)
+		}
+		opts = append(opts, api.WithOIDCHandler(handler.NewOIDCHandler(userRepository, provider, os.Getenv("OIDC_LOGIN_REDIRECT_URL"))))
+	} else {
+		log.Printf("OIDC_ISSUER env variable not set, the single sign-on is disabled\n")
+	}
+
+	// The email digests are only available when an SMTP server is configured.
+	if smtpHost := os.Getenv("SMTP_HOST"); smtpHost != "" {
+		smtpPort := 25
+		if value := os.Getenv("SMTP_PORT"); value != "" {
+			smtpPort, err = strconv.Atoi(value)
+			if err != nil {
+				log.Fatalf("invalid SMTP_PORT: %q", value)
+			}
+		}
+		smtpFrom := os.Getenv("SMTP_FROM")
+		if smtpFrom == "" {
+			log.Fatal("SMTP_FROM env variable not set")
+		}
+
+		digestRepository := database.NewDigestRepository(db)
+		mailer := digest.NewSMTPMailer(smtpHost, smtpPort, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), smtpFrom)
+		go digest.NewSender(digestRepository, mailer).Start(context.Background())
+		opts = append(opts, api.WithDigestHandler(handler.NewDigestHandler(digestRepository)))
+	} else {
+		log.Printf("SMTP_HOST env variable not set, the email digests are disabled\n")
+	}
+
 	// Create a new router.
-	r := api.NewRouter(authHandler, userHandler, feedHandler, feedFollowsHandler, postHandler)
+	r := api.NewRouter(authHandler, userHandler, feedHandler, feedFollowsHandler, postHandler, opts...)
 
 	// start the server.
 	if err := api.NewServer("localhost:"+port, r).Start(); err != nil {
@@ -0,0 +1,61 @@
+-- name: CreateApiKey :one
+-- The API key is only returned at the creation, its hash is stored with its prefix.
+WITH key AS (
+    SELECT encode(sha256(convert_to(gen_random_uuid()::text || gen_random_uuid()::text, 'UTF8')), 'hex')::text AS api_key
+), k AS (
+    INSERT INTO api_keys (user_id, name, prefix, hash, fever_key, scopes, expires_at)
+    SELECT users.id, sqlc.arg(name)::text,
+        left(key.api_key, 8),
+        encode(sha256(convert_to(key.api_key, 'UTF8')), 'hex'),
+        encode(sha256(convert_to(md5(users.id::text || ':' || key.api_key), 'UTF8')), 'hex'),
+        sqlc.arg(scopes)::text[],
+        sqlc.narg(expires_at)::timestamptz
+    FROM users, key
+    WHERE users.id = sqlc.arg(user_id)
+    RETURNING *
+)
+SELECT k.id, k.name, k.prefix, k.scopes::text[] AS scopes, k.expires_at, k.created_at, key.api_key
+FROM k, key;
+
+-- name: ListApiKeys :many
+SELECT * FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: DeleteApiKey :exec
+DELETE FROM api_keys
+WHERE id = $1
+AND user_id = $2;
+
+-- name: RotateApiKey :one
+-- Replaces an API key by a new one with the same name, scopes and expiration, and returns it.
+-- The replaced key still works until the given expiration, or is deleted at once without expiration.
+WITH key AS (
+    SELECT encode(sha256(convert_to(gen_random_uuid()::text || gen_random_uuid()::text, 'UTF8')), 'hex')::text AS api_key
+), k AS (
+    INSERT INTO api_keys (user_id, name, prefix, hash, fever_key, scopes, expires_at)
+    SELECT api_keys.user_id, api_keys.name,
+        left(key.api_key, 8),
+        encode(sha256(convert_to(key.api_key, 'UTF8')), 'hex'),
+        encode(sha256(convert_to(md5(users.id::text || ':' || key.api_key), 'UTF8')), 'hex'),
+        api_keys.scopes,
+        api_keys.expires_at
+    FROM api_keys JOIN users ON users.id = api_keys.user_id, key
+    WHERE api_keys.id = sqlc.arg(id)
+    AND api_keys.user_id = sqlc.arg(user_id)
+    RETURNING *
+), revoked AS (
+    DELETE FROM api_keys
+    WHERE api_keys.id = sqlc.arg(id)
+    AND api_keys.user_id = sqlc.arg(user_id)
+    AND sqlc.narg(previous_expires_at)::timestamptz IS NULL
+), graced AS (
+    UPDATE api_keys SET
+        expires_at = LEAST(api_keys.expires_at, sqlc.narg(previous_expires_at)::timestamptz),
+        updated_at = NOW()
+    WHERE api_keys.id = sqlc.arg(id)
+    AND api_keys.user_id = sqlc.arg(user_id)
+    AND sqlc.narg(previous_expires_at)::timestamptz IS NOT NULL
+)
+SELECT k.id, k.name, k.prefix, k.scopes::text[] AS scopes, k.expires_at, k.created_at, key.api_key
+FROM k, key;
@@ -0,0 +1,61 @@
+-- name: UpsertDigestSettings :one
+INSERT INTO digest_settings (user_id, email, frequency, hour, weekday, timezone, enabled, next_send_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (user_id) DO UPDATE
+SET email = EXCLUDED.email,
+    frequency = EXCLUDED.frequency,
+    hour = EXCLUDED.hour,
+    weekday = EXCLUDED.weekday,
+    timezone = EXCLUDED.timezone,
+    enabled = EXCLUDED.enabled,
+    next_send_at = EXCLUDED.next_send_at,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetDigestSettings :one
+SELECT * FROM digest_settings
+WHERE user_id = $1;
+
+-- name: DeleteDigestSettings :exec
+DELETE FROM digest_settings
+WHERE user_id = $1;
+
+-- name: ListDueDigests :many
+SELECT sqlc.embed(digest_settings), users.name
+FROM digest_settings
+    JOIN users ON users.id = digest_settings.user_id
+WHERE digest_settings.enabled
+AND digest_settings.next_send_at <= sqlc.arg(now)
+ORDER BY digest_settings.next_send_at ASC
+LIMIT sqlc.arg('limit');
+
+-- name: ListDigestPosts :many
+-- Returns the unread posts of the timeline of a user created since a date, and not sent in a previous digest.
+-- Like the timeline, the muted feeds are skipped, and the retention and the filter rules are applied.
+-- The feeds without notifications are skipped too.
+SELECT p.*, COALESCE(ff.title, f.name)::text AS feed_title
+FROM posts p
+    JOIN feed_follows ff ON p.feed_id = ff.feed_id
+    JOIN feeds f ON f.id = ff.feed_id
+    LEFT JOIN post_reads pr ON pr.post_id = p.id AND pr.user_id = ff.user_id
+    LEFT JOIN digest_items di ON di.post_id = p.id AND di.user_id = ff.user_id
+WHERE ff.user_id = sqlc.arg(user_id)::uuid
+AND p.created_at > sqlc.arg(since)
+AND pr.post_id IS NULL
+AND di.post_id IS NULL
+AND NOT ff.muted
+AND ff.notify
+AND (ff.retention_days IS NULL OR p.published_at > NOW() - make_interval(days => ff.retention_days))
+AND post_passes_filters(ff.user_id, ff.folder_id, p)
+ORDER BY feed_title ASC, p.published_at DESC
+LIMIT sqlc.arg('limit');
+
+-- name: CreateDigestItems :exec
+INSERT INTO digest_items (user_id, post_id)
+SELECT sqlc.arg(user_id)::uuid, unnest(sqlc.arg(post_ids)::integer[])
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkDigestSent :exec
+UPDATE digest_settings
+SET last_sent_at = sqlc.arg(last_sent_at), next_send_at = sqlc.arg(next_send_at)
+WHERE user_id = sqlc.arg(user_id);
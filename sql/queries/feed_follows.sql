@@ -1,6 +1,10 @@
 -- name: CreateFeedFollows :one
+-- Nothing is returned when the feed does not exist or the user cannot follow it.
 INSERT INTO feed_follows (user_id, feed_id)
-VALUES ($1, $2)
+SELECT sqlc.narg(user_id)::uuid, feeds.id
+FROM feeds
+WHERE feeds.id = sqlc.narg(feed_id)::uuid
+AND feed_is_followable(feeds, sqlc.narg(user_id)::uuid)
 RETURNING *;
 
 -- name: DeleteFeedFollows :exec
@@ -11,6 +15,80 @@ AND user_id = $2;
 -- name: ListFeedFollows :many
 SELECT * FROM feed_follows
 WHERE user_id = $1
-ORDER BY updated_at DESC
+ORDER BY position ASC, updated_at DESC
 LIMIT $2
-OFFSET $3;
\ No newline at end of file
+OFFSET $3;
+
+-- name: ListAllFeedFollows :many
+SELECT * FROM feed_follows
+WHERE user_id = $1
+ORDER BY position ASC, updated_at DESC;
+
+-- name: GetFeedFollows :one
+SELECT * FROM feed_follows
+WHERE id = $1
+AND user_id = $2;
+
+-- name: UpdateFeedFollows :one
+-- The position, the muted and the notify settings are only changed when given.
+-- The folder, the title and the retention are only changed when set, they are cleared when set to null.
+-- The folder must belong to the user who follows the feed.
+UPDATE feed_follows
+SET folder_id = CASE WHEN sqlc.arg(set_folder_id)::boolean THEN sqlc.narg(folder_id)::uuid ELSE folder_id END,
+    position = COALESCE(sqlc.narg(position)::integer, position),
+    title = CASE WHEN sqlc.arg(set_title)::boolean THEN sqlc.narg(title)::varchar ELSE title END,
+    muted = COALESCE(sqlc.narg(muted)::boolean, muted),
+    notify = COALESCE(sqlc.narg(notify)::boolean, notify),
+    retention_days = CASE WHEN sqlc.arg(set_retention_days)::boolean THEN sqlc.narg(retention_days)::integer ELSE retention_days END,
+    updated_at = NOW()
+WHERE feed_follows.id = sqlc.arg(id)
+AND feed_follows.user_id = sqlc.arg(user_id)
+AND (
+    sqlc.narg(folder_id)::uuid IS NULL
+    OR EXISTS (SELECT 1 FROM folders WHERE folders.id = sqlc.narg(folder_id)::uuid AND folders.user_id = sqlc.arg(user_id))
+)
+RETURNING *;
+
+-- name: CreateFeedFollowsIfNotExists :one
+-- Nothing is returned when the user already follows the feed, or cannot follow it.
+INSERT INTO feed_follows (user_id, feed_id, folder_id, position, title)
+SELECT sqlc.arg(user_id)::uuid, feeds.id, sqlc.narg(folder_id)::uuid, sqlc.arg(position)::integer, sqlc.narg(title)::varchar
+FROM feeds
+WHERE feeds.id = sqlc.arg(feed_id)::uuid
+AND feed_is_followable(feeds, sqlc.arg(user_id)::uuid)
+AND NOT EXISTS (
+    SELECT 1 FROM feed_follows
+    WHERE feed_follows.user_id = sqlc.arg(user_id)
+    AND feed_follows.feed_id = sqlc.arg(feed_id)
+)
+RETURNING *;
+
+-- name: ListFeedFollowsWithFeeds :many
+SELECT ff.id, ff.feed_id, ff.folder_id, ff.title, f.name AS feed_name, f.url AS feed_url, f.site_url AS feed_site_url,
+    f.last_fetched_at AS feed_last_fetched_at
+FROM feed_follows ff
+JOIN feeds f ON f.id = ff.feed_id
+WHERE ff.user_id = $1
+ORDER BY ff.position ASC, ff.updated_at DESC;
+
+-- name: CountUnfollowableFeedFollows :one
+-- Counts the follows of the users who cannot follow the feed anymore.
+SELECT count(*)::integer FROM feed_follows ff
+    JOIN feeds ON feeds.id = ff.feed_id
+WHERE ff.feed_id = sqlc.arg(feed_id)::uuid
+AND NOT feed_is_followable(feeds, ff.user_id);
+
+-- name: DeleteUnfollowableFeedFollows :execrows
+-- Deletes the follows of the users who cannot follow the feed anymore.
+DELETE FROM feed_follows ff
+USING feeds
+WHERE feeds.id = ff.feed_id
+AND ff.feed_id = sqlc.arg(feed_id)::uuid
+AND NOT feed_is_followable(feeds, ff.user_id);
+
+-- name: GetNextFeedFollowPosition :one
+-- Returns the position after the last feed follow of the folder, or of the follows without folder.
+SELECT COALESCE(MAX(position) + 1, 0)::integer AS position
+FROM feed_follows
+WHERE user_id = sqlc.arg(user_id)::uuid
+AND folder_id IS NOT DISTINCT FROM sqlc.narg(folder_id)::uuid;
@@ -1,20 +1,149 @@
 -- name: CreateFeed :one
-INSERT INTO feeds (name, url, user_id)
-VALUES ($1, $2, $3)
+-- A feed is public unless another visibility is given.
+INSERT INTO feeds (name, url, user_id, visibility)
+VALUES (sqlc.arg(name), sqlc.arg(url), sqlc.arg(user_id), COALESCE(sqlc.narg(visibility)::varchar, 'public'))
 RETURNING *;
 
 -- name: ListFeeds :many
+-- Only the public feeds are listed.
 SELECT * FROM feeds
+WHERE visibility = 'public'
 ORDER BY updated_at DESC
 LIMIT $1
 OFFSET $2;
 
 -- name: GetNextFeedsToFetch :many
+-- The disabled feeds are skipped.
 SELECT * FROM feeds
+WHERE disabled_at IS NULL
 ORDER BY last_fetched_at NULLS FIRST, last_fetched_at ASC
 LIMIT $1;
 
 -- name: MarkFeedFetched :exec
+-- The site url is kept when the fetched feed does not provide one.
+-- A successful fetch clears the errors of the feed.
 UPDATE feeds
-SET last_fetched_at = NOW(), updated_at = NOW()
-WHERE id = $1;
\ No newline at end of file
+SET last_fetched_at = NOW(),
+    updated_at = NOW(),
+    last_error = '',
+    error_count = 0,
+    site_url = COALESCE(NULLIF(sqlc.arg(site_url)::varchar, ''), site_url)
+WHERE id = sqlc.arg(id);
+
+-- name: GetFeedByURL :one
+-- Returns the feed of a url the user can follow, the public or unlisted one first.
+-- The private feeds of the other users are ignored, as if their url was unknown.
+SELECT * FROM feeds
+WHERE url = sqlc.arg(url)
+AND feed_is_followable(feeds, sqlc.arg(user_id)::uuid)
+ORDER BY visibility = 'private', created_at
+LIMIT 1;
+
+-- name: MarkFeedFetchFailed :exec
+-- The feed is fetched again at its turn, its failed fetches are counted until one succeeds.
+UPDATE feeds
+SET last_fetched_at = NOW(),
+    last_error = sqlc.arg(last_error)::text,
+    error_count = error_count + 1
+WHERE id = sqlc.arg(id);
+
+-- name: GetFeed :one
+SELECT * FROM feeds
+WHERE id = $1;
+
+-- name: ListFeedsWithHealth :many
+-- Returns all the feeds with their health status and their number of followers, the failing ones first.
+-- A feed is disabled, failing when its last fetch failed, pending when it was never fetched, ok otherwise.
+SELECT sqlc.embed(feeds),
+    (CASE
+        WHEN feeds.disabled_at IS NOT NULL THEN 'disabled'
+        WHEN feeds.error_count > 0 THEN 'failing'
+        WHEN feeds.last_fetched_at IS NULL THEN 'pending'
+        ELSE 'ok'
+    END)::text AS status,
+    (SELECT count(*) FROM feed_follows WHERE feed_follows.feed_id = feeds.id)::integer AS followers
+FROM feeds
+ORDER BY feeds.error_count DESC, feeds.updated_at DESC
+LIMIT $1
+OFFSET $2;
+
+-- name: SetFeedDisabled :one
+-- A disabled feed keeps the date it was first disabled at.
+UPDATE feeds
+SET disabled_at = CASE WHEN sqlc.arg(disabled)::boolean THEN COALESCE(disabled_at, NOW()) END,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: OrphanFollowedFeeds :execrows
+-- The feeds created by a user which other users still follow are kept without creator,
+-- instead of being deleted with the user.
+UPDATE feeds
+SET user_id = NULL,
+    updated_at = NOW()
+WHERE feeds.user_id = sqlc.arg(user_id)
+AND EXISTS (
+    SELECT 1 FROM feed_follows ff
+    WHERE ff.feed_id = feeds.id
+    AND ff.user_id <> sqlc.arg(user_id)
+);
+
+-- name: ListFeedsByCreator :many
+SELECT * FROM feeds
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: GetManagedFeed :one
+-- Returns the feed when the user manages it, as its creator or as an admin.
+SELECT feeds.* FROM feeds
+WHERE feeds.id = sqlc.arg(id)
+AND (feeds.user_id = sqlc.arg(user_id)::uuid OR EXISTS (
+    SELECT 1 FROM users
+    WHERE users.id = sqlc.arg(user_id)::uuid
+    AND users.role = 'admin'
+    AND users.suspended_at IS NULL
+));
+
+-- name: UpdateFeed :one
+-- The name, the url, the visibility and the disabled state are only changed when given.
+-- A new url is fetched at once, without the errors of the previous one.
+UPDATE feeds
+SET name = COALESCE(sqlc.narg(name)::varchar, name),
+    url = COALESCE(sqlc.narg(url)::varchar, url),
+    visibility = COALESCE(sqlc.narg(visibility)::varchar, visibility),
+    last_fetched_at = CASE WHEN sqlc.narg(url)::varchar IS NULL OR sqlc.narg(url)::varchar = url THEN last_fetched_at END,
+    last_error = CASE WHEN sqlc.narg(url)::varchar IS NULL OR sqlc.narg(url)::varchar = url THEN last_error ELSE '' END,
+    error_count = CASE WHEN sqlc.narg(url)::varchar IS NULL OR sqlc.narg(url)::varchar = url THEN error_count ELSE 0 END,
+    disabled_at = CASE
+        WHEN sqlc.narg(disabled)::boolean IS NULL THEN disabled_at
+        WHEN sqlc.narg(disabled)::boolean THEN COALESCE(disabled_at, NOW())
+    END,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CountOtherFeedFollowers :one
+-- Counts the followers of a feed other than its creator.
+SELECT count(*)::integer FROM feed_follows ff
+    JOIN feeds f ON f.id = ff.feed_id
+WHERE ff.feed_id = $1
+AND ff.user_id IS DISTINCT FROM f.user_id;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1;
+
+-- name: CreateFeedShare :one
+INSERT INTO feed_shares (feed_id, user_id)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: ListFeedShares :many
+SELECT * FROM feed_shares
+WHERE feed_id = $1
+ORDER BY created_at ASC;
+
+-- name: DeleteFeedShare :execrows
+DELETE FROM feed_shares
+WHERE feed_id = $1
+AND user_id = $2;
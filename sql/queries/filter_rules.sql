@@ -0,0 +1,45 @@
+-- name: CreateFilterRule :one
+-- The feed must be followed by the user and the folder must belong to the user.
+INSERT INTO filter_rules (user_id, kind, action, pattern, feed_id, folder_id)
+SELECT sqlc.arg(user_id)::uuid, sqlc.arg(kind)::varchar, sqlc.arg(action)::varchar, sqlc.arg(pattern)::text,
+    sqlc.narg(feed_id)::uuid, sqlc.narg(folder_id)::uuid
+WHERE (
+    sqlc.narg(feed_id)::uuid IS NULL
+    OR EXISTS (SELECT 1 FROM feed_follows WHERE feed_follows.feed_id = sqlc.narg(feed_id) AND feed_follows.user_id = sqlc.arg(user_id))
+)
+AND (
+    sqlc.narg(folder_id)::uuid IS NULL
+    OR EXISTS (SELECT 1 FROM folders WHERE folders.id = sqlc.narg(folder_id) AND folders.user_id = sqlc.arg(user_id))
+)
+RETURNING *;
+
+-- name: ListFilterRules :many
+SELECT * FROM filter_rules
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: UpdateFilterRule :one
+-- The feed must be followed by the user and the folder must belong to the user.
+UPDATE filter_rules
+SET kind = sqlc.arg(kind),
+    action = sqlc.arg(action),
+    pattern = sqlc.arg(pattern),
+    feed_id = sqlc.narg(feed_id),
+    folder_id = sqlc.narg(folder_id),
+    updated_at = NOW()
+WHERE filter_rules.id = sqlc.arg(id)
+AND filter_rules.user_id = sqlc.arg(user_id)
+AND (
+    sqlc.narg(feed_id)::uuid IS NULL
+    OR EXISTS (SELECT 1 FROM feed_follows WHERE feed_follows.feed_id = sqlc.narg(feed_id) AND feed_follows.user_id = sqlc.arg(user_id))
+)
+AND (
+    sqlc.narg(folder_id)::uuid IS NULL
+    OR EXISTS (SELECT 1 FROM folders WHERE folders.id = sqlc.narg(folder_id) AND folders.user_id = sqlc.arg(user_id))
+)
+RETURNING *;
+
+-- name: DeleteFilterRule :exec
+DELETE FROM filter_rules
+WHERE id = $1
+AND user_id = $2;
@@ -0,0 +1,34 @@
+-- name: CreateFolder :one
+INSERT INTO folders (user_id, name, position)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListFolders :many
+SELECT * FROM folders
+WHERE user_id = $1
+ORDER BY position ASC, name ASC;
+
+-- name: UpdateFolder :one
+UPDATE folders
+SET name = $3, position = $4, updated_at = NOW()
+WHERE id = $1
+AND user_id = $2
+RETURNING *;
+
+-- name: DeleteFolder :exec
+DELETE FROM folders
+WHERE id = $1
+AND user_id = $2;
+
+-- name: GetOrCreateFolder :one
+-- An existing folder with the same name is returned unchanged.
+INSERT INTO folders (user_id, name, position)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, name) DO UPDATE SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: GetNextFolderPosition :one
+-- Returns the position after the last folder of the user.
+SELECT COALESCE(MAX(position) + 1, 0)::integer AS position
+FROM folders
+WHERE user_id = $1;
@@ -0,0 +1,39 @@
+-- name: UpsertHubSubscription :one
+-- A subscription is renewed when the callback subscribes again to the topic.
+INSERT INTO hub_subscriptions (user_id, topic_url, format, feed_id, folder_id, callback_url, secret, lease_expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (topic_url, callback_url) DO UPDATE
+SET secret = EXCLUDED.secret,
+    lease_expires_at = EXCLUDED.lease_expires_at,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteHubSubscription :exec
+DELETE FROM hub_subscriptions
+WHERE topic_url = $1
+AND callback_url = $2;
+
+-- name: DeleteUserHubSubscriptions :exec
+-- The subscriptions to the timeline of a user are authenticated by its feed token.
+DELETE FROM hub_subscriptions WHERE user_id = $1;
+
+-- name: ListHubSubscriptionsForPost :many
+-- Returns the subscriptions whose timeline contains the post, with the owner of the timeline.
+-- Like the timeline, the muted feeds are only part of the timelines restricted to a feed or a folder,
+-- the posts older than the retention of the feed follow are skipped and the filter rules of the user are applied.
+SELECT sqlc.embed(hs), sqlc.embed(u)
+FROM hub_subscriptions hs
+    JOIN users u ON u.id = hs.user_id
+    JOIN feed_follows ff ON ff.user_id = hs.user_id
+    JOIN posts p ON p.feed_id = ff.feed_id
+WHERE p.id = sqlc.arg(post_id)
+AND hs.lease_expires_at > NOW()
+AND (hs.feed_id IS NULL OR hs.feed_id = p.feed_id)
+AND (hs.folder_id IS NULL OR hs.folder_id = ff.folder_id)
+AND (NOT ff.muted OR hs.feed_id IS NOT NULL OR hs.folder_id IS NOT NULL)
+AND (ff.retention_days IS NULL OR p.published_at > NOW() - make_interval(days => ff.retention_days))
+AND post_passes_filters(ff.user_id, ff.folder_id, p);
+
+-- name: DeleteExpiredHubSubscriptions :execrows
+DELETE FROM hub_subscriptions
+WHERE lease_expires_at <= sqlc.arg(expired_at)::timestamptz;
@@ -0,0 +1,109 @@
+-- name: MarkPostsRead :many
+-- Only the posts of the followed feeds are marked as read, the ids of the marked posts are returned.
+INSERT INTO post_reads (user_id, post_id)
+SELECT DISTINCT ff.user_id, p.id
+FROM posts p
+    JOIN feed_follows ff ON p.feed_id = ff.feed_id
+WHERE ff.user_id = sqlc.arg(user_id)::uuid
+AND p.id = ANY(sqlc.arg(post_ids)::integer[])
+ON CONFLICT (user_id, post_id) DO NOTHING
+RETURNING post_id;
+
+-- name: CountUnreadPosts :many
+-- Counts the unread posts of each followed feed,
+-- the posts hidden by the retention or the filter rules are not counted.
+SELECT ff.feed_id, ff.folder_id, COUNT(p.id)::integer AS unread
+FROM feed_follows ff
+    JOIN posts p ON p.feed_id = ff.feed_id
+    LEFT JOIN post_reads pr ON pr.post_id = p.id AND pr.user_id = ff.user_id
+WHERE ff.user_id = sqlc.arg(user_id)
+AND pr.post_id IS NULL
+AND (ff.retention_days IS NULL OR p.published_at > NOW() - make_interval(days => ff.retention_days))
+AND post_passes_filters(ff.user_id, ff.folder_id, p)
+GROUP BY ff.feed_id, ff.folder_id
+ORDER BY ff.feed_id;
+
+-- name: MarkPostsUnread :exec
+DELETE FROM post_reads
+WHERE user_id = sqlc.arg(user_id)
+AND post_id = ANY(sqlc.arg(post_ids)::integer[]);
+
+-- name: MarkPostsReadBefore :execrows
+-- Marks as read the posts of the followed feeds published before a date,
+-- optionally restricted to a feed or a folder.
+INSERT INTO post_reads (user_id, post_id)
+SELECT ff.user_id, p.id
+FROM posts p
+    JOIN feed_follows ff ON p.feed_id = ff.feed_id
+WHERE ff.user_id = sqlc.arg(user_id)::uuid
+AND (sqlc.narg(feed_id)::uuid IS NULL OR ff.feed_id = sqlc.narg(feed_id))
+AND (sqlc.narg(folder_id)::uuid IS NULL OR ff.folder_id = sqlc.narg(folder_id))
+AND p.published_at <= sqlc.arg(before)::timestamptz
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: ListUnreadPostIDs :many
+-- Returns the ids of the unread posts of the followed feeds,
+-- the posts hidden by the retention or the filter rules are skipped.
+SELECT p.id
+FROM feed_follows ff
+    JOIN posts p ON p.feed_id = ff.feed_id
+    LEFT JOIN post_reads pr ON pr.post_id = p.id AND pr.user_id = ff.user_id
+WHERE ff.user_id = sqlc.arg(user_id)::uuid
+AND pr.post_id IS NULL
+AND (ff.retention_days IS NULL OR p.published_at > NOW() - make_interval(days => ff.retention_days))
+AND post_passes_filters(ff.user_id, ff.folder_id, p)
+ORDER BY p.id ASC;
+
+-- name: ListPostsWithStatus :many
+-- Returns the posts of the followed feeds and the starred posts with their read and starred status, by id:
+-- the posts after an id in ascending order, the posts before an id in descending order, or the given posts.
+-- They can be restricted to a feed, a folder, a read or starred status, or the posts published after a date.
+-- The posts hidden by the retention or the filter rules are skipped, unless they are starred:
+-- a starred post stays listed, even once its feed is unfollowed, but not with followed_only.
+SELECT sqlc.embed(p),
+    (pr.post_id IS NOT NULL)::boolean AS is_read,
+    (sp.id IS NOT NULL)::boolean AS is_starred
+FROM posts p
+    LEFT JOIN feed_follows ff ON ff.feed_id = p.feed_id AND ff.user_id = sqlc.arg(user_id)::uuid
+    LEFT JOIN starred_posts sp ON sp.post_id = p.id AND sp.user_id = sqlc.arg(user_id)::uuid
+    LEFT JOIN post_reads pr ON pr.post_id = p.id AND pr.user_id = sqlc.arg(user_id)::uuid
+    CROSS JOIN LATERAL (
+        SELECT ff.id IS NOT NULL
+            AND (ff.retention_days IS NULL OR p.published_at > NOW() - make_interval(days => ff.retention_days))
+            AND post_passes_filters(ff.user_id, ff.folder_id, p) AS visible
+    ) v
+WHERE (v.visible OR sp.id IS NOT NULL)
+AND (NOT sqlc.arg(followed_only)::boolean OR v.visible)
+AND (sqlc.narg(after_id)::integer IS NULL OR p.id > sqlc.narg(after_id))
+AND (sqlc.narg(before_id)::integer IS NULL OR p.id < sqlc.narg(before_id))
+AND (sqlc.narg(ids)::integer[] IS NULL OR p.id = ANY(sqlc.narg(ids)::integer[]))
+AND (sqlc.narg(feed_id)::uuid IS NULL OR p.feed_id = sqlc.narg(feed_id))
+AND (sqlc.narg(folder_id)::uuid IS NULL OR ff.folder_id = sqlc.narg(folder_id))
+AND (sqlc.narg(is_read)::boolean IS NULL OR (pr.post_id IS NOT NULL) = sqlc.narg(is_read))
+AND (sqlc.narg(is_starred)::boolean IS NULL OR (sp.id IS NOT NULL) = sqlc.narg(is_starred))
+AND (sqlc.narg(published_after)::timestamptz IS NULL OR p.published_at > sqlc.narg(published_after))
+ORDER BY
+    CASE WHEN sqlc.narg(before_id)::integer IS NOT NULL THEN -p.id ELSE p.id END ASC
+LIMIT sqlc.arg('limit');
+
+-- name: CountPosts :one
+-- Counts the posts listed by ListPostsWithStatus without restriction:
+-- the visible posts of the followed feeds and the starred posts.
+SELECT COUNT(p.id)::integer
+FROM posts p
+    LEFT JOIN feed_follows ff ON ff.feed_id = p.feed_id AND ff.user_id = sqlc.arg(user_id)::uuid
+    LEFT JOIN starred_posts sp ON sp.post_id = p.id AND sp.user_id = sqlc.arg(user_id)::uuid
+WHERE (ff.id IS NOT NULL
+        AND (ff.retention_days IS NULL OR p.published_at > NOW() - make_interval(days => ff.retention_days))
+        AND post_passes_filters(ff.user_id, ff.folder_id, p))
+    OR sp.id IS NOT NULL;
+
+-- name: ListReadPosts :many
+-- Returns the posts read by the user with the url of their feed,
+-- the urls identify the posts across instances unlike the ids.
+SELECT p.id, p.url, f.url AS feed_url, pr.created_at AS read_at
+FROM post_reads pr
+    JOIN posts p ON p.id = pr.post_id
+    JOIN feeds f ON f.id = p.feed_id
+WHERE pr.user_id = $1
+ORDER BY pr.created_at ASC, p.id ASC;
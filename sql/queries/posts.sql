@@ -1,12 +1,45 @@
 -- name: CreatePost :one
-INSERT INTO posts (title, url, description, published_at, feed_id)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO posts (title, url, description, published_at, feed_id, author, categories)
+VALUES (
+    sqlc.arg(title),
+    sqlc.arg(url),
+    sqlc.arg(description),
+    sqlc.arg(published_at),
+    sqlc.arg(feed_id),
+    sqlc.arg(author),
+    COALESCE(sqlc.narg(categories)::text[], '{}')
+)
 RETURNING *;
 
 -- name: GetPostsByUser :many
-SELECT p.id, p.title, p.url, p.description, p.published_at, p.feed_id, p.created_at, p.updated_at
+-- Returns the timeline of a user: the posts of the followed feeds,
+-- optionally restricted to a folder or a feed, or to the posts created after a post.
+-- The posts are the most recently published first, or in creation order after a post
+-- for the pages to follow each other.
+-- The muted feeds are only listed when the timeline is restricted,
+-- the posts older than the retention of the feed follow are skipped
+-- and the filter rules of the user are applied.
+SELECT p.*
 FROM posts p
-    JOIN feeds f ON p.feed_id = f.id
-WHERE f.user_id = $1
-ORDER BY p.published_at DESC
-LIMIT $2;
\ No newline at end of file
+    JOIN feed_follows ff ON p.feed_id = ff.feed_id
+WHERE ff.user_id = sqlc.arg(user_id)
+AND (sqlc.narg(folder_id)::uuid IS NULL OR ff.folder_id = sqlc.narg(folder_id))
+AND (sqlc.narg(feed_id)::uuid IS NULL OR ff.feed_id = sqlc.narg(feed_id))
+AND (sqlc.narg(after_id)::integer IS NULL OR p.id > sqlc.narg(after_id))
+AND (NOT ff.muted OR sqlc.narg(folder_id)::uuid IS NOT NULL OR sqlc.narg(feed_id)::uuid IS NOT NULL)
+AND (ff.retention_days IS NULL OR p.published_at > NOW() - make_interval(days => ff.retention_days))
+AND post_passes_filters(ff.user_id, ff.folder_id, p)
+ORDER BY
+    CASE WHEN sqlc.narg(after_id)::integer IS NULL THEN p.published_at END DESC,
+    p.id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetLastPostID :one
+SELECT COALESCE(MAX(id), 0)::integer FROM posts;
+
+-- name: PurgePosts :execrows
+-- Deletes the posts of a feed, or of all the feeds, published before a date.
+-- The starred posts keep their copy.
+DELETE FROM posts
+WHERE (sqlc.narg(feed_id)::uuid IS NULL OR feed_id = sqlc.narg(feed_id))
+AND (sqlc.narg(before)::timestamptz IS NULL OR published_at < sqlc.narg(before));
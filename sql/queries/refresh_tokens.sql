@@ -0,0 +1,44 @@
+-- name: CreateRefreshToken :one
+-- The refresh token is only returned at the creation, its hash is stored.
+-- The expired refresh tokens of the user are deleted meanwhile.
+WITH token AS (
+    SELECT encode(sha256(convert_to(gen_random_uuid()::text || gen_random_uuid()::text, 'UTF8')), 'hex')::text AS token
+), expired AS (
+    DELETE FROM refresh_tokens
+    WHERE refresh_tokens.user_id = sqlc.arg(user_id)
+    AND refresh_tokens.expires_at <= NOW()
+), t AS (
+    INSERT INTO refresh_tokens (user_id, api_key_id, session_id, token_hash, scopes, expires_at)
+    SELECT sqlc.arg(user_id), sqlc.narg(api_key_id), sqlc.narg(session_id),
+        encode(sha256(convert_to(token.token, 'UTF8')), 'hex'),
+        sqlc.arg(scopes)::text[],
+        sqlc.arg(expires_at)::timestamptz
+    FROM token
+    RETURNING *
+)
+SELECT t.id, t.user_id, t.api_key_id, t.session_id, t.scopes::text[] AS scopes, t.expires_at, token.token
+FROM t, token;
+
+-- name: UseRefreshToken :one
+-- A refresh token is used once, it is deleted and replaced by a new one.
+-- It is refused once the API key or the session it was issued for has expired, or once its user is suspended.
+DELETE FROM refresh_tokens r
+WHERE r.token_hash = encode(sha256(convert_to(sqlc.arg(token)::text, 'UTF8')), 'hex')
+AND r.expires_at > NOW()
+AND NOT EXISTS (
+    SELECT 1 FROM api_keys k WHERE k.id = r.api_key_id AND k.expires_at <= NOW()
+)
+AND NOT EXISTS (
+    SELECT 1 FROM sessions s WHERE s.id = r.session_id AND s.expires_at <= NOW()
+)
+AND NOT EXISTS (
+    SELECT 1 FROM users u WHERE u.id = r.user_id AND u.suspended_at IS NOT NULL
+)
+RETURNING r.id, r.user_id, r.api_key_id, r.session_id, r.scopes::text[] AS scopes, r.expires_at;
+
+-- name: DeleteRefreshToken :exec
+DELETE FROM refresh_tokens
+WHERE token_hash = encode(sha256(convert_to(sqlc.arg(token)::text, 'UTF8')), 'hex');
+
+-- name: DeleteUserRefreshTokens :exec
+DELETE FROM refresh_tokens WHERE user_id = $1;
@@ -0,0 +1,34 @@
+-- name: CreateSession :one
+-- The session token is only returned at the creation, its hash is stored.
+-- The expired sessions of the user are deleted meanwhile.
+WITH token AS (
+    SELECT encode(sha256(convert_to(gen_random_uuid()::text || gen_random_uuid()::text, 'UTF8')), 'hex')::text AS token,
+        encode(sha256(convert_to(gen_random_uuid()::text || gen_random_uuid()::text, 'UTF8')), 'hex')::text AS csrf_token
+), expired AS (
+    DELETE FROM sessions
+    WHERE sessions.user_id = sqlc.arg(user_id)
+    AND sessions.expires_at <= NOW()
+), s AS (
+    INSERT INTO sessions (user_id, token_hash, csrf_token, expires_at)
+    SELECT sqlc.arg(user_id), encode(sha256(convert_to(token.token, 'UTF8')), 'hex'), token.csrf_token, sqlc.arg(expires_at)::timestamptz
+    FROM token
+    RETURNING *
+)
+SELECT s.id, s.user_id, s.csrf_token, s.expires_at, s.created_at, token.token
+FROM s, token;
+
+-- name: GetUserFromSession :one
+-- Returns the user of a session which has not expired, with the session id and its CSRF token.
+-- The sessions of the suspended users are refused.
+SELECT sqlc.embed(users), sessions.id AS session_id, sessions.csrf_token
+FROM sessions JOIN users ON users.id = sessions.user_id
+WHERE sessions.token_hash = encode(sha256(convert_to(sqlc.arg(token)::text, 'UTF8')), 'hex')
+AND sessions.expires_at > NOW()
+AND users.suspended_at IS NULL;
+
+-- name: DeleteSession :exec
+DELETE FROM sessions
+WHERE token_hash = encode(sha256(convert_to(sqlc.arg(token)::text, 'UTF8')), 'hex');
+
+-- name: DeleteUserSessions :exec
+DELETE FROM sessions WHERE user_id = $1;
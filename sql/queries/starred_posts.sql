@@ -0,0 +1,40 @@
+-- name: StarPost :one
+INSERT INTO starred_posts (user_id, post_id, feed_id, title, url, description, published_at)
+SELECT ff.user_id, p.id, p.feed_id, p.title, p.url, p.description, p.published_at
+FROM posts p
+    JOIN feed_follows ff ON ff.feed_id = p.feed_id
+WHERE ff.user_id = sqlc.arg(user_id)::uuid
+AND p.id = sqlc.arg(post_id)
+LIMIT 1
+ON CONFLICT (user_id, url) DO UPDATE
+SET post_id = EXCLUDED.post_id, updated_at = NOW()
+RETURNING *;
+
+-- name: ListStarredPosts :many
+SELECT * FROM starred_posts
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: UnstarPost :exec
+DELETE FROM starred_posts
+WHERE id = $1
+AND user_id = $2;
+
+-- name: ListStarredPostIDs :many
+-- Returns the ids of the starred posts still stored.
+SELECT post_id::integer FROM starred_posts
+WHERE user_id = $1
+AND post_id IS NOT NULL
+ORDER BY post_id ASC;
+
+-- name: UnstarPostByPostID :exec
+DELETE FROM starred_posts
+WHERE post_id = sqlc.arg(post_id)::integer
+AND user_id = sqlc.arg(user_id);
+
+-- name: ListAllStarredPosts :many
+SELECT * FROM starred_posts
+WHERE user_id = $1
+ORDER BY created_at ASC;
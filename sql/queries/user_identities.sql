@@ -0,0 +1,13 @@
+-- name: GetUserFromIdentity :one
+-- Returns the user of an identity, its email at the provider is updated meanwhile.
+WITH i AS (
+    UPDATE user_identities SET email = sqlc.arg(email)::text, updated_at = NOW()
+    WHERE issuer = sqlc.arg(issuer)::text AND subject = sqlc.arg(subject)::text
+    RETURNING user_id
+)
+SELECT users.* FROM users JOIN i ON users.id = i.user_id;
+
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (user_id, issuer, subject, email)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
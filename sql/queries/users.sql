@@ -1,10 +1,105 @@
 -- name: CreateUser :one
-INSERT INTO users (name)
-VALUES ($1)
-RETURNING *;
+-- The user is created with a default API key granting all the scopes,
+-- it is only returned at the creation, its hash is stored with its prefix.
+WITH key AS (
+    SELECT encode(sha256(convert_to(gen_random_uuid()::text || gen_random_uuid()::text, 'UTF8')), 'hex')::text AS api_key
+), u AS (
+    INSERT INTO users (name)
+    VALUES (sqlc.arg(name)::text)
+    RETURNING *
+), k AS (
+    INSERT INTO api_keys (user_id, name, prefix, hash, fever_key)
+    SELECT u.id, 'default',
+        left(key.api_key, 8),
+        encode(sha256(convert_to(key.api_key, 'UTF8')), 'hex'),
+        encode(sha256(convert_to(md5(u.id::text || ':' || key.api_key), 'UTF8')), 'hex')
+    FROM u, key
+)
+SELECT u.id, u.name, u.created_at, u.updated_at, u.feed_token, key.api_key FROM u, key;
 
 -- name: GetUserFromApiKey :one
-SELECT * FROM users WHERE api_key = $1;
+-- Returns the user of an API key which has not expired, with the key id and its scopes.
+-- The keys of the suspended users are refused.
+-- The last use of the key is updated at most once a minute.
+WITH k AS (
+    SELECT * FROM api_keys
+    WHERE api_keys.prefix = left(sqlc.arg(api_key)::text, 8)
+    AND api_keys.hash = encode(sha256(convert_to(sqlc.arg(api_key)::text, 'UTF8')), 'hex')
+    AND (api_keys.expires_at IS NULL OR api_keys.expires_at > NOW())
+), used AS (
+    UPDATE api_keys SET last_used_at = NOW()
+    FROM k
+    WHERE api_keys.id = k.id
+    AND (k.last_used_at IS NULL OR k.last_used_at < NOW() - INTERVAL '1 minute')
+)
+SELECT sqlc.embed(users), k.id AS api_key_id, k.scopes::text[] AS scopes
+FROM k JOIN users ON users.id = k.user_id
+WHERE users.suspended_at IS NULL;
 
 -- name: GetUserFromId :one
-SELECT * FROM users WHERE id = $1;
\ No newline at end of file
+SELECT * FROM users WHERE id = $1;
+
+-- name: GetUserFromFeedToken :one
+SELECT * FROM users WHERE id = $1 AND feed_token = $2 AND suspended_at IS NULL;
+
+-- name: RotateFeedToken :one
+-- The feed token is replaced by a new one, generated like the default one.
+UPDATE users
+SET feed_token = encode(sha256(convert_to(gen_random_uuid()::text || gen_random_uuid()::text, 'UTF8')), 'hex'),
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetUserFromFeverKey :one
+-- Returns the user of the API key of a Fever key, with the scopes of the key.
+-- The keys replaced before the Fever keys were stored with the API keys have none.
+-- Only the hash of the Fever keys is stored.
+SELECT sqlc.embed(users), k.scopes::text[] AS scopes
+FROM api_keys k JOIN users ON users.id = k.user_id
+WHERE k.fever_key = encode(sha256(convert_to(sqlc.arg(fever_key)::text, 'UTF8')), 'hex')
+AND k.fever_key <> ''
+AND (k.expires_at IS NULL OR k.expires_at > NOW())
+AND users.suspended_at IS NULL;
+
+-- name: RegisterUser :one
+-- The users registered with an email and a password have no API key, they log in to open a session.
+INSERT INTO users (name, email, password_hash)
+VALUES (sqlc.arg(name)::text, lower(sqlc.arg(email)::text), sqlc.arg(password_hash)::text)
+RETURNING *;
+
+-- name: GetUserFromEmail :one
+SELECT * FROM users WHERE email <> '' AND lower(email) = lower(sqlc.arg(email)::text);
+
+-- name: ListUsers :many
+SELECT * FROM users
+ORDER BY created_at ASC
+LIMIT $1
+OFFSET $2;
+
+-- name: SetUserRole :one
+UPDATE users SET role = sqlc.arg(role)::text, updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: SuspendUser :one
+-- A suspended user keeps the date of its first suspension.
+UPDATE users SET suspended_at = COALESCE(suspended_at, NOW()), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UnsuspendUser :one
+UPDATE users SET suspended_at = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteUser :exec
+DELETE FROM users WHERE id = $1;
+
+-- name: UpdateUser :one
+-- The email is only updated when given, it is lowercased like at the registration.
+UPDATE users
+SET name = sqlc.arg(name)::text,
+    email = COALESCE(lower(sqlc.narg(email)::text), email),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -0,0 +1,102 @@
+-- name: CreateWebhook :one
+-- The feed must be followed by the user and the folder must belong to the user.
+INSERT INTO webhooks (user_id, url, secret, feed_id, folder_id, apply_filters)
+SELECT sqlc.arg(user_id)::uuid, sqlc.arg(url)::varchar, sqlc.arg(secret)::varchar,
+    sqlc.narg(feed_id)::uuid, sqlc.narg(folder_id)::uuid, sqlc.arg(apply_filters)::boolean
+WHERE (
+    sqlc.narg(feed_id)::uuid IS NULL
+    OR EXISTS (SELECT 1 FROM feed_follows WHERE feed_follows.feed_id = sqlc.narg(feed_id) AND feed_follows.user_id = sqlc.arg(user_id))
+)
+AND (
+    sqlc.narg(folder_id)::uuid IS NULL
+    OR EXISTS (SELECT 1 FROM folders WHERE folders.id = sqlc.narg(folder_id) AND folders.user_id = sqlc.arg(user_id))
+)
+RETURNING *;
+
+-- name: ListWebhooks :many
+SELECT * FROM webhooks
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: GetWebhook :one
+SELECT * FROM webhooks
+WHERE id = $1
+AND user_id = $2;
+
+-- name: UpdateWebhook :one
+-- The feed must be followed by the user and the folder must belong to the user.
+UPDATE webhooks
+SET url = sqlc.arg(url),
+    feed_id = sqlc.narg(feed_id),
+    folder_id = sqlc.narg(folder_id),
+    apply_filters = sqlc.arg(apply_filters),
+    active = sqlc.arg(active),
+    updated_at = NOW()
+WHERE webhooks.id = sqlc.arg(id)
+AND webhooks.user_id = sqlc.arg(user_id)
+AND (
+    sqlc.narg(feed_id)::uuid IS NULL
+    OR EXISTS (SELECT 1 FROM feed_follows WHERE feed_follows.feed_id = sqlc.narg(feed_id) AND feed_follows.user_id = sqlc.arg(user_id))
+)
+AND (
+    sqlc.narg(folder_id)::uuid IS NULL
+    OR EXISTS (SELECT 1 FROM folders WHERE folders.id = sqlc.narg(folder_id) AND folders.user_id = sqlc.arg(user_id))
+)
+RETURNING *;
+
+-- name: DeleteWebhook :exec
+DELETE FROM webhooks
+WHERE id = $1
+AND user_id = $2;
+
+-- name: ListWebhooksForPost :many
+-- Returns the active webhooks whose scope matches the post:
+-- the post must be part of a feed followed by the owner of the webhook, with the notifications on.
+SELECT w.*
+FROM webhooks w
+    JOIN feed_follows ff ON ff.user_id = w.user_id
+    JOIN posts p ON p.feed_id = ff.feed_id
+WHERE p.id = sqlc.arg(post_id)
+AND w.active
+AND ff.notify
+AND (w.feed_id IS NULL OR w.feed_id = p.feed_id)
+AND (w.folder_id IS NULL OR w.folder_id = ff.folder_id)
+AND (NOT w.apply_filters OR post_passes_filters(w.user_id, ff.folder_id, p));
+
+-- name: CreateWebhookDelivery :one
+-- Nothing is returned when the post has already been delivered to the webhook.
+INSERT INTO webhook_deliveries (webhook_id, post_id, payload)
+VALUES ($1, $2, $3)
+ON CONFLICT (webhook_id, post_id) DO NOTHING
+RETURNING *;
+
+-- name: ListDueWebhookDeliveries :many
+SELECT sqlc.embed(webhook_deliveries), webhooks.url, webhooks.secret
+FROM webhook_deliveries
+    JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+WHERE webhook_deliveries.status = 'pending'
+AND webhook_deliveries.next_attempt_at <= NOW()
+ORDER BY webhook_deliveries.next_attempt_at ASC
+LIMIT $1;
+
+-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET status = $2,
+    attempts = $3,
+    next_attempt_at = $4,
+    response_status = $5,
+    last_error = $6,
+    delivered_at = $7,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: ListWebhookDeliveries :many
+-- The deliveries of a webhook of the user, the most recent first.
+SELECT webhook_deliveries.*
+FROM webhook_deliveries
+    JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+WHERE webhook_deliveries.webhook_id = sqlc.arg(webhook_id)
+AND webhooks.user_id = sqlc.arg(user_id)
+ORDER BY webhook_deliveries.created_at DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
@@ -0,0 +1,54 @@
+-- name: UpsertWebSubSubscription :one
+-- The secret of an existing subscription is kept, it is pending again when the hub or the topic changes.
+INSERT INTO websub_subscriptions (feed_id, hub_url, topic_url, secret)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (feed_id) DO UPDATE
+SET state = CASE
+        WHEN websub_subscriptions.hub_url = EXCLUDED.hub_url AND websub_subscriptions.topic_url = EXCLUDED.topic_url
+        THEN websub_subscriptions.state
+        ELSE 'pending'
+    END,
+    hub_url = EXCLUDED.hub_url,
+    topic_url = EXCLUDED.topic_url,
+    requested_at = NOW(),
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetWebSubSubscription :one
+SELECT sqlc.embed(websub_subscriptions), sqlc.embed(feeds)
+FROM websub_subscriptions
+    JOIN feeds ON feeds.id = websub_subscriptions.feed_id
+WHERE websub_subscriptions.feed_id = $1;
+
+-- name: ActivateWebSubSubscription :one
+UPDATE websub_subscriptions
+SET state = 'active', lease_expires_at = sqlc.arg(lease_expires_at), updated_at = NOW()
+WHERE feed_id = sqlc.arg(feed_id)
+AND topic_url = sqlc.arg(topic_url)
+RETURNING *;
+
+-- name: DenyWebSubSubscription :exec
+UPDATE websub_subscriptions
+SET state = 'denied', lease_expires_at = NULL, updated_at = NOW()
+WHERE feed_id = sqlc.arg(feed_id)
+AND topic_url = sqlc.arg(topic_url);
+
+-- name: ListWebSubSubscriptionsToRenew :many
+-- Returns the active subscriptions expiring before a date,
+-- and the pending subscriptions requested before a date, their verification has failed.
+-- The subscriptions of the disabled feeds are not renewed.
+SELECT * FROM websub_subscriptions
+WHERE ((state = 'active' AND lease_expires_at < sqlc.arg(expires_before)::timestamptz)
+    OR (state = 'pending' AND requested_at < sqlc.arg(requested_before)))
+AND feed_id IN (SELECT id FROM feeds WHERE disabled_at IS NULL)
+ORDER BY requested_at ASC;
+
+-- name: ListDisabledWebSubSubscriptions :many
+-- Returns the subscriptions of the disabled feeds, they are unsubscribed from their hub.
+SELECT * FROM websub_subscriptions
+WHERE feed_id IN (SELECT id FROM feeds WHERE disabled_at IS NOT NULL)
+ORDER BY requested_at ASC;
+
+-- name: DeleteWebSubSubscription :exec
+DELETE FROM websub_subscriptions
+WHERE feed_id = $1;
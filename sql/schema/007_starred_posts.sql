@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE starred_posts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- post_id and feed_id are kept as a reference only, the starred post
+    -- keeps a copy of the post so it survives a purge or an unfollow.
+    post_id INTEGER REFERENCES posts(id) ON DELETE SET NULL,
+    feed_id UUID REFERENCES feeds(id) ON DELETE SET NULL,
+    title TEXT NOT NULL,
+    url TEXT NOT NULL,
+    description TEXT NOT NULL,
+    published_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL default now(),
+    updated_at TIMESTAMPTZ NOT NULL default now(),
+    UNIQUE (user_id, url)
+);
+
+-- +goose Down
+DROP TABLE starred_posts;
@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE folders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR NOT NULL,
+    position INTEGER NOT NULL default 0,
+    created_at TIMESTAMPTZ NOT NULL default now(),
+    updated_at TIMESTAMPTZ NOT NULL default now(),
+    UNIQUE (user_id, name)
+);
+
+ALTER TABLE feed_follows
+    ADD COLUMN folder_id UUID NULL REFERENCES folders(id) ON DELETE SET NULL,
+    ADD COLUMN position INTEGER NOT NULL default 0;
+
+-- +goose Down
+ALTER TABLE feed_follows
+    DROP COLUMN position,
+    DROP COLUMN folder_id;
+
+DROP TABLE folders;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Settings of a feed for the user who follows it.
+ALTER TABLE feed_follows
+    ADD COLUMN title VARCHAR NULL,
+    ADD COLUMN muted BOOLEAN NOT NULL default false,
+    ADD COLUMN notify BOOLEAN NOT NULL default true,
+    ADD COLUMN retention_days INTEGER NULL CHECK (retention_days > 0);
+
+-- +goose Down
+ALTER TABLE feed_follows
+    DROP COLUMN retention_days,
+    DROP COLUMN notify,
+    DROP COLUMN muted,
+    DROP COLUMN title;
@@ -0,0 +1,61 @@
+-- +goose Up
+ALTER TABLE posts
+    ADD COLUMN author TEXT NOT NULL default '',
+    ADD COLUMN categories TEXT[] NOT NULL default '{}';
+
+CREATE TABLE filter_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR NOT NULL CHECK (kind IN ('keyword', 'regex', 'author', 'category')),
+    action VARCHAR NOT NULL CHECK (action IN ('include', 'exclude')),
+    -- An invalid regular expression is rejected by the check.
+    pattern TEXT NOT NULL CHECK (pattern <> '' AND (kind <> 'regex' OR ('' ~* pattern) IS NOT NULL)),
+    feed_id UUID NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    folder_id UUID NULL REFERENCES folders(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL default now(),
+    updated_at TIMESTAMPTZ NOT NULL default now()
+);
+
+CREATE INDEX filter_rules_user_id_idx ON filter_rules (user_id);
+
+-- filter_rule_matches reports whether a post matches a rule, the matching is case-insensitive.
+-- +goose StatementBegin
+CREATE FUNCTION filter_rule_matches(rule filter_rules, post posts) RETURNS BOOLEAN AS $$
+    SELECT CASE rule.kind
+        WHEN 'keyword' THEN position(lower(rule.pattern) IN lower(post.title || ' ' || post.description)) > 0
+        WHEN 'regex' THEN post.title ~* rule.pattern OR post.description ~* rule.pattern
+        WHEN 'author' THEN position(lower(rule.pattern) IN lower(post.author)) > 0
+        WHEN 'category' THEN EXISTS (SELECT 1 FROM unnest(post.categories) AS c WHERE lower(c) = lower(rule.pattern))
+        ELSE false
+    END;
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- post_is_filtered reports whether the filter rules of a user hide a post of a followed feed.
+-- A rule applies to all the feeds, or only to a feed or a folder.
+-- A post is hidden when it matches an exclude rule, or when include rules apply but none matches.
+-- +goose StatementBegin
+CREATE FUNCTION post_is_filtered(rule_user_id UUID, follow_folder_id UUID, post posts) RETURNS BOOLEAN AS $$
+    WITH rules AS (
+        SELECT fr.action, filter_rule_matches(fr, post) AS matches
+        FROM filter_rules fr
+        WHERE fr.user_id = rule_user_id
+        AND (fr.feed_id IS NULL OR fr.feed_id = post.feed_id)
+        AND (fr.folder_id IS NULL OR fr.folder_id = follow_folder_id)
+    )
+    SELECT EXISTS (SELECT 1 FROM rules WHERE action = 'exclude' AND matches)
+        OR (
+            EXISTS (SELECT 1 FROM rules WHERE action = 'include')
+            AND NOT EXISTS (SELECT 1 FROM rules WHERE action = 'include' AND matches)
+        );
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION post_is_filtered;
+DROP FUNCTION filter_rule_matches;
+DROP TABLE filter_rules;
+
+ALTER TABLE posts
+    DROP COLUMN categories,
+    DROP COLUMN author;
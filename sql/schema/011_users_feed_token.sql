@@ -0,0 +1,6 @@
+-- +goose Up
+-- The feed token authenticates the feed documents of the user timeline.
+ALTER TABLE users ADD COLUMN feed_token VARCHAR(64) UNIQUE NOT NULL default encode(sha256(random()::text::bytea), 'hex');
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN feed_token;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN site_url VARCHAR NOT NULL default '';
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN site_url;
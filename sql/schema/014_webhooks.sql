@@ -0,0 +1,39 @@
+-- +goose Up
+CREATE TABLE webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url VARCHAR NOT NULL,
+    secret VARCHAR NOT NULL,
+    -- The webhook is restricted to the posts of a feed or a folder when they are set.
+    feed_id UUID NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    folder_id UUID NULL REFERENCES folders(id) ON DELETE CASCADE,
+    -- The filter rules of the user are applied to the posts when set.
+    apply_filters BOOLEAN NOT NULL default true,
+    active BOOLEAN NOT NULL default true,
+    created_at TIMESTAMPTZ NOT NULL default now(),
+    updated_at TIMESTAMPTZ NOT NULL default now()
+);
+
+CREATE INDEX webhooks_user_id_idx ON webhooks (user_id);
+
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    post_id INTEGER NULL REFERENCES posts(id) ON DELETE SET NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR NOT NULL default 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts INTEGER NOT NULL default 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL default now(),
+    response_status INTEGER NULL,
+    last_error TEXT NULL,
+    delivered_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL default now(),
+    updated_at TIMESTAMPTZ NOT NULL default now(),
+    UNIQUE (webhook_id, post_id)
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE digest_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR NOT NULL,
+    frequency VARCHAR NOT NULL CHECK (frequency IN ('daily', 'weekly')),
+    -- The digest is sent at this hour of the day, on this day of the week (0 is Sunday) when weekly.
+    hour INTEGER NOT NULL CHECK (hour BETWEEN 0 AND 23),
+    weekday INTEGER NOT NULL default 1 CHECK (weekday BETWEEN 0 AND 6),
+    -- The IANA name of the time zone of the hour and the day of the week.
+    timezone VARCHAR NOT NULL default 'UTC',
+    enabled BOOLEAN NOT NULL default true,
+    next_send_at TIMESTAMPTZ NOT NULL,
+    last_sent_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL default now(),
+    updated_at TIMESTAMPTZ NOT NULL default now()
+);
+
+CREATE INDEX digest_settings_next_send_at_idx ON digest_settings (next_send_at) WHERE enabled;
+
+-- The posts already sent in a digest, they are not sent again.
+CREATE TABLE digest_items (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    sent_at TIMESTAMPTZ NOT NULL default now(),
+    PRIMARY KEY (user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE digest_items;
+DROP TABLE digest_settings;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- The WebSub subscriptions to the hubs advertised by the feeds.
+CREATE TABLE websub_subscriptions (
+    feed_id UUID PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+    hub_url VARCHAR NOT NULL,
+    topic_url VARCHAR NOT NULL,
+    -- The secret shared with the hub to sign the pushed content.
+    secret VARCHAR NOT NULL,
+    state VARCHAR NOT NULL default 'pending' CHECK (state IN ('pending', 'active', 'denied')),
+    lease_expires_at TIMESTAMPTZ NULL,
+    requested_at TIMESTAMPTZ NOT NULL default now(),
+    created_at TIMESTAMPTZ NOT NULL default now(),
+    updated_at TIMESTAMPTZ NOT NULL default now()
+);
+
+-- +goose Down
+DROP TABLE websub_subscriptions;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- The WebSub subscriptions to the timeline feeds published by the server, which acts as their hub.
+CREATE TABLE hub_subscriptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    -- The owner of the timeline, authenticated by the feed token of the topic.
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    topic_url VARCHAR NOT NULL,
+    format VARCHAR NOT NULL CHECK (format IN ('rss', 'atom', 'json')),
+    -- The timeline is restricted to the posts of a feed or a folder when they are set.
+    feed_id UUID NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    folder_id UUID NULL REFERENCES folders(id) ON DELETE CASCADE,
+    callback_url VARCHAR NOT NULL,
+    -- The secret given by the subscriber to sign the pushed content, empty when the content is not signed.
+    secret VARCHAR NOT NULL default '',
+    lease_expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL default now(),
+    updated_at TIMESTAMPTZ NOT NULL default now(),
+    UNIQUE (topic_url, callback_url)
+);
+
+CREATE INDEX hub_subscriptions_user_id_idx ON hub_subscriptions (user_id);
+
+-- +goose Down
+DROP TABLE hub_subscriptions;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- The Fever clients authenticate with the md5 of 'email:password', the name of the user and its API key.
+-- The API keys later use the user id instead of the name, and only the sha256 of the Fever keys is stored since 030.
+ALTER TABLE users ADD COLUMN fever_key VARCHAR(32) NOT NULL GENERATED ALWAYS AS (md5(name || ':' || api_key)) STORED;
+CREATE INDEX users_fever_key_idx ON users (fever_key);
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN fever_key;
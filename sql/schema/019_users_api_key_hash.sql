@@ -0,0 +1,35 @@
+-- +goose Up
+-- Only the hash of the API key is stored, with a short prefix to look it up.
+-- The previous key still authenticates the user until it expires, after a rotation with a grace period.
+ALTER TABLE users
+    ADD COLUMN api_key_prefix VARCHAR(8) NOT NULL default '',
+    ADD COLUMN api_key_hash VARCHAR(64) NOT NULL default '',
+    ADD COLUMN previous_api_key_prefix VARCHAR(8) NULL,
+    ADD COLUMN previous_api_key_hash VARCHAR(64) NULL,
+    ADD COLUMN previous_api_key_expires_at TIMESTAMPTZ NULL;
+UPDATE users SET
+    api_key_prefix = left(api_key, 8),
+    api_key_hash = encode(sha256(convert_to(api_key, 'UTF8')), 'hex');
+ALTER TABLE users
+    ALTER COLUMN api_key_prefix DROP default,
+    ALTER COLUMN api_key_hash DROP default;
+
+-- The Fever key cannot be derived from the hash, it is set with the API key.
+ALTER TABLE users ALTER COLUMN fever_key DROP EXPRESSION;
+ALTER TABLE users DROP COLUMN api_key;
+
+CREATE INDEX users_api_key_prefix_idx ON users (api_key_prefix);
+CREATE INDEX users_previous_api_key_prefix_idx ON users (previous_api_key_prefix);
+
+-- +goose Down
+-- The API keys cannot be recovered from their hashes, new keys are generated.
+ALTER TABLE users ADD COLUMN api_key VARCHAR(64) UNIQUE NOT NULL default encode(sha256(random()::text::bytea), 'hex');
+ALTER TABLE users DROP COLUMN fever_key;
+ALTER TABLE users ADD COLUMN fever_key VARCHAR(32) NOT NULL GENERATED ALWAYS AS (md5(name || ':' || api_key)) STORED;
+CREATE INDEX users_fever_key_idx ON users (fever_key);
+ALTER TABLE users
+    DROP COLUMN api_key_prefix,
+    DROP COLUMN api_key_hash,
+    DROP COLUMN previous_api_key_prefix,
+    DROP COLUMN previous_api_key_hash,
+    DROP COLUMN previous_api_key_expires_at;
@@ -0,0 +1,62 @@
+-- +goose Up
+-- A user can have several API keys, each restricted to a set of scopes.
+-- Only the hash of a key is stored, with a short prefix to look it up.
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR NOT NULL,
+    prefix VARCHAR(8) NOT NULL,
+    hash VARCHAR(64) NOT NULL UNIQUE,
+    -- The Fever clients authenticate with the md5 of 'user id:api key', it is set with the key.
+    -- The keys copied from the users keep the md5 of 'name:api key', only the sha256 is stored since 030.
+    fever_key VARCHAR(32) NOT NULL,
+    -- A key without scopes cannot access the routes restricted by a scope, the default grants them all.
+    scopes VARCHAR[] NOT NULL default ARRAY['feeds:read', 'feeds:write', 'posts:read', 'follows:write']
+        CHECK (scopes <@ ARRAY['feeds:read', 'feeds:write', 'posts:read', 'follows:write']::VARCHAR[]),
+    -- The key never expires when it is not set.
+    expires_at TIMESTAMPTZ NULL,
+    last_used_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL default now(),
+    updated_at TIMESTAMPTZ NOT NULL default now()
+);
+
+CREATE INDEX api_keys_user_id_idx ON api_keys (user_id);
+CREATE INDEX api_keys_prefix_idx ON api_keys (prefix);
+CREATE INDEX api_keys_fever_key_idx ON api_keys (fever_key);
+
+-- The keys of the users become their default key, the previous keys still expire after their grace period.
+INSERT INTO api_keys (user_id, name, prefix, hash, fever_key)
+SELECT id, 'default', api_key_prefix, api_key_hash, fever_key FROM users;
+INSERT INTO api_keys (user_id, name, prefix, hash, fever_key, expires_at)
+SELECT id, 'default (previous)', previous_api_key_prefix, previous_api_key_hash, '', previous_api_key_expires_at
+FROM users
+WHERE previous_api_key_hash IS NOT NULL AND previous_api_key_expires_at > now();
+
+ALTER TABLE users
+    DROP COLUMN api_key_prefix,
+    DROP COLUMN api_key_hash,
+    DROP COLUMN previous_api_key_prefix,
+    DROP COLUMN previous_api_key_hash,
+    DROP COLUMN previous_api_key_expires_at,
+    DROP COLUMN fever_key;
+
+-- +goose Down
+ALTER TABLE users
+    ADD COLUMN api_key_prefix VARCHAR(8) NOT NULL default '',
+    ADD COLUMN api_key_hash VARCHAR(64) NOT NULL default '',
+    ADD COLUMN previous_api_key_prefix VARCHAR(8) NULL,
+    ADD COLUMN previous_api_key_hash VARCHAR(64) NULL,
+    ADD COLUMN previous_api_key_expires_at TIMESTAMPTZ NULL,
+    ADD COLUMN fever_key VARCHAR(32) NOT NULL default '';
+-- Only the oldest key of a user is kept.
+UPDATE users SET api_key_prefix = k.prefix, api_key_hash = k.hash, fever_key = k.fever_key
+FROM (SELECT DISTINCT ON (user_id) * FROM api_keys ORDER BY user_id, created_at) k
+WHERE k.user_id = users.id;
+ALTER TABLE users
+    ALTER COLUMN api_key_prefix DROP default,
+    ALTER COLUMN api_key_hash DROP default,
+    ALTER COLUMN fever_key DROP default;
+CREATE INDEX users_api_key_prefix_idx ON users (api_key_prefix);
+CREATE INDEX users_previous_api_key_prefix_idx ON users (previous_api_key_prefix);
+CREATE INDEX users_fever_key_idx ON users (fever_key);
+DROP TABLE api_keys;
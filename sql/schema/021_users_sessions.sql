@@ -0,0 +1,27 @@
+-- +goose Up
+-- The users of the web interface register with an email and a password, the users of the API have neither.
+-- The emails are unique without regard to case, only the hash of the password is stored.
+ALTER TABLE users
+    ADD COLUMN email VARCHAR NOT NULL default '',
+    ADD COLUMN password_hash VARCHAR NOT NULL default '';
+CREATE UNIQUE INDEX users_email_idx ON users (lower(email)) WHERE email <> '';
+
+-- The sessions of the web interface, only the hash of the session token is stored.
+-- The CSRF token must be sent back with the requests changing data.
+CREATE TABLE sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    csrf_token VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL default now()
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+
+-- +goose Down
+DROP TABLE sessions;
+DROP INDEX users_email_idx;
+ALTER TABLE users
+    DROP COLUMN email,
+    DROP COLUMN password_hash;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- The identities of the users at the OpenID Connect providers, a subject is unique per issuer.
+-- A user is created or linked by its email at the first login with an identity.
+CREATE TABLE user_identities (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer VARCHAR NOT NULL,
+    subject VARCHAR NOT NULL,
+    email VARCHAR NOT NULL default '',
+    created_at TIMESTAMPTZ NOT NULL default now(),
+    updated_at TIMESTAMPTZ NOT NULL default now(),
+    UNIQUE (issuer, subject)
+);
+
+CREATE INDEX user_identities_user_id_idx ON user_identities (user_id);
+
+-- +goose Down
+DROP TABLE user_identities;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- The refresh tokens get new access tokens, only their hashes are stored.
+-- A refresh token is revoked with the API key or the session it was issued for.
+CREATE TABLE refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    api_key_id UUID NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    session_id UUID NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes VARCHAR[] NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL default now()
+);
+
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+CREATE INDEX refresh_tokens_api_key_id_idx ON refresh_tokens (api_key_id);
+CREATE INDEX refresh_tokens_session_id_idx ON refresh_tokens (session_id);
+
+-- +goose Down
+DROP TABLE refresh_tokens;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- The admins manage the users and the feeds of the instance.
+-- A suspended user cannot authenticate anymore, its data is kept until it is deleted.
+ALTER TABLE users
+    ADD COLUMN role VARCHAR NOT NULL default 'user' CHECK (role IN ('admin', 'user')),
+    ADD COLUMN suspended_at TIMESTAMPTZ NULL;
+
+-- +goose Down
+ALTER TABLE users
+    DROP COLUMN role,
+    DROP COLUMN suspended_at;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- The health of the feeds: the error of the last fetch and the number of failed fetches in a row.
+-- A disabled feed is not fetched anymore.
+ALTER TABLE feeds
+    ADD COLUMN last_error TEXT NOT NULL default '',
+    ADD COLUMN error_count INTEGER NOT NULL default 0,
+    ADD COLUMN disabled_at TIMESTAMPTZ NULL;
+
+-- +goose Down
+ALTER TABLE feeds
+    DROP COLUMN last_error,
+    DROP COLUMN error_count,
+    DROP COLUMN disabled_at;
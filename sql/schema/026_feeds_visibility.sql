@@ -0,0 +1,30 @@
+-- +goose Up
+-- A public feed is listed and followable by all the users, an unlisted feed is not listed but followable,
+-- a private feed is neither listed nor followable, except by its creator and the users it is shared with.
+ALTER TABLE feeds
+    ADD COLUMN visibility VARCHAR NOT NULL default 'public' CHECK (visibility IN ('public', 'unlisted', 'private'));
+
+CREATE TABLE feed_shares (
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL default now(),
+    PRIMARY KEY (feed_id, user_id)
+);
+
+CREATE INDEX feed_shares_user_id_idx ON feed_shares (user_id);
+
+-- feed_is_followable reports whether a user can follow a feed, it is never null.
+-- +goose StatementBegin
+CREATE FUNCTION feed_is_followable(feed feeds, follower_id UUID) RETURNS BOOLEAN AS $$
+    SELECT feed.visibility <> 'private'
+        OR (feed.user_id IS NOT NULL AND feed.user_id = follower_id)
+        OR EXISTS (SELECT 1 FROM feed_shares fs WHERE fs.feed_id = feed.id AND fs.user_id = follower_id);
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION feed_is_followable;
+DROP TABLE feed_shares;
+
+ALTER TABLE feeds
+    DROP COLUMN visibility;
@@ -0,0 +1,48 @@
+-- +goose Up
+-- The filter rules are evaluated inline by the queries: post_is_filtered runs its rules
+-- query once per post, and filter_rule_matches is rewritten without a sub-select to be
+-- inlined by the planner.
+DROP FUNCTION post_is_filtered(UUID, UUID, posts);
+
+-- filter_rule_matches reports whether a post matches a rule, the matching is case-insensitive.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION filter_rule_matches(rule filter_rules, post posts) RETURNS BOOLEAN AS $$
+    SELECT CASE rule.kind
+        WHEN 'keyword' THEN position(lower(rule.pattern) IN lower(post.title || ' ' || post.description)) > 0
+        WHEN 'regex' THEN post.title ~* rule.pattern OR post.description ~* rule.pattern
+        WHEN 'author' THEN position(lower(rule.pattern) IN lower(post.author)) > 0
+        WHEN 'category' THEN lower(rule.pattern) = ANY (lower(post.categories::text)::text[])
+        ELSE false
+    END;
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION filter_rule_matches(rule filter_rules, post posts) RETURNS BOOLEAN AS $$
+    SELECT CASE rule.kind
+        WHEN 'keyword' THEN position(lower(rule.pattern) IN lower(post.title || ' ' || post.description)) > 0
+        WHEN 'regex' THEN post.title ~* rule.pattern OR post.description ~* rule.pattern
+        WHEN 'author' THEN position(lower(rule.pattern) IN lower(post.author)) > 0
+        WHEN 'category' THEN EXISTS (SELECT 1 FROM unnest(post.categories) AS c WHERE lower(c) = lower(rule.pattern))
+        ELSE false
+    END;
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION post_is_filtered(rule_user_id UUID, follow_folder_id UUID, post posts) RETURNS BOOLEAN AS $$
+    WITH rules AS (
+        SELECT fr.action, filter_rule_matches(fr, post) AS matches
+        FROM filter_rules fr
+        WHERE fr.user_id = rule_user_id
+        AND (fr.feed_id IS NULL OR fr.feed_id = post.feed_id)
+        AND (fr.folder_id IS NULL OR fr.folder_id = follow_folder_id)
+    )
+    SELECT EXISTS (SELECT 1 FROM rules WHERE action = 'exclude' AND matches)
+        OR (
+            EXISTS (SELECT 1 FROM rules WHERE action = 'include')
+            AND NOT EXISTS (SELECT 1 FROM rules WHERE action = 'include' AND matches)
+        );
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- filter_rule_matches reports whether a post matches a rule, the matching is case-insensitive.
+-- The categories are compared one by one, a null category matches no rule.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION filter_rule_matches(rule filter_rules, post posts) RETURNS BOOLEAN AS $$
+    SELECT CASE rule.kind
+        WHEN 'keyword' THEN position(lower(rule.pattern) IN lower(post.title || ' ' || post.description)) > 0
+        WHEN 'regex' THEN post.title ~* rule.pattern OR post.description ~* rule.pattern
+        WHEN 'author' THEN position(lower(rule.pattern) IN lower(post.author)) > 0
+        WHEN 'category' THEN EXISTS (SELECT 1 FROM unnest(post.categories) AS c WHERE lower(c) = lower(rule.pattern))
+        ELSE false
+    END;
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- post_passes_filters reports whether the filter rules of a user keep a post of a followed feed.
+-- A rule applies to all the feeds, or only to a feed or a folder.
+-- A post is kept when it matches no exclude rule, and matches an include rule when some apply.
+-- The rules of the user are read in a single pass.
+-- +goose StatementBegin
+CREATE FUNCTION post_passes_filters(rule_user_id UUID, follow_folder_id UUID, post posts) RETURNS BOOLEAN AS $$
+    SELECT coalesce(bool_or(filter_rule_matches(fr, post)) FILTER (WHERE fr.action = 'include'), true)
+        AND NOT coalesce(bool_or(filter_rule_matches(fr, post)) FILTER (WHERE fr.action = 'exclude'), false)
+    FROM filter_rules fr
+    WHERE fr.user_id = rule_user_id
+    AND (fr.feed_id IS NULL OR fr.feed_id = post.feed_id)
+    AND (fr.folder_id IS NULL OR fr.folder_id = follow_folder_id);
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION post_passes_filters(UUID, UUID, posts);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION filter_rule_matches(rule filter_rules, post posts) RETURNS BOOLEAN AS $$
+    SELECT CASE rule.kind
+        WHEN 'keyword' THEN position(lower(rule.pattern) IN lower(post.title || ' ' || post.description)) > 0
+        WHEN 'regex' THEN post.title ~* rule.pattern OR post.description ~* rule.pattern
+        WHEN 'author' THEN position(lower(rule.pattern) IN lower(post.author)) > 0
+        WHEN 'category' THEN lower(rule.pattern) = ANY (lower(post.categories::text)::text[])
+        ELSE false
+    END;
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
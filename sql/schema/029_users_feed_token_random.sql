@@ -0,0 +1,8 @@
+-- +goose Up
+-- The feed tokens are generated from a cryptographic random source, like the API keys.
+-- The tokens generated before are kept, the users rotate them to replace them.
+ALTER TABLE users ALTER COLUMN feed_token
+    SET DEFAULT encode(sha256(convert_to(gen_random_uuid()::text || gen_random_uuid()::text, 'UTF8')), 'hex');
+
+-- +goose Down
+ALTER TABLE users ALTER COLUMN feed_token SET DEFAULT encode(sha256(random()::text::bytea), 'hex');
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Only the sha256 of the Fever keys is stored, like the API keys, a Fever key is looked up by its hash.
+ALTER TABLE api_keys ALTER COLUMN fever_key TYPE VARCHAR(64);
+UPDATE api_keys SET fever_key = encode(sha256(convert_to(fever_key, 'UTF8')), 'hex') WHERE fever_key <> '';
+
+-- +goose Down
+-- The Fever keys cannot be recovered from their hash, the keys have to be rotated to get one again.
+UPDATE api_keys SET fever_key = '';
+ALTER TABLE api_keys ALTER COLUMN fever_key TYPE VARCHAR(32);
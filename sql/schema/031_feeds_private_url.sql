@@ -0,0 +1,12 @@
+-- +goose Up
+-- A private feed does not reserve its url for all the users, it is only unique among the feeds of its creator.
+-- Another user can create a feed with the url of a private feed, which can neither be squatted nor discovered by its url.
+ALTER TABLE feeds DROP CONSTRAINT feeds_url_key;
+CREATE UNIQUE INDEX feeds_url_key ON feeds (url) WHERE visibility <> 'private';
+CREATE UNIQUE INDEX feeds_private_url_key ON feeds (url, user_id) WHERE visibility = 'private';
+
+-- +goose Down
+-- It fails while a private feed shares its url with another feed.
+DROP INDEX feeds_private_url_key;
+DROP INDEX feeds_url_key;
+ALTER TABLE feeds ADD CONSTRAINT feeds_url_key UNIQUE (url);